@@ -196,6 +196,25 @@ db.customers.createIndex({ registrationDate: 1 }, { background: true });
 
 print('📊 Created indexes: customerId (unique), email (unique), active, customerTier, registrationDate');
 
+// Append-only change history behind /customers/:id/history; customer-api
+// creates the same indexes on startup
+db.customer_history.createIndex({ customerId: 1, changedAt: 1 }, { background: true });
+db.customer_history.createIndex({ customerId: 1, version: 1 }, { background: true });
+
+// Transactional outbox of CustomerChanged events; published events expire
+// after seven days
+db.customer_outbox.createIndex({ eventId: 1 }, { unique: true, background: true });
+db.customer_outbox.createIndex({ publishedAt: 1, _id: 1 }, { background: true });
+db.customer_outbox.createIndex({ publishedAt: 1 }, { name: 'outbox_retention', expireAfterSeconds: 604800, background: true });
+
+// Webhook subscriptions and their delivery log; delivered deliveries expire
+// after thirty days, dead letters are kept
+db.customer_webhooks.createIndex({ webhookId: 1 }, { unique: true, background: true });
+db.customer_webhook_deliveries.createIndex({ deliveryId: 1 }, { unique: true, background: true });
+db.customer_webhook_deliveries.createIndex({ status: 1, nextAttemptAt: 1 }, { background: true });
+db.customer_webhook_deliveries.createIndex({ webhookId: 1, createdAt: -1 }, { background: true });
+db.customer_webhook_deliveries.createIndex({ deliveredAt: 1 }, { name: 'delivery_retention', expireAfterSeconds: 2592000, background: true });
+
 // Verify data
 const activeCount = db.customers.countDocuments({ active: true });
 const inactiveCount = db.customers.countDocuments({ active: false });
@@ -120,6 +120,14 @@ const products = [
   }
 ];
 
+// Prices are stored as integer minor units plus an ISO 4217 currency; price
+// keeps the exact decimal amount for readers that predate minor units
+products.forEach((product) => {
+  product.currency = 'USD';
+  product.priceMinor = NumberLong(Math.round(product.price * 100));
+  product.price = NumberDecimal(product.price.toFixed(2));
+});
+
 // Insert products with bulk operation
 const result = db.products.insertMany(products, {ordered: false});
 print(`✅ Inserted ${result.insertedIds.length} products`);
@@ -128,9 +136,62 @@ print(`✅ Inserted ${result.insertedIds.length} products`);
 db.products.createIndex({ productId: 1 }, { unique: true, background: true });
 db.products.createIndex({ category: 1 }, { background: true });
 db.products.createIndex({ active: 1 }, { background: true });
-db.products.createIndex({ price: 1 }, { background: true });
+db.products.createIndex({ currency: 1, priceMinor: 1 }, { background: true });
+// Variants of a product, and the top-level products listings show
+db.products.createIndex({ parentId: 1 }, { background: true });
+// SKUs are unique among the products that have one; product-api creates
+// the same index on startup
+db.products.createIndex(
+  { sku: 1 },
+  { name: 'product_sku', unique: true, partialFilterExpression: { sku: { $exists: true } }, background: true }
+);
+// Full-text search; product-api creates the same index on startup
+db.products.createIndex(
+  { name: 'text', category: 'text', description: 'text' },
+  { name: 'product_search', weights: { name: 10, category: 5, description: 1 }, default_language: 'none', background: true }
+);
+
+print('📊 Created indexes: productId (unique), category, active, currency+priceMinor, parentId, sku (unique), product_search (text)');
+
+// Category tree products are assigned to; product-api creates the same
+// indexes on startup
+const now = new Date();
+const categories = [
+  { slug: 'computers', name: 'Computers' },
+  { slug: 'laptops', name: 'Laptops', parent: 'computers' },
+  { slug: 'components', name: 'Components', parent: 'computers' },
+  { slug: 'storage', name: 'Storage', parent: 'computers' },
+  { slug: 'accessories', name: 'Accessories' },
+  { slug: 'peripherals', name: 'Peripherals', parent: 'accessories' },
+  { slug: 'monitors', name: 'Monitors', parent: 'accessories' },
+  { slug: 'furniture', name: 'Furniture' },
+  { slug: 'testing', name: 'Testing' },
+].map((category) => ({ ...category, active: true, version: NumberLong(1), createdAt: now, updatedAt: now }));
+
+db.categories.deleteMany({});
+db.categories.insertMany(categories);
+db.categories.createIndex({ slug: 1 }, { unique: true, background: true });
+db.categories.createIndex({ parent: 1 }, { background: true });
+print(`🗂️ Inserted ${categories.length} categories`);
+
+// Append-only change history behind /products/:id/history; product-api
+// creates the same indexes on startup
+db.product_history.createIndex({ productId: 1, changedAt: 1 }, { background: true });
+db.product_history.createIndex({ productId: 1, version: 1 }, { background: true });
+
+// Transactional outbox of ProductChanged events; published events expire
+// after seven days
+db.product_outbox.createIndex({ eventId: 1 }, { unique: true, background: true });
+db.product_outbox.createIndex({ publishedAt: 1, _id: 1 }, { background: true });
+db.product_outbox.createIndex({ publishedAt: 1 }, { name: 'outbox_retention', expireAfterSeconds: 604800, background: true });
 
-print('📊 Created indexes: productId (unique), category, active, price');
+// Webhook subscriptions and their delivery log; delivered deliveries expire
+// after thirty days, dead letters are kept
+db.product_webhooks.createIndex({ webhookId: 1 }, { unique: true, background: true });
+db.product_webhook_deliveries.createIndex({ deliveryId: 1 }, { unique: true, background: true });
+db.product_webhook_deliveries.createIndex({ status: 1, nextAttemptAt: 1 }, { background: true });
+db.product_webhook_deliveries.createIndex({ webhookId: 1, createdAt: -1 }, { background: true });
+db.product_webhook_deliveries.createIndex({ deliveredAt: 1 }, { name: 'delivery_retention', expireAfterSeconds: 2592000, background: true });
 
 // Verify data
 const count = db.products.countDocuments();
@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"os"
@@ -12,7 +13,9 @@ import (
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
 	"github.com/customer-api-v2/configs"
+	"github.com/customer-api-v2/internal/events"
 	"github.com/customer-api-v2/internal/handlers"
+	"github.com/customer-api-v2/internal/metrics"
 	custommiddleware "github.com/customer-api-v2/internal/middleware"
 	"github.com/customer-api-v2/internal/repository"
 	"github.com/customer-api-v2/internal/services"
@@ -32,26 +35,135 @@ func main() {
 		"port":        config.Server.Port,
 	}).Info("🚀 Starting Customer API")
 	
+	// Background work stops when the server shuts down
+	workerCtx, stopWorkers := context.WithCancel(context.Background())
+	defer stopWorkers()
+	
 	// Initialize dependencies
 	var customerRepo repository.CustomerRepository
-	var err error
+	var historyRepo repository.CustomerHistoryRepository
+	var outboxRepo repository.OutboxRepository
+	var webhookRepo repository.WebhookRepository
+	var deliveryRepo repository.WebhookDeliveryRepository
+	var transactor repository.Transactor
 	
 	if config.Database.Type == "mongodb" && config.Database.URL != "" {
-		logger.Info("🔌 Connecting to MongoDB...")
-		customerRepo, err = repository.NewMongoCustomerRepository(config.Database.URL)
-		if err != nil {
-			logger.WithError(err).Warn("⚠️ Failed to connect to MongoDB, falling back to memory repository")
-			customerRepo = repository.NewMemoryCustomerRepository()
-		} else {
-			logger.Info("✅ Connected to MongoDB successfully")
+		logger.WithFields(logrus.Fields{
+			"database":   config.Database.Database,
+			"collection": config.Database.Collection,
+			"policy":     config.Database.StartupPolicy,
+		}).Info("🔌 Connecting to MongoDB...")
+		
+		// A swappable repository lets a degraded start switch to MongoDB once
+		// the background reconnect succeeds
+		notConnected := errors.New("not connected to MongoDB")
+		customers := repository.NewSwappableCustomerRepository(repository.NewUnavailableCustomerRepository(notConnected))
+		history := repository.NewSwappableCustomerHistoryRepository(repository.NewUnavailableCustomerHistoryRepository(notConnected))
+		outbox := repository.NewSwappableOutboxRepository(repository.NewUnavailableOutboxRepository(notConnected))
+		webhooks := repository.NewSwappableWebhookRepository(repository.NewUnavailableWebhookRepository(notConnected))
+		deliveries := repository.NewSwappableWebhookDeliveryRepository(repository.NewUnavailableWebhookDeliveryRepository(notConnected))
+		transactions := repository.NewSwappableTransactor(repository.NewDirectTransactor())
+		customerRepo = customers
+		historyRepo = history
+		outboxRepo = outbox
+		webhookRepo = webhooks
+		deliveryRepo = deliveries
+		transactor = transactions
+		
+		connect := func() error {
+			mongoRepo, err := repository.NewMongoCustomerRepository(config.Database)
+			if err != nil {
+				return err
+			}
+			mongoHistory, err := repository.NewMongoCustomerHistoryRepository(mongoRepo, config.Database)
+			if err != nil {
+				mongoRepo.Close(context.Background())
+				return err
+			}
+			mongoOutbox, err := repository.NewMongoOutboxRepository(mongoRepo, config.Database)
+			if err != nil {
+				mongoRepo.Close(context.Background())
+				return err
+			}
+			mongoWebhooks, err := repository.NewMongoWebhookRepository(mongoRepo, config.Database)
+			if err != nil {
+				mongoRepo.Close(context.Background())
+				return err
+			}
+			mongoDeliveries, err := repository.NewMongoWebhookDeliveryRepository(mongoRepo, config.Database)
+			if err != nil {
+				mongoRepo.Close(context.Background())
+				return err
+			}
+			mongoTransactor, err := repository.NewMongoTransactor(mongoRepo, config.Database)
+			if err != nil {
+				mongoRepo.Close(context.Background())
+				return err
+			}
+			if !mongoTransactor.Supported() {
+				logger.Warn("⚠️ MongoDB is not a replica set; writes, history and events are stored without transactions")
+			}
+			customers.Swap(mongoRepo)
+			history.Swap(mongoHistory)
+			outbox.Swap(mongoOutbox)
+			webhooks.Swap(mongoWebhooks)
+			deliveries.Swap(mongoDeliveries)
+			transactions.Swap(mongoTransactor)
+			return nil
+		}
+		degrade := func(cause error) {
+			customers.Swap(repository.NewUnavailableCustomerRepository(cause))
+			history.Swap(repository.NewUnavailableCustomerHistoryRepository(cause))
+			outbox.Swap(repository.NewUnavailableOutboxRepository(cause))
+			webhooks.Swap(repository.NewUnavailableWebhookRepository(cause))
+			deliveries.Swap(repository.NewUnavailableWebhookDeliveryRepository(cause))
+			transactions.Swap(repository.NewDirectTransactor())
 		}
+		connectDatabase(workerCtx, config.Database, logger, connect, degrade)
 	} else {
 		logger.Info("💾 Using in-memory repository")
-		customerRepo = repository.NewMemoryCustomerRepository()
+		memoryRepo := repository.NewMemoryCustomerRepository()
+		if config.Database.FixturesPath != "" {
+			seedMemoryRepository(memoryRepo, config.Database, logger)
+		}
+		customerRepo = memoryRepo
+		historyRepo = repository.NewMemoryCustomerHistoryRepository()
+		outboxRepo = repository.NewMemoryOutboxRepository()
+		webhookRepo = repository.NewMemoryWebhookRepository()
+		deliveryRepo = repository.NewMemoryWebhookDeliveryRepository()
+		transactor = repository.NewDirectTransactor()
+	}
+	
+	registry := metrics.NewRegistry()
+	
+	if config.Features.EnableMetrics {
+		repositoryMetrics := metrics.NewRepositoryMetrics(registry)
+		customerRepo = repository.NewInstrumentedCustomerRepository(customerRepo, repositoryMetrics.Observe)
+	}
+	
+	if config.Cache.Enabled {
+		logger.WithFields(logrus.Fields{
+			"ttl":     config.Cache.TTL,
+			"maxSize": config.Cache.MaxSize,
+		}).Info("🗄️ Customer cache enabled")
+		customerRepo = repository.NewCachedCustomerRepository(customerRepo, config.Cache.TTL, config.Cache.MaxSize)
+	}
+	
+	// Change events are only recorded when there is a sink to publish them to
+	eventSink, err := events.NewSink(config.Events)
+	if err != nil {
+		logger.WithError(err).Fatal("💥 Failed to set up event publishing")
+	}
+	var eventOutbox repository.OutboxRepository
+	if eventSink != nil {
+		eventOutbox = outboxRepo
 	}
 	
-	customerService := services.NewCustomerService(customerRepo, config, logger)
+	webhookService := services.NewWebhookService(webhookRepo, deliveryRepo, config, logger)
+	webhookHandler := handlers.NewWebhookHandler(webhookService, logger)
+	customerService := services.NewCustomerService(customerRepo, historyRepo, eventOutbox, webhookService, transactor, config, logger)
 	customerHandler := handlers.NewCustomerHandler(customerService, logger)
+	customerService.RegisterMetrics(registry)
 	
 	// Setup Echo server
 	e := echo.New()
@@ -64,19 +176,20 @@ func main() {
 	e.Use(middleware.Recover())
 	e.Use(middleware.CORS())
 	e.Use(custommiddleware.RequestIDMiddleware())
+	e.Use(custommiddleware.ActorMiddleware())
 	
 	if config.Logging.RequestLog {
 		e.Use(custommiddleware.StructuredLoggingMiddleware(logger))
 	}
 	
 	if config.Features.EnableMetrics {
-		e.Use(custommiddleware.MetricsMiddleware())
+		e.Use(custommiddleware.MetricsMiddleware(metrics.NewHTTPMetrics(registry)))
 	}
 	
 	e.Use(custommiddleware.ErrorHandlingMiddleware(logger))
 	
 	// Setup routes
-	setupRoutes(e, customerHandler)
+	setupRoutes(e, customerHandler, webhookHandler, registry)
 	
 	// Setup server with timeouts
 	server := &http.Server{
@@ -85,6 +198,28 @@ func main() {
 		WriteTimeout: config.Server.WriteTimeout,
 	}
 	
+	// Send webhook deliveries in the background
+	go webhookService.Run(workerCtx)
+	
+	// Publish change events in the background
+	relayDone := make(chan struct{})
+	if eventSink != nil {
+		logger.WithFields(logrus.Fields{
+			"sink":  config.Events.Sink,
+			"topic": config.Events.Topic,
+		}).Info("📣 Publishing customer events")
+		relay := services.NewEventRelay(outboxRepo, eventSink, config, logger)
+		go func() {
+			defer close(relayDone)
+			relay.Run(workerCtx)
+		}()
+	} else {
+		close(relayDone)
+	}
+	
+	// Startup is complete; readiness now depends only on the repository
+	customerService.MarkStarted()
+	
 	// Start server in goroutine
 	go func() {
 		logger.WithFields(logrus.Fields{
@@ -104,6 +239,19 @@ func main() {
 	
 	logger.Info("🛑 Shutting down server...")
 	
+	// Fail readiness first and give load balancers time to stop routing to
+	// this instance before the listener closes
+	customerService.MarkDraining()
+	logger.WithField("drainDelay", config.Server.DrainDelay).Info("⏳ Draining traffic")
+	time.Sleep(config.Server.DrainDelay)
+	stopWorkers()
+	<-relayDone
+	if eventSink != nil {
+		if err := eventSink.Close(); err != nil {
+			logger.WithError(err).Warn("⚠️ Failed to close event sink")
+		}
+	}
+	
 	// Graceful shutdown with timeout
 	ctx, cancel := context.WithTimeout(context.Background(), config.Server.ShutdownTimeout)
 	defer cancel()
@@ -115,6 +263,78 @@ func main() {
 	}
 }
 
+// connectDatabase applies the startup policy to connect, which opens the
+// database and installs it in the repositories. Under StartupDegraded a
+// failure is handed to degrade and connect is retried in the background until
+// it succeeds or ctx ends; the other policies exit when they give up.
+func connectDatabase(ctx context.Context, config configs.DatabaseConfig, logger *logrus.Logger, connect func() error, degrade func(error)) {
+	logRetry := func(attempt int, err error, wait time.Duration) {
+		logger.WithError(err).WithFields(logrus.Fields{
+			"attempt": attempt,
+			"retryIn": wait,
+		}).Warn("⚠️ MongoDB not reachable, retrying")
+	}
+	
+	switch config.StartupPolicy {
+	case configs.StartupFail:
+		if err := connect(); err != nil {
+			logger.WithError(err).Fatal("💥 Failed to connect to MongoDB")
+		}
+	case configs.StartupRetry:
+		retryCtx, cancel := context.WithTimeout(ctx, config.StartupTimeout)
+		defer cancel()
+		if err := repository.RetryWithBackoff(retryCtx, config.RetryInitialBackoff, config.RetryMaxBackoff, connect, logRetry); err != nil {
+			logger.WithError(err).Fatal("💥 Gave up connecting to MongoDB")
+		}
+	case configs.StartupDegraded:
+		err := connect()
+		if err == nil {
+			break
+		}
+		logger.WithError(err).Warn("⚠️ MongoDB unreachable, starting degraded and reconnecting in the background")
+		degrade(err)
+		go func() {
+			if err := repository.RetryWithBackoff(ctx, config.RetryInitialBackoff, config.RetryMaxBackoff, connect, logRetry); err != nil {
+				logger.WithError(err).Warn("⚠️ Stopped reconnecting to MongoDB")
+				return
+			}
+			logger.Info("✅ Connected to MongoDB, leaving degraded mode")
+		}()
+		return
+	default:
+		logger.WithField("policy", config.StartupPolicy).Fatal("💥 Unknown database startup policy")
+	}
+	
+	logger.Info("✅ Connected to MongoDB successfully")
+}
+
+// seedMemoryRepository loads the configured fixtures into repo, validating
+// them like API input, and optionally writes later changes back to disk
+func seedMemoryRepository(repo *repository.MemoryCustomerRepository, config configs.DatabaseConfig, logger *logrus.Logger) {
+	customers, err := repository.LoadCustomerFixtures(config.FixturesPath, config.Collection)
+	if err != nil {
+		logger.WithError(err).Fatal("💥 Failed to load fixtures")
+	}
+	if err := repo.Seed(customers, services.ValidateCustomer); err != nil {
+		logger.WithError(err).Fatal("💥 Invalid fixtures")
+	}
+	
+	logger.WithFields(logrus.Fields{
+		"path":      config.FixturesPath,
+		"customers": len(customers),
+	}).Info("🌱 Seeded in-memory repository from fixtures")
+	
+	if config.PersistFixtures {
+		onError := func(err error) {
+			logger.WithError(err).Error("💥 Failed to persist fixtures")
+		}
+		if err := repo.PersistTo(config.FixturesPath, onError); err != nil {
+			logger.WithError(err).Fatal("💥 Cannot persist fixtures")
+		}
+		logger.WithField("path", config.FixturesPath).Info("💾 Writing changes back to fixtures")
+	}
+}
+
 // setupLogger configures the application logger
 func setupLogger(config *configs.Config) *logrus.Logger {
 	logger := logrus.New()
@@ -156,7 +376,7 @@ func setupLogger(config *configs.Config) *logrus.Logger {
 }
 
 // setupRoutes configures all API routes
-func setupRoutes(e *echo.Echo, customerHandler *handlers.CustomerHandler) {
+func setupRoutes(e *echo.Echo, customerHandler *handlers.CustomerHandler, webhookHandler *handlers.WebhookHandler, registry *metrics.Registry) {
 	// API v1 routes
 	v1 := e.Group("/api/v1")
 	{
@@ -165,6 +385,25 @@ func setupRoutes(e *echo.Echo, customerHandler *handlers.CustomerHandler) {
 		v1.GET("/customers/active", customerHandler.GetActiveCustomers)
 		v1.GET("/customers/:id", customerHandler.GetCustomer)
 		v1.POST("/customers", customerHandler.CreateCustomer)
+		v1.PUT("/customers/:id", customerHandler.UpdateCustomer)
+		v1.PATCH("/customers/:id", customerHandler.PatchCustomer)
+		v1.PUT("/customers/:id/address", customerHandler.ReplaceAddress)
+		v1.PATCH("/customers/:id/address", customerHandler.PatchAddress)
+		v1.PUT("/customers/:id/preferences", customerHandler.ReplacePreferences)
+		v1.PATCH("/customers/:id/preferences", customerHandler.PatchPreferences)
+		v1.POST("/customers/:id/activate", customerHandler.ActivateCustomer)
+		v1.POST("/customers/:id/deactivate", customerHandler.DeactivateCustomer)
+		v1.GET("/customers/:id/history", customerHandler.GetCustomerHistory)
+		v1.POST("/customers/:id/restore", customerHandler.RestoreCustomer)
+		
+		// Webhook subscription and delivery routes
+		v1.POST("/webhooks", webhookHandler.CreateWebhook)
+		v1.GET("/webhooks", webhookHandler.GetWebhooks)
+		v1.GET("/webhooks/dead-letters", webhookHandler.GetDeadLetters)
+		v1.GET("/webhooks/:id", webhookHandler.GetWebhook)
+		v1.DELETE("/webhooks/:id", webhookHandler.DeleteWebhook)
+		v1.GET("/webhooks/:id/deliveries", webhookHandler.GetDeliveries)
+		v1.POST("/webhooks/:id/deliveries/:deliveryId/redeliver", webhookHandler.RedeliverDelivery)
 	}
 	
 	// Legacy routes for backward compatibility
@@ -173,10 +412,17 @@ func setupRoutes(e *echo.Echo, customerHandler *handlers.CustomerHandler) {
 	e.GET("/customers/:id", customerHandler.GetCustomer)
 	e.POST("/customers", customerHandler.CreateCustomer)
 	
-	// Health and monitoring routes (support both GET and HEAD for Docker health checks)
+	// Probes and health (support both GET and HEAD for Docker health checks).
+	// /livez and /readyz are cheap enough for frequent polling; /health
+	// reports every dependency in detail.
+	e.GET("/livez", customerHandler.GetLiveness)
+	e.HEAD("/livez", customerHandler.GetLiveness)
+	e.GET("/readyz", customerHandler.GetReadiness)
+	e.HEAD("/readyz", customerHandler.GetReadiness)
 	e.GET("/health", customerHandler.GetHealth)
 	e.HEAD("/health", customerHandler.GetHealth)
-	e.GET("/metrics", customerHandler.GetMetrics)
+	e.GET("/metrics", handlers.PrometheusMetrics(registry))
+	e.GET("/metrics.json", customerHandler.GetMetrics)
 	
 	// Root endpoint
 	e.GET("/", func(c echo.Context) error {
@@ -186,8 +432,11 @@ func setupRoutes(e *echo.Echo, customerHandler *handlers.CustomerHandler) {
 			"environment": os.Getenv("ENVIRONMENT"),
 			"timestamp":   time.Now(),
 			"endpoints": map[string]string{
+				"liveness":         "/livez",
+				"readiness":        "/readyz",
 				"health":           "/health",
 				"metrics":          "/metrics",
+				"metrics_json":     "/metrics.json",
 				"customers":        "/customers",
 				"active_customers": "/customers/active",
 				"api_v1":           "/api/v1",
@@ -3,6 +3,7 @@ package configs
 import (
 	"os"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -13,6 +14,8 @@ type Config struct {
 	Logging  LoggingConfig  `json:"logging"`
 	Features FeatureFlags   `json:"features"`
 	Cache    CacheConfig    `json:"cache"`
+	Events   EventsConfig   `json:"events"`
+	Webhooks WebhooksConfig `json:"webhooks"`
 }
 
 // ServerConfig holds server-related configuration
@@ -22,25 +25,65 @@ type ServerConfig struct {
 	ReadTimeout     time.Duration `json:"readTimeout"`
 	WriteTimeout    time.Duration `json:"writeTimeout"`
 	ShutdownTimeout time.Duration `json:"shutdownTimeout"`
+	DrainDelay      time.Duration `json:"drainDelay"`   // time between failing readiness and closing the listener
+	ProbeTimeout    time.Duration `json:"probeTimeout"` // deadline for each dependency check
 	Environment     string        `json:"environment"`
 	Version         string        `json:"version"`
 }
 
 // DatabaseConfig holds database-related configuration
 type DatabaseConfig struct {
-	Type           string        `json:"type"`
-	URL            string        `json:"url"`
-	Database       string        `json:"database"`
-	Collection     string        `json:"collection"`
-	MaxConnections int           `json:"maxConnections"`
-	Timeout        time.Duration `json:"timeout"`
+	Type               string        `json:"type"`
+	URL                string        `json:"url"`
+	Database           string        `json:"database"`
+	Collection         string        `json:"collection"`
+	HistoryCollection  string        `json:"historyCollection"`
+	OutboxCollection   string        `json:"outboxCollection"`
+	WebhookCollection  string        `json:"webhookCollection"`
+	DeliveryCollection string        `json:"deliveryCollection"`
+	MaxConnections     int           `json:"maxConnections"`
+	MinConnections     int           `json:"minConnections"`
+	MaxConnIdleTime    time.Duration `json:"maxConnIdleTime"`
+	ConnectTimeout     time.Duration `json:"connectTimeout"`
+	Timeout            time.Duration `json:"timeout"`        // per-operation deadline when the caller sets none
+	ReadPreference     string        `json:"readPreference"` // primary, primaryPreferred, secondary, secondaryPreferred, nearest
+	WriteConcern       string        `json:"writeConcern"`   // majority or a number of nodes
+	WriteJournal       bool          `json:"writeJournal"`
+	TLS                TLSConfig     `json:"tls"`
+
+	// StartupPolicy decides what happens when the database is unreachable at
+	// startup: StartupFail, StartupRetry or StartupDegraded
+	StartupPolicy       string        `json:"startupPolicy"`
+	StartupTimeout      time.Duration `json:"startupTimeout"` // how long StartupRetry keeps trying
+	RetryInitialBackoff time.Duration `json:"retryInitialBackoff"`
+	RetryMaxBackoff     time.Duration `json:"retryMaxBackoff"`
+
+	// FixturesPath seeds the in-memory repository from a JSON/NDJSON file or
+	// a directory of them; PersistFixtures writes changes back to that file
+	FixturesPath    string `json:"fixturesPath"`
+	PersistFixtures bool   `json:"persistFixtures"`
+}
+
+// Database startup policies
+const (
+	StartupFail     = "fail"     // exit immediately
+	StartupRetry    = "retry"    // retry with backoff until StartupTimeout, then exit
+	StartupDegraded = "degraded" // serve 503s and stay not-ready while reconnecting in the background
+)
+
+// TLSConfig holds TLS options for the database connection
+type TLSConfig struct {
+	Enabled            bool   `json:"enabled"`
+	CAFile             string `json:"caFile"`
+	CertificateKeyFile string `json:"certificateKeyFile"` // PEM with client certificate and private key
+	InsecureSkipVerify bool   `json:"insecureSkipVerify"`
 }
 
 // LoggingConfig holds logging configuration
 type LoggingConfig struct {
 	Level      string `json:"level"`
-	Format     string `json:"format"`     // json, text
-	Output     string `json:"output"`     // stdout, file
+	Format     string `json:"format"` // json, text
+	Output     string `json:"output"` // stdout, file
 	RequestLog bool   `json:"requestLog"`
 }
 
@@ -62,6 +105,43 @@ type CacheConfig struct {
 	MaxSize int           `json:"maxSize"`
 }
 
+// EventsConfig holds change-data event configuration. Events are recorded
+// in the outbox and published only when a sink is configured.
+type EventsConfig struct {
+	Sink           string        `json:"sink"` // SinkKafka, SinkWebhook, SinkFile or empty for none
+	Topic          string        `json:"topic"`
+	KafkaBrokers   []string      `json:"kafkaBrokers"`
+	WebhookURL     string        `json:"webhookUrl"`
+	WebhookTimeout time.Duration `json:"webhookTimeout"`
+	FilePath       string        `json:"filePath"`     // NDJSON file the file sink appends to
+	PollInterval   time.Duration `json:"pollInterval"` // how often the relay looks for new events
+	BatchSize      int           `json:"batchSize"`    // events published per sink call
+	MaxBackoff     time.Duration `json:"maxBackoff"`   // longest wait between attempts while the sink fails
+	MaxAttempts    int           `json:"maxAttempts"`  // failed attempts before an event is parked as a dead letter
+}
+
+// Event sinks
+const (
+	SinkKafka   = "kafka"
+	SinkWebhook = "webhook"
+	SinkFile    = "file"
+)
+
+// WebhooksConfig holds outgoing webhook delivery configuration. A delivery
+// that fails is retried after InitialBackoff, doubling up to MaxBackoff
+// with random jitter, and dead-lettered after MaxAttempts attempts.
+// Deliveries only go to public addresses unless AllowPrivateNetworks is
+// set, which is meant for local development and tests.
+type WebhooksConfig struct {
+	Timeout              time.Duration `json:"timeout"`      // deadline for each delivery request
+	PollInterval         time.Duration `json:"pollInterval"` // how often the dispatcher looks for due deliveries
+	BatchSize            int           `json:"batchSize"`    // deliveries claimed per poll
+	MaxAttempts          int           `json:"maxAttempts"`
+	InitialBackoff       time.Duration `json:"initialBackoff"`
+	MaxBackoff           time.Duration `json:"maxBackoff"`
+	AllowPrivateNetworks bool          `json:"allowPrivateNetworks"` // deliver to loopback, private and link-local addresses
+}
+
 // LoadConfig loads configuration from environment variables with defaults
 func LoadConfig() *Config {
 	return &Config{
@@ -71,16 +151,40 @@ func LoadConfig() *Config {
 			ReadTimeout:     getDurationEnv("READ_TIMEOUT", 10*time.Second),
 			WriteTimeout:    getDurationEnv("WRITE_TIMEOUT", 10*time.Second),
 			ShutdownTimeout: getDurationEnv("SHUTDOWN_TIMEOUT", 30*time.Second),
+			DrainDelay:      getDurationEnv("SHUTDOWN_DRAIN_DELAY", 5*time.Second),
+			ProbeTimeout:    getDurationEnv("PROBE_TIMEOUT", 2*time.Second),
 			Environment:     getEnv("ENVIRONMENT", "development"),
 			Version:         getEnv("VERSION", "1.0.0"),
 		},
 		Database: DatabaseConfig{
-			Type:           getEnv("DATABASE_TYPE", "mongodb"),
-			URL:            getEnv("DATABASE_URL", "mongodb://mongo:27017"),
-			Database:       getEnv("DATABASE_NAME", "catalog"),
-			Collection:     getEnv("DATABASE_COLLECTION", "customers"),
-			MaxConnections: getIntEnv("DATABASE_MAX_CONNECTIONS", 10),
-			Timeout:        getDurationEnv("DATABASE_TIMEOUT", 5*time.Second),
+			Type:               getEnv("DATABASE_TYPE", "mongodb"),
+			URL:                getEnv("DATABASE_URL", "mongodb://mongo:27017"),
+			Database:           getEnv("DATABASE_NAME", "catalog"),
+			Collection:         getEnv("DATABASE_COLLECTION", "customers"),
+			HistoryCollection:  getEnv("DATABASE_HISTORY_COLLECTION", "customer_history"),
+			OutboxCollection:   getEnv("DATABASE_OUTBOX_COLLECTION", "customer_outbox"),
+			WebhookCollection:  getEnv("DATABASE_WEBHOOK_COLLECTION", "customer_webhooks"),
+			DeliveryCollection: getEnv("DATABASE_DELIVERY_COLLECTION", "customer_webhook_deliveries"),
+			MaxConnections:     getIntEnv("DATABASE_MAX_CONNECTIONS", 10),
+			MinConnections:     getIntEnv("DATABASE_MIN_CONNECTIONS", 0),
+			MaxConnIdleTime:    getDurationEnv("DATABASE_MAX_CONN_IDLE_TIME", 5*time.Minute),
+			ConnectTimeout:     getDurationEnv("DATABASE_CONNECT_TIMEOUT", 10*time.Second),
+			Timeout:            getDurationEnv("DATABASE_TIMEOUT", 5*time.Second),
+			ReadPreference:     getEnv("DATABASE_READ_PREFERENCE", ""),
+			WriteConcern:       getEnv("DATABASE_WRITE_CONCERN", ""),
+			WriteJournal:       getBoolEnv("DATABASE_WRITE_JOURNAL", false),
+			TLS: TLSConfig{
+				Enabled:            getBoolEnv("DATABASE_TLS", false),
+				CAFile:             getEnv("DATABASE_TLS_CA_FILE", ""),
+				CertificateKeyFile: getEnv("DATABASE_TLS_CERT_KEY_FILE", ""),
+				InsecureSkipVerify: getBoolEnv("DATABASE_TLS_INSECURE", false),
+			},
+			StartupPolicy:       getEnv("DATABASE_STARTUP_POLICY", StartupRetry),
+			StartupTimeout:      getDurationEnv("DATABASE_STARTUP_TIMEOUT", time.Minute),
+			RetryInitialBackoff: getDurationEnv("DATABASE_RETRY_INITIAL_BACKOFF", 500*time.Millisecond),
+			RetryMaxBackoff:     getDurationEnv("DATABASE_RETRY_MAX_BACKOFF", 30*time.Second),
+			FixturesPath:        getEnv("FIXTURES_PATH", ""),
+			PersistFixtures:     getBoolEnv("FIXTURES_PERSIST", false),
 		},
 		Logging: LoggingConfig{
 			Level:      getEnv("LOG_LEVEL", "info"),
@@ -102,6 +206,27 @@ func LoadConfig() *Config {
 			TTL:     getDurationEnv("CACHE_TTL", 5*time.Minute),
 			MaxSize: getIntEnv("CACHE_MAX_SIZE", 1000),
 		},
+		Events: EventsConfig{
+			Sink:           getEnv("EVENTS_SINK", ""),
+			Topic:          getEnv("EVENTS_TOPIC", "customer-events"),
+			KafkaBrokers:   getListEnv("EVENTS_KAFKA_BROKERS", []string{"kafka:9092"}),
+			WebhookURL:     getEnv("EVENTS_WEBHOOK_URL", ""),
+			WebhookTimeout: getDurationEnv("EVENTS_WEBHOOK_TIMEOUT", 10*time.Second),
+			FilePath:       getEnv("EVENTS_FILE_PATH", "customer-events.ndjson"),
+			PollInterval:   getDurationEnv("EVENTS_POLL_INTERVAL", time.Second),
+			BatchSize:      getIntEnv("EVENTS_BATCH_SIZE", 100),
+			MaxBackoff:     getDurationEnv("EVENTS_MAX_BACKOFF", time.Minute),
+			MaxAttempts:    getIntEnv("EVENTS_MAX_ATTEMPTS", 20),
+		},
+		Webhooks: WebhooksConfig{
+			Timeout:              getDurationEnv("WEBHOOKS_TIMEOUT", 10*time.Second),
+			PollInterval:         getDurationEnv("WEBHOOKS_POLL_INTERVAL", time.Second),
+			BatchSize:            getIntEnv("WEBHOOKS_BATCH_SIZE", 50),
+			MaxAttempts:          getIntEnv("WEBHOOKS_MAX_ATTEMPTS", 8),
+			InitialBackoff:       getDurationEnv("WEBHOOKS_INITIAL_BACKOFF", 5*time.Second),
+			MaxBackoff:           getDurationEnv("WEBHOOKS_MAX_BACKOFF", time.Hour),
+			AllowPrivateNetworks: getBoolEnv("WEBHOOKS_ALLOW_PRIVATE_NETWORKS", false),
+		},
 	}
 }
 
@@ -131,6 +256,20 @@ func getFloatEnv(key string, defaultValue float64) float64 {
 	return defaultValue
 }
 
+func getListEnv(key string, defaultValue []string) []string {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	var values []string
+	for _, part := range strings.Split(value, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			values = append(values, part)
+		}
+	}
+	return values
+}
+
 func getBoolEnv(key string, defaultValue bool) bool {
 	if value := os.Getenv(key); value != "" {
 		if boolValue, err := strconv.ParseBool(value); err == nil {
@@ -147,4 +286,4 @@ func getDurationEnv(key string, defaultValue time.Duration) time.Duration {
 		}
 	}
 	return defaultValue
-}
\ No newline at end of file
+}
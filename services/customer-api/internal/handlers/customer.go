@@ -1,9 +1,12 @@
 package handlers
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
-	"time"
+	"strings"
 
 	"github.com/labstack/echo/v4"
 	"github.com/customer-api-v2/internal/models"
@@ -37,21 +40,10 @@ func (h *CustomerHandler) GetCustomer(c echo.Context) error {
 	ctx := c.Request().Context()
 	customer, err := h.service.GetCustomer(ctx, customerID)
 	if err != nil {
-		// Check if it's a not found error
-		if err.Error() == "customer with ID "+customerID+" not found" {
-			return h.errorResponse(c, http.StatusNotFound, "customer_not_found", err.Error())
-		}
-		
-		// Check if it's an inactive customer error
-		if err.Error() == "customer "+customerID+" is not active" {
-			return h.errorResponse(c, http.StatusGone, "customer_inactive", err.Error())
-		}
-		
-		// Other errors are internal server errors
-		return h.errorResponse(c, http.StatusInternalServerError, "internal_error", "Failed to retrieve customer")
+		return h.handleServiceError(c, err, "Failed to retrieve customer")
 	}
 	
-	return c.JSON(http.StatusOK, customer)
+	return respondVersioned(c, http.StatusOK, customer.Version, customer)
 }
 
 // GetCustomers handles GET /customers
@@ -69,6 +61,15 @@ func (h *CustomerHandler) GetCustomers(c echo.Context) error {
 		filters.Email = email
 	}
 	
+	// visibility=public leaves out inactive customers
+	if visibilityStr := c.QueryParam("visibility"); visibilityStr != "" {
+		visibility, err := repository.ParseVisibility(visibilityStr)
+		if err != nil {
+			return h.errorResponse(c, http.StatusBadRequest, "invalid_parameter", err.Error())
+		}
+		filters.Visibility = visibility
+	}
+	
 	// Parse pagination parameters
 	if pageStr := c.QueryParam("page"); pageStr != "" {
 		if page, err := strconv.Atoi(pageStr); err == nil && page >= 0 {
@@ -82,12 +83,18 @@ func (h *CustomerHandler) GetCustomers(c echo.Context) error {
 		}
 	}
 	
+	// Parse sorting and keyset pagination parameters
+	if code, err := parseListingOrder(c, &filters); err != nil {
+		return h.errorResponse(c, http.StatusBadRequest, code, err.Error())
+	}
+	
 	ctx := c.Request().Context()
 	response, err := h.service.GetCustomers(ctx, filters)
 	if err != nil {
-		return h.errorResponse(c, http.StatusInternalServerError, "internal_error", "Failed to retrieve customers")
+		return h.handleServiceError(c, err, "Failed to retrieve customers")
 	}
 	
+	response.Links = pageLinks(c, response.NextCursor, response.PrevCursor)
 	return c.JSON(http.StatusOK, response)
 }
 
@@ -113,12 +120,18 @@ func (h *CustomerHandler) GetActiveCustomers(c echo.Context) error {
 		}
 	}
 	
+	// Parse sorting and keyset pagination parameters
+	if code, err := parseListingOrder(c, &filters); err != nil {
+		return h.errorResponse(c, http.StatusBadRequest, code, err.Error())
+	}
+	
 	ctx := c.Request().Context()
 	response, err := h.service.GetActiveCustomers(ctx, filters)
 	if err != nil {
-		return h.errorResponse(c, http.StatusInternalServerError, "internal_error", "Failed to retrieve active customers")
+		return h.handleServiceError(c, err, "Failed to retrieve active customers")
 	}
 	
+	response.Links = pageLinks(c, response.NextCursor, response.PrevCursor)
 	return c.JSON(http.StatusOK, response)
 }
 
@@ -132,24 +145,229 @@ func (h *CustomerHandler) CreateCustomer(c echo.Context) error {
 	
 	ctx := c.Request().Context()
 	if err := h.service.CreateCustomer(ctx, &customer); err != nil {
-		if err.Error() == "customer "+customer.CustomerID+" already exists" {
-			return h.errorResponse(c, http.StatusConflict, "customer_exists", err.Error())
-		}
-		
-		// Check if it's a validation error
-		if len(err.Error()) > 17 && err.Error()[:17] == "validation failed" {
-			return h.errorResponse(c, http.StatusBadRequest, "validation_error", err.Error())
-		}
-		
-		return h.errorResponse(c, http.StatusInternalServerError, "internal_error", "Failed to create customer")
+		return h.handleServiceError(c, err, "Failed to create customer")
 	}
 	
+	c.Response().Header().Set("ETag", entityTag(customer.Version))
 	return c.JSON(http.StatusCreated, map[string]interface{}{
 		"message":    "Customer created successfully",
 		"customerId": customer.CustomerID,
 	})
 }
 
+// UpdateCustomer handles PUT /customers/:id
+func (h *CustomerHandler) UpdateCustomer(c echo.Context) error {
+	var customer models.Customer
+
+	if err := c.Bind(&customer); err != nil {
+		return h.errorResponse(c, http.StatusBadRequest, "invalid_json", "Invalid JSON format")
+	}
+
+	ifMatch, err := parseIfMatch(c)
+	if err != nil {
+		return h.errorResponse(c, http.StatusBadRequest, "invalid_precondition", err.Error())
+	}
+
+	ctx := c.Request().Context()
+	updated, err := h.service.UpdateCustomer(ctx, c.Param("id"), &customer, ifMatch)
+	if err != nil {
+		return h.handleServiceError(c, err, "Failed to update customer")
+	}
+
+	return respondVersioned(c, http.StatusOK, updated.Version, updated)
+}
+
+// PatchCustomer handles PATCH /customers/:id with JSON merge patch semantics
+func (h *CustomerHandler) PatchCustomer(c echo.Context) error {
+	patch, err := decodePatch(c)
+	if err != nil {
+		return h.errorResponse(c, http.StatusBadRequest, "invalid_json", "Patch body must be a JSON object")
+	}
+
+	ifMatch, err := parseIfMatch(c)
+	if err != nil {
+		return h.errorResponse(c, http.StatusBadRequest, "invalid_precondition", err.Error())
+	}
+
+	ctx := c.Request().Context()
+	updated, err := h.service.PatchCustomer(ctx, c.Param("id"), patch, ifMatch)
+	if err != nil {
+		return h.handleServiceError(c, err, "Failed to patch customer")
+	}
+
+	return respondVersioned(c, http.StatusOK, updated.Version, updated)
+}
+
+// ReplaceAddress handles PUT /customers/:id/address
+func (h *CustomerHandler) ReplaceAddress(c echo.Context) error {
+	var address models.Address
+
+	if err := c.Bind(&address); err != nil {
+		return h.errorResponse(c, http.StatusBadRequest, "invalid_json", "Invalid JSON format")
+	}
+
+	ifMatch, err := parseIfMatch(c)
+	if err != nil {
+		return h.errorResponse(c, http.StatusBadRequest, "invalid_precondition", err.Error())
+	}
+
+	ctx := c.Request().Context()
+	updated, err := h.service.ReplaceAddress(ctx, c.Param("id"), address, ifMatch)
+	if err != nil {
+		return h.handleServiceError(c, err, "Failed to update address")
+	}
+
+	return respondVersioned(c, http.StatusOK, updated.Version, updated.Address)
+}
+
+// PatchAddress handles PATCH /customers/:id/address
+func (h *CustomerHandler) PatchAddress(c echo.Context) error {
+	return h.patchSection(c, "address", func(customer *models.Customer) interface{} { return customer.Address })
+}
+
+// ReplacePreferences handles PUT /customers/:id/preferences
+func (h *CustomerHandler) ReplacePreferences(c echo.Context) error {
+	var preferences models.Preferences
+
+	if err := c.Bind(&preferences); err != nil {
+		return h.errorResponse(c, http.StatusBadRequest, "invalid_json", "Invalid JSON format")
+	}
+
+	ifMatch, err := parseIfMatch(c)
+	if err != nil {
+		return h.errorResponse(c, http.StatusBadRequest, "invalid_precondition", err.Error())
+	}
+
+	ctx := c.Request().Context()
+	updated, err := h.service.ReplacePreferences(ctx, c.Param("id"), preferences, ifMatch)
+	if err != nil {
+		return h.handleServiceError(c, err, "Failed to update preferences")
+	}
+
+	return respondVersioned(c, http.StatusOK, updated.Version, updated.Preferences)
+}
+
+// PatchPreferences handles PATCH /customers/:id/preferences
+func (h *CustomerHandler) PatchPreferences(c echo.Context) error {
+	return h.patchSection(c, "preferences", func(customer *models.Customer) interface{} { return customer.Preferences })
+}
+
+// patchSection applies a merge patch to one nested section of a customer
+// and responds with the updated section
+func (h *CustomerHandler) patchSection(c echo.Context, section string, view func(*models.Customer) interface{}) error {
+	patch, err := decodePatch(c)
+	if err != nil {
+		return h.errorResponse(c, http.StatusBadRequest, "invalid_json", "Patch body must be a JSON object")
+	}
+
+	ifMatch, err := parseIfMatch(c)
+	if err != nil {
+		return h.errorResponse(c, http.StatusBadRequest, "invalid_precondition", err.Error())
+	}
+
+	ctx := c.Request().Context()
+	updated, err := h.service.PatchCustomer(ctx, c.Param("id"), map[string]interface{}{section: patch}, ifMatch)
+	if err != nil {
+		return h.handleServiceError(c, err, "Failed to update "+section)
+	}
+
+	return respondVersioned(c, http.StatusOK, updated.Version, view(updated))
+}
+
+// GetCustomerHistory handles GET /customers/:id/history
+func (h *CustomerHandler) GetCustomerHistory(c echo.Context) error {
+	ctx := c.Request().Context()
+	history, err := h.service.GetCustomerHistory(ctx, c.Param("id"))
+	if err != nil {
+		return h.handleServiceError(c, err, "Failed to retrieve customer history")
+	}
+
+	return c.JSON(http.StatusOK, history)
+}
+
+// RestoreCustomer handles POST /customers/:id/restore with the version to
+// bring back in the body
+func (h *CustomerHandler) RestoreCustomer(c echo.Context) error {
+	var request models.RestoreRequest
+
+	if err := c.Bind(&request); err != nil {
+		return h.errorResponse(c, http.StatusBadRequest, "invalid_json", "Invalid JSON format")
+	}
+	if request.Version < 1 {
+		return h.errorResponse(c, http.StatusBadRequest, "invalid_parameter", "version must be a positive integer")
+	}
+
+	ifMatch, err := parseIfMatch(c)
+	if err != nil {
+		return h.errorResponse(c, http.StatusBadRequest, "invalid_precondition", err.Error())
+	}
+
+	ctx := c.Request().Context()
+	customer, err := h.service.RestoreCustomer(ctx, c.Param("id"), request.Version, ifMatch)
+	if err != nil {
+		return h.handleServiceError(c, err, "Failed to restore customer")
+	}
+
+	return respondVersioned(c, http.StatusOK, customer.Version, customer)
+}
+
+// ActivateCustomer handles POST /customers/:id/activate
+func (h *CustomerHandler) ActivateCustomer(c echo.Context) error {
+	return h.changeStatus(c, h.service.ActivateCustomer)
+}
+
+// DeactivateCustomer handles POST /customers/:id/deactivate
+func (h *CustomerHandler) DeactivateCustomer(c echo.Context) error {
+	return h.changeStatus(c, h.service.DeactivateCustomer)
+}
+
+// changeStatus runs an audited status transition with the reason from the
+// request body and the actor of the request
+func (h *CustomerHandler) changeStatus(c echo.Context, transition func(ctx context.Context, customerID, reason, actor string, ifMatch *int64) (*models.Customer, error)) error {
+	var request models.StatusChangeRequest
+
+	if err := c.Bind(&request); err != nil {
+		return h.errorResponse(c, http.StatusBadRequest, "invalid_json", "Invalid JSON format")
+	}
+
+	ifMatch, err := parseIfMatch(c)
+	if err != nil {
+		return h.errorResponse(c, http.StatusBadRequest, "invalid_precondition", err.Error())
+	}
+
+	ctx := c.Request().Context()
+	customer, err := transition(ctx, c.Param("id"), request.Reason, actorFromRequest(c), ifMatch)
+	if err != nil {
+		return h.handleServiceError(c, err, "Failed to change customer status")
+	}
+
+	return respondVersioned(c, http.StatusOK, customer.Version, customer)
+}
+
+// decodePatch reads a JSON object from the request body
+func decodePatch(c echo.Context) (map[string]interface{}, error) {
+	var patch map[string]interface{}
+	if err := json.NewDecoder(c.Request().Body).Decode(&patch); err != nil {
+		return nil, err
+	}
+	if patch == nil {
+		return nil, errors.New("patch body must be a JSON object")
+	}
+	return patch, nil
+}
+
+// actorFromRequest identifies who is performing a change, as set by
+// ActorMiddleware or, without it, from the X-Actor header
+func actorFromRequest(c echo.Context) string {
+	if actor, ok := c.Get("actor").(string); ok && actor != "" {
+		return actor
+	}
+	if actor := strings.TrimSpace(c.Request().Header.Get("X-Actor")); actor != "" {
+		return actor
+	}
+	return "anonymous"
+}
+
 // GetHealth handles GET /health
 func (h *CustomerHandler) GetHealth(c echo.Context) error {
 	ctx := c.Request().Context()
@@ -158,18 +376,31 @@ func (h *CustomerHandler) GetHealth(c echo.Context) error {
 		return h.errorResponse(c, http.StatusInternalServerError, "health_check_failed", "Health check failed")
 	}
 	
-	// Return appropriate HTTP status based on health
+	// A degraded service still serves requests, so only an unhealthy one
+	// answers 503
 	status := http.StatusOK
-	if health.Status == "degraded" {
-		status = http.StatusPartialContent
-	} else if health.Status != "healthy" {
+	if health.Status == "unhealthy" {
 		status = http.StatusServiceUnavailable
 	}
 	
 	return c.JSON(status, health)
 }
 
-// GetMetrics handles GET /metrics (simple metrics endpoint)
+// GetLiveness handles GET /livez
+func (h *CustomerHandler) GetLiveness(c echo.Context) error {
+	return c.JSON(http.StatusOK, h.service.Liveness())
+}
+
+// GetReadiness handles GET /readyz
+func (h *CustomerHandler) GetReadiness(c echo.Context) error {
+	probe, ready := h.service.Readiness(c.Request().Context())
+	if !ready {
+		return c.JSON(http.StatusServiceUnavailable, probe)
+	}
+	return c.JSON(http.StatusOK, probe)
+}
+
+// GetMetrics handles GET /metrics.json (service metrics as JSON)
 func (h *CustomerHandler) GetMetrics(c echo.Context) error {
 	metrics := h.service.GetMetrics()
 	return c.JSON(http.StatusOK, metrics)
@@ -177,37 +408,5 @@ func (h *CustomerHandler) GetMetrics(c echo.Context) error {
 
 // errorResponse creates a standardized error response
 func (h *CustomerHandler) errorResponse(c echo.Context, status int, errorCode, message string) error {
-	requestID := ""
-	if id := c.Get("requestId"); id != nil {
-		requestID = id.(string)
-	}
-	
-	errorResp := models.ErrorResponse{
-		Error:     errorCode,
-		Message:   message,
-		RequestID: requestID,
-		Timestamp: time.Now(),
-	}
-	
-	// Add additional context for certain errors
-	switch status {
-	case http.StatusBadRequest:
-		errorResp.Details = map[string]interface{}{
-			"hint": "Check your request parameters and try again",
-		}
-	case http.StatusNotFound:
-		errorResp.Details = map[string]interface{}{
-			"hint": "The requested customer was not found",
-		}
-	case http.StatusGone:
-		errorResp.Details = map[string]interface{}{
-			"hint": "The requested customer is inactive",
-		}
-	case http.StatusInternalServerError:
-		errorResp.Details = map[string]interface{}{
-			"hint": "An internal error occurred. Please try again later",
-		}
-	}
-	
-	return c.JSON(status, errorResp)
+	return writeErrorResponse(c, status, errorCode, message)
 }
\ No newline at end of file
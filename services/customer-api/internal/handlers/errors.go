@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/customer-api-v2/internal/models"
+	"github.com/customer-api-v2/internal/services"
+)
+
+// serviceErrorMappings maps service error kinds to HTTP statuses and the
+// default error code used when the service did not provide a specific one
+var serviceErrorMappings = []struct {
+	kind   error
+	status int
+	code   string
+}{
+	{services.ErrNotFound, http.StatusNotFound, "not_found"},
+	{services.ErrInactive, http.StatusGone, "inactive"},
+	{services.ErrConflict, http.StatusConflict, "conflict"},
+	{services.ErrValidation, http.StatusBadRequest, "validation_error"},
+	{services.ErrPreconditionFailed, http.StatusPreconditionFailed, "precondition_failed"},
+	{services.ErrUnavailable, http.StatusServiceUnavailable, "service_unavailable"},
+}
+
+// mapServiceError translates a service error into an HTTP status, error code
+// and client-facing message. Internal and unavailable errors use fallback as
+// the message so repository details are not leaked to clients.
+func mapServiceError(err error, fallback string) (int, string, string) {
+	status := http.StatusInternalServerError
+	code := "internal_error"
+
+	for _, mapping := range serviceErrorMappings {
+		if errors.Is(err, mapping.kind) {
+			status = mapping.status
+			code = mapping.code
+			break
+		}
+	}
+
+	var serviceErr *services.Error
+	if errors.As(err, &serviceErr) && serviceErr.Code != "" {
+		code = serviceErr.Code
+	}
+
+	if status >= http.StatusInternalServerError {
+		return status, code, fallback
+	}
+
+	return status, code, err.Error()
+}
+
+// respondServiceError writes the standardized error response for a service error
+func respondServiceError(c echo.Context, err error, fallback string) error {
+	status, code, message := mapServiceError(err, fallback)
+	return writeErrorResponse(c, status, code, message)
+}
+
+// handleServiceError writes the standardized error response for a service error
+func (h *CustomerHandler) handleServiceError(c echo.Context, err error, fallback string) error {
+	return respondServiceError(c, err, fallback)
+}
+
+// writeErrorResponse creates a standardized error response
+func writeErrorResponse(c echo.Context, status int, errorCode, message string) error {
+	requestID := ""
+	if id := c.Get("requestId"); id != nil {
+		requestID = id.(string)
+	}
+
+	errorResp := models.ErrorResponse{
+		Error:     errorCode,
+		Message:   message,
+		RequestID: requestID,
+		Timestamp: time.Now(),
+	}
+
+	// Add additional context for certain errors
+	switch status {
+	case http.StatusBadRequest:
+		errorResp.Details = map[string]interface{}{
+			"hint": "Check your request parameters and try again",
+		}
+	case http.StatusNotFound:
+		errorResp.Details = map[string]interface{}{
+			"hint": "The requested resource was not found",
+		}
+	case http.StatusConflict:
+		errorResp.Details = map[string]interface{}{
+			"hint": "The resource already exists or was modified concurrently",
+		}
+	case http.StatusPreconditionFailed:
+		errorResp.Details = map[string]interface{}{
+			"hint": "The resource has changed. Fetch it again and retry with the new ETag",
+		}
+	case http.StatusGone:
+		errorResp.Details = map[string]interface{}{
+			"hint": "The requested customer is inactive",
+		}
+	case http.StatusServiceUnavailable:
+		errorResp.Details = map[string]interface{}{
+			"hint": "A dependency is temporarily unavailable. Please retry shortly",
+		}
+	case http.StatusInternalServerError:
+		errorResp.Details = map[string]interface{}{
+			"hint": "An internal error occurred. Please try again later",
+		}
+	}
+
+	return c.JSON(status, errorResp)
+}
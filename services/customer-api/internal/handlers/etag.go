@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+// entityTag formats a resource version as a strong entity tag
+func entityTag(version int64) string {
+	return `"` + strconv.FormatInt(version, 10) + `"`
+}
+
+// parseIfMatch reads the expected version from the If-Match header. A missing
+// header or "*" places no constraint on the version and yields nil.
+func parseIfMatch(c echo.Context) (*int64, error) {
+	header := strings.TrimSpace(c.Request().Header.Get("If-Match"))
+	if header == "" || header == "*" {
+		return nil, nil
+	}
+
+	if strings.Contains(header, ",") {
+		return nil, fmt.Errorf("If-Match must contain a single entity tag")
+	}
+
+	if len(header) < 2 || header[0] != '"' || header[len(header)-1] != '"' {
+		return nil, fmt.Errorf("If-Match must be a quoted entity tag")
+	}
+
+	version, err := strconv.ParseInt(header[1:len(header)-1], 10, 64)
+	if err != nil || version < 0 {
+		return nil, fmt.Errorf("If-Match does not contain a valid entity tag")
+	}
+
+	return &version, nil
+}
+
+// respondVersioned writes body with the ETag for version. Conditional GETs
+// whose If-None-Match already matches get 304 Not Modified.
+func respondVersioned(c echo.Context, status int, version int64, body interface{}) error {
+	tag := entityTag(version)
+	c.Response().Header().Set("ETag", tag)
+
+	if c.Request().Method == http.MethodGet && ifNoneMatch(c.Request().Header.Get("If-None-Match"), tag) {
+		return c.NoContent(http.StatusNotModified)
+	}
+
+	return c.JSON(status, body)
+}
+
+// ifNoneMatch reports whether tag is listed in an If-None-Match header
+func ifNoneMatch(header, tag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == tag {
+			return true
+		}
+	}
+	return false
+}
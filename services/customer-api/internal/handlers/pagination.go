@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"github.com/customer-api-v2/internal/models"
+	"github.com/customer-api-v2/internal/repository"
+	"github.com/labstack/echo/v4"
+)
+
+// parseListingOrder reads the sort and cursor parameters of a customer
+// listing into filters. On failure it returns the error code to respond with.
+func parseListingOrder(c echo.Context, filters *repository.CustomerFilters) (string, error) {
+	sort, err := repository.ParseCustomerSort(c.QueryParam("sort"))
+	if err != nil {
+		return "invalid_parameter", err
+	}
+	filters.Sort = sort
+
+	if token := c.QueryParam("cursor"); token != "" {
+		cursor, err := repository.DecodeCustomerCursor(token, sort)
+		if err != nil {
+			return "invalid_cursor", err
+		}
+		filters.Cursor = cursor
+	}
+	return "", nil
+}
+
+// pageLinks builds the URLs of the neighbouring pages by replacing the
+// cursor in the current request, keeping its filters, sort and page size
+func pageLinks(c echo.Context, next, prev string) *models.PageLinks {
+	if next == "" && prev == "" {
+		return nil
+	}
+
+	link := func(cursor string) string {
+		if cursor == "" {
+			return ""
+		}
+		target := *c.Request().URL
+		query := target.Query()
+		query.Set("cursor", cursor)
+		query.Del("page")
+		target.RawQuery = query.Encode()
+		return target.RequestURI()
+	}
+
+	return &models.PageLinks{Next: link(next), Prev: link(prev)}
+}
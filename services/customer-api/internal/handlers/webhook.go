@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/customer-api-v2/internal/models"
+	"github.com/customer-api-v2/internal/services"
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+)
+
+// WebhookHandler handles HTTP requests for webhook subscriptions and their
+// delivery log
+type WebhookHandler struct {
+	service *services.WebhookService
+	logger  *logrus.Logger
+}
+
+// NewWebhookHandler creates a new webhook handler
+func NewWebhookHandler(service *services.WebhookService, logger *logrus.Logger) *WebhookHandler {
+	return &WebhookHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// CreateWebhook handles POST /webhooks. The response is the only one that
+// includes the signing secret.
+func (h *WebhookHandler) CreateWebhook(c echo.Context) error {
+	var request models.WebhookRequest
+
+	if err := c.Bind(&request); err != nil {
+		return writeErrorResponse(c, http.StatusBadRequest, "invalid_json", "Invalid JSON format")
+	}
+
+	ctx := c.Request().Context()
+	webhook, err := h.service.CreateWebhook(ctx, &request)
+	if err != nil {
+		return respondServiceError(c, err, "Failed to create webhook")
+	}
+
+	return c.JSON(http.StatusCreated, webhook)
+}
+
+// GetWebhooks handles GET /webhooks
+func (h *WebhookHandler) GetWebhooks(c echo.Context) error {
+	ctx := c.Request().Context()
+	webhooks, err := h.service.GetWebhooks(ctx)
+	if err != nil {
+		return respondServiceError(c, err, "Failed to retrieve webhooks")
+	}
+
+	return c.JSON(http.StatusOK, webhooks)
+}
+
+// GetWebhook handles GET /webhooks/:id
+func (h *WebhookHandler) GetWebhook(c echo.Context) error {
+	ctx := c.Request().Context()
+	webhook, err := h.service.GetWebhook(ctx, c.Param("id"))
+	if err != nil {
+		return respondServiceError(c, err, "Failed to retrieve webhook")
+	}
+
+	return c.JSON(http.StatusOK, webhook)
+}
+
+// DeleteWebhook handles DELETE /webhooks/:id
+func (h *WebhookHandler) DeleteWebhook(c echo.Context) error {
+	webhookID := c.Param("id")
+	ctx := c.Request().Context()
+	if err := h.service.DeleteWebhook(ctx, webhookID); err != nil {
+		return respondServiceError(c, err, "Failed to delete webhook")
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"message":   "Webhook deleted successfully",
+		"webhookId": webhookID,
+	})
+}
+
+// GetDeliveries handles GET /webhooks/:id/deliveries?status=&limit=
+func (h *WebhookHandler) GetDeliveries(c echo.Context) error {
+	return h.listDeliveries(c, c.Param("id"), c.QueryParam("status"))
+}
+
+// GetDeadLetters handles GET /webhooks/dead-letters?limit=, the deliveries
+// of every subscription that ran out of attempts
+func (h *WebhookHandler) GetDeadLetters(c echo.Context) error {
+	return h.listDeliveries(c, "", models.DeliveryDead)
+}
+
+// RedeliverDelivery handles POST /webhooks/:id/deliveries/:deliveryId/redeliver
+func (h *WebhookHandler) RedeliverDelivery(c echo.Context) error {
+	ctx := c.Request().Context()
+	delivery, err := h.service.RedeliverDelivery(ctx, c.Param("id"), c.Param("deliveryId"))
+	if err != nil {
+		return respondServiceError(c, err, "Failed to redeliver webhook")
+	}
+
+	return c.JSON(http.StatusAccepted, delivery)
+}
+
+// listDeliveries writes the delivery log of webhookID, or of every
+// subscription when it is empty
+func (h *WebhookHandler) listDeliveries(c echo.Context, webhookID, status string) error {
+	limit := 0
+	if value := c.QueryParam("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			return writeErrorResponse(c, http.StatusBadRequest, "invalid_parameter", "limit must be an integer")
+		}
+		limit = parsed
+	}
+
+	ctx := c.Request().Context()
+	deliveries, err := h.service.GetDeliveries(ctx, webhookID, status, limit)
+	if err != nil {
+		return respondServiceError(c, err, "Failed to retrieve webhook deliveries")
+	}
+
+	return c.JSON(http.StatusOK, deliveries)
+}
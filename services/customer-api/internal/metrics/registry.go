@@ -0,0 +1,376 @@
+// Package metrics is a small in-process metrics registry that renders the
+// Prometheus text exposition format. It covers the counters, gauges and
+// histograms the services need without pulling in a client library.
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ContentType is the media type of the Prometheus text format
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// DefaultBuckets are latency buckets in seconds suited to HTTP and database calls
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// collector is a metric family that can render itself
+type collector interface {
+	name() string
+	write(w *bufio.Writer)
+}
+
+// Registry holds metric families and renders them in registration order
+type Registry struct {
+	mutex      sync.RWMutex
+	collectors []collector
+	names      map[string]bool
+}
+
+// NewRegistry creates an empty registry
+func NewRegistry() *Registry {
+	return &Registry{names: make(map[string]bool)}
+}
+
+// register adds a collector and panics on duplicate names, like a
+// misconfigured route would at startup
+func (r *Registry) register(c collector) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.names[c.name()] {
+		panic(fmt.Sprintf("metrics: duplicate metric %q", c.name()))
+	}
+	r.names[c.name()] = true
+	r.collectors = append(r.collectors, c)
+}
+
+// WriteText renders every registered metric in Prometheus text format
+func (r *Registry) WriteText(w io.Writer) error {
+	r.mutex.RLock()
+	collectors := append([]collector(nil), r.collectors...)
+	r.mutex.RUnlock()
+
+	buffered := bufio.NewWriter(w)
+	for _, c := range collectors {
+		c.write(buffered)
+	}
+	return buffered.Flush()
+}
+
+// NewCounterVec registers a counter family partitioned by labels
+func (r *Registry) NewCounterVec(name, help string, labels ...string) *CounterVec {
+	counter := &CounterVec{family: newFamily(name, help, "counter", labels)}
+	r.register(counter)
+	return counter
+}
+
+// NewGaugeVec registers a gauge family partitioned by labels
+func (r *Registry) NewGaugeVec(name, help string, labels ...string) *GaugeVec {
+	gauge := &GaugeVec{family: newFamily(name, help, "gauge", labels)}
+	r.register(gauge)
+	return gauge
+}
+
+// NewHistogramVec registers a histogram family partitioned by labels. Nil
+// buckets selects DefaultBuckets.
+func (r *Registry) NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	if buckets == nil {
+		buckets = DefaultBuckets
+	}
+	buckets = append([]float64(nil), buckets...)
+	sort.Float64s(buckets)
+
+	histogram := &HistogramVec{
+		family:  newFamily(name, help, "histogram", labels),
+		buckets: buckets,
+		series:  make(map[string]*histogramSeries),
+	}
+	r.register(histogram)
+	return histogram
+}
+
+// NewGaugeFunc registers a gauge whose value is computed at scrape time
+func (r *Registry) NewGaugeFunc(name, help string, value func() float64) {
+	r.register(&funcCollector{family: newFamily(name, help, "gauge", nil), value: value})
+}
+
+// NewCounterFunc registers a counter whose value is read at scrape time from
+// a source that already counts, such as cache statistics
+func (r *Registry) NewCounterFunc(name, help string, value func() float64) {
+	r.register(&funcCollector{family: newFamily(name, help, "counter", nil), value: value})
+}
+
+// family holds the metadata and labelled values shared by counters and gauges
+type family struct {
+	metricName string
+	help       string
+	kind       string
+	labels     []string
+
+	mutex  sync.Mutex
+	values map[string]*sample
+}
+
+// sample is one labelled series of a counter or gauge
+type sample struct {
+	labelValues []string
+	value       float64
+}
+
+func newFamily(name, help, kind string, labels []string) family {
+	return family{
+		metricName: name,
+		help:       help,
+		kind:       kind,
+		labels:     labels,
+		values:     make(map[string]*sample),
+	}
+}
+
+func (f *family) name() string {
+	return f.metricName
+}
+
+// add changes the value of the series identified by labelValues
+func (f *family) add(labelValues []string, delta float64, set bool) {
+	if len(labelValues) != len(f.labels) {
+		panic(fmt.Sprintf("metrics: %s expects %d label values, got %d", f.metricName, len(f.labels), len(labelValues)))
+	}
+
+	key := strings.Join(labelValues, "\xff")
+
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	s, found := f.values[key]
+	if !found {
+		s = &sample{labelValues: append([]string(nil), labelValues...)}
+		f.values[key] = s
+	}
+	if set {
+		s.value = delta
+	} else {
+		s.value += delta
+	}
+}
+
+func (f *family) writeHeader(w *bufio.Writer) {
+	fmt.Fprintf(w, "# HELP %s %s\n", f.metricName, escapeHelp(f.help))
+	fmt.Fprintf(w, "# TYPE %s %s\n", f.metricName, f.kind)
+}
+
+func (f *family) write(w *bufio.Writer) {
+	f.mutex.Lock()
+	samples := make([]sample, 0, len(f.values))
+	for _, s := range f.values {
+		samples = append(samples, *s)
+	}
+	f.mutex.Unlock()
+
+	sortSamples(samples)
+
+	f.writeHeader(w)
+	for _, s := range samples {
+		fmt.Fprintf(w, "%s%s %s\n", f.metricName, formatLabels(f.labels, s.labelValues, "", ""), formatValue(s.value))
+	}
+}
+
+// CounterVec is a monotonically increasing counter partitioned by labels
+type CounterVec struct {
+	family
+}
+
+// Inc adds one to the series identified by labelValues
+func (c *CounterVec) Inc(labelValues ...string) {
+	c.add(labelValues, 1, false)
+}
+
+// Add adds a non-negative delta to the series identified by labelValues
+func (c *CounterVec) Add(delta float64, labelValues ...string) {
+	if delta < 0 {
+		panic("metrics: counters cannot decrease")
+	}
+	c.add(labelValues, delta, false)
+}
+
+// GaugeVec is a value that can go up and down, partitioned by labels
+type GaugeVec struct {
+	family
+}
+
+// Set replaces the value of the series identified by labelValues
+func (g *GaugeVec) Set(value float64, labelValues ...string) {
+	g.add(labelValues, value, true)
+}
+
+// Inc adds one to the series identified by labelValues
+func (g *GaugeVec) Inc(labelValues ...string) {
+	g.add(labelValues, 1, false)
+}
+
+// Dec subtracts one from the series identified by labelValues
+func (g *GaugeVec) Dec(labelValues ...string) {
+	g.add(labelValues, -1, false)
+}
+
+// HistogramVec counts observations into cumulative buckets, partitioned by labels
+type HistogramVec struct {
+	family
+	buckets []float64
+
+	seriesMutex sync.Mutex
+	series      map[string]*histogramSeries
+}
+
+// histogramSeries is one labelled series of a histogram
+type histogramSeries struct {
+	labelValues []string
+	counts      []uint64 // per bucket, not cumulative
+	count       uint64
+	sum         float64
+}
+
+// Observe records a value in the series identified by labelValues
+func (h *HistogramVec) Observe(value float64, labelValues ...string) {
+	if len(labelValues) != len(h.labels) {
+		panic(fmt.Sprintf("metrics: %s expects %d label values, got %d", h.metricName, len(h.labels), len(labelValues)))
+	}
+
+	key := strings.Join(labelValues, "\xff")
+
+	h.seriesMutex.Lock()
+	defer h.seriesMutex.Unlock()
+
+	s, found := h.series[key]
+	if !found {
+		s = &histogramSeries{
+			labelValues: append([]string(nil), labelValues...),
+			counts:      make([]uint64, len(h.buckets)),
+		}
+		h.series[key] = s
+	}
+
+	if i := sort.SearchFloat64s(h.buckets, value); i < len(h.buckets) {
+		s.counts[i]++
+	}
+	s.count++
+	s.sum += value
+}
+
+func (h *HistogramVec) write(w *bufio.Writer) {
+	h.seriesMutex.Lock()
+	series := make([]histogramSeries, 0, len(h.series))
+	for _, s := range h.series {
+		snapshot := *s
+		snapshot.counts = append([]uint64(nil), s.counts...)
+		series = append(series, snapshot)
+	}
+	h.seriesMutex.Unlock()
+
+	sort.Slice(series, func(i, j int) bool {
+		return lessLabelValues(series[i].labelValues, series[j].labelValues)
+	})
+
+	h.writeHeader(w)
+	for _, s := range series {
+		var cumulative uint64
+		for i, upperBound := range h.buckets {
+			cumulative += s.counts[i]
+			fmt.Fprintf(w, "%s_bucket%s %d\n", h.metricName, formatLabels(h.labels, s.labelValues, "le", formatValue(upperBound)), cumulative)
+		}
+		fmt.Fprintf(w, "%s_bucket%s %d\n", h.metricName, formatLabels(h.labels, s.labelValues, "le", "+Inf"), s.count)
+		fmt.Fprintf(w, "%s_sum%s %s\n", h.metricName, formatLabels(h.labels, s.labelValues, "", ""), formatValue(s.sum))
+		fmt.Fprintf(w, "%s_count%s %d\n", h.metricName, formatLabels(h.labels, s.labelValues, "", ""), s.count)
+	}
+}
+
+// funcCollector is an unlabelled metric whose value is computed on scrape
+type funcCollector struct {
+	family
+	value func() float64
+}
+
+func (f *funcCollector) write(w *bufio.Writer) {
+	f.writeHeader(w)
+	fmt.Fprintf(w, "%s %s\n", f.metricName, formatValue(f.value()))
+}
+
+// formatLabels renders {name="value",...}, optionally with one extra label
+func formatLabels(names, values []string, extraName, extraValue string) string {
+	if len(names) == 0 && extraName == "" {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteByte('{')
+	for i, name := range names {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(name)
+		b.WriteString(`="`)
+		b.WriteString(escapeLabelValue(values[i]))
+		b.WriteByte('"')
+	}
+	if extraName != "" {
+		if len(names) > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(extraName)
+		b.WriteString(`="`)
+		b.WriteString(extraValue)
+		b.WriteByte('"')
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+// formatValue renders a sample value the way Prometheus parses it
+func formatValue(value float64) string {
+	switch {
+	case math.IsInf(value, 1):
+		return "+Inf"
+	case math.IsInf(value, -1):
+		return "-Inf"
+	case math.IsNaN(value):
+		return "NaN"
+	default:
+		return strconv.FormatFloat(value, 'g', -1, 64)
+	}
+}
+
+var (
+	labelValueEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+	helpEscaper       = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+)
+
+func escapeLabelValue(value string) string {
+	return labelValueEscaper.Replace(value)
+}
+
+func escapeHelp(help string) string {
+	return helpEscaper.Replace(help)
+}
+
+// sortSamples orders series by label values so output is stable between scrapes
+func sortSamples(samples []sample) {
+	sort.Slice(samples, func(i, j int) bool {
+		return lessLabelValues(samples[i].labelValues, samples[j].labelValues)
+	})
+}
+
+func lessLabelValues(a, b []string) bool {
+	for i := range a {
+		if a[i] != b[i] {
+			return a[i] < b[i]
+		}
+	}
+	return false
+}
@@ -0,0 +1,32 @@
+package middleware
+
+import (
+	"context"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+// AnonymousActor is the actor of requests that do not identify one
+const AnonymousActor = "anonymous"
+
+// ActorMiddleware identifies who is making each request from the X-Actor
+// header, which the gateway in front of the service sets from the
+// authenticated identity. The actor is stored in the Echo context and, for
+// the service layer, in the request context under "actor".
+func ActorMiddleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			actor := strings.TrimSpace(c.Request().Header.Get("X-Actor"))
+			if actor == "" {
+				actor = AnonymousActor
+			}
+			c.Set("actor", actor)
+			
+			ctx := context.WithValue(c.Request().Context(), "actor", actor)
+			c.SetRequest(c.Request().WithContext(ctx))
+			
+			return next(c)
+		}
+	}
+}
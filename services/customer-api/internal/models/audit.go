@@ -0,0 +1,51 @@
+package models
+
+import (
+	"time"
+)
+
+// Actions recorded in the change history
+const (
+	AuditCreate  = "create"
+	AuditUpdate  = "update"
+	AuditRestore = "restore"
+)
+
+// FieldChange is the value of one field before and after a change. Nested
+// fields are named by their path, such as address.city; a missing value
+// means the field was not set.
+type FieldChange struct {
+	Field  string      `json:"field" bson:"field"`
+	Before interface{} `json:"before,omitempty" bson:"before"`
+	After  interface{} `json:"after,omitempty" bson:"after"`
+}
+
+// CustomerChange is an entry of the append-only change history of a
+// customer. Version is the customer version the change produced. Snapshot
+// holds the customer as stored after the change, which is what restoring
+// that version brings back.
+type CustomerChange struct {
+	ChangeID     string        `json:"changeId" bson:"changeId"`
+	CustomerID   string        `json:"customerId" bson:"customerId"`
+	Version      int64         `json:"version" bson:"version"`
+	Action       string        `json:"action" bson:"action"`
+	Actor        string        `json:"actor" bson:"actor"`
+	RequestID    string        `json:"requestId,omitempty" bson:"requestId,omitempty"`
+	RestoredFrom int64         `json:"restoredFrom,omitempty" bson:"restoredFrom,omitempty"` // version a restore brought back
+	Changes      []FieldChange `json:"changes" bson:"changes"`
+	Snapshot     *Customer     `json:"snapshot,omitempty" bson:"snapshot,omitempty"`
+	ChangedAt    time.Time     `json:"changedAt" bson:"changedAt"`
+}
+
+// CustomerHistoryResponse represents the change history of a customer,
+// oldest change first
+type CustomerHistoryResponse struct {
+	CustomerID string            `json:"customerId"`
+	Changes    []*CustomerChange `json:"changes"`
+	Total      int               `json:"total"`
+}
+
+// RestoreRequest represents the body of a restore request
+type RestoreRequest struct {
+	Version int64 `json:"version"`
+}
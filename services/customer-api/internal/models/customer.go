@@ -18,21 +18,36 @@ type Preferences struct {
 	Notifications bool `json:"notifications" bson:"notifications"`
 }
 
+// StatusChange records the latest activation or deactivation of a customer
+type StatusChange struct {
+	Active    bool      `json:"active" bson:"active"`
+	Reason    string    `json:"reason" bson:"reason"`
+	ChangedBy string    `json:"changedBy,omitempty" bson:"changedBy,omitempty"`
+	ChangedAt time.Time `json:"changedAt" bson:"changedAt"`
+}
+
+// StatusChangeRequest represents the body of an activate/deactivate request
+type StatusChangeRequest struct {
+	Reason string `json:"reason"`
+}
+
 // Customer represents a customer in the system
 type Customer struct {
-	CustomerID       string       `json:"customerId" bson:"customerId" validate:"required"`
-	Name             string       `json:"name" bson:"name" validate:"required,min=1,max=255"`
-	Email            string       `json:"email,omitempty" bson:"email,omitempty"`
-	Phone            string       `json:"phone,omitempty" bson:"phone,omitempty"`
-	Address          Address      `json:"address,omitempty" bson:"address,omitempty"`
-	Active           bool         `json:"active" bson:"active"`
-	CustomerTier     string       `json:"customerTier,omitempty" bson:"customerTier,omitempty"`
-	Preferences      Preferences  `json:"preferences,omitempty" bson:"preferences,omitempty"`
-	RegistrationDate *time.Time   `json:"registrationDate,omitempty" bson:"registrationDate,omitempty"`
-	LastLogin        *time.Time   `json:"lastLogin,omitempty" bson:"lastLogin,omitempty"`
-	LoyaltyPoints    int          `json:"loyaltyPoints,omitempty" bson:"loyaltyPoints,omitempty"`
-	CreatedAt        time.Time    `json:"createdAt" bson:"createdAt"`
-	UpdatedAt        time.Time    `json:"updatedAt" bson:"updatedAt"`
+	CustomerID       string        `json:"customerId" bson:"customerId" validate:"required"`
+	Name             string        `json:"name" bson:"name" validate:"required,min=1,max=255"`
+	Email            string        `json:"email,omitempty" bson:"email,omitempty"`
+	Phone            string        `json:"phone,omitempty" bson:"phone,omitempty"`
+	Address          Address       `json:"address,omitempty" bson:"address,omitempty"`
+	Active           bool          `json:"active" bson:"active"`
+	CustomerTier     string        `json:"customerTier,omitempty" bson:"customerTier,omitempty"`
+	Preferences      Preferences   `json:"preferences,omitempty" bson:"preferences,omitempty"`
+	RegistrationDate *time.Time    `json:"registrationDate,omitempty" bson:"registrationDate,omitempty"`
+	LastLogin        *time.Time    `json:"lastLogin,omitempty" bson:"lastLogin,omitempty"`
+	LoyaltyPoints    int           `json:"loyaltyPoints,omitempty" bson:"loyaltyPoints,omitempty"`
+	LastStatusChange *StatusChange `json:"lastStatusChange,omitempty" bson:"lastStatusChange,omitempty"`
+	Version          int64         `json:"version" bson:"version"`
+	CreatedAt        time.Time     `json:"createdAt" bson:"createdAt"`
+	UpdatedAt        time.Time     `json:"updatedAt" bson:"updatedAt"`
 }
 
 // CustomerSummary represents a simplified customer view
@@ -44,12 +59,21 @@ type CustomerSummary struct {
 
 // CustomerResponse represents the response for customer listings
 type CustomerResponse struct {
-	Customers []CustomerSummary `json:"customers"`
-	Total     int               `json:"total"`
-	Active    int               `json:"active_count,omitempty"`
-	Inactive  int               `json:"inactive_count,omitempty"`
-	Page      int               `json:"page,omitempty"`
-	PageSize  int               `json:"pageSize,omitempty"`
+	Customers  []CustomerSummary `json:"customers"`
+	Total      int               `json:"total"`
+	Active     int               `json:"active_count,omitempty"`
+	Inactive   int               `json:"inactive_count,omitempty"`
+	Page       int               `json:"page,omitempty"`
+	PageSize   int               `json:"pageSize,omitempty"`
+	NextCursor string            `json:"next_cursor,omitempty"`
+	PrevCursor string            `json:"prev_cursor,omitempty"`
+	Links      *PageLinks        `json:"links,omitempty"`
+}
+
+// PageLinks holds the URLs of the neighbouring pages of a listing
+type PageLinks struct {
+	Next string `json:"next,omitempty"`
+	Prev string `json:"prev,omitempty"`
 }
 
 // ErrorResponse represents an API error response
@@ -69,8 +93,23 @@ type HealthResponse struct {
 	Timestamp      time.Time         `json:"timestamp"`
 	Uptime         string            `json:"uptime"`
 	Environment    string            `json:"environment"`
+	Ready          bool              `json:"ready"`
 	Metrics        map[string]int    `json:"metrics"`
-	Dependencies   map[string]string `json:"dependencies,omitempty"`
+	Dependencies   map[string]DependencyHealth `json:"dependencies,omitempty"`
 	TotalCustomers int               `json:"total_customers"`
 	ActiveCustomers int              `json:"active_customers"`
+}
+
+// DependencyHealth reports the state of one dependency and how long checking it took
+type DependencyHealth struct {
+	Status    string  `json:"status"`
+	LatencyMs float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// ProbeResponse is the body of the liveness and readiness probes
+type ProbeResponse struct {
+	Status    string            `json:"status"`
+	Checks    map[string]string `json:"checks,omitempty"`
+	Timestamp time.Time         `json:"timestamp"`
 }
\ No newline at end of file
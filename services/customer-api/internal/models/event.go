@@ -0,0 +1,53 @@
+package models
+
+import (
+	"time"
+)
+
+// EventCustomerChanged is published for every write made through the
+// customer service
+const EventCustomerChanged = "CustomerChanged"
+
+// CustomerChangedSchemaVersion is the version of the CustomerChangedEvent
+// schema. It is bumped on changes consumers cannot ignore, such as removing
+// or retyping a field; adding fields keeps the version.
+const CustomerChangedSchemaVersion = 1
+
+// CustomerChangedEvent tells consumers that a customer was created, updated
+// or restored; activation and deactivation are updates of the active
+// field. IdempotencyKey identifies the change the event describes, which is
+// also the changeId of its history entry; an event delivered more than once
+// repeats it. Customer is the customer after the change.
+type CustomerChangedEvent struct {
+	EventID        string    `json:"eventId"`
+	Type           string    `json:"type"`
+	SchemaVersion  int       `json:"schemaVersion"`
+	Source         string    `json:"source"`
+	IdempotencyKey string    `json:"idempotencyKey"`
+	OccurredAt     time.Time `json:"occurredAt"`
+	CustomerID     string    `json:"customerId"`
+	Version        int64     `json:"version"`
+	Action         string    `json:"action"`
+	Actor          string    `json:"actor"`
+	RequestID      string    `json:"requestId,omitempty"`
+	ChangedFields  []string  `json:"changedFields"`
+	Customer       *Customer `json:"customer,omitempty"`
+}
+
+// OutboxMessage is an event waiting in the outbox to be published. Key
+// groups the events of one entity, which are published in order; Payload
+// is the encoded event. A message that keeps failing is parked as a dead
+// letter at DeadAt and no longer published.
+type OutboxMessage struct {
+	EventID        string     `json:"eventId" bson:"eventId"`
+	Type           string     `json:"type" bson:"type"`
+	SchemaVersion  int        `json:"schemaVersion" bson:"schemaVersion"`
+	Key            string     `json:"key" bson:"key"`
+	IdempotencyKey string     `json:"idempotencyKey" bson:"idempotencyKey"`
+	Payload        string     `json:"payload" bson:"payload"`
+	CreatedAt      time.Time  `json:"createdAt" bson:"createdAt"`
+	PublishedAt    *time.Time `json:"publishedAt,omitempty" bson:"publishedAt"`
+	Attempts       int        `json:"attempts" bson:"attempts"`
+	LastError      string     `json:"lastError,omitempty" bson:"lastError,omitempty"`
+	DeadAt         *time.Time `json:"deadAt,omitempty" bson:"deadAt,omitempty"`
+}
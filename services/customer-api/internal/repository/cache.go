@@ -0,0 +1,249 @@
+package repository
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/customer-api-v2/internal/models"
+	"golang.org/x/sync/singleflight"
+)
+
+// sharedLoadTimeout bounds a cache load shared by concurrent callers, which
+// outlives the context of the caller that started it
+const sharedLoadTimeout = 10 * time.Second
+
+// CacheStats reports the effectiveness of a repository cache
+type CacheStats struct {
+	Hits      int64   `json:"hits"`
+	Misses    int64   `json:"misses"`
+	Evictions int64   `json:"evictions"`
+	Size      int     `json:"size"`
+	MaxSize   int     `json:"maxSize"`
+	HitRate   float64 `json:"hitRate"`
+}
+
+// CacheStatsProvider is implemented by repositories that cache reads
+type CacheStatsProvider interface {
+	CacheStats() CacheStats
+}
+
+// CachedCustomerRepository decorates a CustomerRepository with a bounded LRU
+// cache of single-customer lookups. Methods that are not overridden pass
+// through to the wrapped repository.
+type CachedCustomerRepository struct {
+	CustomerRepository
+
+	ttl     time.Duration
+	maxSize int
+
+	mutex      sync.Mutex
+	entries    map[string]*list.Element
+	order      *list.List // front is most recently used
+	generation uint64     // bumped on every invalidation
+
+	loads singleflight.Group
+
+	hits      atomic.Int64
+	misses    atomic.Int64
+	evictions atomic.Int64
+}
+
+// customerCacheEntry is a cached customer and its expiry
+type customerCacheEntry struct {
+	customerID string
+	customer   *models.Customer
+	expiresAt  time.Time
+}
+
+// NewCachedCustomerRepository wraps repo with a cache holding at most maxSize
+// customers for ttl each
+func NewCachedCustomerRepository(repo CustomerRepository, ttl time.Duration, maxSize int) *CachedCustomerRepository {
+	if maxSize <= 0 {
+		maxSize = 1
+	}
+
+	return &CachedCustomerRepository{
+		CustomerRepository: repo,
+		ttl:                ttl,
+		maxSize:            maxSize,
+		entries:            make(map[string]*list.Element),
+		order:              list.New(),
+	}
+}
+
+// GetByID returns a cached customer or loads it once, however many callers
+// miss on the same ID concurrently. Loads in a transaction may see
+// uncommitted writes, so they are neither shared nor cached.
+func (r *CachedCustomerRepository) GetByID(ctx context.Context, customerID string) (*models.Customer, error) {
+	if customer, ok := r.lookup(customerID); ok {
+		r.hits.Add(1)
+		return customer, nil
+	}
+	r.misses.Add(1)
+
+	if inTransaction(ctx) {
+		return r.CustomerRepository.GetByID(ctx, customerID)
+	}
+
+	// The load is shared, so it must not end when the caller that started it
+	// gives up; each caller still stops waiting when its own ctx ends
+	loaded := r.loads.DoChan(customerID, func() (interface{}, error) {
+		loadCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), sharedLoadTimeout)
+		defer cancel()
+
+		generation := r.currentGeneration()
+		customer, err := r.CustomerRepository.GetByID(loadCtx, customerID)
+		if err != nil {
+			return nil, err
+		}
+		r.store(customer, generation)
+		return customer, nil
+	})
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case result := <-loaded:
+		if result.Err != nil {
+			return nil, result.Err
+		}
+		return copyCustomer(result.Val.(*models.Customer)), nil
+	}
+}
+
+// Create stores a customer and drops any cached copy of it
+func (r *CachedCustomerRepository) Create(ctx context.Context, customer *models.Customer) error {
+	defer r.invalidateAfter(ctx, customer.CustomerID)
+	return r.CustomerRepository.Create(ctx, customer)
+}
+
+// Update stores a customer and drops any cached copy of it
+func (r *CachedCustomerRepository) Update(ctx context.Context, customer *models.Customer) error {
+	defer r.invalidateAfter(ctx, customer.CustomerID)
+	return r.CustomerRepository.Update(ctx, customer)
+}
+
+// Delete removes a customer and drops any cached copy of it
+func (r *CachedCustomerRepository) Delete(ctx context.Context, customerID string) error {
+	defer r.invalidateAfter(ctx, customerID)
+	return r.CustomerRepository.Delete(ctx, customerID)
+}
+
+// CacheStats returns hit, miss and eviction counts and the current size
+func (r *CachedCustomerRepository) CacheStats() CacheStats {
+	r.mutex.Lock()
+	size := r.order.Len()
+	r.mutex.Unlock()
+
+	stats := CacheStats{
+		Hits:      r.hits.Load(),
+		Misses:    r.misses.Load(),
+		Evictions: r.evictions.Load(),
+		Size:      size,
+		MaxSize:   r.maxSize,
+	}
+
+	if total := stats.Hits + stats.Misses; total > 0 {
+		stats.HitRate = float64(stats.Hits) / float64(total)
+	}
+
+	return stats
+}
+
+// lookup returns a copy of a fresh cached customer
+func (r *CachedCustomerRepository) lookup(customerID string) (*models.Customer, bool) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	element, found := r.entries[customerID]
+	if !found {
+		return nil, false
+	}
+
+	entry := element.Value.(*customerCacheEntry)
+	if time.Now().After(entry.expiresAt) {
+		r.removeElement(element)
+		return nil, false
+	}
+
+	r.order.MoveToFront(element)
+	return copyCustomer(entry.customer), true
+}
+
+// store caches a customer loaded at generation. Loads that raced with an
+// invalidation are discarded so a stale read cannot outlive a write.
+func (r *CachedCustomerRepository) store(customer *models.Customer, generation uint64) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if generation != r.generation {
+		return
+	}
+
+	entry := &customerCacheEntry{
+		customerID: customer.CustomerID,
+		customer:   copyCustomer(customer),
+		expiresAt:  time.Now().Add(r.ttl),
+	}
+
+	if element, found := r.entries[customer.CustomerID]; found {
+		element.Value = entry
+		r.order.MoveToFront(element)
+		return
+	}
+
+	r.entries[customer.CustomerID] = r.order.PushFront(entry)
+
+	for r.order.Len() > r.maxSize {
+		r.removeElement(r.order.Back())
+		r.evictions.Add(1)
+	}
+}
+
+// invalidate drops a customer from the cache
+func (r *CachedCustomerRepository) invalidate(customerID string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.generation++
+	if element, found := r.entries[customerID]; found {
+		r.removeElement(element)
+	}
+}
+
+// invalidateAfter drops a customer from the cache after a write made
+// through ctx. A write in a transaction only becomes visible when the
+// transaction ends, so it is dropped again then, in case a concurrent read
+// cached the state from before the write in the meantime.
+func (r *CachedCustomerRepository) invalidateAfter(ctx context.Context, customerID string) {
+	r.invalidate(customerID)
+	if inTransaction(ctx) {
+		afterTransaction(ctx, func() { r.invalidate(customerID) })
+	}
+}
+
+// currentGeneration returns the invalidation counter
+func (r *CachedCustomerRepository) currentGeneration() uint64 {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.generation
+}
+
+// removeElement unlinks an entry; the caller must hold the mutex
+func (r *CachedCustomerRepository) removeElement(element *list.Element) {
+	r.order.Remove(element)
+	delete(r.entries, element.Value.(*customerCacheEntry).customerID)
+}
+
+// copyCustomer returns a copy so callers cannot modify cached state
+func copyCustomer(customer *models.Customer) *models.Customer {
+	customerCopy := *customer
+	if customer.LastStatusChange != nil {
+		statusChange := *customer.LastStatusChange
+		customerCopy.LastStatusChange = &statusChange
+	}
+	return &customerCopy
+}
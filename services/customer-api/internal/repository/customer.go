@@ -3,6 +3,9 @@ package repository
 import (
 	"context"
 	"errors"
+	"fmt"
+	"slices"
+	"sort"
 	"sync"
 	"time"
 
@@ -12,6 +15,11 @@ import (
 var (
 	ErrCustomerNotFound = errors.New("customer not found")
 	ErrCustomerExists   = errors.New("customer already exists")
+	ErrVersionConflict  = errors.New("customer was modified concurrently")
+
+	// ErrRepositoryUnavailable marks failures caused by the backing store being
+	// unreachable or too slow, as opposed to bad input or missing data
+	ErrRepositoryUnavailable = errors.New("repository unavailable")
 )
 
 // CustomerRepository defines the interface for customer data operations
@@ -19,12 +27,33 @@ type CustomerRepository interface {
 	GetByID(ctx context.Context, customerID string) (*models.Customer, error)
 	GetAll(ctx context.Context, filters CustomerFilters) ([]*models.Customer, error)
 	Create(ctx context.Context, customer *models.Customer) error
+	// Update stores customer only if the stored version still equals
+	// customer.Version, and increments the version on success
 	Update(ctx context.Context, customer *models.Customer) error
 	Delete(ctx context.Context, customerID string) error
 	Count(ctx context.Context, filters CustomerFilters) (int, error)
 	HealthCheck(ctx context.Context) error
 }
 
+// Visibility decides whether a listing includes inactive records
+type Visibility string
+
+const (
+	// VisibilityPublic lists active records only
+	VisibilityPublic Visibility = "public"
+	// VisibilityAdmin lists inactive records as well
+	VisibilityAdmin Visibility = "admin"
+)
+
+// ParseVisibility accepts "public" or "admin"
+func ParseVisibility(value string) (Visibility, error) {
+	switch visibility := Visibility(value); visibility {
+	case VisibilityPublic, VisibilityAdmin:
+		return visibility, nil
+	}
+	return "", fmt.Errorf("visibility must be %s or %s", VisibilityPublic, VisibilityAdmin)
+}
+
 // CustomerFilters holds filtering options for customer queries
 type CustomerFilters struct {
 	Active       *bool
@@ -32,12 +61,80 @@ type CustomerFilters struct {
 	CustomerTier string
 	Page         int
 	PageSize     int
+	
+	// Visibility restricts the customers a listing may return on top of the
+	// other filters. Empty applies no restriction. ExcludeIDs are never
+	// returned.
+	Visibility Visibility
+	ExcludeIDs []string
+	
+	// Sort orders the results, by customer ID when empty. With a Cursor the
+	// page starts after (or ends before) that position and Page is ignored.
+	Sort   []SortField
+	Cursor *Cursor
+}
+
+// customerSortFields are the fields customer listings can be sorted by.
+// Fields that may be missing from stored documents are left out, since
+// keyset comparisons skip documents without the field.
+var customerSortFields = map[string]sortKind{
+	"customerId": sortString,
+	"name":       sortString,
+	"createdAt":  sortTime,
+	"updatedAt":  sortTime,
+}
+
+// ParseCustomerSort parses a sort parameter such as "name,-createdAt". The
+// customer ID is always the final tie-breaker.
+func ParseCustomerSort(spec string) ([]SortField, error) {
+	return parseSort(spec, customerSortFields, "customerId")
+}
+
+// DecodeCustomerCursor parses a cursor token issued for order
+func DecodeCustomerCursor(token string, order []SortField) (*Cursor, error) {
+	return decodeCursor(token, order, customerSortFields)
+}
+
+// CustomerCursor returns the token for the position of customer in order.
+// A backward cursor selects the page ending just before the customer.
+func CustomerCursor(customer *models.Customer, order []SortField, backward bool) string {
+	return encodeCursor(order, customerSortKey(customer, order), backward)
+}
+
+// customerSortKey returns the values of customer for each field in order
+func customerSortKey(customer *models.Customer, order []SortField) []interface{} {
+	key := make([]interface{}, len(order))
+	for i, field := range order {
+		switch field.Field {
+		case "customerId":
+			key[i] = customer.CustomerID
+		case "name":
+			key[i] = customer.Name
+		case "createdAt":
+			key[i] = customer.CreatedAt
+		case "updatedAt":
+			key[i] = customer.UpdatedAt
+		}
+	}
+	return key
+}
+
+// customerOrder returns the sort to apply for filters
+func customerOrder(filters CustomerFilters) []SortField {
+	if len(filters.Sort) > 0 {
+		return filters.Sort
+	}
+	return []SortField{{Field: "customerId"}}
 }
 
 // MemoryCustomerRepository implements CustomerRepository using in-memory storage
 type MemoryCustomerRepository struct {
 	customers map[string]*models.Customer
 	mutex     sync.RWMutex
+	
+	// persistPath, when set, receives the full customer set after every write
+	persistPath    string
+	onPersistError func(error)
 }
 
 // NewMemoryCustomerRepository creates a new in-memory customer repository
@@ -47,12 +144,84 @@ func NewMemoryCustomerRepository() *MemoryCustomerRepository {
 		mutex:     sync.RWMutex{},
 	}
 	
-	// Initialize with sample data
-	repo.initializeSampleData()
-	
 	return repo
 }
 
+// Seed validates every customer with validate and loads them all, or none if
+// any is invalid or duplicated. Customers without timestamps or a version get
+// the values Create would assign.
+func (r *MemoryCustomerRepository) Seed(customers []*models.Customer, validate func(*models.Customer) error) error {
+	seeded := make(map[string]*models.Customer, len(customers))
+	now := time.Now()
+	
+	for i, customer := range customers {
+		if customer == nil {
+			return fmt.Errorf("fixture %d: empty document", i+1)
+		}
+		if err := validate(customer); err != nil {
+			return fmt.Errorf("fixture %d (%s): %w", i+1, customer.CustomerID, err)
+		}
+		if _, duplicate := seeded[customer.CustomerID]; duplicate {
+			return fmt.Errorf("fixture %d: %w: %s", i+1, ErrCustomerExists, customer.CustomerID)
+		}
+		
+		customerCopy := *customer
+		if customerCopy.CreatedAt.IsZero() {
+			customerCopy.CreatedAt = now
+		}
+		if customerCopy.UpdatedAt.IsZero() {
+			customerCopy.UpdatedAt = customerCopy.CreatedAt
+		}
+		if customerCopy.Version == 0 {
+			customerCopy.Version = 1
+		}
+		seeded[customer.CustomerID] = &customerCopy
+	}
+	
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	
+	for customerID, customer := range seeded {
+		r.customers[customerID] = customer
+	}
+	return nil
+}
+
+// PersistTo writes the full customer set to path after every change, so
+// local edits survive a restart. Write failures are reported to onError; the
+// change itself stays in memory.
+func (r *MemoryCustomerRepository) PersistTo(path string, onError func(error)) error {
+	if err := fixturePersistTarget(path); err != nil {
+		return err
+	}
+	
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	
+	r.persistPath = path
+	r.onPersistError = onError
+	return nil
+}
+
+// persist writes the customers to persistPath. Callers hold the write lock.
+func (r *MemoryCustomerRepository) persist() {
+	if r.persistPath == "" {
+		return
+	}
+	
+	customers := make([]*models.Customer, 0, len(r.customers))
+	for _, customer := range r.customers {
+		customers = append(customers, customer)
+	}
+	sort.Slice(customers, func(i, j int) bool {
+		return customers[i].CustomerID < customers[j].CustomerID
+	})
+	
+	if err := writeFixtureFile(r.persistPath, customers); err != nil && r.onPersistError != nil {
+		r.onPersistError(fmt.Errorf("persisting customers to %s: %w", r.persistPath, err))
+	}
+}
+
 // GetByID retrieves a customer by its ID
 func (r *MemoryCustomerRepository) GetByID(ctx context.Context, customerID string) (*models.Customer, error) {
 	r.mutex.RLock()
@@ -82,23 +251,11 @@ func (r *MemoryCustomerRepository) GetAll(ctx context.Context, filters CustomerF
 		}
 	}
 	
-	// Apply pagination
-	if filters.PageSize > 0 {
-		start := filters.Page * filters.PageSize
-		end := start + filters.PageSize
-		
-		if start >= len(customers) {
-			return []*models.Customer{}, nil
-		}
-		
-		if end > len(customers) {
-			end = len(customers)
-		}
-		
-		customers = customers[start:end]
+	order := customerOrder(filters)
+	key := func(customer *models.Customer) []interface{} {
+		return customerSortKey(customer, order)
 	}
-	
-	return customers, nil
+	return sortPage(customers, order, key, filters.Cursor, filters.Page, filters.PageSize), nil
 }
 
 // Create adds a new customer
@@ -113,9 +270,11 @@ func (r *MemoryCustomerRepository) Create(ctx context.Context, customer *models.
 	now := time.Now()
 	customer.CreatedAt = now
 	customer.UpdatedAt = now
+	customer.Version = 1
 	
 	customerCopy := *customer
 	r.customers[customer.CustomerID] = &customerCopy
+	r.persist()
 	
 	return nil
 }
@@ -130,11 +289,17 @@ func (r *MemoryCustomerRepository) Update(ctx context.Context, customer *models.
 		return ErrCustomerNotFound
 	}
 	
+	if existing.Version != customer.Version {
+		return ErrVersionConflict
+	}
+	
 	customer.CreatedAt = existing.CreatedAt
 	customer.UpdatedAt = time.Now()
+	customer.Version++
 	
 	customerCopy := *customer
 	r.customers[customer.CustomerID] = &customerCopy
+	r.persist()
 	
 	return nil
 }
@@ -149,6 +314,7 @@ func (r *MemoryCustomerRepository) Delete(ctx context.Context, customerID string
 	}
 	
 	delete(r.customers, customerID)
+	r.persist()
 	return nil
 }
 
@@ -191,11 +357,13 @@ func (r *MemoryCustomerRepository) matchesFilters(customer *models.Customer, fil
 		return false
 	}
 	
+	if filters.Visibility == VisibilityPublic && !customer.Active {
+		return false
+	}
+	
+	if slices.Contains(filters.ExcludeIDs, customer.CustomerID) {
+		return false
+	}
+	
 	return true
 }
-
-// initializeSampleData is deprecated - data now comes from MongoDB initialization scripts
-func (r *MemoryCustomerRepository) initializeSampleData() {
-	// No longer needed - MongoDB provides the data via init scripts
-	// This is kept for backward compatibility but does nothing
-}
\ No newline at end of file
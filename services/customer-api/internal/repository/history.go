@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/customer-api-v2/internal/models"
+)
+
+// ErrChangeNotFound reports that no recorded change produced a version
+var ErrChangeNotFound = errors.New("change not found")
+
+// CustomerHistoryRepository stores the append-only change history of
+// customers. Changes are never modified or removed once appended.
+type CustomerHistoryRepository interface {
+	Append(ctx context.Context, changes ...*models.CustomerChange) error
+	// List returns the changes of a customer, oldest first
+	List(ctx context.Context, customerID string) ([]*models.CustomerChange, error)
+	// GetVersion returns the latest change with a snapshot that produced
+	// version of a customer, or ErrChangeNotFound
+	GetVersion(ctx context.Context, customerID string, version int64) (*models.CustomerChange, error)
+}
+
+// MemoryCustomerHistoryRepository implements CustomerHistoryRepository using
+// in-memory storage
+type MemoryCustomerHistoryRepository struct {
+	changes map[string][]*models.CustomerChange
+	mutex   sync.RWMutex
+}
+
+// NewMemoryCustomerHistoryRepository creates a new in-memory history repository
+func NewMemoryCustomerHistoryRepository() *MemoryCustomerHistoryRepository {
+	return &MemoryCustomerHistoryRepository{
+		changes: make(map[string][]*models.CustomerChange),
+	}
+}
+
+// Append stores changes after those already recorded
+func (r *MemoryCustomerHistoryRepository) Append(ctx context.Context, changes ...*models.CustomerChange) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for _, change := range changes {
+		r.changes[change.CustomerID] = append(r.changes[change.CustomerID], copyCustomerChange(change))
+	}
+	return nil
+}
+
+// List returns the changes of a customer, oldest first
+func (r *MemoryCustomerHistoryRepository) List(ctx context.Context, customerID string) ([]*models.CustomerChange, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	changes := make([]*models.CustomerChange, 0, len(r.changes[customerID]))
+	for _, change := range r.changes[customerID] {
+		changes = append(changes, copyCustomerChange(change))
+	}
+	return changes, nil
+}
+
+// GetVersion returns the latest change with a snapshot that produced version
+func (r *MemoryCustomerHistoryRepository) GetVersion(ctx context.Context, customerID string, version int64) (*models.CustomerChange, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	changes := r.changes[customerID]
+	for i := len(changes) - 1; i >= 0; i-- {
+		if changes[i].Version == version && changes[i].Snapshot != nil {
+			return copyCustomerChange(changes[i]), nil
+		}
+	}
+	return nil, ErrChangeNotFound
+}
+
+// copyCustomerChange returns a deep copy so callers cannot modify stored state
+func copyCustomerChange(change *models.CustomerChange) *models.CustomerChange {
+	changeCopy := *change
+	changeCopy.Changes = append([]models.FieldChange(nil), change.Changes...)
+	if change.Snapshot != nil {
+		changeCopy.Snapshot = copyCustomer(change.Snapshot)
+	}
+	return &changeCopy
+}
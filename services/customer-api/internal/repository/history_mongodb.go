@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/customer-api-v2/configs"
+	"github.com/customer-api-v2/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoCustomerHistoryRepository implements CustomerHistoryRepository using MongoDB
+type MongoCustomerHistoryRepository struct {
+	collection *mongo.Collection
+}
+
+// NewMongoCustomerHistoryRepository creates a history repository that
+// shares the database connection of the customer repository and stores
+// changes in config.HistoryCollection
+func NewMongoCustomerHistoryRepository(customers *MongoCustomerRepository, config configs.DatabaseConfig) (*MongoCustomerHistoryRepository, error) {
+	collection := customers.collection.Database().Collection(config.HistoryCollection)
+
+	timeout := config.ConnectTimeout
+	if timeout <= 0 {
+		timeout = defaultConnectTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	_, err := collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "customerId", Value: 1}, {Key: "changedAt", Value: 1}}},
+		{Keys: bson.D{{Key: "customerId", Value: 1}, {Key: "version", Value: 1}}},
+	})
+	if err != nil {
+		return nil, wrapMongoError(err)
+	}
+
+	return &MongoCustomerHistoryRepository{collection: collection}, nil
+}
+
+// Append inserts changes in order
+func (r *MongoCustomerHistoryRepository) Append(ctx context.Context, changes ...*models.CustomerChange) error {
+	if len(changes) == 0 {
+		return nil
+	}
+
+	documents := make([]interface{}, 0, len(changes))
+	for _, change := range changes {
+		documents = append(documents, change)
+	}
+
+	_, err := r.collection.InsertMany(ctx, documents)
+	return wrapMongoError(err)
+}
+
+// List returns the changes of a customer, oldest first
+func (r *MongoCustomerHistoryRepository) List(ctx context.Context, customerID string) ([]*models.CustomerChange, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "changedAt", Value: 1}, {Key: "_id", Value: 1}})
+	cursor, err := r.collection.Find(ctx, bson.M{"customerId": customerID}, opts)
+	if err != nil {
+		return nil, wrapMongoError(err)
+	}
+	defer cursor.Close(ctx)
+
+	changes := []*models.CustomerChange{}
+	if err := cursor.All(ctx, &changes); err != nil {
+		return nil, wrapMongoError(err)
+	}
+
+	return changes, nil
+}
+
+// GetVersion returns the latest change with a snapshot that produced version
+func (r *MongoCustomerHistoryRepository) GetVersion(ctx context.Context, customerID string, version int64) (*models.CustomerChange, error) {
+	var change models.CustomerChange
+
+	filter := bson.M{"customerId": customerID, "version": version, "snapshot": bson.M{"$ne": nil}}
+	opts := options.FindOne().SetSort(bson.D{{Key: "changedAt", Value: -1}, {Key: "_id", Value: -1}})
+	err := r.collection.FindOne(ctx, filter, opts).Decode(&change)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrChangeNotFound
+		}
+		return nil, wrapMongoError(err)
+	}
+
+	return &change, nil
+}
@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/customer-api-v2/internal/models"
+)
+
+// Outcomes reported to an OperationObserver
+const (
+	OutcomeSuccess  = "success"
+	OutcomeRejected = "rejected" // the store answered, but with a domain error such as not found
+	OutcomeError    = "error"
+)
+
+// OperationObserver receives the name, outcome and duration of a repository call
+type OperationObserver func(operation, outcome string, duration time.Duration)
+
+// InstrumentedCustomerRepository reports the duration of every call to the
+// wrapped repository
+type InstrumentedCustomerRepository struct {
+	CustomerRepository
+	observe OperationObserver
+}
+
+// NewInstrumentedCustomerRepository wraps repo so each call is reported to observe
+func NewInstrumentedCustomerRepository(repo CustomerRepository, observe OperationObserver) *InstrumentedCustomerRepository {
+	return &InstrumentedCustomerRepository{CustomerRepository: repo, observe: observe}
+}
+
+// GetByID times CustomerRepository.GetByID
+func (r *InstrumentedCustomerRepository) GetByID(ctx context.Context, customerID string) (*models.Customer, error) {
+	start := time.Now()
+	customer, err := r.CustomerRepository.GetByID(ctx, customerID)
+	r.record("GetByID", start, err)
+	return customer, err
+}
+
+// GetAll times CustomerRepository.GetAll
+func (r *InstrumentedCustomerRepository) GetAll(ctx context.Context, filters CustomerFilters) ([]*models.Customer, error) {
+	start := time.Now()
+	customers, err := r.CustomerRepository.GetAll(ctx, filters)
+	r.record("GetAll", start, err)
+	return customers, err
+}
+
+// Create times CustomerRepository.Create
+func (r *InstrumentedCustomerRepository) Create(ctx context.Context, customer *models.Customer) error {
+	start := time.Now()
+	err := r.CustomerRepository.Create(ctx, customer)
+	r.record("Create", start, err)
+	return err
+}
+
+// Update times CustomerRepository.Update
+func (r *InstrumentedCustomerRepository) Update(ctx context.Context, customer *models.Customer) error {
+	start := time.Now()
+	err := r.CustomerRepository.Update(ctx, customer)
+	r.record("Update", start, err)
+	return err
+}
+
+// Delete times CustomerRepository.Delete
+func (r *InstrumentedCustomerRepository) Delete(ctx context.Context, customerID string) error {
+	start := time.Now()
+	err := r.CustomerRepository.Delete(ctx, customerID)
+	r.record("Delete", start, err)
+	return err
+}
+
+// Count times CustomerRepository.Count
+func (r *InstrumentedCustomerRepository) Count(ctx context.Context, filters CustomerFilters) (int, error) {
+	start := time.Now()
+	count, err := r.CustomerRepository.Count(ctx, filters)
+	r.record("Count", start, err)
+	return count, err
+}
+
+// HealthCheck times CustomerRepository.HealthCheck
+func (r *InstrumentedCustomerRepository) HealthCheck(ctx context.Context) error {
+	start := time.Now()
+	err := r.CustomerRepository.HealthCheck(ctx)
+	r.record("HealthCheck", start, err)
+	return err
+}
+
+// record reports one call to the observer
+func (r *InstrumentedCustomerRepository) record(operation string, start time.Time, err error) {
+	r.observe(operation, operationOutcome(err), time.Since(start))
+}
+
+// operationOutcome classifies a repository error for metrics
+func operationOutcome(err error) string {
+	switch {
+	case err == nil:
+		return OutcomeSuccess
+	case errors.Is(err, ErrCustomerNotFound),
+		errors.Is(err, ErrCustomerExists),
+		errors.Is(err, ErrVersionConflict):
+		return OutcomeRejected
+	default:
+		return OutcomeError
+	}
+}
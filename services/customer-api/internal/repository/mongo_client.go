@@ -0,0 +1,160 @@
+package repository
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/customer-api-v2/configs"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+	"go.mongodb.org/mongo-driver/mongo/writeconcern"
+)
+
+// defaultConnectTimeout bounds the initial connection when none is configured
+const defaultConnectTimeout = 10 * time.Second
+
+// connectMongo opens a client configured from config and pings it within the
+// connect timeout
+func connectMongo(config configs.DatabaseConfig) (*mongo.Client, error) {
+	clientOptions, err := mongoClientOptions(config)
+	if err != nil {
+		return nil, err
+	}
+
+	connectTimeout := config.ConnectTimeout
+	if connectTimeout <= 0 {
+		connectTimeout = defaultConnectTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), connectTimeout)
+	defer cancel()
+
+	client, err := mongo.Connect(ctx, clientOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	// Test connection
+	if err := client.Ping(ctx, nil); err != nil {
+		client.Disconnect(context.Background())
+		return nil, err
+	}
+
+	return client, nil
+}
+
+// mongoClientOptions translates config into driver options. Settings left
+// empty keep whatever the connection URL specifies.
+func mongoClientOptions(config configs.DatabaseConfig) (*options.ClientOptions, error) {
+	clientOptions := options.Client().ApplyURI(config.URL)
+
+	if config.MaxConnections > 0 {
+		clientOptions.SetMaxPoolSize(uint64(config.MaxConnections))
+	}
+	if config.MinConnections > 0 {
+		clientOptions.SetMinPoolSize(uint64(config.MinConnections))
+	}
+	if config.MaxConnIdleTime > 0 {
+		clientOptions.SetMaxConnIdleTime(config.MaxConnIdleTime)
+	}
+	if config.ConnectTimeout > 0 {
+		clientOptions.SetConnectTimeout(config.ConnectTimeout)
+		clientOptions.SetServerSelectionTimeout(config.ConnectTimeout)
+	}
+	if config.Timeout > 0 {
+		// Applies to every operation whose context carries no deadline
+		clientOptions.SetTimeout(config.Timeout)
+	}
+
+	if config.ReadPreference != "" {
+		mode, err := readpref.ModeFromString(config.ReadPreference)
+		if err != nil {
+			return nil, fmt.Errorf("invalid read preference %q: %w", config.ReadPreference, err)
+		}
+		preference, err := readpref.New(mode)
+		if err != nil {
+			return nil, fmt.Errorf("invalid read preference %q: %w", config.ReadPreference, err)
+		}
+		clientOptions.SetReadPreference(preference)
+	}
+
+	if config.WriteConcern != "" || config.WriteJournal {
+		concern, err := parseWriteConcern(config.WriteConcern, config.WriteJournal)
+		if err != nil {
+			return nil, err
+		}
+		clientOptions.SetWriteConcern(concern)
+	}
+
+	if config.TLS.Enabled {
+		tlsConfig, err := mongoTLSConfig(config.TLS)
+		if err != nil {
+			return nil, err
+		}
+		clientOptions.SetTLSConfig(tlsConfig)
+	}
+
+	if err := clientOptions.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid database configuration: %w", err)
+	}
+
+	return clientOptions, nil
+}
+
+// parseWriteConcern accepts "majority" or a number of acknowledging nodes
+func parseWriteConcern(w string, journal bool) (*writeconcern.WriteConcern, error) {
+	concern := &writeconcern.WriteConcern{}
+
+	switch w = strings.TrimSpace(w); {
+	case w == "":
+	case strings.EqualFold(w, "majority"):
+		concern.W = "majority"
+	default:
+		nodes, err := strconv.Atoi(w)
+		if err != nil || nodes < 0 {
+			return nil, fmt.Errorf("invalid write concern %q: expected \"majority\" or a number of nodes", w)
+		}
+		concern.W = nodes
+	}
+
+	if journal {
+		concern.Journal = &journal
+	}
+	return concern, nil
+}
+
+// mongoTLSConfig builds the TLS settings for the database connection
+func mongoTLSConfig(config configs.TLSConfig) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: config.InsecureSkipVerify,
+	}
+
+	if config.CAFile != "" {
+		pem, err := os.ReadFile(config.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read database CA file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("database CA file %s contains no certificates", config.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if config.CertificateKeyFile != "" {
+		certificate, err := tls.LoadX509KeyPair(config.CertificateKeyFile, config.CertificateKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load database client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{certificate}
+	}
+
+	return tlsConfig, nil
+}
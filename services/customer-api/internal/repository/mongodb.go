@@ -2,8 +2,12 @@ package repository
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"slices"
 	"time"
 
+	"github.com/customer-api-v2/configs"
 	"github.com/customer-api-v2/internal/models"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
@@ -16,22 +20,16 @@ type MongoCustomerRepository struct {
 	client     *mongo.Client
 }
 
-// NewMongoCustomerRepository creates a new MongoDB customer repository
-func NewMongoCustomerRepository(mongoURL string) (*MongoCustomerRepository, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-
-	client, err := mongo.Connect(ctx, options.Client().ApplyURI(mongoURL))
+// NewMongoCustomerRepository connects to config.Database with the pool,
+// timeout and TLS settings in config and keeps customer profiles in
+// config.Collection
+func NewMongoCustomerRepository(config configs.DatabaseConfig) (*MongoCustomerRepository, error) {
+	client, err := connectMongo(config)
 	if err != nil {
 		return nil, err
 	}
 
-	// Test connection
-	if err := client.Ping(ctx, nil); err != nil {
-		return nil, err
-	}
-
-	collection := client.Database("catalog").Collection("customers")
+	collection := client.Database(config.Database).Collection(config.Collection)
 
 	return &MongoCustomerRepository{
 		collection: collection,
@@ -47,10 +45,10 @@ func (r *MongoCustomerRepository) GetByID(ctx context.Context, customerID string
 	err := r.collection.FindOne(ctx, filter).Decode(&customer)
 	
 	if err != nil {
-		if err == mongo.ErrNoDocuments {
+		if errors.Is(err, mongo.ErrNoDocuments) {
 			return nil, ErrCustomerNotFound
 		}
-		return nil, err
+		return nil, wrapMongoError(err)
 	}
 	
 	return &customer, nil
@@ -58,31 +56,25 @@ func (r *MongoCustomerRepository) GetByID(ctx context.Context, customerID string
 
 // GetAll retrieves all customers with optional filtering from MongoDB
 func (r *MongoCustomerRepository) GetAll(ctx context.Context, filters CustomerFilters) ([]*models.Customer, error) {
-	// Build MongoDB filter
-	filter := bson.M{}
-	
-	if filters.Active != nil {
-		filter["active"] = *filters.Active
-	}
+	filter := customerFilter(filters)
+	order := customerOrder(filters)
+	backward := filters.Cursor != nil && filters.Cursor.Backward
 	
-	if filters.CustomerTier != "" {
-		filter["customerTier"] = filters.CustomerTier
+	// Keyset pagination when a cursor is given, offsets otherwise
+	opts := options.Find().SetSort(mongoSort(order, backward))
+	if filters.Cursor != nil {
+		filter = bson.M{"$and": bson.A{filter, keysetFilter(order, filters.Cursor)}}
 	}
-	
-	if filters.Email != "" {
-		filter["email"] = bson.M{"$regex": filters.Email, "$options": "i"}
-	}
-	
-	// Build options for pagination
-	opts := options.Find()
 	if filters.PageSize > 0 {
 		opts.SetLimit(int64(filters.PageSize))
-		opts.SetSkip(int64(filters.Page * filters.PageSize))
+		if filters.Cursor == nil {
+			opts.SetSkip(int64(filters.Page * filters.PageSize))
+		}
 	}
 	
 	cursor, err := r.collection.Find(ctx, filter, opts)
 	if err != nil {
-		return nil, err
+		return nil, wrapMongoError(err)
 	}
 	defer cursor.Close(ctx)
 	
@@ -90,13 +82,18 @@ func (r *MongoCustomerRepository) GetAll(ctx context.Context, filters CustomerFi
 	for cursor.Next(ctx) {
 		var customer models.Customer
 		if err := cursor.Decode(&customer); err != nil {
-			return nil, err
+			return nil, wrapMongoError(err)
 		}
 		customers = append(customers, &customer)
 	}
 	
 	if err := cursor.Err(); err != nil {
-		return nil, err
+		return nil, wrapMongoError(err)
+	}
+	
+	// Backward pages are read in reverse order
+	if backward {
+		slices.Reverse(customers)
 	}
 	
 	return customers, nil
@@ -112,25 +109,41 @@ func (r *MongoCustomerRepository) Create(ctx context.Context, customer *models.C
 	
 	customer.CreatedAt = time.Now()
 	customer.UpdatedAt = time.Now()
+	customer.Version = 1
 	
 	_, err = r.collection.InsertOne(ctx, customer)
-	return err
+	return wrapMongoError(err)
 }
 
-// Update modifies an existing customer in MongoDB
+// Update modifies an existing customer in MongoDB. The write only matches if
+// the stored version equals customer.Version, so concurrent editors cannot
+// overwrite each other silently.
 func (r *MongoCustomerRepository) Update(ctx context.Context, customer *models.Customer) error {
+	expectedVersion := customer.Version
 	filter := bson.M{"customerId": customer.CustomerID}
+	if expectedVersion == 0 {
+		// Documents created before versioning have no version field
+		filter["version"] = bson.M{"$in": bson.A{0, nil}}
+	} else {
+		filter["version"] = expectedVersion
+	}
 	
 	customer.UpdatedAt = time.Now()
+	customer.Version = expectedVersion + 1
 	update := bson.M{"$set": customer}
 	
 	result, err := r.collection.UpdateOne(ctx, filter, update)
 	if err != nil {
-		return err
+		customer.Version = expectedVersion
+		return wrapMongoError(err)
 	}
 	
 	if result.MatchedCount == 0 {
-		return ErrCustomerNotFound
+		customer.Version = expectedVersion
+		if _, err := r.GetByID(ctx, customer.CustomerID); err != nil {
+			return err
+		}
+		return ErrVersionConflict
 	}
 	
 	return nil
@@ -142,7 +155,7 @@ func (r *MongoCustomerRepository) Delete(ctx context.Context, customerID string)
 	
 	result, err := r.collection.DeleteOne(ctx, filter)
 	if err != nil {
-		return err
+		return wrapMongoError(err)
 	}
 	
 	if result.DeletedCount == 0 {
@@ -154,6 +167,24 @@ func (r *MongoCustomerRepository) Delete(ctx context.Context, customerID string)
 
 // Count returns the total number of customers matching the filters
 func (r *MongoCustomerRepository) Count(ctx context.Context, filters CustomerFilters) (int, error) {
+	filter := customerFilter(filters)
+	
+	count, err := r.collection.CountDocuments(ctx, filter)
+	return int(count), wrapMongoError(err)
+}
+
+// HealthCheck verifies the MongoDB connection is working
+func (r *MongoCustomerRepository) HealthCheck(ctx context.Context) error {
+	return wrapMongoError(r.client.Ping(ctx, nil))
+}
+
+// Close closes the MongoDB connection
+func (r *MongoCustomerRepository) Close(ctx context.Context) error {
+	return r.client.Disconnect(ctx)
+}
+
+// customerFilter translates filters into a MongoDB query, ignoring pagination
+func customerFilter(filters CustomerFilters) bson.M {
 	filter := bson.M{}
 	
 	if filters.Active != nil {
@@ -168,16 +199,27 @@ func (r *MongoCustomerRepository) Count(ctx context.Context, filters CustomerFil
 		filter["email"] = bson.M{"$regex": filters.Email, "$options": "i"}
 	}
 	
-	count, err := r.collection.CountDocuments(ctx, filter)
-	return int(count), err
+	// Kept apart from the active filter, so public listings asking for
+	// inactive customers match nothing
+	if filters.Visibility == VisibilityPublic {
+		filter["$and"] = bson.A{bson.M{"active": true}}
+	}
+	
+	if len(filters.ExcludeIDs) > 0 {
+		filter["customerId"] = bson.M{"$nin": filters.ExcludeIDs}
+	}
+	
+	return filter
 }
 
-// HealthCheck verifies the MongoDB connection is working
-func (r *MongoCustomerRepository) HealthCheck(ctx context.Context) error {
-	return r.client.Ping(ctx, nil)
+// wrapMongoError marks connectivity failures and timeouts with
+// ErrRepositoryUnavailable so callers can tell them apart from bad queries
+func wrapMongoError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if mongo.IsNetworkError(err) || mongo.IsTimeout(err) || errors.Is(err, mongo.ErrClientDisconnected) {
+		return fmt.Errorf("%w: %v", ErrRepositoryUnavailable, err)
+	}
+	return err
 }
-
-// Close closes the MongoDB connection
-func (r *MongoCustomerRepository) Close(ctx context.Context) error {
-	return r.client.Disconnect(ctx)
-}
\ No newline at end of file
@@ -0,0 +1,120 @@
+package repository
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/customer-api-v2/internal/models"
+)
+
+// OutboxRepository stores events until a relay has published them. Writes
+// append their events within the same transaction, so an event exists if
+// and only if its write was committed.
+type OutboxRepository interface {
+	Append(ctx context.Context, messages ...*models.OutboxMessage) error
+	// Pending returns up to limit messages that are neither published nor
+	// dead letters, in the order they were appended
+	Pending(ctx context.Context, limit int) ([]*models.OutboxMessage, error)
+	// MarkPublished records that messages reached the sink
+	MarkPublished(ctx context.Context, eventIDs []string, publishedAt time.Time) error
+	// RecordFailure counts a failed attempt to publish a message
+	RecordFailure(ctx context.Context, eventID string, cause string) error
+	// MarkDead counts the last failed attempt to publish a message and
+	// parks it as a dead letter
+	MarkDead(ctx context.Context, eventID string, cause string, deadAt time.Time) error
+}
+
+// MemoryOutboxRepository implements OutboxRepository using in-memory
+// storage. Published messages are dropped rather than kept; dead letters
+// are kept.
+type MemoryOutboxRepository struct {
+	messages []*models.OutboxMessage
+	mutex    sync.Mutex
+}
+
+// NewMemoryOutboxRepository creates a new in-memory outbox
+func NewMemoryOutboxRepository() *MemoryOutboxRepository {
+	return &MemoryOutboxRepository{}
+}
+
+// Append stores messages after those already waiting
+func (r *MemoryOutboxRepository) Append(ctx context.Context, messages ...*models.OutboxMessage) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for _, message := range messages {
+		messageCopy := *message
+		r.messages = append(r.messages, &messageCopy)
+	}
+	return nil
+}
+
+// Pending returns up to limit waiting messages, oldest first
+func (r *MemoryOutboxRepository) Pending(ctx context.Context, limit int) ([]*models.OutboxMessage, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	pending := make([]*models.OutboxMessage, 0, min(limit, len(r.messages)))
+	for _, message := range r.messages {
+		if len(pending) == limit {
+			break
+		}
+		if message.DeadAt != nil {
+			continue
+		}
+		messageCopy := *message
+		pending = append(pending, &messageCopy)
+	}
+	return pending, nil
+}
+
+// MarkPublished drops published messages
+func (r *MemoryOutboxRepository) MarkPublished(ctx context.Context, eventIDs []string, publishedAt time.Time) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	published := make(map[string]bool, len(eventIDs))
+	for _, eventID := range eventIDs {
+		published[eventID] = true
+	}
+
+	waiting := r.messages[:0]
+	for _, message := range r.messages {
+		if !published[message.EventID] {
+			waiting = append(waiting, message)
+		}
+	}
+	clear(r.messages[len(waiting):])
+	r.messages = waiting
+	return nil
+}
+
+// RecordFailure counts a failed attempt on a waiting message
+func (r *MemoryOutboxRepository) RecordFailure(ctx context.Context, eventID string, cause string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for _, message := range r.messages {
+		if message.EventID == eventID {
+			message.Attempts++
+			message.LastError = cause
+		}
+	}
+	return nil
+}
+
+// MarkDead counts the last failed attempt on a waiting message and parks it
+func (r *MemoryOutboxRepository) MarkDead(ctx context.Context, eventID string, cause string, deadAt time.Time) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for _, message := range r.messages {
+		if message.EventID == eventID {
+			message.Attempts++
+			message.LastError = cause
+			message.DeadAt = &deadAt
+		}
+	}
+	return nil
+}
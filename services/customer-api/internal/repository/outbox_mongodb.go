@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/customer-api-v2/configs"
+	"github.com/customer-api-v2/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// outboxRetention is how long published messages are kept for inspection
+// before MongoDB removes them
+const outboxRetention = 7 * 24 * time.Hour
+
+// MongoOutboxRepository implements OutboxRepository using MongoDB
+type MongoOutboxRepository struct {
+	collection *mongo.Collection
+}
+
+// NewMongoOutboxRepository creates an outbox that shares the database
+// connection of the customer repository and stores messages in
+// config.OutboxCollection
+func NewMongoOutboxRepository(customers *MongoCustomerRepository, config configs.DatabaseConfig) (*MongoOutboxRepository, error) {
+	collection := customers.collection.Database().Collection(config.OutboxCollection)
+
+	timeout := config.ConnectTimeout
+	if timeout <= 0 {
+		timeout = defaultConnectTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	_, err := collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "eventId", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "publishedAt", Value: 1}, {Key: "_id", Value: 1}}},
+		{
+			Keys:    bson.D{{Key: "publishedAt", Value: 1}},
+			Options: options.Index().SetName("outbox_retention").SetExpireAfterSeconds(int32(outboxRetention.Seconds())),
+		},
+	})
+	if err != nil {
+		return nil, wrapMongoError(err)
+	}
+
+	return &MongoOutboxRepository{collection: collection}, nil
+}
+
+// Append inserts messages in order
+func (r *MongoOutboxRepository) Append(ctx context.Context, messages ...*models.OutboxMessage) error {
+	if len(messages) == 0 {
+		return nil
+	}
+
+	documents := make([]interface{}, 0, len(messages))
+	for _, message := range messages {
+		documents = append(documents, message)
+	}
+
+	_, err := r.collection.InsertMany(ctx, documents)
+	return wrapMongoError(err)
+}
+
+// Pending returns up to limit unpublished messages that are not dead
+// letters, in insertion order
+func (r *MongoOutboxRepository) Pending(ctx context.Context, limit int) ([]*models.OutboxMessage, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}).SetLimit(int64(limit))
+	cursor, err := r.collection.Find(ctx, bson.M{"publishedAt": nil, "deadAt": nil}, opts)
+	if err != nil {
+		return nil, wrapMongoError(err)
+	}
+	defer cursor.Close(ctx)
+
+	messages := []*models.OutboxMessage{}
+	if err := cursor.All(ctx, &messages); err != nil {
+		return nil, wrapMongoError(err)
+	}
+
+	return messages, nil
+}
+
+// MarkPublished sets the publication time of messages, after which they
+// expire
+func (r *MongoOutboxRepository) MarkPublished(ctx context.Context, eventIDs []string, publishedAt time.Time) error {
+	if len(eventIDs) == 0 {
+		return nil
+	}
+
+	_, err := r.collection.UpdateMany(ctx,
+		bson.M{"eventId": bson.M{"$in": eventIDs}},
+		bson.M{"$set": bson.M{"publishedAt": publishedAt}},
+	)
+	return wrapMongoError(err)
+}
+
+// RecordFailure counts a failed attempt and keeps its cause
+func (r *MongoOutboxRepository) RecordFailure(ctx context.Context, eventID string, cause string) error {
+	_, err := r.collection.UpdateOne(ctx,
+		bson.M{"eventId": eventID},
+		bson.M{"$inc": bson.M{"attempts": 1}, "$set": bson.M{"lastError": cause}},
+	)
+	return wrapMongoError(err)
+}
+
+// MarkDead counts the last failed attempt and parks the message. Dead
+// letters have no publication time, so they are kept until removed by hand.
+func (r *MongoOutboxRepository) MarkDead(ctx context.Context, eventID string, cause string, deadAt time.Time) error {
+	_, err := r.collection.UpdateOne(ctx,
+		bson.M{"eventId": eventID},
+		bson.M{"$inc": bson.M{"attempts": 1}, "$set": bson.M{"lastError": cause, "deadAt": deadAt}},
+	)
+	return wrapMongoError(err)
+}
@@ -0,0 +1,480 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/customer-api-v2/internal/models"
+)
+
+// SwappableCustomerRepository forwards every call to a repository that can be
+// replaced at runtime, so a service started without its database can switch
+// to it once a background reconnect succeeds
+type SwappableCustomerRepository struct {
+	current atomic.Pointer[customerRepositoryHolder]
+}
+
+// customerRepositoryHolder lets an interface value live in an atomic.Pointer
+type customerRepositoryHolder struct {
+	repo CustomerRepository
+}
+
+// NewSwappableCustomerRepository creates a swappable repository backed by repo
+func NewSwappableCustomerRepository(repo CustomerRepository) *SwappableCustomerRepository {
+	swappable := &SwappableCustomerRepository{}
+	swappable.Swap(repo)
+	return swappable
+}
+
+// Swap replaces the backing repository; calls already in flight finish on the old one
+func (r *SwappableCustomerRepository) Swap(repo CustomerRepository) {
+	r.current.Store(&customerRepositoryHolder{repo: repo})
+}
+
+// Current returns the backing repository
+func (r *SwappableCustomerRepository) Current() CustomerRepository {
+	return r.current.Load().repo
+}
+
+// GetByID forwards to the current repository
+func (r *SwappableCustomerRepository) GetByID(ctx context.Context, customerID string) (*models.Customer, error) {
+	return r.Current().GetByID(ctx, customerID)
+}
+
+// GetAll forwards to the current repository
+func (r *SwappableCustomerRepository) GetAll(ctx context.Context, filters CustomerFilters) ([]*models.Customer, error) {
+	return r.Current().GetAll(ctx, filters)
+}
+
+// Create forwards to the current repository
+func (r *SwappableCustomerRepository) Create(ctx context.Context, customer *models.Customer) error {
+	return r.Current().Create(ctx, customer)
+}
+
+// Update forwards to the current repository
+func (r *SwappableCustomerRepository) Update(ctx context.Context, customer *models.Customer) error {
+	return r.Current().Update(ctx, customer)
+}
+
+// Delete forwards to the current repository
+func (r *SwappableCustomerRepository) Delete(ctx context.Context, customerID string) error {
+	return r.Current().Delete(ctx, customerID)
+}
+
+// Count forwards to the current repository
+func (r *SwappableCustomerRepository) Count(ctx context.Context, filters CustomerFilters) (int, error) {
+	return r.Current().Count(ctx, filters)
+}
+
+// HealthCheck forwards to the current repository
+func (r *SwappableCustomerRepository) HealthCheck(ctx context.Context) error {
+	return r.Current().HealthCheck(ctx)
+}
+
+// SwappableCustomerHistoryRepository is the CustomerHistoryRepository
+// counterpart of SwappableCustomerRepository
+type SwappableCustomerHistoryRepository struct {
+	current atomic.Pointer[customerHistoryRepositoryHolder]
+}
+
+// customerHistoryRepositoryHolder lets an interface value live in an atomic.Pointer
+type customerHistoryRepositoryHolder struct {
+	repo CustomerHistoryRepository
+}
+
+// NewSwappableCustomerHistoryRepository creates a swappable repository backed by repo
+func NewSwappableCustomerHistoryRepository(repo CustomerHistoryRepository) *SwappableCustomerHistoryRepository {
+	swappable := &SwappableCustomerHistoryRepository{}
+	swappable.Swap(repo)
+	return swappable
+}
+
+// Swap replaces the backing repository; calls already in flight finish on the old one
+func (r *SwappableCustomerHistoryRepository) Swap(repo CustomerHistoryRepository) {
+	r.current.Store(&customerHistoryRepositoryHolder{repo: repo})
+}
+
+// Current returns the backing repository
+func (r *SwappableCustomerHistoryRepository) Current() CustomerHistoryRepository {
+	return r.current.Load().repo
+}
+
+// Append forwards to the current repository
+func (r *SwappableCustomerHistoryRepository) Append(ctx context.Context, changes ...*models.CustomerChange) error {
+	return r.Current().Append(ctx, changes...)
+}
+
+// List forwards to the current repository
+func (r *SwappableCustomerHistoryRepository) List(ctx context.Context, customerID string) ([]*models.CustomerChange, error) {
+	return r.Current().List(ctx, customerID)
+}
+
+// GetVersion forwards to the current repository
+func (r *SwappableCustomerHistoryRepository) GetVersion(ctx context.Context, customerID string, version int64) (*models.CustomerChange, error) {
+	return r.Current().GetVersion(ctx, customerID, version)
+}
+
+// SwappableOutboxRepository is the OutboxRepository counterpart of
+// SwappableCustomerRepository
+type SwappableOutboxRepository struct {
+	current atomic.Pointer[outboxRepositoryHolder]
+}
+
+// outboxRepositoryHolder lets an interface value live in an atomic.Pointer
+type outboxRepositoryHolder struct {
+	repo OutboxRepository
+}
+
+// NewSwappableOutboxRepository creates a swappable repository backed by repo
+func NewSwappableOutboxRepository(repo OutboxRepository) *SwappableOutboxRepository {
+	swappable := &SwappableOutboxRepository{}
+	swappable.Swap(repo)
+	return swappable
+}
+
+// Swap replaces the backing repository; calls already in flight finish on the old one
+func (r *SwappableOutboxRepository) Swap(repo OutboxRepository) {
+	r.current.Store(&outboxRepositoryHolder{repo: repo})
+}
+
+// Current returns the backing repository
+func (r *SwappableOutboxRepository) Current() OutboxRepository {
+	return r.current.Load().repo
+}
+
+// Append forwards to the current repository
+func (r *SwappableOutboxRepository) Append(ctx context.Context, messages ...*models.OutboxMessage) error {
+	return r.Current().Append(ctx, messages...)
+}
+
+// Pending forwards to the current repository
+func (r *SwappableOutboxRepository) Pending(ctx context.Context, limit int) ([]*models.OutboxMessage, error) {
+	return r.Current().Pending(ctx, limit)
+}
+
+// MarkPublished forwards to the current repository
+func (r *SwappableOutboxRepository) MarkPublished(ctx context.Context, eventIDs []string, publishedAt time.Time) error {
+	return r.Current().MarkPublished(ctx, eventIDs, publishedAt)
+}
+
+// RecordFailure forwards to the current repository
+func (r *SwappableOutboxRepository) RecordFailure(ctx context.Context, eventID string, cause string) error {
+	return r.Current().RecordFailure(ctx, eventID, cause)
+}
+
+// MarkDead forwards to the current repository
+func (r *SwappableOutboxRepository) MarkDead(ctx context.Context, eventID string, cause string, deadAt time.Time) error {
+	return r.Current().MarkDead(ctx, eventID, cause, deadAt)
+}
+
+// SwappableTransactor is the Transactor counterpart of
+// SwappableCustomerRepository
+type SwappableTransactor struct {
+	current atomic.Pointer[transactorHolder]
+}
+
+// transactorHolder lets an interface value live in an atomic.Pointer
+type transactorHolder struct {
+	transactor Transactor
+}
+
+// NewSwappableTransactor creates a swappable transactor backed by transactor
+func NewSwappableTransactor(transactor Transactor) *SwappableTransactor {
+	swappable := &SwappableTransactor{}
+	swappable.Swap(transactor)
+	return swappable
+}
+
+// Swap replaces the backing transactor; work already running finishes on the old one
+func (t *SwappableTransactor) Swap(transactor Transactor) {
+	t.current.Store(&transactorHolder{transactor: transactor})
+}
+
+// Current returns the backing transactor
+func (t *SwappableTransactor) Current() Transactor {
+	return t.current.Load().transactor
+}
+
+// WithinTransaction forwards to the current transactor
+func (t *SwappableTransactor) WithinTransaction(ctx context.Context, work func(ctx context.Context) error) error {
+	return t.Current().WithinTransaction(ctx, work)
+}
+
+// SwappableWebhookRepository is the WebhookRepository counterpart of
+// SwappableCustomerRepository
+type SwappableWebhookRepository struct {
+	current atomic.Pointer[webhookRepositoryHolder]
+}
+
+// webhookRepositoryHolder lets an interface value live in an atomic.Pointer
+type webhookRepositoryHolder struct {
+	repo WebhookRepository
+}
+
+// NewSwappableWebhookRepository creates a swappable repository backed by repo
+func NewSwappableWebhookRepository(repo WebhookRepository) *SwappableWebhookRepository {
+	swappable := &SwappableWebhookRepository{}
+	swappable.Swap(repo)
+	return swappable
+}
+
+// Swap replaces the backing repository; calls already in flight finish on the old one
+func (r *SwappableWebhookRepository) Swap(repo WebhookRepository) {
+	r.current.Store(&webhookRepositoryHolder{repo: repo})
+}
+
+// Current returns the backing repository
+func (r *SwappableWebhookRepository) Current() WebhookRepository {
+	return r.current.Load().repo
+}
+
+// Create forwards to the current repository
+func (r *SwappableWebhookRepository) Create(ctx context.Context, webhook *models.WebhookSubscription) error {
+	return r.Current().Create(ctx, webhook)
+}
+
+// GetByID forwards to the current repository
+func (r *SwappableWebhookRepository) GetByID(ctx context.Context, webhookID string) (*models.WebhookSubscription, error) {
+	return r.Current().GetByID(ctx, webhookID)
+}
+
+// GetAll forwards to the current repository
+func (r *SwappableWebhookRepository) GetAll(ctx context.Context) ([]*models.WebhookSubscription, error) {
+	return r.Current().GetAll(ctx)
+}
+
+// Delete forwards to the current repository
+func (r *SwappableWebhookRepository) Delete(ctx context.Context, webhookID string) error {
+	return r.Current().Delete(ctx, webhookID)
+}
+
+// SwappableWebhookDeliveryRepository is the WebhookDeliveryRepository counterpart of
+// SwappableCustomerRepository
+type SwappableWebhookDeliveryRepository struct {
+	current atomic.Pointer[webhookDeliveryRepositoryHolder]
+}
+
+// webhookDeliveryRepositoryHolder lets an interface value live in an atomic.Pointer
+type webhookDeliveryRepositoryHolder struct {
+	repo WebhookDeliveryRepository
+}
+
+// NewSwappableWebhookDeliveryRepository creates a swappable repository backed by repo
+func NewSwappableWebhookDeliveryRepository(repo WebhookDeliveryRepository) *SwappableWebhookDeliveryRepository {
+	swappable := &SwappableWebhookDeliveryRepository{}
+	swappable.Swap(repo)
+	return swappable
+}
+
+// Swap replaces the backing repository; calls already in flight finish on the old one
+func (r *SwappableWebhookDeliveryRepository) Swap(repo WebhookDeliveryRepository) {
+	r.current.Store(&webhookDeliveryRepositoryHolder{repo: repo})
+}
+
+// Current returns the backing repository
+func (r *SwappableWebhookDeliveryRepository) Current() WebhookDeliveryRepository {
+	return r.current.Load().repo
+}
+
+// Append forwards to the current repository
+func (r *SwappableWebhookDeliveryRepository) Append(ctx context.Context, deliveries ...*models.WebhookDelivery) error {
+	return r.Current().Append(ctx, deliveries...)
+}
+
+// ClaimDue forwards to the current repository
+func (r *SwappableWebhookDeliveryRepository) ClaimDue(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*models.WebhookDelivery, error) {
+	return r.Current().ClaimDue(ctx, now, lease, limit)
+}
+
+// GetByID forwards to the current repository
+func (r *SwappableWebhookDeliveryRepository) GetByID(ctx context.Context, deliveryID string) (*models.WebhookDelivery, error) {
+	return r.Current().GetByID(ctx, deliveryID)
+}
+
+// Update forwards to the current repository
+func (r *SwappableWebhookDeliveryRepository) Update(ctx context.Context, delivery *models.WebhookDelivery) error {
+	return r.Current().Update(ctx, delivery)
+}
+
+// List forwards to the current repository
+func (r *SwappableWebhookDeliveryRepository) List(ctx context.Context, filter WebhookDeliveryFilter) ([]*models.WebhookDelivery, error) {
+	return r.Current().List(ctx, filter)
+}
+
+// UnavailableCustomerRepository stands in for a database that could not be
+// reached. Every call fails with ErrRepositoryUnavailable, which the services
+// report as 503, and HealthCheck fails so readiness stays off.
+type UnavailableCustomerRepository struct {
+	err error
+}
+
+// NewUnavailableCustomerRepository creates a repository that fails every call with cause
+func NewUnavailableCustomerRepository(cause error) *UnavailableCustomerRepository {
+	return &UnavailableCustomerRepository{err: fmt.Errorf("%w: %v", ErrRepositoryUnavailable, cause)}
+}
+
+// GetByID always fails
+func (r *UnavailableCustomerRepository) GetByID(ctx context.Context, customerID string) (*models.Customer, error) {
+	return nil, r.err
+}
+
+// GetAll always fails
+func (r *UnavailableCustomerRepository) GetAll(ctx context.Context, filters CustomerFilters) ([]*models.Customer, error) {
+	return nil, r.err
+}
+
+// Create always fails
+func (r *UnavailableCustomerRepository) Create(ctx context.Context, customer *models.Customer) error {
+	return r.err
+}
+
+// Update always fails
+func (r *UnavailableCustomerRepository) Update(ctx context.Context, customer *models.Customer) error {
+	return r.err
+}
+
+// Delete always fails
+func (r *UnavailableCustomerRepository) Delete(ctx context.Context, customerID string) error {
+	return r.err
+}
+
+// Count always fails
+func (r *UnavailableCustomerRepository) Count(ctx context.Context, filters CustomerFilters) (int, error) {
+	return 0, r.err
+}
+
+// HealthCheck always fails
+func (r *UnavailableCustomerRepository) HealthCheck(ctx context.Context) error {
+	return r.err
+}
+
+// UnavailableCustomerHistoryRepository is the CustomerHistoryRepository
+// counterpart of UnavailableCustomerRepository
+type UnavailableCustomerHistoryRepository struct {
+	err error
+}
+
+// NewUnavailableCustomerHistoryRepository creates a repository that fails every call with cause
+func NewUnavailableCustomerHistoryRepository(cause error) *UnavailableCustomerHistoryRepository {
+	return &UnavailableCustomerHistoryRepository{err: fmt.Errorf("%w: %v", ErrRepositoryUnavailable, cause)}
+}
+
+// Append always fails
+func (r *UnavailableCustomerHistoryRepository) Append(ctx context.Context, changes ...*models.CustomerChange) error {
+	return r.err
+}
+
+// List always fails
+func (r *UnavailableCustomerHistoryRepository) List(ctx context.Context, customerID string) ([]*models.CustomerChange, error) {
+	return nil, r.err
+}
+
+// GetVersion always fails
+func (r *UnavailableCustomerHistoryRepository) GetVersion(ctx context.Context, customerID string, version int64) (*models.CustomerChange, error) {
+	return nil, r.err
+}
+
+// UnavailableOutboxRepository is the OutboxRepository counterpart of
+// UnavailableCustomerRepository
+type UnavailableOutboxRepository struct {
+	err error
+}
+
+// NewUnavailableOutboxRepository creates a repository that fails every call with cause
+func NewUnavailableOutboxRepository(cause error) *UnavailableOutboxRepository {
+	return &UnavailableOutboxRepository{err: fmt.Errorf("%w: %v", ErrRepositoryUnavailable, cause)}
+}
+
+// Append always fails
+func (r *UnavailableOutboxRepository) Append(ctx context.Context, messages ...*models.OutboxMessage) error {
+	return r.err
+}
+
+// Pending always fails
+func (r *UnavailableOutboxRepository) Pending(ctx context.Context, limit int) ([]*models.OutboxMessage, error) {
+	return nil, r.err
+}
+
+// MarkPublished always fails
+func (r *UnavailableOutboxRepository) MarkPublished(ctx context.Context, eventIDs []string, publishedAt time.Time) error {
+	return r.err
+}
+
+// RecordFailure always fails
+func (r *UnavailableOutboxRepository) RecordFailure(ctx context.Context, eventID string, cause string) error {
+	return r.err
+}
+
+// MarkDead always fails
+func (r *UnavailableOutboxRepository) MarkDead(ctx context.Context, eventID string, cause string, deadAt time.Time) error {
+	return r.err
+}
+
+// UnavailableWebhookRepository is the WebhookRepository counterpart of
+// UnavailableCustomerRepository
+type UnavailableWebhookRepository struct {
+	err error
+}
+
+// NewUnavailableWebhookRepository creates a repository that fails every call with cause
+func NewUnavailableWebhookRepository(cause error) *UnavailableWebhookRepository {
+	return &UnavailableWebhookRepository{err: fmt.Errorf("%w: %v", ErrRepositoryUnavailable, cause)}
+}
+
+// Create always fails
+func (r *UnavailableWebhookRepository) Create(ctx context.Context, webhook *models.WebhookSubscription) error {
+	return r.err
+}
+
+// GetByID always fails
+func (r *UnavailableWebhookRepository) GetByID(ctx context.Context, webhookID string) (*models.WebhookSubscription, error) {
+	return nil, r.err
+}
+
+// GetAll always fails
+func (r *UnavailableWebhookRepository) GetAll(ctx context.Context) ([]*models.WebhookSubscription, error) {
+	return nil, r.err
+}
+
+// Delete always fails
+func (r *UnavailableWebhookRepository) Delete(ctx context.Context, webhookID string) error {
+	return r.err
+}
+
+// UnavailableWebhookDeliveryRepository is the WebhookDeliveryRepository counterpart of
+// UnavailableCustomerRepository
+type UnavailableWebhookDeliveryRepository struct {
+	err error
+}
+
+// NewUnavailableWebhookDeliveryRepository creates a repository that fails every call with cause
+func NewUnavailableWebhookDeliveryRepository(cause error) *UnavailableWebhookDeliveryRepository {
+	return &UnavailableWebhookDeliveryRepository{err: fmt.Errorf("%w: %v", ErrRepositoryUnavailable, cause)}
+}
+
+// Append always fails
+func (r *UnavailableWebhookDeliveryRepository) Append(ctx context.Context, deliveries ...*models.WebhookDelivery) error {
+	return r.err
+}
+
+// ClaimDue always fails
+func (r *UnavailableWebhookDeliveryRepository) ClaimDue(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*models.WebhookDelivery, error) {
+	return nil, r.err
+}
+
+// GetByID always fails
+func (r *UnavailableWebhookDeliveryRepository) GetByID(ctx context.Context, deliveryID string) (*models.WebhookDelivery, error) {
+	return nil, r.err
+}
+
+// Update always fails
+func (r *UnavailableWebhookDeliveryRepository) Update(ctx context.Context, delivery *models.WebhookDelivery) error {
+	return r.err
+}
+
+// List always fails
+func (r *UnavailableWebhookDeliveryRepository) List(ctx context.Context, filter WebhookDeliveryFilter) ([]*models.WebhookDelivery, error) {
+	return nil, r.err
+}
@@ -1,387 +1,867 @@
-package services
-
-import (
-	"context"
-	"fmt"
-	"math/rand"
-	"time"
-	
-	"github.com/customer-api-v2/configs"
-	"github.com/customer-api-v2/internal/models"
-	"github.com/customer-api-v2/internal/repository"
-	"github.com/sirupsen/logrus"
-)
-
-// CustomerService handles business logic for customers
-type CustomerService struct {
-	repo   repository.CustomerRepository
-	config *configs.Config
-	logger *logrus.Logger
-	
-	// Metrics
-	startTime time.Time
-	requests  int64
-	errors    int64
-}
-
-// NewCustomerService creates a new customer service
-func NewCustomerService(repo repository.CustomerRepository, config *configs.Config, logger *logrus.Logger) *CustomerService {
-	return &CustomerService{
-		repo:      repo,
-		config:    config,
-		logger:    logger,
-		startTime: time.Now(),
-	}
-}
-
-// GetCustomer retrieves a customer by ID with business logic and error simulation
-func (s *CustomerService) GetCustomer(ctx context.Context, customerID string) (*models.Customer, error) {
-	s.requests++
-	
-	logger := s.logger.WithFields(logrus.Fields{
-		"operation":  "GetCustomer",
-		"customerId": customerID,
-		"requestId":  ctx.Value("requestId"),
-	})
-	
-	logger.Info("🔍 Starting customer lookup")
-	
-	// Simulate latency for testing if enabled
-	if s.config.Features.SimulateLatency {
-		delay := time.Duration(rand.Intn(s.config.Features.MaxLatencyMs)+50) * time.Millisecond
-		logger.WithField("latency", delay).Info("💤 Simulating latency")
-		time.Sleep(delay)
-	}
-	
-	// Simulate errors for testing if enabled
-	if s.config.Features.SimulateErrors && rand.Float64() < s.config.Features.ErrorRate {
-		s.errors++
-		logger.Error("💥 Simulating error for testing")
-		return nil, fmt.Errorf("simulated error for testing")
-	}
-	
-	// Special case to always return error (for testing)
-	if customerID == "customer-error" {
-		s.errors++
-		logger.WithField("reason", "test_customer").Error("💥 Test customer error")
-		return nil, fmt.Errorf("this customer always returns an error")
-	}
-	
-	// Get customer from repository
-	customer, err := s.repo.GetByID(ctx, customerID)
-	if err != nil {
-		if err == repository.ErrCustomerNotFound {
-			logger.WithField("reason", "not_found").Warn("⚠️ Customer not found")
-			return nil, fmt.Errorf("customer with ID %s not found", customerID)
-		}
-		s.errors++
-		logger.WithError(err).Error("💥 Repository error")
-		return nil, fmt.Errorf("failed to retrieve customer: %w", err)
-	}
-	
-	// Business logic: Check if customer is active
-	if !customer.Active {
-		logger.WithField("reason", "inactive").Warn("⚠️ Customer is inactive")
-		return nil, fmt.Errorf("customer %s is not active", customerID)
-	}
-	
-	logger.WithFields(logrus.Fields{
-		"name":  customer.Name,
-		"email": customer.Email,
-		"phone": customer.Phone,
-	}).Info("✅ Customer retrieved successfully")
-	
-	return customer, nil
-}
-
-// GetCustomers retrieves all customers with filtering and pagination
-func (s *CustomerService) GetCustomers(ctx context.Context, filters repository.CustomerFilters) (*models.CustomerResponse, error) {
-	s.requests++
-	
-	logger := s.logger.WithFields(logrus.Fields{
-		"operation": "GetCustomers",
-		"filters":   fmt.Sprintf("%+v", filters),
-		"requestId": ctx.Value("requestId"),
-	})
-	
-	logger.Info("📋 Getting customer list")
-	
-	// Get customers from repository
-	customers, err := s.repo.GetAll(ctx, filters)
-	if err != nil {
-		s.errors++
-		logger.WithError(err).Error("💥 Failed to get customers")
-		return nil, fmt.Errorf("failed to retrieve customers: %w", err)
-	}
-	
-	// Get total count for pagination
-	totalCount, err := s.repo.Count(ctx, repository.CustomerFilters{
-		Active: filters.Active,
-		Email:  filters.Email,
-	})
-	if err != nil {
-		logger.WithError(err).Warn("⚠️ Failed to get total count")
-		totalCount = len(customers) // Fallback to current page count
-	}
-	
-	// Convert to summary format and count active/inactive
-	summaries := make([]models.CustomerSummary, 0, len(customers))
-	activeCount := 0
-	inactiveCount := 0
-	
-	for _, customer := range customers {
-		// Include all customers in listings but don't include error customer
-		if customer.CustomerID != "customer-error" {
-			summaries = append(summaries, models.CustomerSummary{
-				CustomerID: customer.CustomerID,
-				Name:       customer.Name,
-				Active:     customer.Active,
-			})
-			
-			if customer.Active {
-				activeCount++
-			} else {
-				inactiveCount++
-			}
-		}
-	}
-	
-	response := &models.CustomerResponse{
-		Customers: summaries,
-		Total:     totalCount,
-		Active:    activeCount,
-		Inactive:  inactiveCount,
-	}
-	
-	// Include pagination info if applicable
-	if filters.PageSize > 0 {
-		response.Page = filters.Page
-		response.PageSize = filters.PageSize
-	}
-	
-	logger.WithFields(logrus.Fields{
-		"count":         len(summaries),
-		"total":         totalCount,
-		"active_count":  activeCount,
-		"inactive_count": inactiveCount,
-	}).Info("✅ Customer list retrieved successfully")
-	
-	return response, nil
-}
-
-// GetActiveCustomers retrieves only active customers
-func (s *CustomerService) GetActiveCustomers(ctx context.Context, filters repository.CustomerFilters) (*models.CustomerResponse, error) {
-	s.requests++
-	
-	logger := s.logger.WithFields(logrus.Fields{
-		"operation": "GetActiveCustomers",
-		"requestId": ctx.Value("requestId"),
-	})
-	
-	logger.Info("📋 Getting active customers only")
-	
-	// Force active filter
-	activeFilter := true
-	filters.Active = &activeFilter
-	
-	// Get customers from repository
-	customers, err := s.repo.GetAll(ctx, filters)
-	if err != nil {
-		s.errors++
-		logger.WithError(err).Error("💥 Failed to get active customers")
-		return nil, fmt.Errorf("failed to retrieve active customers: %w", err)
-	}
-	
-	// Convert to summary format
-	summaries := make([]models.CustomerSummary, 0, len(customers))
-	
-	for _, customer := range customers {
-		// Only include active customers and exclude error customer
-		if customer.Active && customer.CustomerID != "customer-error" {
-			summaries = append(summaries, models.CustomerSummary{
-				CustomerID: customer.CustomerID,
-				Name:       customer.Name,
-				Active:     customer.Active,
-			})
-		}
-	}
-	
-	response := &models.CustomerResponse{
-		Customers: summaries,
-		Total:     len(summaries),
-		Active:    len(summaries),
-		Inactive:  0,
-	}
-	
-	logger.WithFields(logrus.Fields{
-		"count": len(summaries),
-	}).Info("✅ Active customers retrieved successfully")
-	
-	return response, nil
-}
-
-// CreateCustomer adds a new customer with validation
-func (s *CustomerService) CreateCustomer(ctx context.Context, customer *models.Customer) error {
-	s.requests++
-	
-	logger := s.logger.WithFields(logrus.Fields{
-		"operation":  "CreateCustomer",
-		"customerId": customer.CustomerID,
-		"requestId":  ctx.Value("requestId"),
-	})
-	
-	logger.Info("➕ Creating new customer")
-	
-	// Business validation
-	if err := s.validateCustomer(customer); err != nil {
-		s.errors++
-		logger.WithError(err).Error("💥 Customer validation failed")
-		return fmt.Errorf("validation failed: %w", err)
-	}
-	
-	// Create in repository
-	if err := s.repo.Create(ctx, customer); err != nil {
-		if err == repository.ErrCustomerExists {
-			logger.WithField("reason", "already_exists").Warn("⚠️ Customer already exists")
-			return fmt.Errorf("customer %s already exists", customer.CustomerID)
-		}
-		s.errors++
-		logger.WithError(err).Error("💥 Failed to create customer")
-		return fmt.Errorf("failed to create customer: %w", err)
-	}
-	
-	logger.WithFields(logrus.Fields{
-		"name":  customer.Name,
-		"email": customer.Email,
-	}).Info("✅ Customer created successfully")
-	
-	return nil
-}
-
-// GetHealthStatus returns the service health status
-func (s *CustomerService) GetHealthStatus(ctx context.Context) (*models.HealthResponse, error) {
-	logger := s.logger.WithFields(logrus.Fields{
-		"operation": "HealthCheck",
-		"requestId": ctx.Value("requestId"),
-	})
-	
-	// Check repository health
-	dependencies := make(map[string]string)
-	if err := s.repo.HealthCheck(ctx); err != nil {
-		dependencies["repository"] = "unhealthy: " + err.Error()
-		logger.WithError(err).Error("💥 Repository health check failed")
-	} else {
-		dependencies["repository"] = "healthy"
-	}
-	
-	// Calculate metrics
-	uptime := time.Since(s.startTime)
-	
-	metrics := map[string]int{
-		"total_requests": int(s.requests),
-		"total_errors":   int(s.errors),
-		"customers_count": s.getCustomerCount(ctx),
-	}
-	
-	if s.requests > 0 {
-		metrics["error_rate_percent"] = int((s.errors * 100) / s.requests)
-	}
-	
-	status := "healthy"
-	if s.errors > 0 && s.requests > 0 && (s.errors*100/s.requests) > 10 {
-		status = "degraded"
-	}
-	
-	// Get customer counts for health response
-	totalCustomers := s.getCustomerCount(ctx)
-	activeCustomers := s.getActiveCustomerCount(ctx)
-	
-	response := &models.HealthResponse{
-		Status:          status,
-		Service:         "customer-api",
-		Version:         s.config.Server.Version,
-		Timestamp:       time.Now(),
-		Uptime:          uptime.String(),
-		Environment:     s.config.Server.Environment,
-		Metrics:         metrics,
-		Dependencies:    dependencies,
-		TotalCustomers:  totalCustomers,
-		ActiveCustomers: activeCustomers,
-	}
-	
-	logger.WithFields(logrus.Fields{
-		"status":           status,
-		"uptime":           uptime,
-		"requests":         s.requests,
-		"errors":           s.errors,
-		"total_customers":  totalCustomers,
-		"active_customers": activeCustomers,
-	}).Info("🏥 Health check completed")
-	
-	return response, nil
-}
-
-// validateCustomer performs business validation on customer data
-func (s *CustomerService) validateCustomer(customer *models.Customer) error {
-	if customer.CustomerID == "" {
-		return fmt.Errorf("customer ID is required")
-	}
-	
-	if customer.Name == "" {
-		return fmt.Errorf("customer name is required")
-	}
-	
-	if len(customer.Name) > 255 {
-		return fmt.Errorf("customer name cannot exceed 255 characters")
-	}
-	
-	// Optional email validation
-	if customer.Email != "" && len(customer.Email) > 320 {
-		return fmt.Errorf("customer email cannot exceed 320 characters")
-	}
-	
-	return nil
-}
-
-// getCustomerCount returns the total number of customers for metrics
-func (s *CustomerService) getCustomerCount(ctx context.Context) int {
-	count, err := s.repo.Count(ctx, repository.CustomerFilters{})
-	if err != nil {
-		s.logger.WithError(err).Warn("⚠️ Failed to get customer count for metrics")
-		return 0
-	}
-	return count
-}
-
-// getActiveCustomerCount returns the number of active customers
-func (s *CustomerService) getActiveCustomerCount(ctx context.Context) int {
-	activeFilter := true
-	count, err := s.repo.Count(ctx, repository.CustomerFilters{Active: &activeFilter})
-	if err != nil {
-		s.logger.WithError(err).Warn("⚠️ Failed to get active customer count for metrics")
-		return 0
-	}
-	return count
-}
-
-// GetMetrics returns service metrics
-func (s *CustomerService) GetMetrics() map[string]interface{} {
-	uptime := time.Since(s.startTime)
-	
-	metrics := map[string]interface{}{
-		"service":         "customer-api",
-		"version":         s.config.Server.Version,
-		"environment":     s.config.Server.Environment,
-		"uptime_seconds":  int(uptime.Seconds()),
-		"total_requests":  s.requests,
-		"total_errors":    s.errors,
-		"timestamp":       time.Now(),
-	}
-	
-	if s.requests > 0 {
-		metrics["error_rate"] = float64(s.errors) / float64(s.requests)
-		metrics["success_rate"] = 1.0 - (float64(s.errors) / float64(s.requests))
-	}
-	
-	return metrics
-}
\ No newline at end of file
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"strings"
+	"time"
+	
+	"github.com/customer-api-v2/configs"
+	"github.com/customer-api-v2/internal/metrics"
+	"github.com/customer-api-v2/internal/models"
+	"github.com/customer-api-v2/internal/repository"
+	"github.com/sirupsen/logrus"
+)
+
+// errorCustomerID is a test customer whose lookups always fail
+const errorCustomerID = "customer-error"
+
+// CustomerService handles business logic for customers
+type CustomerService struct {
+	repo       repository.CustomerRepository
+	history    repository.CustomerHistoryRepository // nil records no history
+	outbox     repository.OutboxRepository          // nil publishes no events
+	webhooks   *WebhookService                      // nil sends no webhooks
+	transactor repository.Transactor                // nil writes without transactions
+	config     *configs.Config
+	logger     *logrus.Logger
+	
+	// Metrics
+	startTime time.Time
+	stats     *operationStats
+	
+	readiness readiness
+}
+
+// NewCustomerService creates a new customer service. Writes are recorded in
+// history when it is not nil, a CustomerChanged event is queued in outbox
+// when that is not nil, and the subscribed webhooks are notified when
+// webhooks is not nil. The transactor commits a write together with its
+// history entry, event and webhook deliveries.
+func NewCustomerService(repo repository.CustomerRepository, history repository.CustomerHistoryRepository, outbox repository.OutboxRepository, webhooks *WebhookService, transactor repository.Transactor, config *configs.Config, logger *logrus.Logger) *CustomerService {
+	return &CustomerService{
+		repo:       repo,
+		history:    history,
+		outbox:     outbox,
+		webhooks:   webhooks,
+		transactor: transactor,
+		config:     config,
+		logger:     logger,
+		startTime:  time.Now(),
+		stats:      newOperationStats(),
+	}
+}
+
+// GetCustomer retrieves a customer by ID with business logic and error simulation
+func (s *CustomerService) GetCustomer(ctx context.Context, customerID string) (*models.Customer, error) {
+	s.stats.request("GetCustomer")
+	
+	logger := s.logger.WithFields(logrus.Fields{
+		"operation":  "GetCustomer",
+		"customerId": customerID,
+		"requestId":  ctx.Value("requestId"),
+	})
+	
+	logger.Info("🔍 Starting customer lookup")
+	
+	// Simulate latency for testing if enabled
+	if s.config.Features.SimulateLatency {
+		delay := time.Duration(rand.Intn(s.config.Features.MaxLatencyMs)+50) * time.Millisecond
+		logger.WithField("latency", delay).Info("💤 Simulating latency")
+		time.Sleep(delay)
+	}
+	
+	// Simulate errors for testing if enabled
+	if s.config.Features.SimulateErrors && rand.Float64() < s.config.Features.ErrorRate {
+		s.stats.failure("GetCustomer")
+		logger.Error("💥 Simulating error for testing")
+		return nil, newError(ErrInternal, "internal_error", nil, "simulated error for testing")
+	}
+	
+	// Special case to always return error (for testing)
+	if customerID == errorCustomerID {
+		s.stats.failure("GetCustomer")
+		logger.WithField("reason", "test_customer").Error("💥 Test customer error")
+		return nil, newError(ErrInternal, "internal_error", nil, "this customer always returns an error")
+	}
+	
+	// Get customer from repository
+	customer, err := s.repo.GetByID(ctx, customerID)
+	if err != nil {
+		if errors.Is(err, repository.ErrCustomerNotFound) {
+			logger.WithField("reason", "not_found").Warn("⚠️ Customer not found")
+			return nil, newError(ErrNotFound, "customer_not_found", nil, "customer with ID %s not found", customerID)
+		}
+		s.stats.failure("GetCustomer")
+		logger.WithError(err).Error("💥 Repository error")
+		return nil, repositoryError(err, "failed to retrieve customer")
+	}
+	
+	// Business logic: Check if customer is active
+	if !customer.Active {
+		logger.WithField("reason", "inactive").Warn("⚠️ Customer is inactive")
+		return nil, newError(ErrInactive, "customer_inactive", nil, "customer %s is not active", customerID)
+	}
+	
+	logger.WithFields(logrus.Fields{
+		"name":  customer.Name,
+		"email": customer.Email,
+		"phone": customer.Phone,
+	}).Info("✅ Customer retrieved successfully")
+	
+	return customer, nil
+}
+
+// GetCustomers retrieves all customers with filtering and pagination. Unless
+// filters ask for public visibility, inactive customers are listed too.
+func (s *CustomerService) GetCustomers(ctx context.Context, filters repository.CustomerFilters) (*models.CustomerResponse, error) {
+	if filters.Visibility == "" {
+		filters.Visibility = repository.VisibilityAdmin
+	}
+	return s.listCustomers(ctx, "GetCustomers", "customers", filters)
+}
+
+// GetActiveCustomers retrieves only active customers
+func (s *CustomerService) GetActiveCustomers(ctx context.Context, filters repository.CustomerFilters) (*models.CustomerResponse, error) {
+	// Force active filter
+	activeFilter := true
+	filters.Active = &activeFilter
+	filters.Visibility = repository.VisibilityPublic
+	
+	return s.listCustomers(ctx, "GetActiveCustomers", "active customers", filters)
+}
+
+// listCustomers serves a customer listing. The visibility rules are part of
+// the repository query, so the total, the page size and the active and
+// inactive counts all describe the same set of customers.
+func (s *CustomerService) listCustomers(ctx context.Context, operation, description string, filters repository.CustomerFilters) (*models.CustomerResponse, error) {
+	s.stats.request(operation)
+	
+	logger := s.logger.WithFields(logrus.Fields{
+		"operation": operation,
+		"filters":   fmt.Sprintf("%+v", filters),
+		"requestId": ctx.Value("requestId"),
+	})
+	
+	logger.Info("📋 Listing customers")
+	
+	// The test customer that always fails is never listed
+	filters.ExcludeIDs = append(filters.ExcludeIDs, errorCustomerID)
+	
+	order := customerOrder(filters)
+	paging := customerPagination(&filters)
+	
+	// Get customers from repository
+	query := filters
+	query.PageSize = paging.fetchSize()
+	customers, err := s.repo.GetAll(ctx, query)
+	if err != nil {
+		s.stats.failure(operation)
+		logger.WithError(err).Error("💥 Failed to get customers")
+		return nil, repositoryError(err, "failed to retrieve %s", description)
+	}
+	
+	// Get total count for pagination; Count ignores paging and sorting
+	totalCount, err := s.repo.Count(ctx, filters)
+	if err != nil {
+		logger.WithError(err).Warn("⚠️ Failed to get total count")
+		totalCount = len(customers) // Fallback to current page count
+	}
+	activeCount := s.countActive(ctx, logger, filters, totalCount)
+	
+	customers, hasPrev, hasNext := pageWindow(paging, customers, totalCount)
+	
+	// Convert to summary format
+	summaries := make([]models.CustomerSummary, 0, len(customers))
+	for _, customer := range customers {
+		summaries = append(summaries, models.CustomerSummary{
+			CustomerID: customer.CustomerID,
+			Name:       customer.Name,
+			Active:     customer.Active,
+		})
+	}
+	
+	response := &models.CustomerResponse{
+		Customers: summaries,
+		Total:     totalCount,
+		Active:    activeCount,
+		Inactive:  totalCount - activeCount,
+	}
+	
+	// Include pagination info if applicable
+	if filters.PageSize > 0 {
+		response.Page = filters.Page
+		response.PageSize = filters.PageSize
+	}
+	setCustomerCursors(response, customers, order, hasPrev, hasNext)
+	
+	logger.WithFields(logrus.Fields{
+		"count":          len(summaries),
+		"total":          totalCount,
+		"active_count":   response.Active,
+		"inactive_count": response.Inactive,
+	}).Info("✅ Customer list retrieved successfully")
+	
+	return response, nil
+}
+
+// countActive returns how many of the total customers matching filters are
+// active, querying only when the filters leave it open
+func (s *CustomerService) countActive(ctx context.Context, logger *logrus.Entry, filters repository.CustomerFilters, total int) int {
+	switch {
+	case filters.Visibility == repository.VisibilityPublic:
+		return total
+	case filters.Active != nil && *filters.Active:
+		return total
+	case filters.Active != nil:
+		return 0
+	}
+	
+	activeFilter := true
+	filters.Active = &activeFilter
+	count, err := s.repo.Count(ctx, filters)
+	if err != nil {
+		logger.WithError(err).Warn("⚠️ Failed to count active customers")
+		return 0
+	}
+	return min(count, total)
+}
+
+// customerOrder returns the sort a listing is served in
+func customerOrder(filters repository.CustomerFilters) []repository.SortField {
+	if len(filters.Sort) > 0 {
+		return filters.Sort
+	}
+	order, _ := repository.ParseCustomerSort("")
+	return order
+}
+
+// customerPagination defaults the page size of cursor requests and returns
+// the page filters ask for
+func customerPagination(filters *repository.CustomerFilters) pagination {
+	if filters.Cursor != nil && filters.PageSize == 0 {
+		filters.PageSize = defaultPageSize
+	}
+	return pagination{page: filters.Page, pageSize: filters.PageSize, cursor: filters.Cursor}
+}
+
+// setCustomerCursors sets the cursors of the pages before and after customers
+func setCustomerCursors(response *models.CustomerResponse, customers []*models.Customer, order []repository.SortField, hasPrev, hasNext bool) {
+	if len(customers) == 0 {
+		return
+	}
+	if hasNext {
+		response.NextCursor = repository.CustomerCursor(customers[len(customers)-1], order, false)
+	}
+	if hasPrev {
+		response.PrevCursor = repository.CustomerCursor(customers[0], order, true)
+	}
+}
+
+// CreateCustomer adds a new customer with validation
+func (s *CustomerService) CreateCustomer(ctx context.Context, customer *models.Customer) error {
+	s.stats.request("CreateCustomer")
+	
+	logger := s.logger.WithFields(logrus.Fields{
+		"operation":  "CreateCustomer",
+		"customerId": customer.CustomerID,
+		"requestId":  ctx.Value("requestId"),
+	})
+	
+	logger.Info("➕ Creating new customer")
+	
+	// Business validation
+	if err := s.validateCustomer(customer); err != nil {
+		s.stats.failure("CreateCustomer")
+		logger.WithError(err).Error("💥 Customer validation failed")
+		return validationError(err)
+	}
+	
+	// Create in repository
+	err := s.commit(ctx, logger, "CreateCustomer", func(ctx context.Context) ([]*models.CustomerChange, error) {
+		if err := s.repo.Create(ctx, customer); err != nil {
+			if errors.Is(err, repository.ErrCustomerExists) {
+				logger.WithField("reason", "already_exists").Warn("⚠️ Customer already exists")
+				return nil, newError(ErrConflict, "customer_exists", nil, "customer %s already exists", customer.CustomerID)
+			}
+			s.stats.failure("CreateCustomer")
+			logger.WithError(err).Error("💥 Failed to create customer")
+			return nil, repositoryError(err, "failed to create customer")
+		}
+		return []*models.CustomerChange{newCustomerChange(ctx, models.AuditCreate, nil, customer)}, nil
+	})
+	if err != nil {
+		return err
+	}
+	
+	logger.WithFields(logrus.Fields{
+		"name":  customer.Name,
+		"email": customer.Email,
+	}).Info("✅ Customer created successfully")
+	
+	return nil
+}
+
+// UpdateCustomer replaces a customer's profile. The active flag and its
+// status history are not part of the profile and can only be changed through
+// ActivateCustomer and DeactivateCustomer. If ifMatch is set the customer
+// must still be at that version.
+func (s *CustomerService) UpdateCustomer(ctx context.Context, customerID string, customer *models.Customer, ifMatch *int64) (*models.Customer, error) {
+	s.stats.request("UpdateCustomer")
+
+	logger := s.logger.WithFields(logrus.Fields{
+		"operation":  "UpdateCustomer",
+		"customerId": customerID,
+		"requestId":  ctx.Value("requestId"),
+	})
+
+	logger.Info("✏️ Updating customer")
+
+	if customer.CustomerID == "" {
+		customer.CustomerID = customerID
+	}
+
+	if customer.CustomerID != customerID {
+		s.stats.failure("UpdateCustomer")
+		logger.WithField("bodyCustomerId", customer.CustomerID).Error("💥 Customer ID mismatch")
+		return nil, validationError(fmt.Errorf("customer ID in body does not match %s", customerID))
+	}
+
+	if err := s.validateCustomer(customer); err != nil {
+		s.stats.failure("UpdateCustomer")
+		logger.WithError(err).Error("💥 Customer validation failed")
+		return nil, validationError(err)
+	}
+
+	existing, err := s.getExistingCustomer(ctx, logger, "UpdateCustomer", customerID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkVersion(ifMatch, existing.Version); err != nil {
+		logger.WithField("version", existing.Version).Warn("⚠️ Stale customer version")
+		return nil, err
+	}
+
+	customer.Active = existing.Active
+	customer.LastStatusChange = existing.LastStatusChange
+	customer.CreatedAt = existing.CreatedAt
+	if err := s.commitUpdate(ctx, logger, "UpdateCustomer", models.AuditUpdate, existing, customer, ifMatch); err != nil {
+		return nil, err
+	}
+
+	logger.WithField("name", customer.Name).Info("✅ Customer updated successfully")
+
+	return customer, nil
+}
+
+// PatchCustomer applies a JSON merge patch to a customer's profile. The
+// customer ID, version, timestamps and status fields are read-only.
+func (s *CustomerService) PatchCustomer(ctx context.Context, customerID string, patch map[string]interface{}, ifMatch *int64) (*models.Customer, error) {
+	s.stats.request("PatchCustomer")
+
+	logger := s.logger.WithFields(logrus.Fields{
+		"operation":  "PatchCustomer",
+		"customerId": customerID,
+		"requestId":  ctx.Value("requestId"),
+	})
+
+	logger.Info("🩹 Patching customer")
+
+	if id, ok := patch["customerId"]; ok && id != customerID {
+		s.stats.failure("PatchCustomer")
+		logger.Error("💥 Attempt to change customer ID")
+		return nil, validationError(fmt.Errorf("customer ID cannot be changed"))
+	}
+
+	for _, field := range []string{"active", "lastStatusChange"} {
+		if _, ok := patch[field]; ok {
+			s.stats.failure("PatchCustomer")
+			logger.WithField("field", field).Error("💥 Attempt to patch status field")
+			return nil, validationError(fmt.Errorf("%s can only be changed through activate/deactivate", field))
+		}
+	}
+	delete(patch, "version")
+	delete(patch, "createdAt")
+	delete(patch, "updatedAt")
+
+	existing, err := s.getExistingCustomer(ctx, logger, "PatchCustomer", customerID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkVersion(ifMatch, existing.Version); err != nil {
+		logger.WithField("version", existing.Version).Warn("⚠️ Stale customer version")
+		return nil, err
+	}
+
+	var customer models.Customer
+	if err := mergePatchInto(existing, patch, &customer); err != nil {
+		s.stats.failure("PatchCustomer")
+		logger.WithError(err).Error("💥 Invalid patch document")
+		return nil, validationError(fmt.Errorf("invalid patch document: %w", err))
+	}
+	customer.CustomerID = customerID
+	customer.CreatedAt = existing.CreatedAt
+
+	if err := s.validateCustomer(&customer); err != nil {
+		s.stats.failure("PatchCustomer")
+		logger.WithError(err).Error("💥 Customer validation failed")
+		return nil, validationError(err)
+	}
+
+	if err := s.commitUpdate(ctx, logger, "PatchCustomer", models.AuditUpdate, existing, &customer, ifMatch); err != nil {
+		return nil, err
+	}
+
+	logger.WithField("fields", len(patch)).Info("✅ Customer patched successfully")
+
+	return &customer, nil
+}
+
+// ReplaceAddress replaces a customer's address
+func (s *CustomerService) ReplaceAddress(ctx context.Context, customerID string, address models.Address, ifMatch *int64) (*models.Customer, error) {
+	return s.replaceProfileSection(ctx, customerID, "ReplaceAddress", ifMatch, func(customer *models.Customer) {
+		customer.Address = address
+	})
+}
+
+// ReplacePreferences replaces a customer's preferences
+func (s *CustomerService) ReplacePreferences(ctx context.Context, customerID string, preferences models.Preferences, ifMatch *int64) (*models.Customer, error) {
+	return s.replaceProfileSection(ctx, customerID, "ReplacePreferences", ifMatch, func(customer *models.Customer) {
+		customer.Preferences = preferences
+	})
+}
+
+// replaceProfileSection loads a customer, applies replace and stores the result
+func (s *CustomerService) replaceProfileSection(ctx context.Context, customerID, operation string, ifMatch *int64, replace func(*models.Customer)) (*models.Customer, error) {
+	s.stats.request(operation)
+
+	logger := s.logger.WithFields(logrus.Fields{
+		"operation":  operation,
+		"customerId": customerID,
+		"requestId":  ctx.Value("requestId"),
+	})
+
+	customer, err := s.getExistingCustomer(ctx, logger, operation, customerID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkVersion(ifMatch, customer.Version); err != nil {
+		logger.WithField("version", customer.Version).Warn("⚠️ Stale customer version")
+		return nil, err
+	}
+
+	before := *customer
+	replace(customer)
+	if err := s.validateCustomer(customer); err != nil {
+		s.stats.failure(operation)
+		logger.WithError(err).Error("💥 Customer validation failed")
+		return nil, validationError(err)
+	}
+
+	if err := s.commitUpdate(ctx, logger, operation, models.AuditUpdate, &before, customer, ifMatch); err != nil {
+		return nil, err
+	}
+
+	logger.Info("✅ Customer profile section replaced")
+
+	return customer, nil
+}
+
+// ActivateCustomer marks a customer as active, recording who did it and why
+func (s *CustomerService) ActivateCustomer(ctx context.Context, customerID, reason, actor string, ifMatch *int64) (*models.Customer, error) {
+	return s.changeStatus(ctx, customerID, true, reason, actor, ifMatch)
+}
+
+// DeactivateCustomer marks a customer as inactive, recording who did it and
+// why. Orders from inactive customers are rejected by the order worker.
+func (s *CustomerService) DeactivateCustomer(ctx context.Context, customerID, reason, actor string, ifMatch *int64) (*models.Customer, error) {
+	return s.changeStatus(ctx, customerID, false, reason, actor, ifMatch)
+}
+
+// changeStatus performs an audited activation or deactivation
+func (s *CustomerService) changeStatus(ctx context.Context, customerID string, active bool, reason, actor string, ifMatch *int64) (*models.Customer, error) {
+	s.stats.request("ChangeCustomerStatus")
+
+	logger := s.logger.WithFields(logrus.Fields{
+		"operation":  "ChangeCustomerStatus",
+		"customerId": customerID,
+		"active":     active,
+		"actor":      actor,
+		"requestId":  ctx.Value("requestId"),
+	})
+
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		s.stats.failure("ChangeCustomerStatus")
+		logger.Error("💥 Status change without reason")
+		return nil, validationError(fmt.Errorf("a reason is required to change customer status"))
+	}
+
+	customer, err := s.getExistingCustomer(ctx, logger, "ChangeCustomerStatus", customerID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkVersion(ifMatch, customer.Version); err != nil {
+		logger.WithField("version", customer.Version).Warn("⚠️ Stale customer version")
+		return nil, err
+	}
+
+	if customer.Active == active {
+		logger.Info("ℹ️ Customer already in requested state")
+		return customer, nil
+	}
+
+	before := *customer
+	customer.Active = active
+	customer.LastStatusChange = &models.StatusChange{
+		Active:    active,
+		Reason:    reason,
+		ChangedBy: actor,
+		ChangedAt: time.Now(),
+	}
+
+	if err := s.commitUpdate(ctx, logger, "ChangeCustomerStatus", models.AuditUpdate, &before, customer, ifMatch); err != nil {
+		return nil, err
+	}
+
+	logger.WithFields(logrus.Fields{
+		"event":  "customer_status_changed",
+		"reason": reason,
+	}).Info("✅ Customer status changed")
+
+	return customer, nil
+}
+
+// getExistingCustomer loads a customer regardless of its active flag, for
+// operations that manage the customer rather than serve orders
+func (s *CustomerService) getExistingCustomer(ctx context.Context, logger *logrus.Entry, operation, customerID string) (*models.Customer, error) {
+	customer, err := s.repo.GetByID(ctx, customerID)
+	if err != nil {
+		if errors.Is(err, repository.ErrCustomerNotFound) {
+			logger.WithField("reason", "not_found").Warn("⚠️ Customer not found")
+			return nil, newError(ErrNotFound, "customer_not_found", nil, "customer with ID %s not found", customerID)
+		}
+		s.stats.failure(operation)
+		logger.WithError(err).Error("💥 Repository error")
+		return nil, repositoryError(err, "failed to retrieve customer")
+	}
+	return customer, nil
+}
+
+// saveCustomer stores an updated customer and maps repository errors. The
+// customer must carry the version it was read at.
+func (s *CustomerService) saveCustomer(ctx context.Context, logger *logrus.Entry, operation string, customer *models.Customer, ifMatch *int64) error {
+	if err := s.repo.Update(ctx, customer); err != nil {
+		if errors.Is(err, repository.ErrCustomerNotFound) {
+			logger.WithField("reason", "not_found").Warn("⚠️ Customer not found")
+			return newError(ErrNotFound, "customer_not_found", nil, "customer with ID %s not found", customer.CustomerID)
+		}
+		if errors.Is(err, repository.ErrVersionConflict) {
+			logger.WithField("reason", "version_conflict").Warn("⚠️ Customer modified concurrently")
+			return versionConflictError(ifMatch)
+		}
+		s.stats.failure(operation)
+		logger.WithError(err).Error("💥 Failed to update customer")
+		return repositoryError(err, "failed to update customer")
+	}
+	return nil
+}
+
+// commitUpdate stores customer, which was read as before, and records the
+// change as action
+func (s *CustomerService) commitUpdate(ctx context.Context, logger *logrus.Entry, operation, action string, before, customer *models.Customer, ifMatch *int64) error {
+	return s.commit(ctx, logger, operation, func(ctx context.Context) ([]*models.CustomerChange, error) {
+		// A retried transaction starts again from the version that was read
+		customer.Version = before.Version
+		if err := s.saveCustomer(ctx, logger, operation, customer, ifMatch); err != nil {
+			return nil, err
+		}
+		return []*models.CustomerChange{newCustomerChange(ctx, action, before, customer)}, nil
+	})
+}
+
+// GetHealthStatus returns the service health status
+func (s *CustomerService) GetHealthStatus(ctx context.Context) (*models.HealthResponse, error) {
+	logger := s.logger.WithFields(logrus.Fields{
+		"operation": "HealthCheck",
+		"requestId": ctx.Value("requestId"),
+	})
+	
+	// Check repository health
+	repositoryHealth := checkDependency(ctx, s.config.Server.ProbeTimeout, s.repo.HealthCheck)
+	dependencies := map[string]models.DependencyHealth{"repository": repositoryHealth}
+	if repositoryHealth.Status != DependencyHealthy {
+		logger.WithField("error", repositoryHealth.Error).Error("💥 Repository health check failed")
+	}
+	
+	// Counts are bounded like the dependency check so that a slow database
+	// cannot hang the probe
+	countCtx, cancel := probeContext(ctx, s.config.Server.ProbeTimeout)
+	defer cancel()
+	
+	// Calculate metrics
+	uptime := time.Since(s.startTime)
+	
+	totals := s.stats.totals()
+	recent := s.stats.recent()
+	
+	metrics := map[string]int{
+		"total_requests":            int(totals.Requests),
+		"total_errors":              int(totals.Errors),
+		"recent_requests":           int(recent.Requests),
+		"recent_errors":             int(recent.Errors),
+		"recent_error_rate_percent": int(recent.ErrorRate() * 100),
+		"customers_count":           s.getCustomerCount(countCtx),
+	}
+	
+	if totals.Requests > 0 {
+		metrics["error_rate_percent"] = int(totals.ErrorRate() * 100)
+	}
+	
+	if stats, ok := s.cacheStats(); ok {
+		metrics["cache_hits"] = int(stats.Hits)
+		metrics["cache_misses"] = int(stats.Misses)
+		metrics["cache_size"] = stats.Size
+		metrics["cache_hit_rate_percent"] = int(stats.HitRate * 100)
+		dependencies["cache"] = models.DependencyHealth{Status: DependencyEnabled}
+	}
+	
+	// Degradation reflects the recent error rate, not lifetime totals, so a
+	// service recovers once the errors stop
+	status := "healthy"
+	if repositoryHealth.Status != DependencyHealthy {
+		status = "unhealthy"
+	} else if s.stats.degraded() {
+		status = "degraded"
+	}
+	
+	_, ready := s.readiness.checks()
+	
+	// Get customer counts for health response
+	totalCustomers := s.getCustomerCount(countCtx)
+	activeCustomers := s.getActiveCustomerCount(countCtx)
+	
+	response := &models.HealthResponse{
+		Status:          status,
+		Service:         "customer-api",
+		Version:         s.config.Server.Version,
+		Timestamp:       time.Now(),
+		Uptime:          uptime.String(),
+		Environment:     s.config.Server.Environment,
+		Ready:           ready && repositoryHealth.Status == DependencyHealthy,
+		Metrics:         metrics,
+		Dependencies:    dependencies,
+		TotalCustomers:  totalCustomers,
+		ActiveCustomers: activeCustomers,
+	}
+	
+	logger.WithFields(logrus.Fields{
+		"status":           status,
+		"uptime":           uptime,
+		"requests":         totals.Requests,
+		"errors":           totals.Errors,
+		"recent":           recent,
+		"total_customers":  totalCustomers,
+		"active_customers": activeCustomers,
+	}).Info("🏥 Health check completed")
+	
+	return response, nil
+}
+
+// MarkStarted records that startup has finished so readiness can pass
+func (s *CustomerService) MarkStarted() {
+	s.readiness.started.Store(true)
+}
+
+// MarkDraining fails readiness from now on so load balancers stop sending
+// traffic before the server shuts down
+func (s *CustomerService) MarkDraining() {
+	s.readiness.draining.Store(true)
+}
+
+// Liveness reports that the process is running. It performs no I/O so a slow
+// database never gets a healthy process restarted.
+func (s *CustomerService) Liveness() *models.ProbeResponse {
+	return &models.ProbeResponse{
+		Status:    ProbeStatusOK,
+		Timestamp: time.Now(),
+	}
+}
+
+// Readiness reports whether the service should receive traffic: startup has
+// finished, shutdown has not begun and the repository answers within the
+// probe timeout
+func (s *CustomerService) Readiness(ctx context.Context) (*models.ProbeResponse, bool) {
+	checks, ready := s.readiness.checks()
+	
+	repositoryHealth := checkDependency(ctx, s.config.Server.ProbeTimeout, s.repo.HealthCheck)
+	checks["repository"] = repositoryHealth.Status
+	if repositoryHealth.Status != DependencyHealthy {
+		ready = false
+		s.logger.WithFields(logrus.Fields{
+			"operation": "Readiness",
+			"requestId": ctx.Value("requestId"),
+			"error":     repositoryHealth.Error,
+		}).Warn("⚠️ Readiness check failed")
+	}
+	
+	status := ProbeStatusOK
+	if !ready {
+		status = ProbeStatusNotReady
+	}
+	
+	return &models.ProbeResponse{
+		Status:    status,
+		Checks:    checks,
+		Timestamp: time.Now(),
+	}, ready
+}
+
+// validateCustomer performs business validation on customer data
+func (s *CustomerService) validateCustomer(customer *models.Customer) error {
+	return ValidateCustomer(customer)
+}
+
+// ValidateCustomer applies the business rules every stored customer must
+// meet. It is exported so fixture loading enforces the same rules as the API.
+func ValidateCustomer(customer *models.Customer) error {
+	if customer.CustomerID == "" {
+		return fmt.Errorf("customer ID is required")
+	}
+	
+	if customer.Name == "" {
+		return fmt.Errorf("customer name is required")
+	}
+	
+	if len(customer.Name) > 255 {
+		return fmt.Errorf("customer name cannot exceed 255 characters")
+	}
+	
+	// Optional email validation
+	if customer.Email != "" && len(customer.Email) > 320 {
+		return fmt.Errorf("customer email cannot exceed 320 characters")
+	}
+	
+	return nil
+}
+
+// getCustomerCount returns the total number of customers for metrics
+func (s *CustomerService) getCustomerCount(ctx context.Context) int {
+	count, err := s.repo.Count(ctx, repository.CustomerFilters{})
+	if err != nil {
+		s.logger.WithError(err).Warn("⚠️ Failed to get customer count for metrics")
+		return 0
+	}
+	return count
+}
+
+// getActiveCustomerCount returns the number of active customers
+func (s *CustomerService) getActiveCustomerCount(ctx context.Context) int {
+	activeFilter := true
+	count, err := s.repo.Count(ctx, repository.CustomerFilters{Active: &activeFilter})
+	if err != nil {
+		s.logger.WithError(err).Warn("⚠️ Failed to get active customer count for metrics")
+		return 0
+	}
+	return count
+}
+
+// GetMetrics returns service metrics
+func (s *CustomerService) GetMetrics() map[string]interface{} {
+	uptime := time.Since(s.startTime)
+	totals := s.stats.totals()
+	recent := s.stats.recent()
+	
+	metrics := map[string]interface{}{
+		"service":               "customer-api",
+		"version":               s.config.Server.Version,
+		"environment":           s.config.Server.Environment,
+		"uptime_seconds":        int(uptime.Seconds()),
+		"total_requests":        totals.Requests,
+		"total_errors":          totals.Errors,
+		"operations":            s.stats.byOperation(),
+		"recent":                recent,
+		"recent_window_seconds": int((errorWindowBucket * errorWindowBuckets).Seconds()),
+		"timestamp":             time.Now(),
+	}
+	
+	if totals.Requests > 0 {
+		metrics["error_rate"] = totals.ErrorRate()
+		metrics["success_rate"] = 1.0 - totals.ErrorRate()
+	}
+	
+	if recent.Requests > 0 {
+		metrics["recent_error_rate"] = recent.ErrorRate()
+	}
+	
+	if stats, ok := s.cacheStats(); ok {
+		metrics["cache"] = stats
+	}
+	
+	return metrics
+}
+
+// cacheStats returns the repository cache statistics when caching is enabled
+func (s *CustomerService) cacheStats() (repository.CacheStats, bool) {
+	provider, ok := s.repo.(repository.CacheStatsProvider)
+	if !ok {
+		return repository.CacheStats{}, false
+	}
+	return provider.CacheStats(), true
+}
+
+// metricsScrapeTimeout bounds repository queries made while serving /metrics
+const metricsScrapeTimeout = 2 * time.Second
+
+// RegisterMetrics publishes the customer gauges and cache counters on
+// registry. Gauges are computed at scrape time.
+func (s *CustomerService) RegisterMetrics(registry *metrics.Registry) {
+	registry.NewGaugeFunc("customers_total", "Number of registered customers.", func() float64 {
+		return s.countCustomers(repository.CustomerFilters{})
+	})
+
+	active := true
+	registry.NewGaugeFunc("customers_active", "Number of active customers.", func() float64 {
+		return s.countCustomers(repository.CustomerFilters{Active: &active})
+	})
+
+	if _, ok := s.cacheStats(); ok {
+		registry.NewCounterFunc("customer_cache_hits_total", "Customer cache hits.", func() float64 {
+			stats, _ := s.cacheStats()
+			return float64(stats.Hits)
+		})
+		registry.NewCounterFunc("customer_cache_misses_total", "Customer cache misses.", func() float64 {
+			stats, _ := s.cacheStats()
+			return float64(stats.Misses)
+		})
+		registry.NewCounterFunc("customer_cache_evictions_total", "Customer cache evictions.", func() float64 {
+			stats, _ := s.cacheStats()
+			return float64(stats.Evictions)
+		})
+		registry.NewGaugeFunc("customer_cache_entries", "Customers currently cached.", func() float64 {
+			stats, _ := s.cacheStats()
+			return float64(stats.Size)
+		})
+	}
+}
+
+// countCustomers counts customers for a metrics scrape with a bounded
+// timeout. Failures yield NaN so a broken count is not mistaken for zero.
+func (s *CustomerService) countCustomers(filters repository.CustomerFilters) float64 {
+	ctx, cancel := context.WithTimeout(context.Background(), metricsScrapeTimeout)
+	defer cancel()
+
+	count, err := s.repo.Count(ctx, filters)
+	if err != nil {
+		s.logger.WithError(err).Warn("⚠️ Failed to count customers for metrics")
+		return math.NaN()
+	}
+	return float64(count)
+}
@@ -3,10 +3,16 @@ package services
 import (
 	"context"
 	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/customer-api-v2/configs"
+	"github.com/customer-api-v2/internal/metrics"
 	"github.com/customer-api-v2/internal/models"
 	"github.com/customer-api-v2/internal/repository"
 	"github.com/sirupsen/logrus"
@@ -78,7 +84,7 @@ func createTestCustomerService(repo repository.CustomerRepository) *CustomerServ
 	logger := logrus.New()
 	logger.SetLevel(logrus.ErrorLevel) // Reduce log noise in tests
 	
-	return NewCustomerService(repo, config, logger)
+	return NewCustomerService(repo, nil, nil, nil, nil, config, logger)
 }
 
 // Helper function to create a test customer
@@ -128,7 +134,7 @@ func TestCustomerService_GetCustomer_Success(t *testing.T) {
 	
 	assert.NoError(t, err)
 	assert.Equal(t, expectedCustomer, customer)
-	assert.Equal(t, int64(1), service.requests)
+	assert.Equal(t, int64(1), service.stats.totals().Requests)
 	mockRepo.AssertExpectations(t)
 }
 
@@ -144,7 +150,7 @@ func TestCustomerService_GetCustomer_NotFound(t *testing.T) {
 	assert.Error(t, err)
 	assert.Nil(t, customer)
 	assert.Contains(t, err.Error(), "not found")
-	assert.Equal(t, int64(1), service.requests)
+	assert.Equal(t, int64(1), service.stats.totals().Requests)
 	mockRepo.AssertExpectations(t)
 }
 
@@ -178,7 +184,7 @@ func TestCustomerService_GetCustomer_ErrorCase(t *testing.T) {
 	assert.Error(t, err)
 	assert.Nil(t, customer)
 	assert.Contains(t, err.Error(), "this customer always returns an error")
-	assert.Equal(t, int64(1), service.errors)
+	assert.Equal(t, int64(1), service.stats.totals().Errors)
 	// No need to assert expectations since no repository call is made
 }
 
@@ -193,7 +199,56 @@ func TestCustomerService_GetCustomer_TestErrorCustomer(t *testing.T) {
 	assert.Error(t, err)
 	assert.Nil(t, customer)
 	assert.Contains(t, err.Error(), "always returns an error")
-	assert.Equal(t, int64(1), service.errors)
+	assert.Equal(t, int64(1), service.stats.totals().Errors)
+}
+
+func TestCustomerService_GetCustomer_ErrorKinds(t *testing.T) {
+	ctx := context.Background()
+	inactiveCustomer := createTestCustomer()
+	inactiveCustomer.Active = false
+
+	tests := []struct {
+		name     string
+		customer *models.Customer
+		repoErr  error
+		kind     error
+		code     string
+	}{
+		{"not found", nil, repository.ErrCustomerNotFound, ErrNotFound, "customer_not_found"},
+		{"inactive", inactiveCustomer, nil, ErrInactive, "customer_inactive"},
+		{"unavailable", nil, repository.ErrRepositoryUnavailable, ErrUnavailable, "service_unavailable"},
+		{"internal", nil, errors.New("boom"), ErrInternal, "internal_error"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := &MockCustomerRepository{}
+			service := createTestCustomerService(mockRepo)
+			mockRepo.On("GetByID", ctx, "test-customer-1").Return(tt.customer, tt.repoErr)
+
+			_, err := service.GetCustomer(ctx, "test-customer-1")
+
+			var serviceErr *Error
+			assert.ErrorIs(t, err, tt.kind)
+			assert.True(t, errors.As(err, &serviceErr))
+			assert.Equal(t, tt.code, serviceErr.Code)
+		})
+	}
+}
+
+func TestCustomerService_CreateCustomer_ErrorKinds(t *testing.T) {
+	mockRepo := &MockCustomerRepository{}
+	service := createTestCustomerService(mockRepo)
+	ctx := context.Background()
+
+	err := service.CreateCustomer(ctx, &models.Customer{})
+	assert.ErrorIs(t, err, ErrValidation)
+
+	customer := createTestCustomer()
+	mockRepo.On("Create", ctx, customer).Return(repository.ErrCustomerExists)
+
+	err = service.CreateCustomer(ctx, customer)
+	assert.ErrorIs(t, err, ErrConflict)
 }
 
 func TestCustomerService_GetCustomers_Success(t *testing.T) {
@@ -204,7 +259,11 @@ func TestCustomerService_GetCustomers_Success(t *testing.T) {
 	customers := []*models.Customer{createTestCustomer()}
 	filters := repository.CustomerFilters{Email: "john.doe@example.com"}
 	
-	mockRepo.On("GetAll", ctx, filters).Return(customers, nil)
+	// Listings include inactive customers and never the error test customer
+	query := filters
+	query.Visibility = repository.VisibilityAdmin
+	query.ExcludeIDs = []string{"customer-error"}
+	mockRepo.On("GetAll", ctx, query).Return(customers, nil)
 	mockRepo.On("Count", ctx, mock.AnythingOfType("repository.CustomerFilters")).Return(1, nil)
 	
 	response, err := service.GetCustomers(ctx, filters)
@@ -223,14 +282,16 @@ func TestCustomerService_GetCustomers_Error(t *testing.T) {
 	ctx := context.Background()
 	
 	filters := repository.CustomerFilters{Email: "test@example.com"}
-	mockRepo.On("GetAll", ctx, filters).Return(nil, errors.New("database error"))
+	mockRepo.On("GetAll", ctx, mock.MatchedBy(func(f repository.CustomerFilters) bool {
+		return f.Email == filters.Email
+	})).Return(nil, errors.New("database error"))
 	
 	response, err := service.GetCustomers(ctx, filters)
 	
 	assert.Error(t, err)
 	assert.Nil(t, response)
 	assert.Contains(t, err.Error(), "failed to retrieve customers")
-	assert.Equal(t, int64(1), service.errors)
+	assert.Equal(t, int64(1), service.stats.totals().Errors)
 	mockRepo.AssertExpectations(t)
 }
 
@@ -242,15 +303,17 @@ func TestCustomerService_GetActiveCustomers_Success(t *testing.T) {
 	activeCustomer := createTestCustomer()
 	customers := []*models.Customer{activeCustomer}
 	
-	// GetActiveCustomers only calls GetAll, not Count (it calculates total from results)
 	activeFilter := true
 	expectedFilters := repository.CustomerFilters{Active: &activeFilter}
 	
 	mockRepo.On("GetAll", ctx, mock.MatchedBy(func(f repository.CustomerFilters) bool {
-		return f.Active != nil && *f.Active == true
+		return f.Active != nil && *f.Active == true && f.Visibility == repository.VisibilityPublic
 	})).Return(customers, nil)
 	
-	// No Count call needed - GetActiveCustomers calculates total from len(summaries)
+	// Total comes from Count, not from the length of the page
+	mockRepo.On("Count", ctx, mock.MatchedBy(func(f repository.CustomerFilters) bool {
+		return f.Visibility == repository.VisibilityPublic
+	})).Return(1, nil).Once()
 	
 	response, err := service.GetActiveCustomers(ctx, expectedFilters)
 	
@@ -262,6 +325,100 @@ func TestCustomerService_GetActiveCustomers_Success(t *testing.T) {
 	mockRepo.AssertExpectations(t)
 }
 
+func TestCustomerService_GetCustomers_CountsMatchListing(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewMemoryCustomerRepository()
+	for i, id := range []string{"customer-1", "customer-2", "customer-3", "customer-error", "customer-4", "customer-5"} {
+		assert.NoError(t, repo.Create(ctx, &models.Customer{
+			CustomerID: id,
+			Name:       id,
+			Email:      id + "@example.com",
+			Active:     i != 1 && i != 4,
+		}))
+	}
+	service := createTestCustomerService(repo)
+	
+	response, err := service.GetCustomers(ctx, repository.CustomerFilters{PageSize: 3})
+	assert.NoError(t, err)
+	assert.Equal(t, 5, response.Total)
+	assert.Equal(t, 3, response.Active)
+	assert.Equal(t, 2, response.Inactive)
+	assert.Len(t, response.Customers, 3)
+	
+	response, err = service.GetCustomers(ctx, repository.CustomerFilters{PageSize: 3, Page: 1})
+	assert.NoError(t, err)
+	assert.Len(t, response.Customers, 2)
+	for _, customer := range response.Customers {
+		assert.NotEqual(t, "customer-error", customer.CustomerID)
+	}
+	
+	response, err = service.GetCustomers(ctx, repository.CustomerFilters{Visibility: repository.VisibilityPublic})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, response.Total)
+	assert.Len(t, response.Customers, 3)
+	
+	// Total covers every page, not just the one returned
+	response, err = service.GetActiveCustomers(ctx, repository.CustomerFilters{PageSize: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, response.Total)
+	assert.Len(t, response.Customers, 2)
+}
+
+func TestCustomerService_GetActiveCustomers_CursorPagination(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewMemoryCustomerRepository()
+	names := []string{"Carol", "Alice", "Bob", "Alice", "Dave", "Erin", "Bob"}
+	for i, name := range names {
+		assert.NoError(t, repo.Create(ctx, &models.Customer{
+			CustomerID: fmt.Sprintf("customer-%d", i+1),
+			Name:       name,
+			Email:      fmt.Sprintf("customer-%d@example.com", i+1),
+			Active:     i != 4,
+		}))
+	}
+	service := createTestCustomerService(repo)
+	
+	sort, err := repository.ParseCustomerSort("-name")
+	assert.NoError(t, err)
+	
+	ids := func(response *models.CustomerResponse) []string {
+		var result []string
+		for _, customer := range response.Customers {
+			result = append(result, customer.CustomerID)
+		}
+		return result
+	}
+	page := func(token string) *models.CustomerResponse {
+		filters := repository.CustomerFilters{Sort: sort, PageSize: 2}
+		if token != "" {
+			cursor, err := repository.DecodeCustomerCursor(token, sort)
+			assert.NoError(t, err)
+			filters.Cursor = cursor
+		}
+		response, err := service.GetActiveCustomers(ctx, filters)
+		assert.NoError(t, err)
+		return response
+	}
+	
+	first := page("")
+	assert.Equal(t, []string{"customer-6", "customer-1"}, ids(first))
+	assert.Empty(t, first.PrevCursor)
+	
+	second := page(first.NextCursor)
+	assert.Equal(t, []string{"customer-3", "customer-7"}, ids(second))
+	
+	last := page(second.NextCursor)
+	assert.Equal(t, []string{"customer-2", "customer-4"}, ids(last))
+	assert.Empty(t, last.NextCursor)
+	
+	// Walking back returns the same pages
+	assert.Equal(t, ids(second), ids(page(last.PrevCursor)))
+	assert.Equal(t, ids(first), ids(page(second.PrevCursor)))
+	
+	_, err = repository.DecodeCustomerCursor(first.NextCursor, nil)
+	assert.ErrorIs(t, err, repository.ErrInvalidCursor)
+}
+
 func TestCustomerService_CreateCustomer_Success(t *testing.T) {
 	mockRepo := &MockCustomerRepository{}
 	service := createTestCustomerService(mockRepo)
@@ -273,7 +430,7 @@ func TestCustomerService_CreateCustomer_Success(t *testing.T) {
 	err := service.CreateCustomer(ctx, customer)
 	
 	assert.NoError(t, err)
-	assert.Equal(t, int64(1), service.requests)
+	assert.Equal(t, int64(1), service.stats.totals().Requests)
 	mockRepo.AssertExpectations(t)
 }
 
@@ -289,7 +446,7 @@ func TestCustomerService_CreateCustomer_ValidationError(t *testing.T) {
 	
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "validation failed")
-	assert.Equal(t, int64(1), service.errors)
+	assert.Equal(t, int64(1), service.stats.totals().Errors)
 }
 
 func TestCustomerService_CreateCustomer_AlreadyExists(t *testing.T) {
@@ -307,6 +464,202 @@ func TestCustomerService_CreateCustomer_AlreadyExists(t *testing.T) {
 	mockRepo.AssertExpectations(t)
 }
 
+func TestCustomerService_UpdateCustomer_PreservesStatus(t *testing.T) {
+	mockRepo := &MockCustomerRepository{}
+	service := createTestCustomerService(mockRepo)
+	ctx := context.Background()
+
+	existing := createTestCustomer()
+	existing.Active = false
+	update := createTestCustomer()
+	update.Name = "Jane Doe"
+	update.Active = true
+
+	mockRepo.On("GetByID", ctx, "test-customer-1").Return(existing, nil)
+	mockRepo.On("Update", ctx, mock.AnythingOfType("*models.Customer")).Return(nil)
+
+	updated, err := service.UpdateCustomer(ctx, "test-customer-1", update, nil)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "Jane Doe", updated.Name)
+	assert.False(t, updated.Active)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestCustomerService_UpdateCustomer_StaleIfMatch(t *testing.T) {
+	mockRepo := &MockCustomerRepository{}
+	service := createTestCustomerService(mockRepo)
+	ctx := context.Background()
+
+	existing := createTestCustomer()
+	existing.Version = 4
+	mockRepo.On("GetByID", ctx, "test-customer-1").Return(existing, nil)
+
+	staleVersion := int64(3)
+	_, err := service.UpdateCustomer(ctx, "test-customer-1", createTestCustomer(), &staleVersion)
+
+	assert.ErrorIs(t, err, ErrPreconditionFailed)
+	mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+}
+
+func TestMemoryCustomerRepository_UpdateChecksVersion(t *testing.T) {
+	repo := repository.NewMemoryCustomerRepository()
+	ctx := context.Background()
+
+	customer := createTestCustomer()
+	customer.CustomerID = "versioned-customer"
+	assert.NoError(t, repo.Create(ctx, customer))
+	assert.Equal(t, int64(1), customer.Version)
+
+	first := *customer
+	second := *customer
+
+	assert.NoError(t, repo.Update(ctx, &first))
+	assert.Equal(t, int64(2), first.Version)
+	assert.ErrorIs(t, repo.Update(ctx, &second), repository.ErrVersionConflict)
+}
+
+func TestCachedCustomerRepository_HitsAndInvalidation(t *testing.T) {
+	mockRepo := &MockCustomerRepository{}
+	cached := repository.NewCachedCustomerRepository(mockRepo, time.Minute, 10)
+	ctx := context.Background()
+
+	mockRepo.On("GetByID", mock.Anything, "test-customer-1").Return(createTestCustomer(), nil)
+	mockRepo.On("Update", ctx, mock.AnythingOfType("*models.Customer")).Return(nil)
+
+	first, err := cached.GetByID(ctx, "test-customer-1")
+	assert.NoError(t, err)
+	first.Name = "Mutated by caller"
+
+	second, err := cached.GetByID(ctx, "test-customer-1")
+	assert.NoError(t, err)
+	assert.Equal(t, "John Doe", second.Name)
+	mockRepo.AssertNumberOfCalls(t, "GetByID", 1)
+
+	assert.NoError(t, cached.Update(ctx, second))
+	_, err = cached.GetByID(ctx, "test-customer-1")
+	assert.NoError(t, err)
+	mockRepo.AssertNumberOfCalls(t, "GetByID", 2)
+
+	stats := cached.CacheStats()
+	assert.Equal(t, int64(1), stats.Hits)
+	assert.Equal(t, int64(2), stats.Misses)
+}
+
+func TestCachedCustomerRepository_CoalescesConcurrentMisses(t *testing.T) {
+	mockRepo := &MockCustomerRepository{}
+	cached := repository.NewCachedCustomerRepository(mockRepo, time.Minute, 10)
+	ctx := context.Background()
+
+	mockRepo.On("GetByID", mock.Anything, "test-customer-1").After(50*time.Millisecond).Return(createTestCustomer(), nil)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := cached.GetByID(ctx, "test-customer-1")
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	mockRepo.AssertNumberOfCalls(t, "GetByID", 1)
+}
+
+func TestCachedCustomerRepository_EvictsLeastRecentlyUsed(t *testing.T) {
+	mockRepo := &MockCustomerRepository{}
+	cached := repository.NewCachedCustomerRepository(mockRepo, time.Minute, 1)
+	ctx := context.Background()
+
+	for _, customerID := range []string{"customer-a", "customer-b"} {
+		customer := createTestCustomer()
+		customer.CustomerID = customerID
+		mockRepo.On("GetByID", mock.Anything, customerID).Return(customer, nil)
+
+		_, err := cached.GetByID(ctx, customerID)
+		assert.NoError(t, err)
+	}
+
+	_, err := cached.GetByID(ctx, "customer-a")
+	assert.NoError(t, err)
+
+	stats := cached.CacheStats()
+	assert.Equal(t, 1, stats.Size)
+	assert.Equal(t, int64(2), stats.Evictions)
+	mockRepo.AssertNumberOfCalls(t, "GetByID", 3)
+}
+
+func TestCustomerService_RegisterMetrics(t *testing.T) {
+	mockRepo := &MockCustomerRepository{}
+	service := createTestCustomerService(mockRepo)
+	registry := metrics.NewRegistry()
+
+	mockRepo.On("Count", mock.Anything, repository.CustomerFilters{}).Return(12, nil)
+	mockRepo.On("Count", mock.Anything, mock.MatchedBy(func(f repository.CustomerFilters) bool { return f.Active != nil })).Return(0, errors.New("database error"))
+
+	service.RegisterMetrics(registry)
+
+	var out strings.Builder
+	assert.NoError(t, registry.WriteText(&out))
+	assert.Contains(t, out.String(), "# TYPE customers_total gauge\ncustomers_total 12\n")
+	assert.Contains(t, out.String(), "customers_active NaN\n")
+}
+
+func TestCustomerService_PatchCustomer_NestedAddress(t *testing.T) {
+	mockRepo := &MockCustomerRepository{}
+	service := createTestCustomerService(mockRepo)
+	ctx := context.Background()
+
+	mockRepo.On("GetByID", ctx, "test-customer-1").Return(createTestCustomer(), nil)
+	mockRepo.On("Update", ctx, mock.AnythingOfType("*models.Customer")).Return(nil)
+
+	patched, err := service.PatchCustomer(ctx, "test-customer-1", map[string]interface{}{
+		"address": map[string]interface{}{"city": "Madrid"},
+	}, nil)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "Madrid", patched.Address.City)
+	assert.Equal(t, "123 Main St", patched.Address.Street)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestCustomerService_PatchCustomer_RejectsStatusFields(t *testing.T) {
+	service := createTestCustomerService(&MockCustomerRepository{})
+
+	_, err := service.PatchCustomer(context.Background(), "test-customer-1", map[string]interface{}{"active": false}, nil)
+
+	assert.ErrorIs(t, err, ErrValidation)
+}
+
+func TestCustomerService_DeactivateCustomer_RecordsReason(t *testing.T) {
+	mockRepo := &MockCustomerRepository{}
+	service := createTestCustomerService(mockRepo)
+	ctx := context.Background()
+
+	mockRepo.On("GetByID", ctx, "test-customer-1").Return(createTestCustomer(), nil)
+	mockRepo.On("Update", ctx, mock.AnythingOfType("*models.Customer")).Return(nil)
+
+	customer, err := service.DeactivateCustomer(ctx, "test-customer-1", "chargeback fraud", "support-agent", nil)
+
+	assert.NoError(t, err)
+	assert.False(t, customer.Active)
+	assert.NotNil(t, customer.LastStatusChange)
+	assert.Equal(t, "chargeback fraud", customer.LastStatusChange.Reason)
+	assert.Equal(t, "support-agent", customer.LastStatusChange.ChangedBy)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestCustomerService_DeactivateCustomer_RequiresReason(t *testing.T) {
+	mockRepo := &MockCustomerRepository{}
+	service := createTestCustomerService(mockRepo)
+
+	_, err := service.DeactivateCustomer(context.Background(), "test-customer-1", "  ", "support-agent", nil)
+
+	assert.ErrorIs(t, err, ErrValidation)
+	mockRepo.AssertNotCalled(t, "GetByID", mock.Anything, mock.Anything)
+}
+
 func TestCustomerService_ValidateCustomer_Success(t *testing.T) {
 	service := createTestCustomerService(&MockCustomerRepository{})
 	
@@ -369,11 +722,11 @@ func TestCustomerService_GetHealthStatus_Healthy(t *testing.T) {
 	service := createTestCustomerService(mockRepo)
 	ctx := context.Background()
 	
-	mockRepo.On("HealthCheck", ctx).Return(nil)
-	mockRepo.On("Count", ctx, repository.CustomerFilters{}).Return(10, nil)
+	mockRepo.On("HealthCheck", mock.Anything).Return(nil)
+	mockRepo.On("Count", mock.Anything, repository.CustomerFilters{}).Return(10, nil)
 	
 	activeFilter := true
-	mockRepo.On("Count", ctx, repository.CustomerFilters{Active: &activeFilter}).Return(8, nil)
+	mockRepo.On("Count", mock.Anything, repository.CustomerFilters{Active: &activeFilter}).Return(8, nil)
 	
 	health, err := service.GetHealthStatus(ctx)
 	
@@ -384,7 +737,8 @@ func TestCustomerService_GetHealthStatus_Healthy(t *testing.T) {
 	assert.Equal(t, "test-v1.0.0", health.Version)
 	assert.Equal(t, "test", health.Environment)
 	assert.Contains(t, health.Dependencies, "repository")
-	assert.Equal(t, "healthy", health.Dependencies["repository"])
+	assert.Equal(t, DependencyHealthy, health.Dependencies["repository"].Status)
+	assert.GreaterOrEqual(t, health.Dependencies["repository"].LatencyMs, 0.0)
 	assert.Equal(t, 10, health.TotalCustomers)
 	assert.Equal(t, 8, health.ActiveCustomers)
 	mockRepo.AssertExpectations(t)
@@ -395,26 +749,250 @@ func TestCustomerService_GetHealthStatus_UnhealthyRepository(t *testing.T) {
 	service := createTestCustomerService(mockRepo)
 	ctx := context.Background()
 	
-	mockRepo.On("HealthCheck", ctx).Return(errors.New("database connection failed"))
-	mockRepo.On("Count", ctx, repository.CustomerFilters{}).Return(0, nil)
+	mockRepo.On("HealthCheck", mock.Anything).Return(errors.New("database connection failed"))
+	mockRepo.On("Count", mock.Anything, repository.CustomerFilters{}).Return(0, nil)
 	
 	activeFilter := true
-	mockRepo.On("Count", ctx, repository.CustomerFilters{Active: &activeFilter}).Return(0, nil)
+	mockRepo.On("Count", mock.Anything, repository.CustomerFilters{Active: &activeFilter}).Return(0, nil)
 	
 	health, err := service.GetHealthStatus(ctx)
 	
 	assert.NoError(t, err)
 	assert.NotNil(t, health)
-	assert.Contains(t, health.Dependencies["repository"], "unhealthy")
+	assert.Equal(t, "unhealthy", health.Status)
+	assert.False(t, health.Ready)
+	assert.Equal(t, DependencyUnhealthy, health.Dependencies["repository"].Status)
+	assert.Equal(t, "database connection failed", health.Dependencies["repository"].Error)
 	mockRepo.AssertExpectations(t)
 }
 
+func TestCustomerService_GetHealthStatus_DegradedOnRecentErrors(t *testing.T) {
+	mockRepo := &MockCustomerRepository{}
+	service := createTestCustomerService(mockRepo)
+	ctx := context.Background()
+	
+	now := time.Now()
+	service.stats.now = func() time.Time { return now }
+	
+	mockRepo.On("HealthCheck", mock.Anything).Return(nil)
+	mockRepo.On("Count", mock.Anything, mock.Anything).Return(0, nil)
+	
+	// Plenty of healthy traffic in the past must not mask a burst of errors now
+	for i := 0; i < 1000; i++ {
+		service.stats.request("GetCustomer")
+	}
+	now = now.Add(errorWindowBucket * errorWindowBuckets)
+	for i := 0; i < 20; i++ {
+		service.stats.request("GetCustomer")
+		service.stats.failure("GetCustomer")
+	}
+	
+	health, err := service.GetHealthStatus(ctx)
+	
+	assert.NoError(t, err)
+	assert.Equal(t, "degraded", health.Status)
+	assert.Equal(t, 100, health.Metrics["recent_error_rate_percent"])
+	
+	// Once the errors age out of the window the service reports healthy again
+	now = now.Add(errorWindowBucket * errorWindowBuckets)
+	
+	health, err = service.GetHealthStatus(ctx)
+	
+	assert.NoError(t, err)
+	assert.Equal(t, "healthy", health.Status)
+	assert.Equal(t, 0, health.Metrics["recent_requests"])
+	assert.Equal(t, 1020, health.Metrics["total_requests"])
+}
+
+func TestCustomerService_Liveness(t *testing.T) {
+	mockRepo := &MockCustomerRepository{}
+	service := createTestCustomerService(mockRepo)
+	
+	probe := service.Liveness()
+	
+	assert.Equal(t, ProbeStatusOK, probe.Status)
+	// Liveness must not touch the repository
+	mockRepo.AssertNotCalled(t, "HealthCheck", mock.Anything)
+}
+
+func TestCustomerService_Readiness(t *testing.T) {
+	ctx := context.Background()
+	
+	t.Run("not ready until startup finishes", func(t *testing.T) {
+		mockRepo := &MockCustomerRepository{}
+		service := createTestCustomerService(mockRepo)
+		mockRepo.On("HealthCheck", mock.Anything).Return(nil)
+		
+		probe, ready := service.Readiness(ctx)
+		
+		assert.False(t, ready)
+		assert.Equal(t, ProbeStatusNotReady, probe.Status)
+		assert.Equal(t, "in_progress", probe.Checks["startup"])
+	})
+	
+	t.Run("ready once started with a healthy repository", func(t *testing.T) {
+		mockRepo := &MockCustomerRepository{}
+		service := createTestCustomerService(mockRepo)
+		mockRepo.On("HealthCheck", mock.Anything).Return(nil)
+		service.MarkStarted()
+		
+		probe, ready := service.Readiness(ctx)
+		
+		assert.True(t, ready)
+		assert.Equal(t, ProbeStatusOK, probe.Status)
+		assert.Equal(t, DependencyHealthy, probe.Checks["repository"])
+		mockRepo.AssertNotCalled(t, "Count", mock.Anything, mock.Anything)
+	})
+	
+	t.Run("not ready when the repository is unreachable", func(t *testing.T) {
+		mockRepo := &MockCustomerRepository{}
+		service := createTestCustomerService(mockRepo)
+		mockRepo.On("HealthCheck", mock.Anything).Return(errors.New("server selection timeout"))
+		service.MarkStarted()
+		
+		probe, ready := service.Readiness(ctx)
+		
+		assert.False(t, ready)
+		assert.Equal(t, DependencyUnhealthy, probe.Checks["repository"])
+	})
+	
+	t.Run("not ready while draining", func(t *testing.T) {
+		mockRepo := &MockCustomerRepository{}
+		service := createTestCustomerService(mockRepo)
+		mockRepo.On("HealthCheck", mock.Anything).Return(nil)
+		service.MarkStarted()
+		service.MarkDraining()
+		
+		probe, ready := service.Readiness(ctx)
+		
+		assert.False(t, ready)
+		assert.Equal(t, "draining", probe.Checks["shutdown"])
+	})
+	
+	t.Run("repository check is bounded by the probe timeout", func(t *testing.T) {
+		mockRepo := &MockCustomerRepository{}
+		service := createTestCustomerService(mockRepo)
+		service.config.Server.ProbeTimeout = 20 * time.Millisecond
+		mockRepo.On("HealthCheck", mock.Anything).Return(context.DeadlineExceeded).Run(func(args mock.Arguments) {
+			<-args.Get(0).(context.Context).Done()
+		})
+		service.MarkStarted()
+		
+		start := time.Now()
+		_, ready := service.Readiness(ctx)
+		
+		assert.False(t, ready)
+		assert.Less(t, time.Since(start), time.Second)
+	})
+}
+
+func TestCustomerService_DegradedStartupRecoversAfterSwap(t *testing.T) {
+	ctx := context.Background()
+	swappable := repository.NewSwappableCustomerRepository(repository.NewUnavailableCustomerRepository(errors.New("connection refused")))
+	service := createTestCustomerService(swappable)
+	service.MarkStarted()
+	
+	_, err := service.GetCustomer(ctx, "test-customer-1")
+	assert.ErrorIs(t, err, ErrUnavailable)
+	
+	_, ready := service.Readiness(ctx)
+	assert.False(t, ready)
+	
+	health, err := service.GetHealthStatus(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "unhealthy", health.Status)
+	
+	// The background reconnect installs the real repository
+	memory := repository.NewMemoryCustomerRepository()
+	assert.NoError(t, memory.Create(ctx, createTestCustomer()))
+	swappable.Swap(memory)
+	
+	_, ready = service.Readiness(ctx)
+	assert.True(t, ready)
+	
+	found, err := service.GetCustomer(ctx, "test-customer-1")
+	assert.NoError(t, err)
+	assert.Equal(t, "test-customer-1", found.CustomerID)
+}
+
+func TestMemoryCustomerRepository_SeedFromFixtures(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "customers.ndjson")
+	fixtures := `{"customerId":"customer-1","name":"Juan Pérez","email":"juan@example.com","active":true}
+
+{"customerId":"customer-2","name":"María García","active":false}
+`
+	assert.NoError(t, os.WriteFile(path, []byte(fixtures), 0o644))
+	
+	customers, err := repository.LoadCustomerFixtures(path, "customers")
+	assert.NoError(t, err)
+	assert.Len(t, customers, 2)
+	
+	memory := repository.NewMemoryCustomerRepository()
+	assert.NoError(t, memory.Seed(customers, ValidateCustomer))
+	
+	service := createTestCustomerService(memory)
+	found, err := service.GetCustomer(ctx, "customer-1")
+	assert.NoError(t, err)
+	assert.Equal(t, "Juan Pérez", found.Name)
+	assert.Equal(t, int64(1), found.Version)
+	assert.False(t, found.CreatedAt.IsZero())
+}
+
+func TestMemoryCustomerRepository_SeedRejectsInvalidFixtures(t *testing.T) {
+	memory := repository.NewMemoryCustomerRepository()
+	
+	err := memory.Seed([]*models.Customer{
+		{CustomerID: "customer-1", Name: "Juan Pérez"},
+		{CustomerID: "customer-2"},
+	}, ValidateCustomer)
+	assert.ErrorContains(t, err, "fixture 2 (customer-2)")
+	
+	err = memory.Seed([]*models.Customer{
+		{CustomerID: "customer-1", Name: "Juan Pérez"},
+		{CustomerID: "customer-1", Name: "Juan Pérez"},
+	}, ValidateCustomer)
+	assert.ErrorIs(t, err, repository.ErrCustomerExists)
+	
+	// Nothing is loaded from a rejected batch
+	count, err := memory.Count(context.Background(), repository.CustomerFilters{})
+	assert.NoError(t, err)
+	assert.Equal(t, 0, count)
+}
+
+func TestMemoryCustomerRepository_PersistsWritesToFixtures(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "customers.json")
+	assert.NoError(t, os.WriteFile(path, []byte(`[{"customerId":"customer-1","name":"Juan Pérez","active":true}]`), 0o644))
+	
+	customers, err := repository.LoadCustomerFixtures(path, "customers")
+	assert.NoError(t, err)
+	memory := repository.NewMemoryCustomerRepository()
+	assert.NoError(t, memory.Seed(customers, ValidateCustomer))
+	assert.NoError(t, memory.PersistTo(path, func(err error) { t.Error(err) }))
+	
+	service := createTestCustomerService(memory)
+	assert.NoError(t, service.CreateCustomer(ctx, &models.Customer{CustomerID: "customer-2", Name: "María García", Active: true}))
+	
+	// A restart sees the customer created before it
+	reloaded, err := repository.LoadCustomerFixtures(path, "customers")
+	assert.NoError(t, err)
+	assert.Len(t, reloaded, 2)
+	assert.Equal(t, "customer-2", reloaded[1].CustomerID)
+	
+	assert.Error(t, memory.PersistTo(filepath.Dir(path), nil))
+}
+
 func TestCustomerService_GetMetrics(t *testing.T) {
 	service := createTestCustomerService(&MockCustomerRepository{})
 	
 	// Simulate some requests and errors
-	service.requests = 150
-	service.errors = 3
+	for i := 0; i < 150; i++ {
+		service.stats.request("GetCustomer")
+	}
+	for i := 0; i < 3; i++ {
+		service.stats.failure("GetCustomer")
+	}
 	
 	metrics := service.GetMetrics()
 	
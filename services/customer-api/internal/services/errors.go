@@ -0,0 +1,89 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/customer-api-v2/internal/repository"
+)
+
+// Error kinds returned by the service layer. Handlers match them with
+// errors.Is to choose the HTTP status, so message wording can change freely.
+var (
+	ErrNotFound           = errors.New("not found")
+	ErrInactive           = errors.New("inactive")
+	ErrConflict           = errors.New("conflict")
+	ErrValidation         = errors.New("validation failed")
+	ErrPreconditionFailed = errors.New("precondition failed")
+	ErrUnavailable        = errors.New("unavailable")
+	ErrInternal           = errors.New("internal error")
+)
+
+// Error is a service error carrying its kind, a machine-readable code for the
+// API response and the underlying cause, if any
+type Error struct {
+	Kind    error
+	Code    string
+	Message string
+	Err     error
+}
+
+// Error returns the message followed by the underlying cause
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+// Unwrap exposes both the kind and the cause to errors.Is and errors.As
+func (e *Error) Unwrap() []error {
+	if e.Err != nil {
+		return []error{e.Kind, e.Err}
+	}
+	return []error{e.Kind}
+}
+
+// newError creates a service error of the given kind
+func newError(kind error, code string, cause error, format string, args ...interface{}) *Error {
+	return &Error{
+		Kind:    kind,
+		Code:    code,
+		Message: fmt.Sprintf(format, args...),
+		Err:     cause,
+	}
+}
+
+// validationError wraps a business validation failure
+func validationError(err error) *Error {
+	return newError(ErrValidation, "validation_error", err, "validation failed")
+}
+
+// repositoryError classifies an unexpected repository failure. Connectivity
+// problems and deadlines are reported as unavailable, everything else as internal.
+func repositoryError(err error, format string, args ...interface{}) *Error {
+	if errors.Is(err, repository.ErrRepositoryUnavailable) || errors.Is(err, context.DeadlineExceeded) {
+		return newError(ErrUnavailable, "service_unavailable", err, format, args...)
+	}
+	return newError(ErrInternal, "internal_error", err, format, args...)
+}
+
+// checkVersion enforces an If-Match precondition. A nil ifMatch matches any
+// version.
+func checkVersion(ifMatch *int64, current int64) *Error {
+	if ifMatch == nil || *ifMatch == current {
+		return nil
+	}
+	return newError(ErrPreconditionFailed, "version_mismatch", nil, "resource is at version %d, not %d", current, *ifMatch)
+}
+
+// versionConflictError reports a write that lost a race with another writer.
+// Clients that sent If-Match get a failed precondition, others a conflict
+// they can retry.
+func versionConflictError(ifMatch *int64) *Error {
+	if ifMatch != nil {
+		return newError(ErrPreconditionFailed, "version_mismatch", nil, "resource was modified by another request")
+	}
+	return newError(ErrConflict, "concurrent_modification", nil, "resource was modified by another request")
+}
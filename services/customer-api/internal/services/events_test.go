@@ -0,0 +1,162 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/customer-api-v2/configs"
+	"github.com/customer-api-v2/internal/models"
+	"github.com/customer-api-v2/internal/repository"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingSink keeps what it is asked to publish, failing while err is set
+// and for any call that includes a message with a rejected key
+type recordingSink struct {
+	published []*models.OutboxMessage
+	err       error
+	rejected  map[string]bool
+}
+
+func (s *recordingSink) Publish(ctx context.Context, messages []*models.OutboxMessage) error {
+	if s.err != nil {
+		return s.err
+	}
+	for _, message := range messages {
+		if s.rejected[message.Key] {
+			return errors.New("message too large")
+		}
+	}
+	s.published = append(s.published, messages...)
+	return nil
+}
+
+func (s *recordingSink) Close() error {
+	return nil
+}
+
+// createTestEventService returns a service that records history and events
+// and a relay publishing those events to sink
+func createTestEventService(sink *recordingSink) (*CustomerService, *EventRelay, repository.OutboxRepository) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+
+	config := &configs.Config{Events: configs.EventsConfig{BatchSize: 2, MaxAttempts: 2}}
+	outbox := repository.NewMemoryOutboxRepository()
+	service := NewCustomerService(repository.NewMemoryCustomerRepository(), repository.NewMemoryCustomerHistoryRepository(), outbox, nil, repository.NewDirectTransactor(), config, logger)
+	return service, NewEventRelay(outbox, sink, config, logger), outbox
+}
+
+func decodeCustomerChanged(t *testing.T, message *models.OutboxMessage) models.CustomerChangedEvent {
+	var event models.CustomerChangedEvent
+	require.NoError(t, json.Unmarshal([]byte(message.Payload), &event))
+	return event
+}
+
+func TestEventRelay_PublishesChangesInOrder(t *testing.T) {
+	sink := &recordingSink{}
+	service, relay, outbox := createTestEventService(sink)
+
+	ctx := context.WithValue(context.Background(), "actor", "alice")
+	require.NoError(t, service.CreateCustomer(ctx, createTestCustomer()))
+	_, err := service.PatchCustomer(ctx, "test-customer-1", map[string]interface{}{"phone": "+34 600 000 000"}, nil)
+	require.NoError(t, err)
+	_, err = service.DeactivateCustomer(ctx, "test-customer-1", "chargeback fraud", "alice", nil)
+	require.NoError(t, err)
+
+	published, err := relay.PublishPending(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 3, published)
+	require.Len(t, sink.published, 3)
+
+	history, err := service.GetCustomerHistory(ctx, "test-customer-1")
+	require.NoError(t, err)
+	for i, message := range sink.published {
+		event := decodeCustomerChanged(t, message)
+		assert.Equal(t, models.EventCustomerChanged, event.Type)
+		assert.Equal(t, models.CustomerChangedSchemaVersion, event.SchemaVersion)
+		assert.Equal(t, eventSource, event.Source)
+		assert.Equal(t, "test-customer-1", message.Key)
+		assert.Equal(t, "alice", event.Actor)
+		assert.Equal(t, history.Changes[i].ChangeID, event.IdempotencyKey)
+		assert.Equal(t, int64(i+1), event.Version)
+		assert.Equal(t, message.EventID, event.EventID)
+	}
+
+	deactivated := decodeCustomerChanged(t, sink.published[2])
+	assert.Contains(t, deactivated.ChangedFields, "active")
+	require.NotNil(t, deactivated.Customer)
+	assert.False(t, deactivated.Customer.Active)
+
+	pending, err := outbox.Pending(ctx, 10)
+	require.NoError(t, err)
+	assert.Empty(t, pending)
+}
+
+func TestEventRelay_FailureKeepsEventsPending(t *testing.T) {
+	sink := &recordingSink{err: errors.New("broker down")}
+	service, relay, outbox := createTestEventService(sink)
+
+	ctx := context.Background()
+	require.NoError(t, service.CreateCustomer(ctx, createTestCustomer()))
+
+	published, err := relay.PublishPending(ctx)
+	assert.Error(t, err)
+	assert.Equal(t, 0, published)
+
+	pending, err := outbox.Pending(ctx, 10)
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+	assert.Equal(t, 1, pending[0].Attempts)
+	assert.Equal(t, "broker down", pending[0].LastError)
+
+	sink.err = nil
+	published, err = relay.PublishPending(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, published)
+	assert.Equal(t, pending[0].EventID, sink.published[0].EventID)
+}
+
+func TestEventRelay_RejectedEventIsDeadLettered(t *testing.T) {
+	sink := &recordingSink{rejected: map[string]bool{"alice": true}}
+	service, relay, outbox := createTestEventService(sink)
+
+	ctx := context.Background()
+	for _, customerID := range []string{"alice", "bob"} {
+		require.NoError(t, service.CreateCustomer(ctx, &models.Customer{CustomerID: customerID, Name: customerID, Active: true}))
+	}
+	_, err := service.PatchCustomer(ctx, "alice", map[string]interface{}{"name": "Alice"}, nil)
+	require.NoError(t, err)
+
+	// Alice's events wait behind the rejected one; Bob's is still published
+	published, err := relay.PublishPending(ctx)
+	assert.ErrorContains(t, err, "message too large")
+	assert.Equal(t, 1, published)
+	require.Len(t, sink.published, 1)
+	assert.Equal(t, "bob", sink.published[0].Key)
+
+	pending, err := outbox.Pending(ctx, 10)
+	require.NoError(t, err)
+	require.Len(t, pending, 2)
+	assert.Equal(t, 1, pending[0].Attempts)
+	assert.Equal(t, "message too large", pending[0].LastError)
+	assert.Equal(t, 0, pending[1].Attempts)
+
+	// Out of attempts, the rejected event is parked and Alice's next one
+	// goes out once the sink accepts it
+	_, err = relay.PublishPending(ctx)
+	assert.Error(t, err)
+	pending, err = outbox.Pending(ctx, 10)
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+	assert.Equal(t, int64(2), decodeCustomerChanged(t, pending[0]).Version)
+
+	delete(sink.rejected, "alice")
+	published, err = relay.PublishPending(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, published)
+}
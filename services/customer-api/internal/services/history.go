@@ -0,0 +1,330 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/customer-api-v2/internal/models"
+	"github.com/customer-api-v2/internal/repository"
+	"github.com/sirupsen/logrus"
+)
+
+// systemActor is recorded for changes made outside a request
+const systemActor = "system"
+
+// historyIgnoredFields are maintained by the repository on every write and
+// left out of change diffs
+var historyIgnoredFields = map[string]bool{"version": true, "createdAt": true, "updatedAt": true}
+
+// GetCustomerHistory returns every recorded change of a customer, oldest
+// first. A customer without recorded changes, such as one loaded from
+// fixtures, has an empty history.
+func (s *CustomerService) GetCustomerHistory(ctx context.Context, customerID string) (*models.CustomerHistoryResponse, error) {
+	s.stats.request("GetCustomerHistory")
+
+	logger := s.logger.WithFields(logrus.Fields{
+		"operation":  "GetCustomerHistory",
+		"customerId": customerID,
+		"requestId":  ctx.Value("requestId"),
+	})
+
+	changes := []*models.CustomerChange{}
+	if s.history != nil {
+		recorded, err := s.history.List(ctx, customerID)
+		if err != nil {
+			s.stats.failure("GetCustomerHistory")
+			logger.WithError(err).Error("💥 Failed to read customer history")
+			return nil, repositoryError(err, "failed to retrieve customer history")
+		}
+		changes = recorded
+	}
+
+	if len(changes) == 0 {
+		if _, err := s.getExistingCustomer(ctx, logger, "GetCustomerHistory", customerID); err != nil {
+			return nil, err
+		}
+	}
+
+	logger.WithField("changes", len(changes)).Info("📜 Customer history retrieved")
+
+	return &models.CustomerHistoryResponse{
+		CustomerID: customerID,
+		Changes:    changes,
+		Total:      len(changes),
+	}, nil
+}
+
+// RestoreCustomer brings a customer's profile back to a version recorded in
+// its history: name, contact details, address, tier and preferences. The
+// active flag and its status history only change through ActivateCustomer
+// and DeactivateCustomer, and the registration date, last login and
+// loyalty points record activity since that version, so all of them stay
+// as they are. The restored profile is written as a new version. If
+// ifMatch is set the customer must still be at that version.
+func (s *CustomerService) RestoreCustomer(ctx context.Context, customerID string, version int64, ifMatch *int64) (*models.Customer, error) {
+	s.stats.request("RestoreCustomer")
+
+	logger := s.logger.WithFields(logrus.Fields{
+		"operation":   "RestoreCustomer",
+		"customerId":  customerID,
+		"fromVersion": version,
+		"requestId":   ctx.Value("requestId"),
+	})
+
+	logger.Info("⏪ Restoring customer version")
+
+	existing, err := s.getExistingCustomer(ctx, logger, "RestoreCustomer", customerID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkVersion(ifMatch, existing.Version); err != nil {
+		logger.WithField("version", existing.Version).Warn("⚠️ Stale customer version")
+		return nil, err
+	}
+
+	if version == existing.Version {
+		logger.Info("ℹ️ Customer already at requested version")
+		return existing, nil
+	}
+
+	change, err := s.getRecordedVersion(ctx, customerID, version)
+	if err != nil {
+		if errors.Is(err, repository.ErrChangeNotFound) {
+			logger.WithField("reason", "version_not_found").Warn("⚠️ Version not in history")
+			return nil, newError(ErrNotFound, "version_not_found", nil, "customer %s has no recorded version %d", customerID, version)
+		}
+		s.stats.failure("RestoreCustomer")
+		logger.WithError(err).Error("💥 Failed to read customer history")
+		return nil, repositoryError(err, "failed to retrieve customer history")
+	}
+
+	customer := *existing
+	restoreProfileFields(&customer, change.Snapshot)
+
+	if err := s.validateCustomer(&customer); err != nil {
+		s.stats.failure("RestoreCustomer")
+		logger.WithError(err).Error("💥 Customer validation failed")
+		return nil, validationError(err)
+	}
+
+	err = s.commit(ctx, logger, "RestoreCustomer", func(ctx context.Context) ([]*models.CustomerChange, error) {
+		customer.Version = existing.Version
+		if err := s.saveCustomer(ctx, logger, "RestoreCustomer", &customer, ifMatch); err != nil {
+			return nil, err
+		}
+		restore := newCustomerChange(ctx, models.AuditRestore, existing, &customer)
+		restore.RestoredFrom = version
+		return []*models.CustomerChange{restore}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	logger.WithField("version", customer.Version).Info("✅ Customer restored")
+
+	return &customer, nil
+}
+
+// restoreProfileFields copies the profile fields of snapshot onto customer
+func restoreProfileFields(customer, snapshot *models.Customer) {
+	customer.Name = snapshot.Name
+	customer.Email = snapshot.Email
+	customer.Phone = snapshot.Phone
+	customer.Address = snapshot.Address
+	customer.CustomerTier = snapshot.CustomerTier
+	customer.Preferences = snapshot.Preferences
+}
+
+// getRecordedVersion returns the change that produced version of a customer
+func (s *CustomerService) getRecordedVersion(ctx context.Context, customerID string, version int64) (*models.CustomerChange, error) {
+	if s.history == nil {
+		return nil, repository.ErrChangeNotFound
+	}
+	return s.history.GetVersion(ctx, customerID, version)
+}
+
+// commit runs write and records the changes it made in one transaction, so
+// a write is kept only if its history entries and events are kept too.
+// Errors returned by write are passed through.
+func (s *CustomerService) commit(ctx context.Context, logger *logrus.Entry, operation string, write func(ctx context.Context) ([]*models.CustomerChange, error)) error {
+	work := func(ctx context.Context) error {
+		changes, err := write(ctx)
+		if err != nil {
+			return err
+		}
+		return s.recordChanges(ctx, changes...)
+	}
+
+	var err error
+	if s.transactor != nil {
+		err = s.transactor.WithinTransaction(ctx, work)
+	} else {
+		err = work(ctx)
+	}
+	if err == nil {
+		return nil
+	}
+
+	var serviceErr *Error
+	if errors.As(err, &serviceErr) {
+		return err
+	}
+	s.stats.failure(operation)
+	logger.WithError(err).Error("💥 Failed to record customer changes")
+	return repositoryError(err, "failed to record customer changes")
+}
+
+// recordChanges appends changes to the customer history, queues a
+// CustomerChanged event for each and queues the webhook deliveries they
+// trigger
+func (s *CustomerService) recordChanges(ctx context.Context, changes ...*models.CustomerChange) error {
+	if (s.history == nil && s.outbox == nil && s.webhooks == nil) || len(changes) == 0 {
+		return nil
+	}
+
+	for _, change := range changes {
+		changeID, err := newChangeID()
+		if err != nil {
+			return err
+		}
+		change.ChangeID = changeID
+	}
+
+	if s.history != nil {
+		if err := s.history.Append(ctx, changes...); err != nil {
+			return err
+		}
+	}
+
+	if s.outbox == nil && s.webhooks == nil {
+		return nil
+	}
+
+	events := make([]*models.CustomerChangedEvent, 0, len(changes))
+	for _, change := range changes {
+		event, err := newCustomerChangedEvent(change)
+		if err != nil {
+			return err
+		}
+		events = append(events, event)
+	}
+
+	if s.outbox != nil {
+		messages := make([]*models.OutboxMessage, 0, len(events))
+		for _, event := range events {
+			message, err := newOutboxMessage(event)
+			if err != nil {
+				return err
+			}
+			messages = append(messages, message)
+		}
+		if err := s.outbox.Append(ctx, messages...); err != nil {
+			return err
+		}
+	}
+
+	if s.webhooks != nil {
+		return s.webhooks.enqueue(ctx, events...)
+	}
+	return nil
+}
+
+// newCustomerChange describes a write that turned before into after. Before
+// is nil for a creation. The actor and request ID come from ctx.
+func newCustomerChange(ctx context.Context, action string, before, after *models.Customer) *models.CustomerChange {
+	snapshot := *after
+	change := &models.CustomerChange{
+		CustomerID: after.CustomerID,
+		Version:    after.Version,
+		Action:     action,
+		Actor:      actorFrom(ctx),
+		Snapshot:   &snapshot,
+		ChangedAt:  time.Now(),
+	}
+	if requestID, ok := ctx.Value("requestId").(string); ok {
+		change.RequestID = requestID
+	}
+
+	var beforeFields map[string]interface{}
+	if before != nil {
+		beforeFields = flattenFields(before)
+	}
+	change.Changes = diffFields(beforeFields, flattenFields(after))
+	return change
+}
+
+// actorFrom returns who is making the request in ctx
+func actorFrom(ctx context.Context) string {
+	if actor, ok := ctx.Value("actor").(string); ok && actor != "" {
+		return actor
+	}
+	return systemActor
+}
+
+// flattenFields returns the JSON fields of value by path, with nested
+// objects flattened into dotted paths
+func flattenFields(value interface{}) map[string]interface{} {
+	// Models always encode, so errors cannot occur
+	data, _ := json.Marshal(value)
+	var document map[string]interface{}
+	_ = json.Unmarshal(data, &document)
+
+	fields := make(map[string]interface{})
+	flattenInto(fields, "", document)
+	for field := range historyIgnoredFields {
+		delete(fields, field)
+	}
+	return fields
+}
+
+// flattenInto adds the fields of document to fields, prefixing their names
+func flattenInto(fields map[string]interface{}, prefix string, document map[string]interface{}) {
+	for name, value := range document {
+		if nested, ok := value.(map[string]interface{}); ok {
+			flattenInto(fields, prefix+name+".", nested)
+			continue
+		}
+		fields[prefix+name] = value
+	}
+}
+
+// diffFields lists the fields whose values differ between before and
+// after, by name
+func diffFields(before, after map[string]interface{}) []models.FieldChange {
+	names := make([]string, 0, len(before)+len(after))
+	for name := range before {
+		names = append(names, name)
+	}
+	for name := range after {
+		if _, seen := before[name]; !seen {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	changes := []models.FieldChange{}
+	for _, name := range names {
+		beforeValue, afterValue := before[name], after[name]
+		if reflect.DeepEqual(beforeValue, afterValue) {
+			continue
+		}
+		changes = append(changes, models.FieldChange{Field: name, Before: beforeValue, After: afterValue})
+	}
+	return changes
+}
+
+// newChangeID generates a random history entry identifier
+func newChangeID() (string, error) {
+	buf := make([]byte, 12)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return "chg-" + hex.EncodeToString(buf), nil
+}
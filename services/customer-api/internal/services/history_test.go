@@ -0,0 +1,109 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/customer-api-v2/configs"
+	"github.com/customer-api-v2/internal/models"
+	"github.com/customer-api-v2/internal/repository"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// createTestHistoryService returns a service that records history, with
+// the test customer created by alice
+func createTestHistoryService(t *testing.T) (*CustomerService, context.Context) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+
+	service := NewCustomerService(repository.NewMemoryCustomerRepository(), repository.NewMemoryCustomerHistoryRepository(), nil, nil, nil, &configs.Config{}, logger)
+
+	ctx := context.WithValue(context.Background(), "actor", "alice")
+	ctx = context.WithValue(ctx, "requestId", "req-1")
+	require.NoError(t, service.CreateCustomer(ctx, createTestCustomer()))
+	return service, ctx
+}
+
+func TestCustomerService_History_RecordsWrites(t *testing.T) {
+	service, ctx := createTestHistoryService(t)
+
+	bob := context.WithValue(context.Background(), "actor", "bob")
+	_, err := service.PatchCustomer(bob, "test-customer-1", map[string]interface{}{
+		"address": map[string]interface{}{"city": "Madrid"},
+	}, nil)
+	require.NoError(t, err)
+	_, err = service.DeactivateCustomer(ctx, "test-customer-1", "chargeback fraud", "alice", nil)
+	require.NoError(t, err)
+
+	history, err := service.GetCustomerHistory(ctx, "test-customer-1")
+	require.NoError(t, err)
+	require.Equal(t, 3, history.Total)
+
+	created := history.Changes[0]
+	assert.Equal(t, models.AuditCreate, created.Action)
+	assert.Equal(t, "alice", created.Actor)
+	assert.Equal(t, "req-1", created.RequestID)
+	assert.Equal(t, int64(1), created.Version)
+	assert.Contains(t, created.Changes, models.FieldChange{Field: "name", After: "John Doe"})
+
+	patched := history.Changes[1]
+	assert.Equal(t, "bob", patched.Actor)
+	assert.Equal(t, int64(2), patched.Version)
+	assert.Equal(t, []models.FieldChange{{Field: "address.city", Before: "Anytown", After: "Madrid"}}, patched.Changes)
+
+	deactivated := history.Changes[2]
+	assert.Equal(t, models.AuditUpdate, deactivated.Action)
+	assert.Contains(t, deactivated.Changes, models.FieldChange{Field: "active", Before: true, After: false})
+	assert.Contains(t, deactivated.Changes, models.FieldChange{Field: "lastStatusChange.reason", After: "chargeback fraud"})
+}
+
+func TestCustomerService_RestoreCustomer(t *testing.T) {
+	service, ctx := createTestHistoryService(t)
+
+	_, err := service.PatchCustomer(ctx, "test-customer-1", map[string]interface{}{"name": "Wrong Name"}, nil)
+	require.NoError(t, err)
+	_, err = service.DeactivateCustomer(ctx, "test-customer-1", "closed account", "alice", nil)
+	require.NoError(t, err)
+
+	stale := int64(1)
+	_, err = service.RestoreCustomer(ctx, "test-customer-1", 1, &stale)
+	assert.ErrorIs(t, err, ErrPreconditionFailed)
+	_, err = service.RestoreCustomer(ctx, "test-customer-1", 7, nil)
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	current := int64(3)
+	restored, err := service.RestoreCustomer(ctx, "test-customer-1", 1, &current)
+	require.NoError(t, err)
+	assert.Equal(t, "John Doe", restored.Name)
+	assert.False(t, restored.Active, "restoring a profile must not reactivate the customer")
+	assert.Equal(t, int64(4), restored.Version)
+
+	history, err := service.GetCustomerHistory(ctx, "test-customer-1")
+	require.NoError(t, err)
+	require.Equal(t, 4, history.Total)
+	assert.Equal(t, models.AuditRestore, history.Changes[3].Action)
+	assert.Equal(t, int64(1), history.Changes[3].RestoredFrom)
+	assert.Equal(t, []models.FieldChange{{Field: "name", Before: "Wrong Name", After: "John Doe"}}, history.Changes[3].Changes)
+}
+
+func TestCustomerService_RestoreCustomer_KeepsActivity(t *testing.T) {
+	service, ctx := createTestHistoryService(t)
+
+	_, err := service.PatchCustomer(ctx, "test-customer-1", map[string]interface{}{"name": "Wrong Name", "loyaltyPoints": 250}, nil)
+	require.NoError(t, err)
+
+	restored, err := service.RestoreCustomer(ctx, "test-customer-1", 1, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "John Doe", restored.Name)
+	assert.Equal(t, 250, restored.LoyaltyPoints, "loyalty points are not restored")
+}
+
+func TestCustomerService_GetCustomerHistory_UnknownCustomer(t *testing.T) {
+	service, ctx := createTestHistoryService(t)
+
+	_, err := service.GetCustomerHistory(ctx, "missing")
+
+	assert.ErrorIs(t, err, ErrNotFound)
+}
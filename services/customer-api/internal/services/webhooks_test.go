@@ -0,0 +1,284 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/customer-api-v2/configs"
+	"github.com/customer-api-v2/internal/models"
+	"github.com/customer-api-v2/internal/repository"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// webhookReceiver records the deliveries it receives and answers with status
+type webhookReceiver struct {
+	server   *httptest.Server
+	mutex    sync.Mutex
+	status   int
+	requests []*http.Request
+	bodies   [][]byte
+}
+
+func newWebhookReceiver(t *testing.T) *webhookReceiver {
+	receiver := &webhookReceiver{status: http.StatusNoContent}
+	receiver.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		receiver.mutex.Lock()
+		defer receiver.mutex.Unlock()
+		receiver.requests = append(receiver.requests, r)
+		receiver.bodies = append(receiver.bodies, body)
+		w.WriteHeader(receiver.status)
+	}))
+	t.Cleanup(receiver.server.Close)
+	return receiver
+}
+
+func (r *webhookReceiver) setStatus(status int) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.status = status
+}
+
+// received returns the event of every delivery received so far
+func (r *webhookReceiver) received(t *testing.T) []models.WebhookEvent {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	events := make([]models.WebhookEvent, 0, len(r.bodies))
+	for _, body := range r.bodies {
+		var event models.WebhookEvent
+		require.NoError(t, json.Unmarshal(body, &event))
+		events = append(events, event)
+	}
+	return events
+}
+
+// createTestWebhookService returns a webhook service and a customer service
+// that queues deliveries with it. Private networks are allowed, since the
+// test receivers listen on loopback.
+func createTestWebhookService(config configs.WebhooksConfig) (*WebhookService, *CustomerService) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+
+	config.AllowPrivateNetworks = true
+	cfg := &configs.Config{Webhooks: config}
+	webhooks := NewWebhookService(repository.NewMemoryWebhookRepository(), repository.NewMemoryWebhookDeliveryRepository(), cfg, logger)
+	customers := NewCustomerService(repository.NewMemoryCustomerRepository(), repository.NewMemoryCustomerHistoryRepository(), nil, webhooks, repository.NewDirectTransactor(), cfg, logger)
+	return webhooks, customers
+}
+
+func TestWebhookService_DeliversSubscribedEventsSigned(t *testing.T) {
+	receiver := newWebhookReceiver(t)
+	webhooks, customers := createTestWebhookService(configs.WebhooksConfig{})
+	ctx := context.Background()
+
+	webhook, err := webhooks.CreateWebhook(ctx, &models.WebhookRequest{
+		URL:        receiver.server.URL,
+		EventTypes: []string{models.WebhookCustomerDeactivated, models.WebhookCustomerActivated},
+	})
+	require.NoError(t, err)
+	require.NotEmpty(t, webhook.Secret)
+
+	require.NoError(t, customers.CreateCustomer(ctx, createTestCustomer()))
+	_, err = customers.PatchCustomer(ctx, "test-customer-1", map[string]interface{}{"phone": "+34 600 000 000"}, nil)
+	require.NoError(t, err)
+	_, err = customers.DeactivateCustomer(ctx, "test-customer-1", "chargeback fraud", "alice", nil)
+	require.NoError(t, err)
+
+	attempted, err := webhooks.DispatchDue(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, attempted, "creations and updates are not subscribed to")
+
+	received := receiver.received(t)
+	require.Len(t, received, 1)
+	assert.Equal(t, models.WebhookCustomerDeactivated, received[0].Type)
+	require.NotNil(t, received[0].Data)
+	assert.Equal(t, "test-customer-1", received[0].Data.CustomerID)
+	assert.False(t, received[0].Data.Customer.Active)
+
+	request := receiver.requests[0]
+	assert.Equal(t, webhook.WebhookID, request.Header.Get(HeaderWebhookID))
+	assert.Equal(t, models.WebhookCustomerDeactivated, request.Header.Get(HeaderWebhookEvent))
+	assert.NotEmpty(t, request.Header.Get(HeaderWebhookDelivery))
+	timestamp := request.Header.Get(HeaderWebhookTimestamp)
+	assert.Equal(t, SignWebhook(webhook.Secret, timestamp, receiver.bodies[0]), request.Header.Get(HeaderWebhookSignature))
+
+	_, err = customers.ActivateCustomer(ctx, "test-customer-1", "cleared", "alice", nil)
+	require.NoError(t, err)
+	_, err = webhooks.DispatchDue(ctx)
+	require.NoError(t, err)
+	received = receiver.received(t)
+	require.Len(t, received, 2)
+	assert.Equal(t, models.WebhookCustomerActivated, received[1].Type)
+
+	// Secrets are only returned on creation
+	stored, err := webhooks.GetWebhook(ctx, webhook.WebhookID)
+	require.NoError(t, err)
+	assert.Empty(t, stored.Secret)
+}
+
+func TestWebhookService_RetriesThenDeadLetters(t *testing.T) {
+	receiver := newWebhookReceiver(t)
+	receiver.setStatus(http.StatusServiceUnavailable)
+	webhooks, customers := createTestWebhookService(configs.WebhooksConfig{
+		MaxAttempts:    2,
+		InitialBackoff: time.Millisecond,
+		MaxBackoff:     time.Millisecond,
+	})
+	ctx := context.Background()
+
+	webhook, err := webhooks.CreateWebhook(ctx, &models.WebhookRequest{
+		URL:        receiver.server.URL,
+		EventTypes: []string{models.WebhookCustomerCreated},
+	})
+	require.NoError(t, err)
+	require.NoError(t, customers.CreateCustomer(ctx, createTestCustomer()))
+
+	_, err = webhooks.DispatchDue(ctx)
+	require.NoError(t, err)
+	pending, err := webhooks.GetDeliveries(ctx, webhook.WebhookID, models.DeliveryPending, 0)
+	require.NoError(t, err)
+	require.Equal(t, 1, pending.Total)
+	assert.Equal(t, 1, pending.Deliveries[0].Attempts)
+	assert.Equal(t, http.StatusServiceUnavailable, pending.Deliveries[0].LastStatusCode)
+
+	time.Sleep(5 * time.Millisecond)
+	_, err = webhooks.DispatchDue(ctx)
+	require.NoError(t, err)
+
+	dead, err := webhooks.GetDeliveries(ctx, "", models.DeliveryDead, 0)
+	require.NoError(t, err)
+	require.Equal(t, 1, dead.Total)
+	assert.Equal(t, 2, dead.Deliveries[0].Attempts)
+
+	receiver.setStatus(http.StatusOK)
+	_, err = webhooks.RedeliverDelivery(ctx, webhook.WebhookID, dead.Deliveries[0].DeliveryID)
+	require.NoError(t, err)
+	_, err = webhooks.DispatchDue(ctx)
+	require.NoError(t, err)
+
+	delivered, err := webhooks.GetDeliveries(ctx, webhook.WebhookID, models.DeliveryDelivered, 0)
+	require.NoError(t, err)
+	require.Equal(t, 1, delivered.Total)
+
+	// Every attempt carries the same event ID for receivers to deduplicate on
+	received := receiver.received(t)
+	require.Len(t, received, 3)
+	assert.Equal(t, received[0].EventID, received[2].EventID)
+
+	_, err = webhooks.RedeliverDelivery(ctx, "wh-missing", dead.Deliveries[0].DeliveryID)
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+// flakyWebhookRepository fails to look up the subscription failID
+type flakyWebhookRepository struct {
+	repository.WebhookRepository
+	failID string
+}
+
+func (r *flakyWebhookRepository) GetByID(ctx context.Context, webhookID string) (*models.WebhookSubscription, error) {
+	if webhookID == r.failID {
+		return nil, errors.New("connection reset")
+	}
+	return r.WebhookRepository.GetByID(ctx, webhookID)
+}
+
+func TestWebhookService_DispatchDue_LookupFailureKeepsOthersGoing(t *testing.T) {
+	receiver := newWebhookReceiver(t)
+	webhooks, customers := createTestWebhookService(configs.WebhooksConfig{})
+	ctx := context.Background()
+
+	var created []*models.WebhookSubscription
+	for range 2 {
+		webhook, err := webhooks.CreateWebhook(ctx, &models.WebhookRequest{
+			URL:        receiver.server.URL,
+			EventTypes: []string{models.WebhookCustomerCreated},
+		})
+		require.NoError(t, err)
+		created = append(created, webhook)
+	}
+	stored := webhooks.webhooks
+	webhooks.webhooks = &flakyWebhookRepository{WebhookRepository: stored, failID: created[0].WebhookID}
+
+	require.NoError(t, customers.CreateCustomer(ctx, createTestCustomer()))
+
+	attempted, err := webhooks.DispatchDue(ctx)
+	assert.ErrorContains(t, err, "connection reset")
+	assert.Equal(t, 1, attempted)
+	assert.Len(t, receiver.received(t), 1)
+
+	// The delivery that was not attempted keeps its attempts for later
+	webhooks.webhooks = stored
+	pending, err := webhooks.GetDeliveries(ctx, created[0].WebhookID, models.DeliveryPending, 0)
+	require.NoError(t, err)
+	require.Equal(t, 1, pending.Total)
+	assert.Equal(t, 0, pending.Deliveries[0].Attempts)
+}
+
+func TestWebhookService_RefusesPrivateAddresses(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+	cfg := &configs.Config{Webhooks: configs.WebhooksConfig{MaxAttempts: 3}}
+	webhooks := NewWebhookService(repository.NewMemoryWebhookRepository(), repository.NewMemoryWebhookDeliveryRepository(), cfg, logger)
+	customers := NewCustomerService(repository.NewMemoryCustomerRepository(), nil, nil, webhooks, repository.NewDirectTransactor(), cfg, logger)
+	ctx := context.Background()
+
+	for _, target := range []string{"http://169.254.169.254/latest/meta-data", "http://127.0.0.1:8080/hooks", "http://10.0.0.7/hooks", "http://[::1]/hooks", "http://[::ffff:192.168.1.1]/hooks"} {
+		_, err := webhooks.CreateWebhook(ctx, &models.WebhookRequest{URL: target, EventTypes: []string{models.WebhookCustomerCreated}})
+		assert.ErrorIs(t, err, ErrValidation, target)
+	}
+
+	// A host name is only resolved when delivering, where loopback is refused
+	receiver := newWebhookReceiver(t)
+	port := receiver.server.URL[strings.LastIndex(receiver.server.URL, ":")+1:]
+	webhook, err := webhooks.CreateWebhook(ctx, &models.WebhookRequest{
+		URL:        "http://localhost:" + port + "/hooks",
+		EventTypes: []string{models.WebhookCustomerCreated},
+	})
+	require.NoError(t, err)
+	require.NoError(t, customers.CreateCustomer(ctx, createTestCustomer()))
+
+	attempted, err := webhooks.DispatchDue(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, attempted)
+	assert.Empty(t, receiver.received(t))
+
+	pending, err := webhooks.GetDeliveries(ctx, webhook.WebhookID, models.DeliveryPending, 0)
+	require.NoError(t, err)
+	require.Equal(t, 1, pending.Total)
+	assert.Contains(t, pending.Deliveries[0].LastError, "not public")
+}
+
+func TestMemoryWebhookDeliveryRepository_PrunesOldDeliveries(t *testing.T) {
+	deliveries := repository.NewMemoryWebhookDeliveryRepository()
+	ctx := context.Background()
+
+	longAgo := time.Now().Add(-31 * 24 * time.Hour)
+	recently := time.Now().Add(-time.Hour)
+	require.NoError(t, deliveries.Append(ctx,
+		&models.WebhookDelivery{DeliveryID: "old", Status: models.DeliveryDelivered, DeliveredAt: &longAgo},
+		&models.WebhookDelivery{DeliveryID: "recent", Status: models.DeliveryDelivered, DeliveredAt: &recently},
+		&models.WebhookDelivery{DeliveryID: "dead", Status: models.DeliveryDead},
+	))
+
+	// Pruning happens as new deliveries are recorded
+	require.NoError(t, deliveries.Append(ctx, &models.WebhookDelivery{DeliveryID: "new", Status: models.DeliveryPending}))
+
+	_, err := deliveries.GetByID(ctx, "old")
+	assert.ErrorIs(t, err, repository.ErrDeliveryNotFound)
+	for _, deliveryID := range []string{"recent", "dead", "new"} {
+		_, err := deliveries.GetByID(ctx, deliveryID)
+		assert.NoError(t, err, deliveryID)
+	}
+}
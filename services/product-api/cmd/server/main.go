@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"os"
@@ -12,7 +13,9 @@ import (
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
 	"github.com/product-api-v2/configs"
+	"github.com/product-api-v2/internal/events"
 	"github.com/product-api-v2/internal/handlers"
+	"github.com/product-api-v2/internal/metrics"
 	custommiddleware "github.com/product-api-v2/internal/middleware"
 	"github.com/product-api-v2/internal/repository"
 	"github.com/product-api-v2/internal/services"
@@ -32,26 +35,198 @@ func main() {
 		"port":        config.Server.Port,
 	}).Info("🚀 Starting Product API")
 	
+	// Background work stops when the server shuts down
+	workerCtx, stopWorkers := context.WithCancel(context.Background())
+	defer stopWorkers()
+	
 	// Initialize dependencies
 	var productRepo repository.ProductRepository
-	var err error
+	var reservationRepo repository.ReservationRepository
+	var priceListRepo repository.PriceListRepository
+	var categoryRepo repository.CategoryRepository
+	var historyRepo repository.ProductHistoryRepository
+	var outboxRepo repository.OutboxRepository
+	var webhookRepo repository.WebhookRepository
+	var deliveryRepo repository.WebhookDeliveryRepository
+	var transactor repository.Transactor
 	
 	if config.Database.Type == "mongodb" && config.Database.URL != "" {
-		logger.Info("🔌 Connecting to MongoDB...")
-		productRepo, err = repository.NewMongoProductRepository(config.Database.URL)
-		if err != nil {
-			logger.WithError(err).Warn("⚠️ Failed to connect to MongoDB, falling back to memory repository")
-			productRepo = repository.NewMemoryProductRepository()
-		} else {
-			logger.Info("✅ Connected to MongoDB successfully")
+		logger.WithFields(logrus.Fields{
+			"database":   config.Database.Database,
+			"collection": config.Database.Collection,
+			"policy":     config.Database.StartupPolicy,
+		}).Info("🔌 Connecting to MongoDB...")
+		
+		// Swappable repositories let a degraded start switch to MongoDB once
+		// the background reconnect succeeds
+		notConnected := errors.New("not connected to MongoDB")
+		products := repository.NewSwappableProductRepository(repository.NewUnavailableProductRepository(notConnected))
+		reservations := repository.NewSwappableReservationRepository(repository.NewUnavailableReservationRepository(notConnected))
+		priceLists := repository.NewSwappablePriceListRepository(repository.NewUnavailablePriceListRepository(notConnected))
+		categories := repository.NewSwappableCategoryRepository(repository.NewUnavailableCategoryRepository(notConnected))
+		history := repository.NewSwappableProductHistoryRepository(repository.NewUnavailableProductHistoryRepository(notConnected))
+		outbox := repository.NewSwappableOutboxRepository(repository.NewUnavailableOutboxRepository(notConnected))
+		webhooks := repository.NewSwappableWebhookRepository(repository.NewUnavailableWebhookRepository(notConnected))
+		deliveries := repository.NewSwappableWebhookDeliveryRepository(repository.NewUnavailableWebhookDeliveryRepository(notConnected))
+		transactions := repository.NewSwappableTransactor(repository.NewDirectTransactor())
+		productRepo = products
+		reservationRepo = reservations
+		priceListRepo = priceLists
+		categoryRepo = categories
+		historyRepo = history
+		outboxRepo = outbox
+		webhookRepo = webhooks
+		deliveryRepo = deliveries
+		transactor = transactions
+		
+		connect := func() error {
+			mongoRepo, err := repository.NewMongoProductRepository(config.Database)
+			if err != nil {
+				return err
+			}
+			mongoReservations, err := repository.NewMongoReservationRepository(mongoRepo, config.Database)
+			if err != nil {
+				mongoRepo.Close(context.Background())
+				return err
+			}
+			mongoPriceLists, err := repository.NewMongoPriceListRepository(mongoRepo, config.Database)
+			if err != nil {
+				mongoRepo.Close(context.Background())
+				return err
+			}
+			mongoCategories, err := repository.NewMongoCategoryRepository(mongoRepo, config.Database)
+			if err != nil {
+				mongoRepo.Close(context.Background())
+				return err
+			}
+			mongoHistory, err := repository.NewMongoProductHistoryRepository(mongoRepo, config.Database)
+			if err != nil {
+				mongoRepo.Close(context.Background())
+				return err
+			}
+			mongoOutbox, err := repository.NewMongoOutboxRepository(mongoRepo, config.Database)
+			if err != nil {
+				mongoRepo.Close(context.Background())
+				return err
+			}
+			mongoWebhooks, err := repository.NewMongoWebhookRepository(mongoRepo, config.Database)
+			if err != nil {
+				mongoRepo.Close(context.Background())
+				return err
+			}
+			mongoDeliveries, err := repository.NewMongoWebhookDeliveryRepository(mongoRepo, config.Database)
+			if err != nil {
+				mongoRepo.Close(context.Background())
+				return err
+			}
+			mongoTransactor, err := repository.NewMongoTransactor(mongoRepo, config.Database)
+			if err != nil {
+				mongoRepo.Close(context.Background())
+				return err
+			}
+			if !mongoTransactor.Supported() {
+				logger.Warn("⚠️ MongoDB is not a replica set; writes, history and events are stored without transactions")
+			}
+			products.Swap(mongoRepo)
+			reservations.Swap(mongoReservations)
+			priceLists.Swap(mongoPriceLists)
+			categories.Swap(mongoCategories)
+			history.Swap(mongoHistory)
+			outbox.Swap(mongoOutbox)
+			webhooks.Swap(mongoWebhooks)
+			deliveries.Swap(mongoDeliveries)
+			transactions.Swap(mongoTransactor)
+			return nil
 		}
+		degrade := func(cause error) {
+			products.Swap(repository.NewUnavailableProductRepository(cause))
+			reservations.Swap(repository.NewUnavailableReservationRepository(cause))
+			priceLists.Swap(repository.NewUnavailablePriceListRepository(cause))
+			categories.Swap(repository.NewUnavailableCategoryRepository(cause))
+			history.Swap(repository.NewUnavailableProductHistoryRepository(cause))
+			outbox.Swap(repository.NewUnavailableOutboxRepository(cause))
+			webhooks.Swap(repository.NewUnavailableWebhookRepository(cause))
+			deliveries.Swap(repository.NewUnavailableWebhookDeliveryRepository(cause))
+			transactions.Swap(repository.NewDirectTransactor())
+		}
+		connectDatabase(workerCtx, config.Database, logger, connect, degrade)
 	} else {
 		logger.Info("💾 Using in-memory repository")
-		productRepo = repository.NewMemoryProductRepository()
+		memoryRepo := repository.NewMemoryProductRepository()
+		memoryCategories := repository.NewMemoryCategoryRepository()
+		if config.Database.FixturesPath != "" {
+			seedMemoryCategories(memoryCategories, config.Database, logger)
+			seedMemoryRepository(memoryRepo, config.Database, logger)
+		}
+		productRepo = memoryRepo
+		reservationRepo = repository.NewMemoryReservationRepository()
+		priceListRepo = repository.NewMemoryPriceListRepository()
+		categoryRepo = memoryCategories
+		historyRepo = repository.NewMemoryProductHistoryRepository()
+		outboxRepo = repository.NewMemoryOutboxRepository()
+		webhookRepo = repository.NewMemoryWebhookRepository()
+		deliveryRepo = repository.NewMemoryWebhookDeliveryRepository()
+		transactor = repository.NewDirectTransactor()
+	}
+	
+	registry := metrics.NewRegistry()
+	
+	if config.Features.EnableMetrics {
+		repositoryMetrics := metrics.NewRepositoryMetrics(registry)
+		productRepo = repository.NewInstrumentedProductRepository(productRepo, repositoryMetrics.Observe)
+	}
+	
+	if config.Cache.Enabled {
+		logger.WithFields(logrus.Fields{
+			"ttl":     config.Cache.TTL,
+			"maxSize": config.Cache.MaxSize,
+		}).Info("🗄️ Product cache enabled")
+		productRepo = repository.NewCachedProductRepository(productRepo, config.Cache.TTL, config.Cache.MaxSize)
 	}
 	
-	productService := services.NewProductService(productRepo, config, logger)
+	// Change events are only recorded when there is a sink to publish them to
+	eventSink, err := events.NewSink(config.Events)
+	if err != nil {
+		logger.WithError(err).Fatal("💥 Failed to set up event publishing")
+	}
+	var eventOutbox repository.OutboxRepository
+	if eventSink != nil {
+		eventOutbox = outboxRepo
+	}
+	
+	webhookService := services.NewWebhookService(webhookRepo, deliveryRepo, config, logger)
+	webhookHandler := handlers.NewWebhookHandler(webhookService, logger)
+	productService := services.NewProductService(productRepo, categoryRepo, historyRepo, eventOutbox, webhookService, transactor, config, logger)
 	productHandler := handlers.NewProductHandler(productService, logger)
+	productService.RegisterMetrics(registry)
+	reservationService := services.NewReservationService(reservationRepo, productRepo, eventOutbox, webhookService, transactor, config, logger)
+	reservationHandler := handlers.NewReservationHandler(reservationService, logger)
+	pricingService := services.NewPricingService(priceListRepo, productRepo, config, logger)
+	pricingHandler := handlers.NewPricingHandler(pricingService, logger)
+	categoryService := services.NewCategoryService(categoryRepo, productRepo, config, logger)
+	categoryHandler := handlers.NewCategoryHandler(categoryService, logger)
+	
+	// Expire abandoned reservations in the background
+	go reservationService.Run(workerCtx)
+	
+	// Send webhook deliveries in the background
+	go webhookService.Run(workerCtx)
+	
+	// Publish change events in the background
+	relayDone := make(chan struct{})
+	if eventSink != nil {
+		logger.WithFields(logrus.Fields{
+			"sink":  config.Events.Sink,
+			"topic": config.Events.Topic,
+		}).Info("📣 Publishing product events")
+		relay := services.NewEventRelay(outboxRepo, eventSink, config, logger)
+		go func() {
+			defer close(relayDone)
+			relay.Run(workerCtx)
+		}()
+	} else {
+		close(relayDone)
+	}
 	
 	// Setup Echo server
 	e := echo.New()
@@ -64,19 +239,20 @@ func main() {
 	e.Use(middleware.Recover())
 	e.Use(middleware.CORS())
 	e.Use(custommiddleware.RequestIDMiddleware())
+	e.Use(custommiddleware.ActorMiddleware())
 	
 	if config.Logging.RequestLog {
 		e.Use(custommiddleware.StructuredLoggingMiddleware(logger))
 	}
 	
 	if config.Features.EnableMetrics {
-		e.Use(custommiddleware.MetricsMiddleware())
+		e.Use(custommiddleware.MetricsMiddleware(metrics.NewHTTPMetrics(registry)))
 	}
 	
 	e.Use(custommiddleware.ErrorHandlingMiddleware(logger))
 	
 	// Setup routes
-	setupRoutes(e, productHandler)
+	setupRoutes(e, productHandler, reservationHandler, pricingHandler, categoryHandler, webhookHandler, registry)
 	
 	// Setup server with timeouts
 	server := &http.Server{
@@ -85,6 +261,9 @@ func main() {
 		WriteTimeout: config.Server.WriteTimeout,
 	}
 	
+	// Startup is complete; readiness now depends only on the repository
+	productService.MarkStarted()
+	
 	// Start server in goroutine
 	go func() {
 		logger.WithFields(logrus.Fields{
@@ -104,6 +283,19 @@ func main() {
 	
 	logger.Info("🛑 Shutting down server...")
 	
+	// Fail readiness first and give load balancers time to stop routing to
+	// this instance before the listener closes
+	productService.MarkDraining()
+	logger.WithField("drainDelay", config.Server.DrainDelay).Info("⏳ Draining traffic")
+	time.Sleep(config.Server.DrainDelay)
+	stopWorkers()
+	<-relayDone
+	if eventSink != nil {
+		if err := eventSink.Close(); err != nil {
+			logger.WithError(err).Warn("⚠️ Failed to close event sink")
+		}
+	}
+	
 	// Graceful shutdown with timeout
 	ctx, cancel := context.WithTimeout(context.Background(), config.Server.ShutdownTimeout)
 	defer cancel()
@@ -115,6 +307,105 @@ func main() {
 	}
 }
 
+// connectDatabase applies the startup policy to connect, which opens the
+// database and installs it in the repositories. Under StartupDegraded a
+// failure is handed to degrade and connect is retried in the background until
+// it succeeds or ctx ends; the other policies exit when they give up.
+func connectDatabase(ctx context.Context, config configs.DatabaseConfig, logger *logrus.Logger, connect func() error, degrade func(error)) {
+	logRetry := func(attempt int, err error, wait time.Duration) {
+		logger.WithError(err).WithFields(logrus.Fields{
+			"attempt": attempt,
+			"retryIn": wait,
+		}).Warn("⚠️ MongoDB not reachable, retrying")
+	}
+	
+	switch config.StartupPolicy {
+	case configs.StartupFail:
+		if err := connect(); err != nil {
+			logger.WithError(err).Fatal("💥 Failed to connect to MongoDB")
+		}
+	case configs.StartupRetry:
+		retryCtx, cancel := context.WithTimeout(ctx, config.StartupTimeout)
+		defer cancel()
+		if err := repository.RetryWithBackoff(retryCtx, config.RetryInitialBackoff, config.RetryMaxBackoff, connect, logRetry); err != nil {
+			logger.WithError(err).Fatal("💥 Gave up connecting to MongoDB")
+		}
+	case configs.StartupDegraded:
+		err := connect()
+		if err == nil {
+			break
+		}
+		logger.WithError(err).Warn("⚠️ MongoDB unreachable, starting degraded and reconnecting in the background")
+		degrade(err)
+		go func() {
+			if err := repository.RetryWithBackoff(ctx, config.RetryInitialBackoff, config.RetryMaxBackoff, connect, logRetry); err != nil {
+				logger.WithError(err).Warn("⚠️ Stopped reconnecting to MongoDB")
+				return
+			}
+			logger.Info("✅ Connected to MongoDB, leaving degraded mode")
+		}()
+		return
+	default:
+		logger.WithField("policy", config.StartupPolicy).Fatal("💥 Unknown database startup policy")
+	}
+	
+	logger.Info("✅ Connected to MongoDB successfully")
+}
+
+// seedMemoryCategories loads the category fixtures of a fixture directory
+// into repo. A single fixture file only holds products, and fixtures without
+// categories leave the tree empty; until a category is created, products
+// may then use any category.
+func seedMemoryCategories(repo *repository.MemoryCategoryRepository, config configs.DatabaseConfig, logger *logrus.Logger) {
+	if info, err := os.Stat(config.FixturesPath); err != nil || !info.IsDir() {
+		return
+	}
+	
+	categories, err := repository.LoadCategoryFixtures(config.FixturesPath, config.CategoryCollection)
+	if errors.Is(err, repository.ErrNoFixtures) {
+		logger.WithField("path", config.FixturesPath).Warn("⚠️ No category fixtures, starting with an empty category tree")
+		return
+	}
+	if err != nil {
+		logger.WithError(err).Fatal("💥 Failed to load category fixtures")
+	}
+	if err := repo.Seed(categories); err != nil {
+		logger.WithError(err).Fatal("💥 Invalid category fixtures")
+	}
+	
+	logger.WithFields(logrus.Fields{
+		"path":       config.FixturesPath,
+		"categories": len(categories),
+	}).Info("🌱 Seeded categories from fixtures")
+}
+
+// seedMemoryRepository loads the configured fixtures into repo, validating
+// them like API input, and optionally writes later changes back to disk
+func seedMemoryRepository(repo *repository.MemoryProductRepository, config configs.DatabaseConfig, logger *logrus.Logger) {
+	products, err := repository.LoadProductFixtures(config.FixturesPath, config.Collection)
+	if err != nil {
+		logger.WithError(err).Fatal("💥 Failed to load fixtures")
+	}
+	if err := repo.Seed(products, services.ValidateProduct); err != nil {
+		logger.WithError(err).Fatal("💥 Invalid fixtures")
+	}
+	
+	logger.WithFields(logrus.Fields{
+		"path":     config.FixturesPath,
+		"products": len(products),
+	}).Info("🌱 Seeded in-memory repository from fixtures")
+	
+	if config.PersistFixtures {
+		onError := func(err error) {
+			logger.WithError(err).Error("💥 Failed to persist fixtures")
+		}
+		if err := repo.PersistTo(config.FixturesPath, onError); err != nil {
+			logger.WithError(err).Fatal("💥 Cannot persist fixtures")
+		}
+		logger.WithField("path", config.FixturesPath).Info("💾 Writing changes back to fixtures")
+	}
+}
+
 // setupLogger configures the application logger
 func setupLogger(config *configs.Config) *logrus.Logger {
 	logger := logrus.New()
@@ -156,14 +447,55 @@ func setupLogger(config *configs.Config) *logrus.Logger {
 }
 
 // setupRoutes configures all API routes
-func setupRoutes(e *echo.Echo, productHandler *handlers.ProductHandler) {
+func setupRoutes(e *echo.Echo, productHandler *handlers.ProductHandler, reservationHandler *handlers.ReservationHandler, pricingHandler *handlers.PricingHandler, categoryHandler *handlers.CategoryHandler, webhookHandler *handlers.WebhookHandler, registry *metrics.Registry) {
 	// API v1 routes
 	v1 := e.Group("/api/v1")
 	{
 		// Product routes
 		v1.GET("/products", productHandler.GetProducts)
+		v1.GET("/products/search", productHandler.SearchProducts)
 		v1.GET("/products/:id", productHandler.GetProduct)
 		v1.POST("/products", productHandler.CreateProduct)
+		v1.POST("/products\\:batchGet", productHandler.BatchGetProducts)
+		v1.POST("/products\\:import", productHandler.ImportProducts)
+		v1.GET("/products\\:export", productHandler.ExportProducts)
+		v1.PUT("/products/:id", productHandler.UpdateProduct)
+		v1.PATCH("/products/:id", productHandler.PatchProduct)
+		v1.DELETE("/products/:id", productHandler.DeleteProduct)
+		v1.POST("/products/:id/activate", productHandler.ActivateProduct)
+		v1.POST("/products/:id/deactivate", productHandler.DeactivateProduct)
+		v1.GET("/products/:id/history", productHandler.GetProductHistory)
+		v1.POST("/products/:id/restore", productHandler.RestoreProduct)
+		
+		// Stock reservation routes
+		v1.POST("/reservations", reservationHandler.CreateReservation)
+		v1.GET("/reservations/:id", reservationHandler.GetReservation)
+		v1.POST("/reservations/:id/confirm", reservationHandler.ConfirmReservation)
+		v1.POST("/reservations/:id/release", reservationHandler.ReleaseReservation)
+		
+		// Price list and effective price routes
+		v1.GET("/products/:id/price", pricingHandler.GetProductPrice)
+		v1.POST("/price-lists", pricingHandler.CreatePriceList)
+		v1.GET("/price-lists", pricingHandler.GetPriceLists)
+		v1.GET("/price-lists/:id", pricingHandler.GetPriceList)
+		v1.PUT("/price-lists/:id", pricingHandler.UpdatePriceList)
+		v1.DELETE("/price-lists/:id", pricingHandler.DeletePriceList)
+		
+		// Category tree routes
+		v1.POST("/categories", categoryHandler.CreateCategory)
+		v1.GET("/categories", categoryHandler.GetCategories)
+		v1.GET("/categories/:slug", categoryHandler.GetCategory)
+		v1.PUT("/categories/:slug", categoryHandler.UpdateCategory)
+		v1.DELETE("/categories/:slug", categoryHandler.DeleteCategory)
+		
+		// Webhook subscription and delivery routes
+		v1.POST("/webhooks", webhookHandler.CreateWebhook)
+		v1.GET("/webhooks", webhookHandler.GetWebhooks)
+		v1.GET("/webhooks/dead-letters", webhookHandler.GetDeadLetters)
+		v1.GET("/webhooks/:id", webhookHandler.GetWebhook)
+		v1.DELETE("/webhooks/:id", webhookHandler.DeleteWebhook)
+		v1.GET("/webhooks/:id/deliveries", webhookHandler.GetDeliveries)
+		v1.POST("/webhooks/:id/deliveries/:deliveryId/redeliver", webhookHandler.RedeliverDelivery)
 	}
 	
 	// Legacy routes for backward compatibility
@@ -171,10 +503,17 @@ func setupRoutes(e *echo.Echo, productHandler *handlers.ProductHandler) {
 	e.GET("/products/:id", productHandler.GetProduct)
 	e.POST("/products", productHandler.CreateProduct)
 	
-	// Health and monitoring routes (support both GET and HEAD for Docker health checks)
+	// Probes and health (support both GET and HEAD for Docker health checks).
+	// /livez and /readyz are cheap enough for frequent polling; /health
+	// reports every dependency in detail.
+	e.GET("/livez", productHandler.GetLiveness)
+	e.HEAD("/livez", productHandler.GetLiveness)
+	e.GET("/readyz", productHandler.GetReadiness)
+	e.HEAD("/readyz", productHandler.GetReadiness)
 	e.GET("/health", productHandler.GetHealth)
 	e.HEAD("/health", productHandler.GetHealth)
-	e.GET("/metrics", productHandler.GetMetrics)
+	e.GET("/metrics", handlers.PrometheusMetrics(registry))
+	e.GET("/metrics.json", productHandler.GetMetrics)
 	
 	// Root endpoint
 	e.GET("/", func(c echo.Context) error {
@@ -184,10 +523,19 @@ func setupRoutes(e *echo.Echo, productHandler *handlers.ProductHandler) {
 			"environment": os.Getenv("ENVIRONMENT"),
 			"timestamp":   time.Now(),
 			"endpoints": map[string]string{
-				"health":   "/health",
-				"metrics":  "/metrics",
-				"products": "/products",
-				"api_v1":   "/api/v1",
+				"liveness":     "/livez",
+				"readiness":    "/readyz",
+				"health":       "/health",
+				"metrics":      "/metrics",
+				"metrics_json": "/metrics.json",
+				"products":     "/products",
+				"search":       "/api/v1/products/search",
+				"batch":        "/api/v1/products:batchGet",
+				"import":       "/api/v1/products:import",
+				"export":       "/api/v1/products:export",
+				"price_lists":  "/api/v1/price-lists",
+				"categories":   "/api/v1/categories",
+				"api_v1":       "/api/v1",
 			},
 		})
 	})
@@ -0,0 +1,66 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/product-api-v2/internal/models"
+)
+
+// FileSink appends each message payload to a file as one NDJSON line. It
+// is meant for local development and tests.
+type FileSink struct {
+	path  string
+	mutex sync.Mutex
+}
+
+// NewFileSink creates a sink appending to path, creating the file if needed
+func NewFileSink(path string) (*FileSink, error) {
+	if path == "" {
+		return nil, errors.New("file sink needs a path")
+	}
+
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	if err := file.Close(); err != nil {
+		return nil, err
+	}
+
+	return &FileSink{path: path}, nil
+}
+
+// Publish appends messages and syncs the file
+func (s *FileSink) Publish(ctx context.Context, messages []*models.OutboxMessage) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	var lines strings.Builder
+	for _, message := range messages {
+		lines.WriteString(message.Payload)
+		lines.WriteByte('\n')
+	}
+
+	file, err := os.OpenFile(s.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := file.WriteString(lines.String()); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// Close does nothing; the file is only open while publishing
+func (s *FileSink) Close() error {
+	return nil
+}
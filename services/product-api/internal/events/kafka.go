@@ -0,0 +1,61 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/product-api-v2/internal/models"
+	"github.com/segmentio/kafka-go"
+)
+
+// KafkaSink publishes messages to a Kafka topic. Messages are keyed by
+// entity, so the events of one entity land in one partition in order.
+type KafkaSink struct {
+	writer *kafka.Writer
+}
+
+// NewKafkaSink creates a sink writing to topic on brokers
+func NewKafkaSink(brokers []string, topic string) (*KafkaSink, error) {
+	if len(brokers) == 0 {
+		return nil, errors.New("kafka sink needs at least one broker")
+	}
+	if topic == "" {
+		return nil, errors.New("kafka sink needs a topic")
+	}
+
+	return &KafkaSink{
+		writer: &kafka.Writer{
+			Addr:                   kafka.TCP(brokers...),
+			Topic:                  topic,
+			Balancer:               &kafka.Hash{},
+			RequiredAcks:           kafka.RequireAll,
+			MaxAttempts:            1, // the relay retries with backoff
+			BatchTimeout:           10 * time.Millisecond,
+			AllowAutoTopicCreation: true,
+		},
+	}, nil
+}
+
+// Publish writes messages and waits for the brokers to acknowledge them
+func (s *KafkaSink) Publish(ctx context.Context, messages []*models.OutboxMessage) error {
+	records := make([]kafka.Message, 0, len(messages))
+	for _, message := range messages {
+		record := kafka.Message{
+			Key:   []byte(message.Key),
+			Value: []byte(message.Payload),
+			Time:  message.CreatedAt,
+		}
+		for name, value := range messageHeaders(message) {
+			record.Headers = append(record.Headers, kafka.Header{Key: name, Value: []byte(value)})
+		}
+		records = append(records, record)
+	}
+
+	return s.writer.WriteMessages(ctx, records...)
+}
+
+// Close flushes and closes the writer
+func (s *KafkaSink) Close() error {
+	return s.writer.Close()
+}
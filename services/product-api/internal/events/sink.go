@@ -0,0 +1,61 @@
+// Package events publishes the change-data events recorded in the outbox to
+// the systems that consume them
+package events
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/product-api-v2/configs"
+	"github.com/product-api-v2/internal/models"
+)
+
+// Sink delivers outbox messages to consumers. Publish delivers messages in
+// order and returns an error unless all of them were delivered; messages
+// may then be delivered again, so consumers deduplicate on the idempotency
+// key.
+type Sink interface {
+	Publish(ctx context.Context, messages []*models.OutboxMessage) error
+	Close() error
+}
+
+// Headers that carry event metadata alongside the payload
+const (
+	HeaderEventID        = "X-Event-Id"
+	HeaderEventType      = "X-Event-Type"
+	HeaderSchemaVersion  = "X-Event-Schema-Version"
+	HeaderIdempotencyKey = "Idempotency-Key"
+)
+
+// NewSink creates the sink selected in config, or returns nil when none is
+func NewSink(config configs.EventsConfig) (Sink, error) {
+	var sink Sink
+	var err error
+	switch config.Sink {
+	case "":
+		return nil, nil
+	case configs.SinkKafka:
+		sink, err = NewKafkaSink(config.KafkaBrokers, config.Topic)
+	case configs.SinkWebhook:
+		sink, err = NewWebhookSink(config.WebhookURL, config.WebhookTimeout)
+	case configs.SinkFile:
+		sink, err = NewFileSink(config.FilePath)
+	default:
+		return nil, fmt.Errorf("unknown event sink %q", config.Sink)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s event sink: %w", config.Sink, err)
+	}
+	return sink, nil
+}
+
+// messageHeaders returns the metadata headers of message
+func messageHeaders(message *models.OutboxMessage) map[string]string {
+	return map[string]string{
+		HeaderEventID:        message.EventID,
+		HeaderEventType:      message.Type,
+		HeaderSchemaVersion:  strconv.Itoa(message.SchemaVersion),
+		HeaderIdempotencyKey: message.IdempotencyKey,
+	}
+}
@@ -0,0 +1,73 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/product-api-v2/internal/models"
+)
+
+// WebhookSink posts each message to a URL as a JSON request, one at a time
+// and in order. Any status outside 2xx counts as a failed delivery.
+type WebhookSink struct {
+	url    string
+	client *http.Client
+}
+
+// NewWebhookSink creates a sink posting to url, giving up on a request
+// after timeout
+func NewWebhookSink(url string, timeout time.Duration) (*WebhookSink, error) {
+	if url == "" {
+		return nil, errors.New("webhook sink needs a URL")
+	}
+
+	return &WebhookSink{
+		url:    url,
+		client: &http.Client{Timeout: timeout},
+	}, nil
+}
+
+// Publish posts messages until one fails
+func (s *WebhookSink) Publish(ctx context.Context, messages []*models.OutboxMessage) error {
+	for _, message := range messages {
+		if err := s.post(ctx, message); err != nil {
+			return fmt.Errorf("event %s: %w", message.EventID, err)
+		}
+	}
+	return nil
+}
+
+// post delivers one message
+func (s *WebhookSink) post(ctx context.Context, message *models.OutboxMessage) error {
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, strings.NewReader(message.Payload))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	for name, value := range messageHeaders(message) {
+		request.Header.Set(name, value)
+	}
+
+	response, err := s.client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	io.Copy(io.Discard, response.Body)
+
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return fmt.Errorf("webhook responded %s", response.Status)
+	}
+	return nil
+}
+
+// Close releases idle connections
+func (s *WebhookSink) Close() error {
+	s.client.CloseIdleConnections()
+	return nil
+}
@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/product-api-v2/internal/models"
+	"github.com/product-api-v2/internal/services"
+	"github.com/sirupsen/logrus"
+)
+
+// CategoryHandler handles HTTP requests for the category tree
+type CategoryHandler struct {
+	service *services.CategoryService
+	logger  *logrus.Logger
+}
+
+// NewCategoryHandler creates a new category handler
+func NewCategoryHandler(service *services.CategoryService, logger *logrus.Logger) *CategoryHandler {
+	return &CategoryHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// CreateCategory handles POST /categories
+func (h *CategoryHandler) CreateCategory(c echo.Context) error {
+	var category models.Category
+
+	if err := c.Bind(&category); err != nil {
+		return writeErrorResponse(c, http.StatusBadRequest, "invalid_json", "Invalid JSON format")
+	}
+
+	ctx := c.Request().Context()
+	if err := h.service.CreateCategory(ctx, &category); err != nil {
+		return respondServiceError(c, err, "Failed to create category")
+	}
+
+	return respondVersioned(c, http.StatusCreated, category.Version, category)
+}
+
+// GetCategories handles GET /categories, returning the tree with product
+// counts per node
+func (h *CategoryHandler) GetCategories(c echo.Context) error {
+	ctx := c.Request().Context()
+	response, err := h.service.GetCategoryTree(ctx)
+	if err != nil {
+		return respondServiceError(c, err, "Failed to retrieve categories")
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+// GetCategory handles GET /categories/:slug
+func (h *CategoryHandler) GetCategory(c echo.Context) error {
+	ctx := c.Request().Context()
+	category, err := h.service.GetCategory(ctx, c.Param("slug"))
+	if err != nil {
+		return respondServiceError(c, err, "Failed to retrieve category")
+	}
+
+	return respondVersioned(c, http.StatusOK, category.Version, category)
+}
+
+// UpdateCategory handles PUT /categories/:slug
+func (h *CategoryHandler) UpdateCategory(c echo.Context) error {
+	var category models.Category
+
+	if err := c.Bind(&category); err != nil {
+		return writeErrorResponse(c, http.StatusBadRequest, "invalid_json", "Invalid JSON format")
+	}
+
+	ifMatch, err := parseIfMatch(c)
+	if err != nil {
+		return writeErrorResponse(c, http.StatusBadRequest, "invalid_precondition", err.Error())
+	}
+
+	ctx := c.Request().Context()
+	updated, err := h.service.UpdateCategory(ctx, c.Param("slug"), &category, ifMatch)
+	if err != nil {
+		return respondServiceError(c, err, "Failed to update category")
+	}
+
+	return respondVersioned(c, http.StatusOK, updated.Version, updated)
+}
+
+// DeleteCategory handles DELETE /categories/:slug
+func (h *CategoryHandler) DeleteCategory(c echo.Context) error {
+	slug := c.Param("slug")
+	ctx := c.Request().Context()
+	if err := h.service.DeleteCategory(ctx, slug); err != nil {
+		return respondServiceError(c, err, "Failed to delete category")
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"message": "Category deleted successfully",
+		"slug":    slug,
+	})
+}
@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+// entityTag formats a resource version as a strong entity tag
+func entityTag(version int64) string {
+	return `"` + strconv.FormatInt(version, 10) + `"`
+}
+
+// contentTag formats the entity tag of a body that also carries fields of
+// related resources: the version, which If-Match compares, followed by a
+// digest of the encoded body, which changes along with those resources
+func contentTag(version int64, body []byte) string {
+	sum := sha256.Sum256(body)
+	return `"` + strconv.FormatInt(version, 10) + "-" + hex.EncodeToString(sum[:8]) + `"`
+}
+
+// parseIfMatch reads the expected version from the If-Match header. A missing
+// header or "*" places no constraint on the version and yields nil.
+func parseIfMatch(c echo.Context) (*int64, error) {
+	header := strings.TrimSpace(c.Request().Header.Get("If-Match"))
+	if header == "" || header == "*" {
+		return nil, nil
+	}
+
+	if strings.Contains(header, ",") {
+		return nil, fmt.Errorf("If-Match must contain a single entity tag")
+	}
+
+	if len(header) < 2 || header[0] != '"' || header[len(header)-1] != '"' {
+		return nil, fmt.Errorf("If-Match must be a quoted entity tag")
+	}
+
+	// Only the version part of a content tag is compared
+	value := header[1 : len(header)-1]
+	if i := strings.IndexByte(value, '-'); i >= 0 {
+		value = value[:i]
+	}
+
+	version, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || version < 0 {
+		return nil, fmt.Errorf("If-Match does not contain a valid entity tag")
+	}
+
+	return &version, nil
+}
+
+// respondVersioned writes body with the ETag for version
+func respondVersioned(c echo.Context, status int, version int64, body interface{}) error {
+	return respondTagged(c, status, entityTag(version), body)
+}
+
+// respondDerived writes body like respondVersioned, for bodies that include
+// fields of related resources, such as a variant's parent or a product's
+// variants. Its tag covers the whole body so a change to those resources is
+// not answered with 304 Not Modified.
+func respondDerived(c echo.Context, status int, version int64, body interface{}) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	return respondTagged(c, status, contentTag(version, data), body)
+}
+
+// respondTagged writes body with tag as its ETag, or 304 Not Modified to a
+// GET whose If-None-Match already lists it
+func respondTagged(c echo.Context, status int, tag string, body interface{}) error {
+	c.Response().Header().Set("ETag", tag)
+
+	if c.Request().Method == http.MethodGet && ifNoneMatch(c.Request().Header.Get("If-None-Match"), tag) {
+		return c.NoContent(http.StatusNotModified)
+	}
+
+	return c.JSON(status, body)
+}
+
+// ifNoneMatch reports whether tag is listed in an If-None-Match header
+func ifNoneMatch(header, tag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == tag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,18 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/product-api-v2/internal/metrics"
+)
+
+// PrometheusMetrics returns a handler for GET /metrics that renders registry
+// in the Prometheus text exposition format
+func PrometheusMetrics(registry *metrics.Registry) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		c.Response().Header().Set(echo.HeaderContentType, metrics.ContentType)
+		c.Response().WriteHeader(http.StatusOK)
+		return registry.WriteText(c.Response())
+	}
+}
@@ -0,0 +1,28 @@
+package handlers
+
+import (
+	"github.com/labstack/echo/v4"
+	"github.com/product-api-v2/internal/models"
+)
+
+// pageLinks builds the URLs of the neighbouring pages by replacing the
+// cursor in the current request, keeping its filters, sort and page size
+func pageLinks(c echo.Context, next, prev string) *models.PageLinks {
+	if next == "" && prev == "" {
+		return nil
+	}
+
+	link := func(cursor string) string {
+		if cursor == "" {
+			return ""
+		}
+		target := *c.Request().URL
+		query := target.Query()
+		query.Set("cursor", cursor)
+		query.Del("page")
+		target.RawQuery = query.Encode()
+		return target.RequestURI()
+	}
+
+	return &models.PageLinks{Next: link(next), Prev: link(prev)}
+}
@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/product-api-v2/internal/models"
+	"github.com/product-api-v2/internal/services"
+	"github.com/sirupsen/logrus"
+)
+
+// PricingHandler handles HTTP requests for price lists and effective prices
+type PricingHandler struct {
+	service *services.PricingService
+	logger  *logrus.Logger
+}
+
+// NewPricingHandler creates a new pricing handler
+func NewPricingHandler(service *services.PricingService, logger *logrus.Logger) *PricingHandler {
+	return &PricingHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// CreatePriceList handles POST /price-lists
+func (h *PricingHandler) CreatePriceList(c echo.Context) error {
+	var priceList models.PriceList
+
+	if err := c.Bind(&priceList); err != nil {
+		return writeErrorResponse(c, http.StatusBadRequest, "invalid_json", "Invalid JSON format")
+	}
+
+	ctx := c.Request().Context()
+	if err := h.service.CreatePriceList(ctx, &priceList); err != nil {
+		return respondServiceError(c, err, "Failed to create price list")
+	}
+
+	return respondVersioned(c, http.StatusCreated, priceList.Version, priceList)
+}
+
+// GetPriceLists handles GET /price-lists
+func (h *PricingHandler) GetPriceLists(c echo.Context) error {
+	ctx := c.Request().Context()
+	response, err := h.service.GetPriceLists(ctx)
+	if err != nil {
+		return respondServiceError(c, err, "Failed to retrieve price lists")
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+// GetPriceList handles GET /price-lists/:id
+func (h *PricingHandler) GetPriceList(c echo.Context) error {
+	ctx := c.Request().Context()
+	priceList, err := h.service.GetPriceList(ctx, c.Param("id"))
+	if err != nil {
+		return respondServiceError(c, err, "Failed to retrieve price list")
+	}
+
+	return respondVersioned(c, http.StatusOK, priceList.Version, priceList)
+}
+
+// UpdatePriceList handles PUT /price-lists/:id
+func (h *PricingHandler) UpdatePriceList(c echo.Context) error {
+	var priceList models.PriceList
+
+	if err := c.Bind(&priceList); err != nil {
+		return writeErrorResponse(c, http.StatusBadRequest, "invalid_json", "Invalid JSON format")
+	}
+
+	ifMatch, err := parseIfMatch(c)
+	if err != nil {
+		return writeErrorResponse(c, http.StatusBadRequest, "invalid_precondition", err.Error())
+	}
+
+	ctx := c.Request().Context()
+	updated, err := h.service.UpdatePriceList(ctx, c.Param("id"), &priceList, ifMatch)
+	if err != nil {
+		return respondServiceError(c, err, "Failed to update price list")
+	}
+
+	return respondVersioned(c, http.StatusOK, updated.Version, updated)
+}
+
+// DeletePriceList handles DELETE /price-lists/:id
+func (h *PricingHandler) DeletePriceList(c echo.Context) error {
+	priceListID := c.Param("id")
+	ctx := c.Request().Context()
+	if err := h.service.DeletePriceList(ctx, priceListID); err != nil {
+		return respondServiceError(c, err, "Failed to delete price list")
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"message":     "Price list deleted successfully",
+		"priceListId": priceListID,
+	})
+}
+
+// GetProductPrice handles GET /products/:id/price?at=&tier=&qty=. at is an
+// RFC 3339 time and defaults to now; qty defaults to 1.
+func (h *PricingHandler) GetProductPrice(c echo.Context) error {
+	at := time.Now().UTC()
+	if value := c.QueryParam("at"); value != "" {
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return writeErrorResponse(c, http.StatusBadRequest, "invalid_parameter", "at must be an RFC 3339 time")
+		}
+		at = parsed
+	}
+
+	quantity := 1
+	if value := c.QueryParam("qty"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			return writeErrorResponse(c, http.StatusBadRequest, "invalid_parameter", "qty must be an integer")
+		}
+		quantity = parsed
+	}
+
+	ctx := c.Request().Context()
+	price, err := h.service.ResolvePrice(ctx, c.Param("id"), at, c.QueryParam("tier"), quantity)
+	if err != nil {
+		return respondServiceError(c, err, "Failed to resolve product price")
+	}
+
+	return c.JSON(http.StatusOK, price)
+}
@@ -1,9 +1,11 @@
 package handlers
 
 import (
+	"encoding/json"
+	"fmt"
 	"net/http"
 	"strconv"
-	"time"
+	"strings"
 
 	"github.com/labstack/echo/v4"
 	"github.com/product-api-v2/internal/models"
@@ -12,6 +14,9 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
+// maxBatchSize caps the number of IDs accepted by a batch lookup
+const maxBatchSize = 100
+
 // ProductHandler handles HTTP requests for products
 type ProductHandler struct {
 	service *services.ProductService
@@ -35,27 +40,71 @@ func (h *ProductHandler) GetProduct(c echo.Context) error {
 	}
 	
 	ctx := c.Request().Context()
-	product, err := h.service.GetProduct(ctx, productID)
-	if err != nil {
-		// Check if it's a not found error
-		if err.Error() == "product with ID "+productID+" not found" {
-			return h.errorResponse(c, http.StatusNotFound, "product_not_found", err.Error())
+	
+	// view= or fields= narrow the response and the database read
+	view, fields := c.QueryParam("view"), c.QueryParam("fields")
+	if view != "" || fields != "" {
+		selected, err := services.ResolveProductFields(view, fields, models.ViewFull)
+		if err != nil {
+			return h.handleServiceError(c, err, "Invalid field selection")
 		}
-		
-		// Check if it's an availability error
-		if err.Error() == "product "+productID+" is not available" {
-			return h.errorResponse(c, http.StatusGone, "product_unavailable", err.Error())
+		product, err := h.service.GetProductFields(ctx, productID, selected)
+		if err != nil {
+			return h.handleServiceError(c, err, "Failed to retrieve product")
 		}
-		
-		// Other errors are internal server errors
-		return h.errorResponse(c, http.StatusInternalServerError, "internal_error", "Failed to retrieve product")
+		return respondDerived(c, http.StatusOK, product.Version, product)
 	}
 	
-	return c.JSON(http.StatusOK, product)
+	product, err := h.service.GetProduct(ctx, productID)
+	if err != nil {
+		return h.handleServiceError(c, err, "Failed to retrieve product")
+	}
+	
+	return respondDerived(c, http.StatusOK, product.Version, product)
+}
+
+// BatchGetProducts handles POST /products:batchGet
+func (h *ProductHandler) BatchGetProducts(c echo.Context) error {
+	var request models.ProductBatchRequest
+
+	if err := c.Bind(&request); err != nil {
+		return h.errorResponse(c, http.StatusBadRequest, "invalid_json", "Invalid JSON format")
+	}
+
+	return h.batchGetProducts(c, request.ProductIDs)
+}
+
+// batchGetProducts validates the requested IDs and runs the batch lookup
+func (h *ProductHandler) batchGetProducts(c echo.Context, productIDs []string) error {
+	if len(productIDs) == 0 {
+		return h.errorResponse(c, http.StatusBadRequest, "missing_parameter", "At least one product ID is required")
+	}
+
+	if len(productIDs) > maxBatchSize {
+		return h.errorResponse(c, http.StatusBadRequest, "batch_too_large",
+			fmt.Sprintf("A batch lookup accepts at most %d product IDs", maxBatchSize))
+	}
+
+	ctx := c.Request().Context()
+	response, err := h.service.GetProductsByIDs(ctx, productIDs)
+	if err != nil {
+		return h.handleServiceError(c, err, "Failed to retrieve products")
+	}
+
+	return c.JSON(http.StatusOK, response)
 }
 
 // GetProducts handles GET /products
 func (h *ProductHandler) GetProducts(c echo.Context) error {
+	// GET /products?ids=a,b,c is an alias for the batch lookup
+	if ids := c.QueryParam("ids"); ids != "" {
+		productIDs := strings.Split(ids, ",")
+		for i := range productIDs {
+			productIDs[i] = strings.TrimSpace(productIDs[i])
+		}
+		return h.batchGetProducts(c, productIDs)
+	}
+
 	// Parse query parameters
 	filters := repository.ProductFilters{}
 	
@@ -69,6 +118,9 @@ func (h *ProductHandler) GetProducts(c echo.Context) error {
 		}
 	}
 	
+	// Price bounds are decimal amounts in currency, the default one if unset
+	filters.Currency = strings.ToUpper(c.QueryParam("currency"))
+	
 	if minPriceStr := c.QueryParam("min_price"); minPriceStr != "" {
 		if minPrice, err := strconv.ParseFloat(minPriceStr, 64); err == nil {
 			filters.MinPrice = &minPrice
@@ -94,10 +146,93 @@ func (h *ProductHandler) GetProducts(c echo.Context) error {
 		}
 	}
 	
+	// view= or fields= choose the product fields listed, summary by default
+	fields, err := services.ResolveProductFields(c.QueryParam("view"), c.QueryParam("fields"), models.ViewSummary)
+	if err != nil {
+		return h.handleServiceError(c, err, "Invalid field selection")
+	}
+	filters.Fields = fields
+	
+	// visibility=admin lists inactive products as well
+	if visibilityStr := c.QueryParam("visibility"); visibilityStr != "" {
+		visibility, err := repository.ParseVisibility(visibilityStr)
+		if err != nil {
+			return h.errorResponse(c, http.StatusBadRequest, "invalid_parameter", err.Error())
+		}
+		filters.Visibility = visibility
+	}
+	
+	// Parse sorting and keyset pagination parameters
+	sort, err := repository.ParseProductSort(c.QueryParam("sort"))
+	if err != nil {
+		return h.errorResponse(c, http.StatusBadRequest, "invalid_parameter", err.Error())
+	}
+	filters.Sort = sort
+	
+	if token := c.QueryParam("cursor"); token != "" {
+		cursor, err := repository.DecodeProductCursor(token, sort)
+		if err != nil {
+			return h.errorResponse(c, http.StatusBadRequest, "invalid_cursor", err.Error())
+		}
+		filters.Cursor = cursor
+	}
+	
 	ctx := c.Request().Context()
 	response, err := h.service.GetProducts(ctx, filters)
 	if err != nil {
-		return h.errorResponse(c, http.StatusInternalServerError, "internal_error", "Failed to retrieve products")
+		return h.handleServiceError(c, err, "Failed to retrieve products")
+	}
+	
+	response.Links = pageLinks(c, response.NextCursor, response.PrevCursor)
+	return c.JSON(http.StatusOK, response)
+}
+
+// SearchProducts handles GET /api/v1/products/search?q=
+func (h *ProductHandler) SearchProducts(c echo.Context) error {
+	filters := repository.ProductFilters{Category: c.QueryParam("category")}
+	
+	// Price bounds are decimal amounts in currency, the default one if unset
+	filters.Currency = strings.ToUpper(c.QueryParam("currency"))
+	
+	if minPriceStr := c.QueryParam("min_price"); minPriceStr != "" {
+		if minPrice, err := strconv.ParseFloat(minPriceStr, 64); err == nil {
+			filters.MinPrice = &minPrice
+		}
+	}
+	
+	if maxPriceStr := c.QueryParam("max_price"); maxPriceStr != "" {
+		if maxPrice, err := strconv.ParseFloat(maxPriceStr, 64); err == nil {
+			filters.MaxPrice = &maxPrice
+		}
+	}
+	
+	// Parse pagination parameters
+	if pageStr := c.QueryParam("page"); pageStr != "" {
+		if page, err := strconv.Atoi(pageStr); err == nil && page >= 0 {
+			filters.Page = page
+		}
+	}
+	
+	if pageSizeStr := c.QueryParam("page_size"); pageSizeStr != "" {
+		if pageSize, err := strconv.Atoi(pageSizeStr); err == nil && pageSize > 0 && pageSize <= 100 {
+			filters.PageSize = pageSize
+		}
+	}
+	
+	// prefix=true treats the last word as the start of a word, for autocomplete
+	prefix := false
+	if prefixStr := c.QueryParam("prefix"); prefixStr != "" {
+		parsed, err := strconv.ParseBool(prefixStr)
+		if err != nil {
+			return h.errorResponse(c, http.StatusBadRequest, "invalid_parameter", "prefix must be true or false")
+		}
+		prefix = parsed
+	}
+	
+	ctx := c.Request().Context()
+	response, err := h.service.SearchProducts(ctx, c.QueryParam("q"), prefix, filters)
+	if err != nil {
+		return h.handleServiceError(c, err, "Failed to search products")
 	}
 	
 	return c.JSON(http.StatusOK, response)
@@ -113,24 +248,237 @@ func (h *ProductHandler) CreateProduct(c echo.Context) error {
 	
 	ctx := c.Request().Context()
 	if err := h.service.CreateProduct(ctx, &product); err != nil {
-		if err.Error() == "product "+product.ProductID+" already exists" {
-			return h.errorResponse(c, http.StatusConflict, "product_exists", err.Error())
-		}
-		
-		// Check if it's a validation error
-		if err.Error()[:17] == "validation failed" {
-			return h.errorResponse(c, http.StatusBadRequest, "validation_error", err.Error())
-		}
-		
-		return h.errorResponse(c, http.StatusInternalServerError, "internal_error", "Failed to create product")
+		return h.handleServiceError(c, err, "Failed to create product")
 	}
 	
+	c.Response().Header().Set("ETag", entityTag(product.Version))
 	return c.JSON(http.StatusCreated, map[string]interface{}{
 		"message":   "Product created successfully",
 		"productId": product.ProductID,
 	})
 }
 
+// UpdateProduct handles PUT /products/:id
+func (h *ProductHandler) UpdateProduct(c echo.Context) error {
+	var product models.Product
+
+	if err := c.Bind(&product); err != nil {
+		return h.errorResponse(c, http.StatusBadRequest, "invalid_json", "Invalid JSON format")
+	}
+
+	ifMatch, err := parseIfMatch(c)
+	if err != nil {
+		return h.errorResponse(c, http.StatusBadRequest, "invalid_precondition", err.Error())
+	}
+
+	ctx := c.Request().Context()
+	updated, err := h.service.UpdateProduct(ctx, c.Param("id"), &product, ifMatch)
+	if err != nil {
+		return h.handleServiceError(c, err, "Failed to update product")
+	}
+
+	return respondDerived(c, http.StatusOK, updated.Version, updated)
+}
+
+// PatchProduct handles PATCH /products/:id with JSON merge patch semantics
+func (h *ProductHandler) PatchProduct(c echo.Context) error {
+	var patch map[string]interface{}
+
+	if err := json.NewDecoder(c.Request().Body).Decode(&patch); err != nil || patch == nil {
+		return h.errorResponse(c, http.StatusBadRequest, "invalid_json", "Patch body must be a JSON object")
+	}
+
+	ifMatch, err := parseIfMatch(c)
+	if err != nil {
+		return h.errorResponse(c, http.StatusBadRequest, "invalid_precondition", err.Error())
+	}
+
+	ctx := c.Request().Context()
+	updated, err := h.service.PatchProduct(ctx, c.Param("id"), patch, ifMatch)
+	if err != nil {
+		return h.handleServiceError(c, err, "Failed to patch product")
+	}
+
+	return respondDerived(c, http.StatusOK, updated.Version, updated)
+}
+
+// ActivateProduct handles POST /products/:id/activate
+func (h *ProductHandler) ActivateProduct(c echo.Context) error {
+	return h.setProductActive(c, true)
+}
+
+// DeactivateProduct handles POST /products/:id/deactivate
+func (h *ProductHandler) DeactivateProduct(c echo.Context) error {
+	return h.setProductActive(c, false)
+}
+
+// setProductActive changes the active flag of the product in the URL
+func (h *ProductHandler) setProductActive(c echo.Context, active bool) error {
+	ifMatch, err := parseIfMatch(c)
+	if err != nil {
+		return h.errorResponse(c, http.StatusBadRequest, "invalid_precondition", err.Error())
+	}
+
+	ctx := c.Request().Context()
+	product, err := h.service.SetProductActive(ctx, c.Param("id"), active, ifMatch)
+	if err != nil {
+		return h.handleServiceError(c, err, "Failed to change product state")
+	}
+
+	return respondDerived(c, http.StatusOK, product.Version, product)
+}
+
+// DeleteProduct handles DELETE /products/:id. Products are deactivated
+// unless ?hard=true is given, which removes them permanently.
+func (h *ProductHandler) DeleteProduct(c echo.Context) error {
+	hard := false
+	if hardStr := c.QueryParam("hard"); hardStr != "" {
+		parsed, err := strconv.ParseBool(hardStr)
+		if err != nil {
+			return h.errorResponse(c, http.StatusBadRequest, "invalid_parameter", "hard must be true or false")
+		}
+		hard = parsed
+	}
+
+	ifMatch, err := parseIfMatch(c)
+	if err != nil {
+		return h.errorResponse(c, http.StatusBadRequest, "invalid_precondition", err.Error())
+	}
+
+	productID := c.Param("id")
+	ctx := c.Request().Context()
+	if err := h.service.DeleteProduct(ctx, productID, hard, ifMatch); err != nil {
+		return h.handleServiceError(c, err, "Failed to delete product")
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"message":   "Product deleted successfully",
+		"productId": productID,
+		"hard":      hard,
+	})
+}
+
+// GetProductHistory handles GET /products/:id/history
+func (h *ProductHandler) GetProductHistory(c echo.Context) error {
+	ctx := c.Request().Context()
+	history, err := h.service.GetProductHistory(ctx, c.Param("id"))
+	if err != nil {
+		return h.handleServiceError(c, err, "Failed to retrieve product history")
+	}
+
+	return c.JSON(http.StatusOK, history)
+}
+
+// RestoreProduct handles POST /products/:id/restore with the version to
+// bring back in the body
+func (h *ProductHandler) RestoreProduct(c echo.Context) error {
+	var request models.RestoreRequest
+
+	if err := c.Bind(&request); err != nil {
+		return h.errorResponse(c, http.StatusBadRequest, "invalid_json", "Invalid JSON format")
+	}
+	if request.Version < 1 {
+		return h.errorResponse(c, http.StatusBadRequest, "invalid_parameter", "version must be a positive integer")
+	}
+
+	ifMatch, err := parseIfMatch(c)
+	if err != nil {
+		return h.errorResponse(c, http.StatusBadRequest, "invalid_precondition", err.Error())
+	}
+
+	ctx := c.Request().Context()
+	product, err := h.service.RestoreProduct(ctx, c.Param("id"), request.Version, ifMatch)
+	if err != nil {
+		return h.handleServiceError(c, err, "Failed to restore product")
+	}
+
+	return respondDerived(c, http.StatusOK, product.Version, product)
+}
+
+// ImportProducts handles POST /products:import. The format comes from
+// ?format= or the Content-Type header and the mode from ?mode=, which
+// defaults to upsert.
+func (h *ProductHandler) ImportProducts(c echo.Context) error {
+	format := c.QueryParam("format")
+	if format == "" {
+		format = formatFromMediaType(c.Request().Header.Get(echo.HeaderContentType))
+	}
+	if format == "" {
+		return h.errorResponse(c, http.StatusUnsupportedMediaType, "unsupported_format",
+			"Send text/csv or application/x-ndjson, or set format=csv|ndjson")
+	}
+
+	mode := c.QueryParam("mode")
+	if mode == "" {
+		mode = services.ImportUpsert
+	}
+
+	ctx := c.Request().Context()
+	report, err := h.service.ImportProducts(ctx, c.Request().Body, format, mode)
+	if err != nil {
+		if report == nil {
+			return h.handleServiceError(c, err, "Failed to import products")
+		}
+		// Part of the input was written before the failure; the report
+		// tells the client which rows made it
+		status, _, _ := mapServiceError(err, "")
+		return c.JSON(status, report)
+	}
+
+	return c.JSON(http.StatusOK, report)
+}
+
+// ExportProducts handles GET /products:export, streaming every product that
+// matches the category and active filters as CSV (the default) or NDJSON
+func (h *ProductHandler) ExportProducts(c echo.Context) error {
+	format := c.QueryParam("format")
+	if format == "" {
+		format = formatFromMediaType(c.Request().Header.Get(echo.HeaderAccept))
+	}
+	if format == "" {
+		format = services.FormatCSV
+	}
+
+	contentType := "text/csv; charset=utf-8"
+	switch format {
+	case services.FormatCSV:
+	case services.FormatNDJSON:
+		contentType = "application/x-ndjson"
+	default:
+		return h.errorResponse(c, http.StatusBadRequest, "invalid_parameter", "format must be csv or ndjson")
+	}
+
+	filters := repository.ProductFilters{Category: c.QueryParam("category")}
+	if activeStr := c.QueryParam("active"); activeStr != "" {
+		if active, err := strconv.ParseBool(activeStr); err == nil {
+			filters.Active = &active
+		}
+	}
+
+	response := c.Response()
+	response.Header().Set(echo.HeaderContentType, contentType)
+	response.Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=\"products.%s\"", format))
+	response.WriteHeader(http.StatusOK)
+
+	// The status is already sent, so a failure can only cut the stream short
+	if _, err := h.service.ExportProducts(c.Request().Context(), response, format, filters); err != nil {
+		h.logger.WithError(err).WithField("requestId", c.Get("requestId")).Error("💥 Product export interrupted")
+	}
+	return nil
+}
+
+// formatFromMediaType maps a Content-Type or Accept header to a catalog format
+func formatFromMediaType(header string) string {
+	switch {
+	case strings.Contains(header, "text/csv"):
+		return services.FormatCSV
+	case strings.Contains(header, "ndjson"), strings.Contains(header, "jsonl"):
+		return services.FormatNDJSON
+	default:
+		return ""
+	}
+}
+
 // GetHealth handles GET /health
 func (h *ProductHandler) GetHealth(c echo.Context) error {
 	ctx := c.Request().Context()
@@ -139,18 +487,31 @@ func (h *ProductHandler) GetHealth(c echo.Context) error {
 		return h.errorResponse(c, http.StatusInternalServerError, "health_check_failed", "Health check failed")
 	}
 	
-	// Return appropriate HTTP status based on health
+	// A degraded service still serves requests, so only an unhealthy one
+	// answers 503
 	status := http.StatusOK
-	if health.Status == "degraded" {
-		status = http.StatusPartialContent
-	} else if health.Status != "healthy" {
+	if health.Status == "unhealthy" {
 		status = http.StatusServiceUnavailable
 	}
 	
 	return c.JSON(status, health)
 }
 
-// GetMetrics handles GET /metrics (simple metrics endpoint)
+// GetLiveness handles GET /livez
+func (h *ProductHandler) GetLiveness(c echo.Context) error {
+	return c.JSON(http.StatusOK, h.service.Liveness())
+}
+
+// GetReadiness handles GET /readyz
+func (h *ProductHandler) GetReadiness(c echo.Context) error {
+	probe, ready := h.service.Readiness(c.Request().Context())
+	if !ready {
+		return c.JSON(http.StatusServiceUnavailable, probe)
+	}
+	return c.JSON(http.StatusOK, probe)
+}
+
+// GetMetrics handles GET /metrics.json (service metrics as JSON)
 func (h *ProductHandler) GetMetrics(c echo.Context) error {
 	metrics := h.service.GetMetrics()
 	return c.JSON(http.StatusOK, metrics)
@@ -158,33 +519,5 @@ func (h *ProductHandler) GetMetrics(c echo.Context) error {
 
 // errorResponse creates a standardized error response
 func (h *ProductHandler) errorResponse(c echo.Context, status int, errorCode, message string) error {
-	requestID := ""
-	if id := c.Get("requestId"); id != nil {
-		requestID = id.(string)
-	}
-	
-	errorResp := models.ErrorResponse{
-		Error:     errorCode,
-		Message:   message,
-		RequestID: requestID,
-		Timestamp: time.Now(),
-	}
-	
-	// Add additional context for certain errors
-	switch status {
-	case http.StatusBadRequest:
-		errorResp.Details = map[string]interface{}{
-			"hint": "Check your request parameters and try again",
-		}
-	case http.StatusNotFound:
-		errorResp.Details = map[string]interface{}{
-			"hint": "The requested resource was not found",
-		}
-	case http.StatusInternalServerError:
-		errorResp.Details = map[string]interface{}{
-			"hint": "An internal error occurred. Please try again later",
-		}
-	}
-	
-	return c.JSON(status, errorResp)
-}
\ No newline at end of file
+	return writeErrorResponse(c, status, errorCode, message)
+}
@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/product-api-v2/internal/models"
+	"github.com/product-api-v2/internal/services"
+	"github.com/sirupsen/logrus"
+)
+
+// ReservationHandler handles HTTP requests for stock reservations
+type ReservationHandler struct {
+	service *services.ReservationService
+	logger  *logrus.Logger
+}
+
+// NewReservationHandler creates a new reservation handler
+func NewReservationHandler(service *services.ReservationService, logger *logrus.Logger) *ReservationHandler {
+	return &ReservationHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// CreateReservation handles POST /reservations
+func (h *ReservationHandler) CreateReservation(c echo.Context) error {
+	var request models.ReservationRequest
+
+	if err := c.Bind(&request); err != nil {
+		return writeErrorResponse(c, http.StatusBadRequest, "invalid_json", "Invalid JSON format")
+	}
+
+	ctx := c.Request().Context()
+	reservation, err := h.service.CreateReservation(ctx, &request)
+	if err != nil {
+		return respondServiceError(c, err, "Failed to reserve stock")
+	}
+
+	return c.JSON(http.StatusCreated, reservation)
+}
+
+// GetReservation handles GET /reservations/:id
+func (h *ReservationHandler) GetReservation(c echo.Context) error {
+	ctx := c.Request().Context()
+	reservation, err := h.service.GetReservation(ctx, c.Param("id"))
+	if err != nil {
+		return respondServiceError(c, err, "Failed to retrieve reservation")
+	}
+
+	return c.JSON(http.StatusOK, reservation)
+}
+
+// ConfirmReservation handles POST /reservations/:id/confirm
+func (h *ReservationHandler) ConfirmReservation(c echo.Context) error {
+	ctx := c.Request().Context()
+	reservation, err := h.service.ConfirmReservation(ctx, c.Param("id"))
+	if err != nil {
+		return respondServiceError(c, err, "Failed to confirm reservation")
+	}
+
+	return c.JSON(http.StatusOK, reservation)
+}
+
+// ReleaseReservation handles POST /reservations/:id/release
+func (h *ReservationHandler) ReleaseReservation(c echo.Context) error {
+	ctx := c.Request().Context()
+	reservation, err := h.service.ReleaseReservation(ctx, c.Param("id"))
+	if err != nil {
+		return respondServiceError(c, err, "Failed to release reservation")
+	}
+
+	return c.JSON(http.StatusOK, reservation)
+}
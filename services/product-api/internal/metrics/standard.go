@@ -0,0 +1,52 @@
+package metrics
+
+import (
+	"strconv"
+	"time"
+)
+
+// HTTPMetrics are the request metrics recorded by the metrics middleware
+type HTTPMetrics struct {
+	requests *CounterVec
+	duration *HistogramVec
+	inFlight *GaugeVec
+}
+
+// NewHTTPMetrics registers the HTTP request metrics
+func NewHTTPMetrics(registry *Registry) *HTTPMetrics {
+	return &HTTPMetrics{
+		requests: registry.NewCounterVec("http_requests_total", "Total HTTP requests by route, method and status.", "method", "route", "status"),
+		duration: registry.NewHistogramVec("http_request_duration_seconds", "HTTP request latency in seconds.", nil, "method", "route"),
+		inFlight: registry.NewGaugeVec("http_requests_in_flight", "HTTP requests currently being served.", "method", "route"),
+	}
+}
+
+// Start marks a request as in flight and returns a function that records
+// its status and latency when it completes
+func (m *HTTPMetrics) Start(method, route string) func(status int) {
+	start := time.Now()
+	m.inFlight.Inc(method, route)
+
+	return func(status int) {
+		m.inFlight.Dec(method, route)
+		m.requests.Inc(method, route, strconv.Itoa(status))
+		m.duration.Observe(time.Since(start).Seconds(), method, route)
+	}
+}
+
+// RepositoryMetrics time repository operations
+type RepositoryMetrics struct {
+	duration *HistogramVec
+}
+
+// NewRepositoryMetrics registers the repository operation metrics
+func NewRepositoryMetrics(registry *Registry) *RepositoryMetrics {
+	return &RepositoryMetrics{
+		duration: registry.NewHistogramVec("repository_operation_duration_seconds", "Repository operation latency in seconds by operation and outcome.", nil, "operation", "outcome"),
+	}
+}
+
+// Observe records one repository call
+func (m *RepositoryMetrics) Observe(operation, outcome string, duration time.Duration) {
+	m.duration.Observe(duration.Seconds(), operation, outcome)
+}
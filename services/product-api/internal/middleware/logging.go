@@ -2,10 +2,13 @@ package middleware
 
 import (
 	"context"
+	"errors"
+	"net/http"
 	"strconv"
 	"time"
 
 	"github.com/labstack/echo/v4"
+	"github.com/product-api-v2/internal/metrics"
 	"github.com/sirupsen/logrus"
 )
 
@@ -86,18 +89,32 @@ func StructuredLoggingMiddleware(logger *logrus.Logger) echo.MiddlewareFunc {
 	}
 }
 
-// MetricsMiddleware collects basic request metrics
-func MetricsMiddleware() echo.MiddlewareFunc {
+// MetricsMiddleware records request counts, latency and in-flight requests
+// per route. Routes are labelled with their pattern, not the raw path, so
+// IDs do not explode the number of series.
+func MetricsMiddleware(httpMetrics *metrics.HTTPMetrics) echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
-			start := time.Now()
+			route := c.Path()
+			if route == "" {
+				route = "unmatched"
+			}
+			
+			done := httpMetrics.Start(c.Request().Method, route)
 			
 			err := next(c)
 			
-			// You could collect metrics here and send to a metrics service
-			// For now, we'll just add timing headers
-			duration := time.Since(start)
-			c.Response().Header().Set("X-Response-Time", duration.String())
+			// Errors returned to Echo are written after the middleware chain
+			// unwinds, so derive their status the same way the error handler will
+			status := c.Response().Status
+			if err != nil && !c.Response().Committed {
+				status = http.StatusInternalServerError
+				var httpErr *echo.HTTPError
+				if errors.As(err, &httpErr) {
+					status = httpErr.Code
+				}
+			}
+			done(status)
 			
 			return err
 		}
@@ -0,0 +1,57 @@
+package models
+
+import (
+	"time"
+)
+
+// Actions recorded in the change history
+const (
+	AuditCreate  = "create"
+	AuditUpdate  = "update"
+	AuditDelete  = "delete"
+	AuditRestore = "restore"
+
+	// AuditReserve marks events raised when a reservation sells out a product.
+	// Reservations are not recorded in the history.
+	AuditReserve = "reserve"
+)
+
+// FieldChange is the value of one field before and after a change. Nested
+// fields are named by their path, such as attributes.size; a missing value
+// means the field was not set.
+type FieldChange struct {
+	Field  string      `json:"field" bson:"field"`
+	Before interface{} `json:"before,omitempty" bson:"before"`
+	After  interface{} `json:"after,omitempty" bson:"after"`
+}
+
+// ProductChange is an entry of the append-only change history of a product.
+// Version is the product version the change produced, or for a deletion
+// the version that was deleted. Snapshot holds the product as stored after
+// the change, which is what restoring that version brings back; deletions
+// have none.
+type ProductChange struct {
+	ChangeID     string        `json:"changeId" bson:"changeId"`
+	ProductID    string        `json:"productId" bson:"productId"`
+	Version      int64         `json:"version" bson:"version"`
+	Action       string        `json:"action" bson:"action"`
+	Actor        string        `json:"actor" bson:"actor"`
+	RequestID    string        `json:"requestId,omitempty" bson:"requestId,omitempty"`
+	RestoredFrom int64         `json:"restoredFrom,omitempty" bson:"restoredFrom,omitempty"` // version a restore brought back
+	Changes      []FieldChange `json:"changes" bson:"changes"`
+	Snapshot     *Product      `json:"snapshot,omitempty" bson:"snapshot,omitempty"`
+	ChangedAt    time.Time     `json:"changedAt" bson:"changedAt"`
+}
+
+// ProductHistoryResponse represents the change history of a product, oldest
+// change first
+type ProductHistoryResponse struct {
+	ProductID string           `json:"productId"`
+	Changes   []*ProductChange `json:"changes"`
+	Total     int              `json:"total"`
+}
+
+// RestoreRequest represents the body of a restore request
+type RestoreRequest struct {
+	Version int64 `json:"version"`
+}
@@ -0,0 +1,36 @@
+package models
+
+import (
+	"time"
+)
+
+// Category is a node of the managed category tree. Products refer to it by
+// Slug, which never changes once the category exists.
+type Category struct {
+	Slug   string `json:"slug" bson:"slug"`
+	Name   string `json:"name" bson:"name"`                         // display name
+	Parent string `json:"parent,omitempty" bson:"parent,omitempty"` // slug of the parent; empty for a root
+	Active bool   `json:"active" bson:"active"`                     // products can only be assigned to active categories
+
+	Version   int64     `json:"version" bson:"version"`
+	CreatedAt time.Time `json:"createdAt" bson:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt" bson:"updatedAt"`
+}
+
+// CategoryNode is a category in the tree returned by category listings.
+// ProductCount counts the products assigned to the category itself and
+// SubtreeCount those of the whole subtree.
+type CategoryNode struct {
+	Category
+	ProductCount int             `json:"productCount"`
+	SubtreeCount int             `json:"subtreeCount"`
+	Children     []*CategoryNode `json:"children"`
+}
+
+// CategoryTreeResponse represents the category tree. Uncategorized counts
+// the products without a category or with one outside the tree.
+type CategoryTreeResponse struct {
+	Categories    []*CategoryNode `json:"categories"`
+	Total         int             `json:"total"`
+	Uncategorized int             `json:"uncategorized"`
+}
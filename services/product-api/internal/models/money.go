@@ -0,0 +1,221 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// DefaultCurrency is the currency of prices given without one: documents
+// stored before products had a currency, and clients that only send price
+const DefaultCurrency = "USD"
+
+// currencyExponents maps the ISO 4217 codes the catalog accepts to the
+// number of decimals of their minor unit
+var currencyExponents = map[string]int{
+	"AED": 2, "ARS": 2, "AUD": 2, "BHD": 3, "BOB": 2, "BRL": 2, "CAD": 2,
+	"CHF": 2, "CLP": 0, "CNY": 2, "COP": 2, "CRC": 2, "CZK": 2, "DKK": 2,
+	"DOP": 2, "EUR": 2, "GBP": 2, "GTQ": 2, "HKD": 2, "HUF": 2, "ILS": 2,
+	"INR": 2, "JOD": 3, "JPY": 0, "KRW": 0, "KWD": 3, "MXN": 2, "NOK": 2,
+	"NZD": 2, "OMR": 3, "PEN": 2, "PLN": 2, "PYG": 0, "SAR": 2, "SEK": 2,
+	"SGD": 2, "TND": 3, "TRY": 2, "USD": 2, "UYU": 2, "ZAR": 2,
+}
+
+// CurrencyExponent returns the number of decimals of the minor unit of an
+// ISO 4217 currency, and false for codes the catalog does not accept
+func CurrencyExponent(currency string) (int, bool) {
+	exponent, ok := currencyExponents[currency]
+	return exponent, ok
+}
+
+// exponentOf is CurrencyExponent for data already stored, where an unknown
+// or missing currency is read as DefaultCurrency
+func exponentOf(currency string) int {
+	if exponent, ok := currencyExponents[currency]; ok {
+		return exponent
+	}
+	return currencyExponents[DefaultCurrency]
+}
+
+// maxExactAmount bounds the scaled amounts a float64 holds exactly
+const maxExactAmount = 1 << 53
+
+// ToMinorUnits converts a decimal amount of currency to minor units. Amounts
+// with more decimals than the currency has are rejected, not rounded.
+func ToMinorUnits(amount float64, currency string) (int64, error) {
+	exponent, ok := CurrencyExponent(currency)
+	if !ok {
+		return 0, fmt.Errorf("unknown currency %q", currency)
+	}
+
+	scaled := amount * math.Pow10(exponent)
+	if math.IsNaN(scaled) || math.Abs(scaled) >= maxExactAmount {
+		return 0, fmt.Errorf("amount %v is out of range", amount)
+	}
+	minor := math.Round(scaled)
+	if math.Abs(scaled-minor) > 1e-6 {
+		return 0, fmt.Errorf("amount %v has more than %d decimals for %s", amount, exponent, currency)
+	}
+	return int64(minor), nil
+}
+
+// FromMinorUnits converts minor units of currency to a decimal amount. The
+// result is the nearest float64, so it is for display and old clients only.
+func FromMinorUnits(minor int64, currency string) float64 {
+	value, _ := strconv.ParseFloat(FormatAmount(minor, currency), 64)
+	return value
+}
+
+// FormatAmount writes minor units of currency as an exact decimal, such as
+// "12.50" for 1250 USD cents
+func FormatAmount(minor int64, currency string) string {
+	exponent := exponentOf(currency)
+	digits := strconv.FormatInt(minor, 10)
+	if exponent == 0 {
+		return digits
+	}
+
+	sign := ""
+	if strings.HasPrefix(digits, "-") {
+		sign, digits = "-", digits[1:]
+	}
+	if len(digits) <= exponent {
+		digits = strings.Repeat("0", exponent-len(digits)+1) + digits
+	}
+	point := len(digits) - exponent
+	return sign + digits[:point] + "." + digits[point:]
+}
+
+// DecimalAmount returns minor units of currency as the exact Decimal128
+// amount stored in Mongo
+func DecimalAmount(minor int64, currency string) primitive.Decimal128 {
+	amount, _ := primitive.ParseDecimal128FromBigInt(big.NewInt(minor), -exponentOf(currency))
+	return amount
+}
+
+// decimalToMinor converts a Decimal128 amount to minor units of currency,
+// rounding half away from zero below the minor unit
+func decimalToMinor(amount primitive.Decimal128, currency string) (int64, error) {
+	significand, exp, err := amount.BigInt()
+	if err != nil {
+		return 0, err
+	}
+
+	exp += exponentOf(currency)
+	if exp >= 0 {
+		significand.Mul(significand, new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(exp)), nil))
+	} else {
+		divisor := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(-exp)), nil)
+		quotient, remainder := new(big.Int).QuoRem(significand, divisor, new(big.Int))
+		if remainder.Mul(remainder.Abs(remainder), big.NewInt(2)).Cmp(divisor) >= 0 {
+			quotient.Add(quotient, big.NewInt(int64(significand.Sign())))
+		}
+		significand = quotient
+	}
+
+	if !significand.IsInt64() {
+		return 0, fmt.Errorf("amount %s is out of range", amount)
+	}
+	return significand.Int64(), nil
+}
+
+// CheckPrice reports price input NormalizePrice would have to round or
+// discard: an unknown currency, a decimal Price with more decimals than the
+// currency has, or a Price that disagrees with PriceMinor
+func (p *Product) CheckPrice() error {
+	currency := p.Currency
+	if currency == "" {
+		currency = DefaultCurrency
+	}
+	if _, ok := CurrencyExponent(currency); !ok {
+		return fmt.Errorf("product currency %q is not a supported ISO 4217 code", p.Currency)
+	}
+	if p.Price == 0 {
+		return nil
+	}
+
+	minor, err := ToMinorUnits(p.Price, currency)
+	if err != nil {
+		return fmt.Errorf("product price: %w", err)
+	}
+	if p.PriceMinor != 0 && p.PriceMinor != minor {
+		return fmt.Errorf("product price %v does not match priceMinor %d %s", p.Price, p.PriceMinor, currency)
+	}
+	return nil
+}
+
+// NormalizePrice settles the price fields. A product without a currency gets
+// DefaultCurrency and one given only the legacy decimal Price gets the
+// nearest minor units; Price is then recomputed from PriceMinor, so the two
+// always agree once stored.
+func (p *Product) NormalizePrice() {
+	if p.Currency == "" {
+		p.Currency = DefaultCurrency
+	}
+	if p.PriceMinor == 0 && p.Price != 0 {
+		p.PriceMinor = int64(math.Round(p.Price * math.Pow10(exponentOf(p.Currency))))
+	}
+	p.Price = FromMinorUnits(p.PriceMinor, p.Currency)
+}
+
+// MarshalBSON stores the price as minor units and currency, plus the exact
+// Decimal128 amount under price for readers that predate minor units
+func (p Product) MarshalBSON() ([]byte, error) {
+	type Stored Product // without these methods, and exported to be inlined
+
+	p.NormalizePrice()
+	return bson.Marshal(struct {
+		Stored `bson:",inline"`
+		Amount primitive.Decimal128 `bson:"price"`
+	}{Stored(p), DecimalAmount(p.PriceMinor, p.Currency)})
+}
+
+// UnmarshalBSON reads a stored product. Documents written before minor units
+// only have price, as a double or a decimal, which is converted here.
+func (p *Product) UnmarshalBSON(data []byte) error {
+	type Stored Product
+
+	var document struct {
+		Stored `bson:",inline"`
+		Amount bson.RawValue `bson:"price"`
+	}
+	if err := bson.Unmarshal(data, &document); err != nil {
+		return err
+	}
+	*p = Product(document.Stored)
+
+	if p.PriceMinor == 0 && document.Amount.Type != 0 {
+		minor, err := storedAmountToMinor(document.Amount, p.Currency)
+		if err != nil {
+			return fmt.Errorf("product %s: %w", p.ProductID, err)
+		}
+		p.PriceMinor = minor
+	}
+	if p.PriceMinor != 0 {
+		p.Price = FromMinorUnits(p.PriceMinor, p.Currency)
+	}
+	return nil
+}
+
+// storedAmountToMinor converts a stored price of any numeric BSON type
+func storedAmountToMinor(value bson.RawValue, currency string) (int64, error) {
+	switch value.Type {
+	case bsontype.Decimal128:
+		return decimalToMinor(value.Decimal128(), currency)
+	case bsontype.Double:
+		return int64(math.Round(value.Double() * math.Pow10(exponentOf(currency)))), nil
+	case bsontype.Int32, bsontype.Int64:
+		amount, _ := value.AsInt64OK()
+		return amount * int64(math.Pow10(exponentOf(currency))), nil
+	case bsontype.Null:
+		return 0, nil
+	}
+	return 0, errors.New("price is not a number")
+}
@@ -0,0 +1,86 @@
+package models
+
+import (
+	"time"
+)
+
+// DiscountType is how a price rule lowers the price of a product
+type DiscountType string
+
+const (
+	DiscountPercentage DiscountType = "percentage" // Percent off the base price
+	DiscountFixed      DiscountType = "fixed"      // AmountMinor off the base price
+)
+
+// PriceList is a set of price rules that applies during a validity window,
+// to every customer or only to some customer tiers
+type PriceList struct {
+	PriceListID string `json:"priceListId" bson:"priceListId"`
+	Name        string `json:"name" bson:"name"`
+	Description string `json:"description,omitempty" bson:"description,omitempty"`
+
+	// Tiers are the Customer.CustomerTier values of customer-api the list is
+	// for, such as "VIP". A list without tiers applies to every customer.
+	Tiers []string `json:"tiers,omitempty" bson:"tiers"`
+
+	// The list applies from ValidFrom up to, but excluding, ValidUntil. A
+	// nil ValidUntil never ends.
+	ValidFrom  time.Time  `json:"validFrom" bson:"validFrom"`
+	ValidUntil *time.Time `json:"validUntil,omitempty" bson:"validUntil,omitempty"`
+
+	// Priority decides between lists that apply at the same time; the
+	// highest wins, whatever price the others would give
+	Priority int         `json:"priority" bson:"priority"`
+	Active   bool        `json:"active" bson:"active"`
+	Rules    []PriceRule `json:"rules" bson:"rules"`
+
+	Version   int64     `json:"version" bson:"version"`
+	CreatedAt time.Time `json:"createdAt" bson:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt" bson:"updatedAt"`
+}
+
+// PriceRule discounts the products it matches: one product, every product
+// of a category, or every product when both are empty. MinQuantity makes it
+// a volume discount.
+type PriceRule struct {
+	ProductID   string       `json:"productId,omitempty" bson:"productId,omitempty"`
+	Category    string       `json:"category,omitempty" bson:"category,omitempty"`
+	MinQuantity int          `json:"minQuantity,omitempty" bson:"minQuantity,omitempty"`
+	Type        DiscountType `json:"type" bson:"type"`
+	Percent     float64      `json:"percent,omitempty" bson:"percent,omitempty"`         // percentage discounts, up to 100
+	AmountMinor int64        `json:"amountMinor,omitempty" bson:"amountMinor,omitempty"` // fixed discounts, in minor units of Currency
+	Currency    string       `json:"currency,omitempty" bson:"currency,omitempty"`       // fixed discounts only apply to prices in it
+}
+
+// PriceListResponse represents the response for price list listings
+type PriceListResponse struct {
+	PriceLists []*PriceList `json:"priceLists"`
+	Total      int          `json:"total"`
+}
+
+// ProductPrice is the effective price of a product at a point in time, for a
+// customer tier and quantity. Rule is the rule that produced the price, and
+// nil when no price list applies and the base price stands.
+type ProductPrice struct {
+	ProductID      string       `json:"productId"`
+	Currency       string       `json:"currency"`
+	At             time.Time    `json:"at"`
+	Tier           string       `json:"tier,omitempty"`
+	Quantity       int          `json:"quantity"`
+	BasePrice      float64      `json:"basePrice"`
+	BasePriceMinor int64        `json:"basePriceMinor"`
+	Price          float64      `json:"price"` // per unit
+	PriceMinor     int64        `json:"priceMinor"`
+	TotalMinor     int64        `json:"totalMinor"` // PriceMinor times Quantity
+	Rule           *AppliedRule `json:"rule,omitempty"`
+}
+
+// AppliedRule identifies the price rule behind an effective price
+type AppliedRule struct {
+	PriceListID   string    `json:"priceListId"`
+	PriceListName string    `json:"priceListName"`
+	Priority      int       `json:"priority"`
+	RuleIndex     int       `json:"ruleIndex"` // position of Rule in the list
+	Rule          PriceRule `json:"rule"`
+	DiscountMinor int64     `json:"discountMinor"` // per unit
+}
@@ -1,35 +1,238 @@
 package models
 
 import (
+	"bytes"
+	"encoding/json"
+	"slices"
 	"time"
 )
 
-// Product represents a product in the catalog
+// Product represents a product in the catalog. A product with a ParentID is
+// a variant of that parent, such as one size of a monitor; the name,
+// description and price it leaves empty are the parent's, and its category
+// always is.
 type Product struct {
-	ProductID   string    `json:"productId" validate:"required"`
-	Name        string    `json:"name" validate:"required,min=1,max=255"`
-	Description string    `json:"description,omitempty"`
-	Price       float64   `json:"price" validate:"required,gt=0"`
-	Category    string    `json:"category,omitempty"`
-	Stock       int       `json:"stock,omitempty"`
-	Active      bool      `json:"active"`
-	CreatedAt   time.Time `json:"createdAt"`
-	UpdatedAt   time.Time `json:"updatedAt"`
+	ProductID   string            `json:"productId" bson:"productId" validate:"required"`
+	ParentID    string            `json:"parentId,omitempty" bson:"parentId,omitempty"`
+	SKU         string            `json:"sku,omitempty" bson:"sku,omitempty"`
+	Name        string            `json:"name" bson:"name" validate:"required,min=1,max=255"`
+	Description string            `json:"description,omitempty" bson:"description,omitempty"`
+	Price       float64           `json:"price" bson:"-"` // decimal PriceMinor, kept for older clients
+	PriceMinor  int64             `json:"priceMinor" bson:"priceMinor" validate:"required,gt=0"`
+	Currency    string            `json:"currency" bson:"currency" validate:"required,iso4217"`
+	Category    string            `json:"category,omitempty" bson:"category,omitempty"`
+	Attributes  map[string]string `json:"attributes,omitempty" bson:"attributes,omitempty"` // variant attributes, such as size and color
+	Stock       int               `json:"stock,omitempty" bson:"stock"`
+	Active      bool              `json:"active" bson:"active"`
+	Version     int64             `json:"version" bson:"version"`
+	CreatedAt   time.Time         `json:"createdAt" bson:"createdAt"`
+	UpdatedAt   time.Time         `json:"updatedAt" bson:"updatedAt"`
+
+	// Variants are the variants of a parent product, filled in on reads;
+	// they are never stored with the parent
+	Variants []*Product `json:"variants,omitempty" bson:"-"`
+}
+
+// IsVariant reports whether the product is a variant of another product
+func (p *Product) IsVariant() bool {
+	return p.ParentID != ""
+}
+
+// Inherit fills the fields a variant leaves to its parent. The variant
+// keeps its own price only if it sets one, and is only active while its
+// parent is.
+func (p *Product) Inherit(parent *Product) {
+	if p.Name == "" {
+		p.Name = parent.Name
+	}
+	if p.Description == "" {
+		p.Description = parent.Description
+	}
+	if p.PriceMinor == 0 {
+		p.Price = parent.Price
+		p.PriceMinor = parent.PriceMinor
+		p.Currency = parent.Currency
+	}
+	p.Category = parent.Category
+	p.Active = p.Active && parent.Active
+}
+
+// ProductFields are the JSON names of the product fields, in the order
+// responses list them. Stored documents use the same names; there price
+// holds the exact Decimal128 amount. Variants are not stored.
+var ProductFields = []string{
+	"productId", "parentId", "sku", "name", "description", "price",
+	"priceMinor", "currency", "category", "attributes", "stock", "active",
+	"version", "createdAt", "updatedAt", "variants",
+}
+
+// Product views are the preset field sets of product responses
+const (
+	ViewSummary    = "summary"    // what catalog listings show
+	ViewFull       = "full"       // every field
+	ViewEnrichment = "enrichment" // what the order worker needs, without timestamps
+)
+
+// ProductViews maps each view to its fields
+var ProductViews = map[string][]string{
+	ViewSummary:    {"productId", "name", "price", "currency", "active", "variants"},
+	ViewFull:       ProductFields,
+	ViewEnrichment: {"productId", "parentId", "sku", "name", "price", "priceMinor", "currency", "category", "attributes", "stock", "active"},
 }
 
-// ProductSummary represents a simplified product view
-type ProductSummary struct {
-	ProductID string  `json:"productId"`
-	Name      string  `json:"name"`
-	Price     float64 `json:"price"`
+// ProductView is a product that encodes only Fields to JSON, in
+// ProductFields order. Fields left empty by the product are omitted as in
+// the full encoding; an empty Fields encodes the whole product. Variants
+// are encoded with the same Fields, plus what tells them apart.
+type ProductView struct {
+	*Product
+	Fields []string
+}
+
+// variantFields are the fields nested variants show besides those selected
+var variantFields = []string{"sku", "attributes"}
+
+// MarshalJSON writes the selected fields of the product
+func (v ProductView) MarshalJSON() ([]byte, error) {
+	full, err := json.Marshal(v.Product)
+	if err != nil || len(v.Fields) == 0 {
+		return full, err
+	}
+
+	var values map[string]json.RawMessage
+	if err := json.Unmarshal(full, &values); err != nil {
+		return nil, err
+	}
+
+	selected := make(map[string]bool, len(v.Fields))
+	for _, field := range v.Fields {
+		selected[field] = true
+	}
+
+	if selected["variants"] && len(v.Variants) > 0 {
+		fields := append(slices.Clone(v.Fields), variantFields...)
+		variants := make([]ProductView, len(v.Variants))
+		for i, variant := range v.Variants {
+			variants[i] = ProductView{Product: variant, Fields: fields}
+		}
+		if values["variants"], err = json.Marshal(variants); err != nil {
+			return nil, err
+		}
+	}
+
+	var buffer bytes.Buffer
+	buffer.WriteByte('{')
+	for _, field := range ProductFields {
+		value, ok := values[field]
+		if !ok || !selected[field] {
+			continue
+		}
+		if buffer.Len() > 1 {
+			buffer.WriteByte(',')
+		}
+		key, _ := json.Marshal(field)
+		buffer.Write(key)
+		buffer.WriteByte(':')
+		buffer.Write(value)
+	}
+	buffer.WriteByte('}')
+	return buffer.Bytes(), nil
 }
 
 // ProductCatalogResponse represents the response for product listings
 type ProductCatalogResponse struct {
-	Products []ProductSummary `json:"products"`
-	Total    int              `json:"total"`
-	Page     int              `json:"page,omitempty"`
-	PageSize int              `json:"pageSize,omitempty"`
+	Products   []ProductView `json:"products"`
+	Total      int           `json:"total"`
+	Page       int           `json:"page,omitempty"`
+	PageSize   int           `json:"pageSize,omitempty"`
+	NextCursor string        `json:"next_cursor,omitempty"`
+	PrevCursor string        `json:"prev_cursor,omitempty"`
+	Links      *PageLinks    `json:"links,omitempty"`
+}
+
+// PageLinks holds the URLs of the neighbouring pages of a listing
+type PageLinks struct {
+	Next string `json:"next,omitempty"`
+	Prev string `json:"prev,omitempty"`
+}
+
+// ProductSearchResponse represents the result of a full-text product search.
+// Total and Facets cover every matching product, not only the page shown.
+type ProductSearchResponse struct {
+	Query    string             `json:"query"`
+	Results  []ProductSearchHit `json:"results"`
+	Total    int                `json:"total"`
+	Page     int                `json:"page"`
+	PageSize int                `json:"pageSize"`
+	Facets   SearchFacets       `json:"facets"`
+}
+
+// ProductSearchHit is a product matching a search, with its relevance score
+type ProductSearchHit struct {
+	ProductID  string  `json:"productId"`
+	Name       string  `json:"name"`
+	Price      float64 `json:"price"`
+	PriceMinor int64   `json:"priceMinor"`
+	Currency   string  `json:"currency"`
+	Category   string  `json:"category,omitempty"`
+	Score      float64 `json:"score"`
+}
+
+// SearchFacets counts the matching products per category and price range.
+// Price ranges only count the products priced in PriceCurrency.
+type SearchFacets struct {
+	Categories    []CategoryFacet `json:"categories"`
+	Prices        []PriceFacet    `json:"prices"`
+	PriceCurrency string          `json:"priceCurrency,omitempty"`
+}
+
+// CategoryFacet is the number of matching products in a category
+type CategoryFacet struct {
+	Category string `json:"category"`
+	Count    int    `json:"count"`
+}
+
+// PriceFacet is the number of matching products priced from Min up to, but
+// excluding, Max. The most expensive range has no Max.
+type PriceFacet struct {
+	Min   float64  `json:"min"`
+	Max   *float64 `json:"max,omitempty"`
+	Count int      `json:"count"`
+}
+
+// ProductBatchRequest represents a request to look up several products at once
+type ProductBatchRequest struct {
+	ProductIDs []string `json:"productIds"`
+}
+
+// ProductBatchResponse represents the result of a batch product lookup.
+// Products keeps the order of the requested IDs; IDs that do not exist are
+// listed in Missing and inactive products in Inactive.
+type ProductBatchResponse struct {
+	Products []*Product `json:"products"`
+	Missing  []string   `json:"missing"`
+	Inactive []string   `json:"inactive"`
+}
+
+// ImportRowError describes an input row that was not imported
+type ImportRowError struct {
+	Line      int    `json:"line"`
+	ProductID string `json:"productId,omitempty"`
+	Error     string `json:"error"`
+}
+
+// ImportReport summarises a bulk product import. A dry run counts the
+// products it would have inserted or updated without writing them.
+type ImportReport struct {
+	Mode            string           `json:"mode"`
+	Format          string           `json:"format"`
+	Rows            int              `json:"rows"`
+	Inserted        int              `json:"inserted"`
+	Updated         int              `json:"updated"`
+	Failed          int              `json:"failed"`
+	Errors          []ImportRowError `json:"errors"`
+	ErrorsTruncated bool             `json:"errorsTruncated,omitempty"`
+	Aborted         string           `json:"aborted,omitempty"` // why the import stopped before the end of the input
 }
 
 // ErrorResponse represents an API error response
@@ -49,6 +252,21 @@ type HealthResponse struct {
 	Timestamp   time.Time         `json:"timestamp"`
 	Uptime      string            `json:"uptime"`
 	Environment string            `json:"environment"`
+	Ready       bool              `json:"ready"`
 	Metrics     map[string]int    `json:"metrics"`
-	Dependencies map[string]string `json:"dependencies,omitempty"`
+	Dependencies map[string]DependencyHealth `json:"dependencies,omitempty"`
+}
+
+// DependencyHealth reports the state of one dependency and how long checking it took
+type DependencyHealth struct {
+	Status    string  `json:"status"`
+	LatencyMs float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// ProbeResponse is the body of the liveness and readiness probes
+type ProbeResponse struct {
+	Status    string            `json:"status"`
+	Checks    map[string]string `json:"checks,omitempty"`
+	Timestamp time.Time         `json:"timestamp"`
 }
\ No newline at end of file
@@ -0,0 +1,41 @@
+package models
+
+import (
+	"time"
+)
+
+// ReservationStatus represents the lifecycle state of a stock reservation
+type ReservationStatus string
+
+const (
+	ReservationPending   ReservationStatus = "pending"
+	ReservationConfirmed ReservationStatus = "confirmed"
+	ReservationReleased  ReservationStatus = "released"
+	ReservationExpired   ReservationStatus = "expired"
+)
+
+// StockLine represents a quantity of a single product
+type StockLine struct {
+	ProductID string `json:"productId" bson:"productId"`
+	Quantity  int    `json:"quantity" bson:"quantity"`
+}
+
+// Reservation represents stock held for an order until it is confirmed,
+// released or expires
+type Reservation struct {
+	ReservationID string            `json:"reservationId" bson:"reservationId"`
+	OrderID       string            `json:"orderId,omitempty" bson:"orderId,omitempty"`
+	Lines         []StockLine       `json:"lines" bson:"lines"`
+	Status        ReservationStatus `json:"status" bson:"status"`
+	TTLSeconds    int               `json:"ttlSeconds" bson:"ttlSeconds"`
+	ExpiresAt     time.Time         `json:"expiresAt" bson:"expiresAt"`
+	CreatedAt     time.Time         `json:"createdAt" bson:"createdAt"`
+	UpdatedAt     time.Time         `json:"updatedAt" bson:"updatedAt"`
+}
+
+// ReservationRequest represents a request to reserve stock
+type ReservationRequest struct {
+	OrderID    string      `json:"orderId,omitempty"`
+	Lines      []StockLine `json:"lines"`
+	TTLSeconds int         `json:"ttlSeconds,omitempty"`
+}
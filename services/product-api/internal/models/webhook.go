@@ -0,0 +1,90 @@
+package models
+
+import (
+	"time"
+)
+
+// Webhook event types partners can subscribe to
+const (
+	WebhookProductCreated    = "product.created"
+	WebhookProductUpdated    = "product.updated"
+	WebhookProductDeleted    = "product.deleted"
+	WebhookProductOutOfStock = "product.out_of_stock"
+)
+
+// WebhookEventTypes lists every webhook event type, in documentation order
+var WebhookEventTypes = []string{
+	WebhookProductCreated,
+	WebhookProductUpdated,
+	WebhookProductDeleted,
+	WebhookProductOutOfStock,
+}
+
+// Webhook delivery states. A pending delivery is attempted until it
+// succeeds or runs out of attempts, at which point it is dead-lettered.
+const (
+	DeliveryPending   = "pending"
+	DeliveryDelivered = "delivered"
+	DeliveryDead      = "dead"
+)
+
+// WebhookSubscription sends the events of EventTypes to URL. Secret signs
+// every delivery; it is only returned when the subscription is created.
+type WebhookSubscription struct {
+	WebhookID   string    `json:"webhookId" bson:"webhookId"`
+	URL         string    `json:"url" bson:"url"`
+	EventTypes  []string  `json:"eventTypes" bson:"eventTypes"`
+	Description string    `json:"description,omitempty" bson:"description,omitempty"`
+	Secret      string    `json:"secret,omitempty" bson:"secret"`
+	CreatedAt   time.Time `json:"createdAt" bson:"createdAt"`
+}
+
+// WebhookRequest represents the body of a subscription request. A secret
+// is generated when none is given.
+type WebhookRequest struct {
+	URL         string   `json:"url"`
+	EventTypes  []string `json:"eventTypes"`
+	Description string   `json:"description,omitempty"`
+	Secret      string   `json:"secret,omitempty"`
+}
+
+// WebhookListResponse represents a list of subscriptions
+type WebhookListResponse struct {
+	Webhooks []*WebhookSubscription `json:"webhooks"`
+	Total    int                    `json:"total"`
+}
+
+// WebhookEvent is the body of a webhook delivery. EventID stays the same
+// when a delivery is retried or redelivered, so receivers deduplicate on
+// it. Data is the product event it was derived from.
+type WebhookEvent struct {
+	EventID    string               `json:"eventId"`
+	Type       string               `json:"type"`
+	OccurredAt time.Time            `json:"occurredAt"`
+	Data       *ProductChangedEvent `json:"data"`
+}
+
+// WebhookDelivery is one event on its way to one subscription. NextAttemptAt
+// is when a pending delivery is due; the log keeps the outcome of the last
+// attempt.
+type WebhookDelivery struct {
+	DeliveryID     string     `json:"deliveryId" bson:"deliveryId"`
+	WebhookID      string     `json:"webhookId" bson:"webhookId"`
+	EventID        string     `json:"eventId" bson:"eventId"`
+	EventType      string     `json:"eventType" bson:"eventType"`
+	Payload        string     `json:"payload" bson:"payload"`
+	Status         string     `json:"status" bson:"status"`
+	Attempts       int        `json:"attempts" bson:"attempts"`
+	LastStatusCode int        `json:"lastStatusCode,omitempty" bson:"lastStatusCode,omitempty"`
+	LastError      string     `json:"lastError,omitempty" bson:"lastError,omitempty"`
+	NextAttemptAt  time.Time  `json:"nextAttemptAt" bson:"nextAttemptAt"`
+	CreatedAt      time.Time  `json:"createdAt" bson:"createdAt"`
+	DeliveredAt    *time.Time `json:"deliveredAt,omitempty" bson:"deliveredAt,omitempty"`
+}
+
+// WebhookDeliveryResponse represents a page of the delivery log, newest
+// first
+type WebhookDeliveryResponse struct {
+	Deliveries []*WebhookDelivery `json:"deliveries"`
+	Total      int                `json:"total"`
+}
@@ -0,0 +1,333 @@
+package repository
+
+import (
+	"container/list"
+	"context"
+	"maps"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/product-api-v2/internal/models"
+	"golang.org/x/sync/singleflight"
+)
+
+// sharedLoadTimeout bounds a cache load shared by concurrent callers, which
+// outlives the context of the caller that started it
+const sharedLoadTimeout = 10 * time.Second
+
+// CacheStats reports the effectiveness of a repository cache
+type CacheStats struct {
+	Hits      int64   `json:"hits"`
+	Misses    int64   `json:"misses"`
+	Evictions int64   `json:"evictions"`
+	Size      int     `json:"size"`
+	MaxSize   int     `json:"maxSize"`
+	HitRate   float64 `json:"hitRate"`
+}
+
+// CacheStatsProvider is implemented by repositories that cache reads
+type CacheStatsProvider interface {
+	CacheStats() CacheStats
+}
+
+// CachedProductRepository decorates a ProductRepository with a bounded LRU
+// cache of single-product lookups. Methods that are not overridden pass
+// through to the wrapped repository.
+type CachedProductRepository struct {
+	ProductRepository
+
+	ttl     time.Duration
+	maxSize int
+
+	mutex      sync.Mutex
+	entries    map[string]*list.Element
+	order      *list.List // front is most recently used
+	generation uint64     // bumped on every invalidation
+
+	loads singleflight.Group
+
+	hits      atomic.Int64
+	misses    atomic.Int64
+	evictions atomic.Int64
+}
+
+// productCacheEntry is a cached product and its expiry
+type productCacheEntry struct {
+	productID string
+	product   *models.Product
+	expiresAt time.Time
+}
+
+// NewCachedProductRepository wraps repo with a cache holding at most maxSize
+// products for ttl each
+func NewCachedProductRepository(repo ProductRepository, ttl time.Duration, maxSize int) *CachedProductRepository {
+	if maxSize <= 0 {
+		maxSize = 1
+	}
+
+	return &CachedProductRepository{
+		ProductRepository: repo,
+		ttl:               ttl,
+		maxSize:           maxSize,
+		entries:           make(map[string]*list.Element),
+		order:             list.New(),
+	}
+}
+
+// GetByID returns a cached product or loads it once, however many callers
+// miss on the same ID concurrently. Loads in a transaction may see
+// uncommitted writes, so they are neither shared nor cached.
+func (r *CachedProductRepository) GetByID(ctx context.Context, productID string) (*models.Product, error) {
+	if product, ok := r.lookup(productID); ok {
+		r.hits.Add(1)
+		return product, nil
+	}
+	r.misses.Add(1)
+
+	if inTransaction(ctx) {
+		return r.ProductRepository.GetByID(ctx, productID)
+	}
+
+	// The load is shared, so it must not end when the caller that started it
+	// gives up; each caller still stops waiting when its own ctx ends
+	loaded := r.loads.DoChan(productID, func() (interface{}, error) {
+		loadCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), sharedLoadTimeout)
+		defer cancel()
+
+		generation := r.currentGeneration()
+		product, err := r.ProductRepository.GetByID(loadCtx, productID)
+		if err != nil {
+			return nil, err
+		}
+		r.store(product, generation)
+		return product, nil
+	})
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case result := <-loaded:
+		if result.Err != nil {
+			return nil, result.Err
+		}
+		return copyProduct(result.Val.(*models.Product)), nil
+	}
+}
+
+// GetByIDFields projects a cached product, or reads only fields on a miss
+// without caching the partial product
+func (r *CachedProductRepository) GetByIDFields(ctx context.Context, productID string, fields []string) (*models.Product, error) {
+	if product, ok := r.lookup(productID); ok {
+		r.hits.Add(1)
+		return projectProduct(product, fields), nil
+	}
+	r.misses.Add(1)
+
+	return r.ProductRepository.GetByIDFields(ctx, productID, fields)
+}
+
+// GetByIDs serves cached products and loads only the missing ones
+func (r *CachedProductRepository) GetByIDs(ctx context.Context, productIDs []string) ([]*models.Product, error) {
+	products := make([]*models.Product, 0, len(productIDs))
+	var missing []string
+
+	for _, productID := range productIDs {
+		if product, ok := r.lookup(productID); ok {
+			r.hits.Add(1)
+			products = append(products, product)
+			continue
+		}
+		r.misses.Add(1)
+		missing = append(missing, productID)
+	}
+
+	if len(missing) == 0 {
+		return products, nil
+	}
+
+	generation := r.currentGeneration()
+	loaded, err := r.ProductRepository.GetByIDs(ctx, missing)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, product := range loaded {
+		if !inTransaction(ctx) {
+			r.store(product, generation)
+		}
+		products = append(products, copyProduct(product))
+	}
+
+	return products, nil
+}
+
+// Create stores a product and drops any cached copy of it
+func (r *CachedProductRepository) Create(ctx context.Context, product *models.Product) error {
+	defer r.invalidateAfter(ctx, product.ProductID)
+	return r.ProductRepository.Create(ctx, product)
+}
+
+// Update stores a product and drops any cached copy of it
+func (r *CachedProductRepository) Update(ctx context.Context, product *models.Product) error {
+	defer r.invalidateAfter(ctx, product.ProductID)
+	return r.ProductRepository.Update(ctx, product)
+}
+
+// Delete removes a product and drops any cached copy of it
+func (r *CachedProductRepository) Delete(ctx context.Context, productID string) error {
+	defer r.invalidateAfter(ctx, productID)
+	return r.ProductRepository.Delete(ctx, productID)
+}
+
+// ReserveStock reserves stock and drops the affected products from the cache
+func (r *CachedProductRepository) ReserveStock(ctx context.Context, lines []models.StockLine) error {
+	defer r.invalidateAfter(ctx, lineProductIDs(lines)...)
+	return r.ProductRepository.ReserveStock(ctx, lines)
+}
+
+// ReleaseStock returns stock and drops the affected products from the cache
+func (r *CachedProductRepository) ReleaseStock(ctx context.Context, lines []models.StockLine) error {
+	defer r.invalidateAfter(ctx, lineProductIDs(lines)...)
+	return r.ProductRepository.ReleaseStock(ctx, lines)
+}
+
+// BulkWrite stores products and drops every cached copy of them
+func (r *CachedProductRepository) BulkWrite(ctx context.Context, products []*models.Product, mode BulkMode) (*BulkWriteResult, error) {
+	productIDs := make([]string, 0, len(products))
+	for _, product := range products {
+		productIDs = append(productIDs, product.ProductID)
+	}
+	defer r.invalidateAfter(ctx, productIDs...)
+	return r.ProductRepository.BulkWrite(ctx, products, mode)
+}
+
+// CacheStats returns hit, miss and eviction counts and the current size
+func (r *CachedProductRepository) CacheStats() CacheStats {
+	r.mutex.Lock()
+	size := r.order.Len()
+	r.mutex.Unlock()
+
+	stats := CacheStats{
+		Hits:      r.hits.Load(),
+		Misses:    r.misses.Load(),
+		Evictions: r.evictions.Load(),
+		Size:      size,
+		MaxSize:   r.maxSize,
+	}
+
+	if total := stats.Hits + stats.Misses; total > 0 {
+		stats.HitRate = float64(stats.Hits) / float64(total)
+	}
+
+	return stats
+}
+
+// lookup returns a copy of a fresh cached product
+func (r *CachedProductRepository) lookup(productID string) (*models.Product, bool) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	element, found := r.entries[productID]
+	if !found {
+		return nil, false
+	}
+
+	entry := element.Value.(*productCacheEntry)
+	if time.Now().After(entry.expiresAt) {
+		r.removeElement(element)
+		return nil, false
+	}
+
+	r.order.MoveToFront(element)
+	return copyProduct(entry.product), true
+}
+
+// store caches a product loaded at generation. Loads that raced with an
+// invalidation are discarded so a stale read cannot outlive a write.
+func (r *CachedProductRepository) store(product *models.Product, generation uint64) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if generation != r.generation {
+		return
+	}
+
+	entry := &productCacheEntry{
+		productID: product.ProductID,
+		product:   copyProduct(product),
+		expiresAt: time.Now().Add(r.ttl),
+	}
+
+	if element, found := r.entries[product.ProductID]; found {
+		element.Value = entry
+		r.order.MoveToFront(element)
+		return
+	}
+
+	r.entries[product.ProductID] = r.order.PushFront(entry)
+
+	for r.order.Len() > r.maxSize {
+		r.removeElement(r.order.Back())
+		r.evictions.Add(1)
+	}
+}
+
+// invalidate drops the given products from the cache
+func (r *CachedProductRepository) invalidate(productIDs ...string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.generation++
+	for _, productID := range productIDs {
+		if element, found := r.entries[productID]; found {
+			r.removeElement(element)
+		}
+	}
+}
+
+// invalidateAfter drops the given products from the cache after a write
+// made through ctx. A write in a transaction only becomes visible when the
+// transaction ends, so they are dropped again then, in case a concurrent
+// read cached the state from before the write in the meantime.
+func (r *CachedProductRepository) invalidateAfter(ctx context.Context, productIDs ...string) {
+	r.invalidate(productIDs...)
+	if inTransaction(ctx) {
+		afterTransaction(ctx, func() { r.invalidate(productIDs...) })
+	}
+}
+
+// lineProductIDs returns the product referenced by every line
+func lineProductIDs(lines []models.StockLine) []string {
+	productIDs := make([]string, 0, len(lines))
+	for _, line := range lines {
+		productIDs = append(productIDs, line.ProductID)
+	}
+	return productIDs
+}
+
+// currentGeneration returns the invalidation counter
+func (r *CachedProductRepository) currentGeneration() uint64 {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.generation
+}
+
+// removeElement unlinks an entry; the caller must hold the mutex
+func (r *CachedProductRepository) removeElement(element *list.Element) {
+	r.order.Remove(element)
+	delete(r.entries, element.Value.(*productCacheEntry).productID)
+}
+
+// copyProduct returns a deep copy so callers cannot modify cached state
+func copyProduct(product *models.Product) *models.Product {
+	productCopy := *product
+	productCopy.Attributes = maps.Clone(product.Attributes)
+	if product.Variants != nil {
+		productCopy.Variants = make([]*models.Product, 0, len(product.Variants))
+		for _, variant := range product.Variants {
+			productCopy.Variants = append(productCopy.Variants, copyProduct(variant))
+		}
+	}
+	return &productCopy
+}
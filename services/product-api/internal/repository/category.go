@@ -0,0 +1,162 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/product-api-v2/internal/models"
+)
+
+var (
+	ErrCategoryNotFound = errors.New("category not found")
+	ErrCategoryExists   = errors.New("category already exists")
+)
+
+// CategoryRepository defines the interface for category storage
+type CategoryRepository interface {
+	Create(ctx context.Context, category *models.Category) error
+	GetBySlug(ctx context.Context, slug string) (*models.Category, error)
+	// GetAll returns every category ordered by slug
+	GetAll(ctx context.Context) ([]*models.Category, error)
+	// Update replaces a category if it is still at category.Version and
+	// fails with ErrVersionConflict otherwise
+	Update(ctx context.Context, category *models.Category) error
+	Delete(ctx context.Context, slug string) error
+}
+
+// MemoryCategoryRepository implements CategoryRepository using in-memory storage
+type MemoryCategoryRepository struct {
+	categories map[string]*models.Category
+	mutex      sync.RWMutex
+}
+
+// NewMemoryCategoryRepository creates a new in-memory category repository
+func NewMemoryCategoryRepository() *MemoryCategoryRepository {
+	return &MemoryCategoryRepository{
+		categories: make(map[string]*models.Category),
+	}
+}
+
+// Seed loads categories as given, or none if a slug is duplicated.
+// Categories without timestamps or a version get the values Create would
+// assign.
+func (r *MemoryCategoryRepository) Seed(categories []*models.Category) error {
+	seeded := make(map[string]*models.Category, len(categories))
+	now := time.Now()
+
+	for _, category := range categories {
+		if _, duplicate := seeded[category.Slug]; duplicate {
+			return fmt.Errorf("category fixture: %w: %s", ErrCategoryExists, category.Slug)
+		}
+
+		categoryCopy := *category
+		if categoryCopy.CreatedAt.IsZero() {
+			categoryCopy.CreatedAt = now
+		}
+		if categoryCopy.UpdatedAt.IsZero() {
+			categoryCopy.UpdatedAt = categoryCopy.CreatedAt
+		}
+		if categoryCopy.Version == 0 {
+			categoryCopy.Version = 1
+		}
+		seeded[category.Slug] = &categoryCopy
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for slug, category := range seeded {
+		r.categories[slug] = category
+	}
+	return nil
+}
+
+// Create stores a new category
+func (r *MemoryCategoryRepository) Create(ctx context.Context, category *models.Category) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, exists := r.categories[category.Slug]; exists {
+		return ErrCategoryExists
+	}
+
+	now := time.Now()
+	category.CreatedAt = now
+	category.UpdatedAt = now
+	category.Version = 1
+
+	categoryCopy := *category
+	r.categories[category.Slug] = &categoryCopy
+	return nil
+}
+
+// GetBySlug retrieves a category by its slug
+func (r *MemoryCategoryRepository) GetBySlug(ctx context.Context, slug string) (*models.Category, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	category, exists := r.categories[slug]
+	if !exists {
+		return nil, ErrCategoryNotFound
+	}
+
+	categoryCopy := *category
+	return &categoryCopy, nil
+}
+
+// GetAll returns every category ordered by slug
+func (r *MemoryCategoryRepository) GetAll(ctx context.Context) ([]*models.Category, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	categories := make([]*models.Category, 0, len(r.categories))
+	for _, category := range r.categories {
+		categoryCopy := *category
+		categories = append(categories, &categoryCopy)
+	}
+
+	sort.Slice(categories, func(i, j int) bool {
+		return categories[i].Slug < categories[j].Slug
+	})
+	return categories, nil
+}
+
+// Update replaces a category, checking its version
+func (r *MemoryCategoryRepository) Update(ctx context.Context, category *models.Category) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	existing, exists := r.categories[category.Slug]
+	if !exists {
+		return ErrCategoryNotFound
+	}
+
+	if existing.Version != category.Version {
+		return ErrVersionConflict
+	}
+
+	category.CreatedAt = existing.CreatedAt
+	category.UpdatedAt = time.Now()
+	category.Version++
+
+	categoryCopy := *category
+	r.categories[category.Slug] = &categoryCopy
+	return nil
+}
+
+// Delete removes a category
+func (r *MemoryCategoryRepository) Delete(ctx context.Context, slug string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, exists := r.categories[slug]; !exists {
+		return ErrCategoryNotFound
+	}
+
+	delete(r.categories, slug)
+	return nil
+}
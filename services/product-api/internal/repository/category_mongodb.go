@@ -0,0 +1,128 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/product-api-v2/configs"
+	"github.com/product-api-v2/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoCategoryRepository implements CategoryRepository using MongoDB
+type MongoCategoryRepository struct {
+	collection *mongo.Collection
+}
+
+// NewMongoCategoryRepository creates a category repository that shares
+// the database connection of the product repository and stores categories
+// in config.CategoryCollection
+func NewMongoCategoryRepository(products *MongoProductRepository, config configs.DatabaseConfig) (*MongoCategoryRepository, error) {
+	collection := products.collection.Database().Collection(config.CategoryCollection)
+
+	timeout := config.ConnectTimeout
+	if timeout <= 0 {
+		timeout = defaultConnectTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	_, err := collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "slug", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "parent", Value: 1}}},
+	})
+	if err != nil {
+		return nil, wrapMongoError(err)
+	}
+
+	return &MongoCategoryRepository{collection: collection}, nil
+}
+
+// Create stores a new category in MongoDB
+func (r *MongoCategoryRepository) Create(ctx context.Context, category *models.Category) error {
+	now := time.Now()
+	category.CreatedAt = now
+	category.UpdatedAt = now
+	category.Version = 1
+
+	_, err := r.collection.InsertOne(ctx, category)
+	if mongo.IsDuplicateKeyError(err) {
+		return ErrCategoryExists
+	}
+	return wrapMongoError(err)
+}
+
+// GetBySlug retrieves a category by its slug from MongoDB
+func (r *MongoCategoryRepository) GetBySlug(ctx context.Context, slug string) (*models.Category, error) {
+	var category models.Category
+
+	err := r.collection.FindOne(ctx, bson.M{"slug": slug}).Decode(&category)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrCategoryNotFound
+		}
+		return nil, wrapMongoError(err)
+	}
+
+	return &category, nil
+}
+
+// GetAll returns every category ordered by slug
+func (r *MongoCategoryRepository) GetAll(ctx context.Context) ([]*models.Category, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "slug", Value: 1}})
+	cursor, err := r.collection.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, wrapMongoError(err)
+	}
+	defer cursor.Close(ctx)
+
+	categories := []*models.Category{}
+	if err := cursor.All(ctx, &categories); err != nil {
+		return nil, wrapMongoError(err)
+	}
+
+	return categories, nil
+}
+
+// Update replaces a category with a write that only matches its current
+// version. The creation time is kept from category, as stored.
+func (r *MongoCategoryRepository) Update(ctx context.Context, category *models.Category) error {
+	expectedVersion := category.Version
+	filter := bson.M{"slug": category.Slug, "version": expectedVersion}
+
+	category.UpdatedAt = time.Now()
+	category.Version = expectedVersion + 1
+
+	result, err := r.collection.ReplaceOne(ctx, filter, category)
+	if err != nil {
+		category.Version = expectedVersion
+		return wrapMongoError(err)
+	}
+
+	if result.MatchedCount == 0 {
+		category.Version = expectedVersion
+		if _, err := r.GetBySlug(ctx, category.Slug); err != nil {
+			return err
+		}
+		return ErrVersionConflict
+	}
+
+	return nil
+}
+
+// Delete removes a category from MongoDB
+func (r *MongoCategoryRepository) Delete(ctx context.Context, slug string) error {
+	result, err := r.collection.DeleteOne(ctx, bson.M{"slug": slug})
+	if err != nil {
+		return wrapMongoError(err)
+	}
+
+	if result.DeletedCount == 0 {
+		return ErrCategoryNotFound
+	}
+
+	return nil
+}
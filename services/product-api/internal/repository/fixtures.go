@@ -0,0 +1,178 @@
+package repository
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/product-api-v2/internal/models"
+)
+
+// Fixture files hold one document per record using the same field names as
+// the MongoDB collections: either a JSON array (.json) or one JSON document
+// per line (.ndjson, .jsonl)
+var fixtureExtensions = map[string]bool{".json": true, ".ndjson": true, ".jsonl": true}
+
+// ErrNoFixtures reports a fixture directory without files for a collection
+var ErrNoFixtures = errors.New("no fixture files")
+
+// LoadProductFixtures reads products from path. A directory contributes every
+// fixture file named after collection, such as products.json or
+// products-sale.ndjson, in name order.
+func LoadProductFixtures(path, collection string) ([]*models.Product, error) {
+	return loadFixtures[models.Product](path, collection)
+}
+
+// LoadCategoryFixtures reads categories from path like LoadProductFixtures
+func LoadCategoryFixtures(path, collection string) ([]*models.Category, error) {
+	return loadFixtures[models.Category](path, collection)
+}
+
+// loadFixtures reads the records of one collection from a file or directory
+func loadFixtures[T any](path, collection string) ([]*T, error) {
+	files, err := fixtureFiles(path, collection)
+	if err != nil {
+		return nil, err
+	}
+
+	var records []*T
+	for _, file := range files {
+		fileRecords, err := readFixtureFile[T](file)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, fileRecords...)
+	}
+	return records, nil
+}
+
+// fixtureFiles resolves path to the fixture files to read
+func fixtureFiles(path, collection string) ([]string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("fixtures: %w", err)
+	}
+	if !info.IsDir() {
+		if !fixtureExtensions[filepath.Ext(path)] {
+			return nil, fmt.Errorf("fixtures: %s: unknown fixture format, expected .json, .ndjson or .jsonl", path)
+		}
+		return []string{path}, nil
+	}
+
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return nil, fmt.Errorf("fixtures: %w", err)
+	}
+
+	var files []string
+	for _, entry := range entries {
+		name := entry.Name()
+		ext := filepath.Ext(name)
+		base := strings.TrimSuffix(name, ext)
+		if entry.IsDir() || !fixtureExtensions[ext] {
+			continue
+		}
+		if base == collection || strings.HasPrefix(base, collection+"-") {
+			files = append(files, filepath.Join(path, name))
+		}
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("fixtures: %w for %s in %s", ErrNoFixtures, collection, path)
+	}
+
+	sort.Strings(files)
+	return files, nil
+}
+
+// readFixtureFile decodes one fixture file according to its extension
+func readFixtureFile[T any](file string) ([]*T, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("fixtures: %w", err)
+	}
+
+	if filepath.Ext(file) == ".json" {
+		var records []*T
+		if err := json.Unmarshal(data, &records); err != nil {
+			return nil, fmt.Errorf("fixtures: %s: expected a JSON array of documents: %w", file, err)
+		}
+		return records, nil
+	}
+
+	var records []*T
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		text := bytes.TrimSpace(scanner.Bytes())
+		if len(text) == 0 {
+			continue
+		}
+		record := new(T)
+		if err := json.Unmarshal(text, record); err != nil {
+			return nil, fmt.Errorf("fixtures: %s:%d: %w", file, line, err)
+		}
+		records = append(records, record)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("fixtures: %s: %w", file, err)
+	}
+	return records, nil
+}
+
+// writeFixtureFile replaces file with records in the format given by its
+// extension. The data goes to a temporary file that is renamed into place, so
+// a crash never leaves a half-written fixture behind.
+func writeFixtureFile[T any](file string, records []*T) error {
+	var buffer bytes.Buffer
+	if filepath.Ext(file) == ".json" {
+		encoded, err := json.MarshalIndent(records, "", "  ")
+		if err != nil {
+			return err
+		}
+		buffer.Write(encoded)
+		buffer.WriteByte('\n')
+	} else {
+		encoder := json.NewEncoder(&buffer)
+		for _, record := range records {
+			if err := encoder.Encode(record); err != nil {
+				return err
+			}
+		}
+	}
+
+	temp, err := os.CreateTemp(filepath.Dir(file), "."+filepath.Base(file)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(temp.Name())
+
+	if _, err := temp.Write(buffer.Bytes()); err != nil {
+		temp.Close()
+		return err
+	}
+	if err := temp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(temp.Name(), file)
+}
+
+// fixturePersistTarget checks that path can receive written-back fixtures
+func fixturePersistTarget(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("fixtures: %w", err)
+	}
+	if info.IsDir() {
+		return fmt.Errorf("fixtures: persisting needs a single fixture file, %s is a directory", path)
+	}
+	if !fixtureExtensions[filepath.Ext(path)] {
+		return fmt.Errorf("fixtures: cannot persist to %s: unknown fixture format", path)
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"sync"
+
+	"github.com/product-api-v2/internal/models"
+)
+
+// ErrChangeNotFound reports that no recorded change produced a version
+var ErrChangeNotFound = errors.New("change not found")
+
+// ProductHistoryRepository stores the append-only change history of
+// products. Changes are never modified or removed once appended.
+type ProductHistoryRepository interface {
+	Append(ctx context.Context, changes ...*models.ProductChange) error
+	// List returns the changes of a product, oldest first
+	List(ctx context.Context, productID string) ([]*models.ProductChange, error)
+	// GetVersion returns the latest change with a snapshot that produced
+	// version of a product, or ErrChangeNotFound
+	GetVersion(ctx context.Context, productID string, version int64) (*models.ProductChange, error)
+}
+
+// MemoryProductHistoryRepository implements ProductHistoryRepository using
+// in-memory storage
+type MemoryProductHistoryRepository struct {
+	changes map[string][]*models.ProductChange
+	mutex   sync.RWMutex
+}
+
+// NewMemoryProductHistoryRepository creates a new in-memory history repository
+func NewMemoryProductHistoryRepository() *MemoryProductHistoryRepository {
+	return &MemoryProductHistoryRepository{
+		changes: make(map[string][]*models.ProductChange),
+	}
+}
+
+// Append stores changes after those already recorded
+func (r *MemoryProductHistoryRepository) Append(ctx context.Context, changes ...*models.ProductChange) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for _, change := range changes {
+		r.changes[change.ProductID] = append(r.changes[change.ProductID], copyProductChange(change))
+	}
+	return nil
+}
+
+// List returns the changes of a product, oldest first
+func (r *MemoryProductHistoryRepository) List(ctx context.Context, productID string) ([]*models.ProductChange, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	changes := make([]*models.ProductChange, 0, len(r.changes[productID]))
+	for _, change := range r.changes[productID] {
+		changes = append(changes, copyProductChange(change))
+	}
+	return changes, nil
+}
+
+// GetVersion returns the latest change with a snapshot that produced version
+func (r *MemoryProductHistoryRepository) GetVersion(ctx context.Context, productID string, version int64) (*models.ProductChange, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	changes := r.changes[productID]
+	for i := len(changes) - 1; i >= 0; i-- {
+		if changes[i].Version == version && changes[i].Snapshot != nil {
+			return copyProductChange(changes[i]), nil
+		}
+	}
+	return nil, ErrChangeNotFound
+}
+
+// copyProductChange returns a deep copy so callers cannot modify stored state
+func copyProductChange(change *models.ProductChange) *models.ProductChange {
+	changeCopy := *change
+	changeCopy.Changes = append([]models.FieldChange(nil), change.Changes...)
+	if change.Snapshot != nil {
+		snapshot := *change.Snapshot
+		snapshot.Attributes = maps.Clone(change.Snapshot.Attributes)
+		changeCopy.Snapshot = &snapshot
+	}
+	return &changeCopy
+}
@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/product-api-v2/configs"
+	"github.com/product-api-v2/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoProductHistoryRepository implements ProductHistoryRepository using MongoDB
+type MongoProductHistoryRepository struct {
+	collection *mongo.Collection
+}
+
+// NewMongoProductHistoryRepository creates a history repository that shares
+// the database connection of the product repository and stores changes in
+// config.HistoryCollection
+func NewMongoProductHistoryRepository(products *MongoProductRepository, config configs.DatabaseConfig) (*MongoProductHistoryRepository, error) {
+	collection := products.collection.Database().Collection(config.HistoryCollection)
+
+	timeout := config.ConnectTimeout
+	if timeout <= 0 {
+		timeout = defaultConnectTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	_, err := collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "productId", Value: 1}, {Key: "changedAt", Value: 1}}},
+		{Keys: bson.D{{Key: "productId", Value: 1}, {Key: "version", Value: 1}}},
+	})
+	if err != nil {
+		return nil, wrapMongoError(err)
+	}
+
+	return &MongoProductHistoryRepository{collection: collection}, nil
+}
+
+// Append inserts changes in order
+func (r *MongoProductHistoryRepository) Append(ctx context.Context, changes ...*models.ProductChange) error {
+	if len(changes) == 0 {
+		return nil
+	}
+
+	documents := make([]interface{}, 0, len(changes))
+	for _, change := range changes {
+		documents = append(documents, change)
+	}
+
+	_, err := r.collection.InsertMany(ctx, documents)
+	return wrapMongoError(err)
+}
+
+// List returns the changes of a product, oldest first
+func (r *MongoProductHistoryRepository) List(ctx context.Context, productID string) ([]*models.ProductChange, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "changedAt", Value: 1}, {Key: "_id", Value: 1}})
+	cursor, err := r.collection.Find(ctx, bson.M{"productId": productID}, opts)
+	if err != nil {
+		return nil, wrapMongoError(err)
+	}
+	defer cursor.Close(ctx)
+
+	changes := []*models.ProductChange{}
+	if err := cursor.All(ctx, &changes); err != nil {
+		return nil, wrapMongoError(err)
+	}
+
+	return changes, nil
+}
+
+// GetVersion returns the latest change with a snapshot that produced version
+func (r *MongoProductHistoryRepository) GetVersion(ctx context.Context, productID string, version int64) (*models.ProductChange, error) {
+	var change models.ProductChange
+
+	filter := bson.M{"productId": productID, "version": version, "snapshot": bson.M{"$ne": nil}}
+	opts := options.FindOne().SetSort(bson.D{{Key: "changedAt", Value: -1}, {Key: "_id", Value: -1}})
+	err := r.collection.FindOne(ctx, filter, opts).Decode(&change)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrChangeNotFound
+		}
+		return nil, wrapMongoError(err)
+	}
+
+	return &change, nil
+}
@@ -0,0 +1,172 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/product-api-v2/internal/models"
+)
+
+// Outcomes reported to an OperationObserver
+const (
+	OutcomeSuccess  = "success"
+	OutcomeRejected = "rejected" // the store answered, but with a domain error such as not found
+	OutcomeError    = "error"
+)
+
+// OperationObserver receives the name, outcome and duration of a repository call
+type OperationObserver func(operation, outcome string, duration time.Duration)
+
+// InstrumentedProductRepository reports the duration of every call to the
+// wrapped repository
+type InstrumentedProductRepository struct {
+	ProductRepository
+	observe OperationObserver
+}
+
+// NewInstrumentedProductRepository wraps repo so each call is reported to observe
+func NewInstrumentedProductRepository(repo ProductRepository, observe OperationObserver) *InstrumentedProductRepository {
+	return &InstrumentedProductRepository{ProductRepository: repo, observe: observe}
+}
+
+// GetByID times ProductRepository.GetByID
+func (r *InstrumentedProductRepository) GetByID(ctx context.Context, productID string) (*models.Product, error) {
+	start := time.Now()
+	product, err := r.ProductRepository.GetByID(ctx, productID)
+	r.record("GetByID", start, err)
+	return product, err
+}
+
+// GetByIDFields times ProductRepository.GetByIDFields
+func (r *InstrumentedProductRepository) GetByIDFields(ctx context.Context, productID string, fields []string) (*models.Product, error) {
+	start := time.Now()
+	product, err := r.ProductRepository.GetByIDFields(ctx, productID, fields)
+	r.record("GetByIDFields", start, err)
+	return product, err
+}
+
+// GetByIDs times ProductRepository.GetByIDs
+func (r *InstrumentedProductRepository) GetByIDs(ctx context.Context, productIDs []string) ([]*models.Product, error) {
+	start := time.Now()
+	products, err := r.ProductRepository.GetByIDs(ctx, productIDs)
+	r.record("GetByIDs", start, err)
+	return products, err
+}
+
+// GetAll times ProductRepository.GetAll
+func (r *InstrumentedProductRepository) GetAll(ctx context.Context, filters ProductFilters) ([]*models.Product, error) {
+	start := time.Now()
+	products, err := r.ProductRepository.GetAll(ctx, filters)
+	r.record("GetAll", start, err)
+	return products, err
+}
+
+// Create times ProductRepository.Create
+func (r *InstrumentedProductRepository) Create(ctx context.Context, product *models.Product) error {
+	start := time.Now()
+	err := r.ProductRepository.Create(ctx, product)
+	r.record("Create", start, err)
+	return err
+}
+
+// Update times ProductRepository.Update
+func (r *InstrumentedProductRepository) Update(ctx context.Context, product *models.Product) error {
+	start := time.Now()
+	err := r.ProductRepository.Update(ctx, product)
+	r.record("Update", start, err)
+	return err
+}
+
+// Delete times ProductRepository.Delete
+func (r *InstrumentedProductRepository) Delete(ctx context.Context, productID string) error {
+	start := time.Now()
+	err := r.ProductRepository.Delete(ctx, productID)
+	r.record("Delete", start, err)
+	return err
+}
+
+// Count times ProductRepository.Count
+func (r *InstrumentedProductRepository) Count(ctx context.Context, filters ProductFilters) (int, error) {
+	start := time.Now()
+	count, err := r.ProductRepository.Count(ctx, filters)
+	r.record("Count", start, err)
+	return count, err
+}
+
+// CountByCategory times ProductRepository.CountByCategory
+func (r *InstrumentedProductRepository) CountByCategory(ctx context.Context, filters ProductFilters) (map[string]int, error) {
+	start := time.Now()
+	counts, err := r.ProductRepository.CountByCategory(ctx, filters)
+	r.record("CountByCategory", start, err)
+	return counts, err
+}
+
+// ReserveStock times ProductRepository.ReserveStock
+func (r *InstrumentedProductRepository) ReserveStock(ctx context.Context, lines []models.StockLine) error {
+	start := time.Now()
+	err := r.ProductRepository.ReserveStock(ctx, lines)
+	r.record("ReserveStock", start, err)
+	return err
+}
+
+// ReleaseStock times ProductRepository.ReleaseStock
+func (r *InstrumentedProductRepository) ReleaseStock(ctx context.Context, lines []models.StockLine) error {
+	start := time.Now()
+	err := r.ProductRepository.ReleaseStock(ctx, lines)
+	r.record("ReleaseStock", start, err)
+	return err
+}
+
+// BulkWrite times ProductRepository.BulkWrite
+func (r *InstrumentedProductRepository) BulkWrite(ctx context.Context, products []*models.Product, mode BulkMode) (*BulkWriteResult, error) {
+	start := time.Now()
+	result, err := r.ProductRepository.BulkWrite(ctx, products, mode)
+	r.record("BulkWrite", start, err)
+	return result, err
+}
+
+// Iterate times ProductRepository.Iterate, including the time spent in yield
+func (r *InstrumentedProductRepository) Iterate(ctx context.Context, filters ProductFilters, yield func(*models.Product) error) error {
+	start := time.Now()
+	err := r.ProductRepository.Iterate(ctx, filters, yield)
+	r.record("Iterate", start, err)
+	return err
+}
+
+// Search times ProductRepository.Search
+func (r *InstrumentedProductRepository) Search(ctx context.Context, search ProductSearch) (*ProductSearchResult, error) {
+	start := time.Now()
+	result, err := r.ProductRepository.Search(ctx, search)
+	r.record("Search", start, err)
+	return result, err
+}
+
+// HealthCheck times ProductRepository.HealthCheck
+func (r *InstrumentedProductRepository) HealthCheck(ctx context.Context) error {
+	start := time.Now()
+	err := r.ProductRepository.HealthCheck(ctx)
+	r.record("HealthCheck", start, err)
+	return err
+}
+
+// record reports one call to the observer
+func (r *InstrumentedProductRepository) record(operation string, start time.Time, err error) {
+	r.observe(operation, operationOutcome(err), time.Since(start))
+}
+
+// operationOutcome classifies a repository error for metrics
+func operationOutcome(err error) string {
+	switch {
+	case err == nil:
+		return OutcomeSuccess
+	case errors.Is(err, ErrProductNotFound),
+		errors.Is(err, ErrProductExists),
+		errors.Is(err, ErrSKUExists),
+		errors.Is(err, ErrVersionConflict),
+		errors.Is(err, ErrInsufficientStock):
+		return OutcomeRejected
+	default:
+		return OutcomeError
+	}
+}
@@ -2,10 +2,18 @@ package repository
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"strings"
 	"time"
 
+	"github.com/product-api-v2/configs"
 	"github.com/product-api-v2/internal/models"
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
@@ -16,22 +24,28 @@ type MongoProductRepository struct {
 	client     *mongo.Client
 }
 
-// NewMongoProductRepository creates a new MongoDB product repository
-func NewMongoProductRepository(mongoURL string) (*MongoProductRepository, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-
-	client, err := mongo.Connect(ctx, options.Client().ApplyURI(mongoURL))
+// NewMongoProductRepository connects to config.Database with the pool,
+// timeout and TLS settings in config and keeps products in config.Collection.
+// The repositories of the other product collections share its connection.
+func NewMongoProductRepository(config configs.DatabaseConfig) (*MongoProductRepository, error) {
+	client, err := connectMongo(config)
 	if err != nil {
 		return nil, err
 	}
 
-	// Test connection
-	if err := client.Ping(ctx, nil); err != nil {
+	collection := client.Database(config.Database).Collection(config.Collection)
+	if err := ensureSearchIndex(collection, config); err != nil {
+		client.Disconnect(context.Background())
+		return nil, err
+	}
+	if err := ensureSKUIndex(collection, config); err != nil {
+		client.Disconnect(context.Background())
+		return nil, err
+	}
+	if err := migrateLegacyPrices(collection, config); err != nil {
+		client.Disconnect(context.Background())
 		return nil, err
 	}
-
-	collection := client.Database("catalog").Collection("products")
 
 	return &MongoProductRepository{
 		collection: collection,
@@ -47,49 +61,83 @@ func (r *MongoProductRepository) GetByID(ctx context.Context, productID string)
 	err := r.collection.FindOne(ctx, filter).Decode(&product)
 	
 	if err != nil {
-		if err == mongo.ErrNoDocuments {
+		if errors.Is(err, mongo.ErrNoDocuments) {
 			return nil, ErrProductNotFound
 		}
-		return nil, err
+		return nil, wrapMongoError(err)
 	}
 	
 	return &product, nil
 }
 
-// GetAll retrieves all products with optional filtering from MongoDB
-func (r *MongoProductRepository) GetAll(ctx context.Context, filters ProductFilters) ([]*models.Product, error) {
-	// Build MongoDB filter
-	filter := bson.M{}
+// GetByIDFields retrieves a product from MongoDB with a projection on fields
+func (r *MongoProductRepository) GetByIDFields(ctx context.Context, productID string, fields []string) (*models.Product, error) {
+	var product models.Product
 	
-	if filters.Category != "" {
-		filter["category"] = filters.Category
-	}
+	filter := bson.M{"productId": productID}
+	opts := options.FindOne().SetProjection(productProjection(fields))
+	err := r.collection.FindOne(ctx, filter, opts).Decode(&product)
 	
-	if filters.Active != nil {
-		filter["active"] = *filters.Active
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrProductNotFound
+		}
+		return nil, wrapMongoError(err)
 	}
 	
-	if filters.MinPrice != nil || filters.MaxPrice != nil {
-		priceFilter := bson.M{}
-		if filters.MinPrice != nil {
-			priceFilter["$gte"] = *filters.MinPrice
-		}
-		if filters.MaxPrice != nil {
-			priceFilter["$lte"] = *filters.MaxPrice
+	return &product, nil
+}
+
+// GetByIDs retrieves every product whose ID is in productIDs with a single $in query
+func (r *MongoProductRepository) GetByIDs(ctx context.Context, productIDs []string) ([]*models.Product, error) {
+	filter := bson.M{"productId": bson.M{"$in": productIDs}}
+
+	cursor, err := r.collection.Find(ctx, filter)
+	if err != nil {
+		return nil, wrapMongoError(err)
+	}
+	defer cursor.Close(ctx)
+
+	products := make([]*models.Product, 0, len(productIDs))
+	for cursor.Next(ctx) {
+		var product models.Product
+		if err := cursor.Decode(&product); err != nil {
+			return nil, wrapMongoError(err)
 		}
-		filter["price"] = priceFilter
+		products = append(products, &product)
 	}
+
+	if err := cursor.Err(); err != nil {
+		return nil, wrapMongoError(err)
+	}
+
+	return products, nil
+}
+
+// GetAll retrieves all products with optional filtering from MongoDB
+func (r *MongoProductRepository) GetAll(ctx context.Context, filters ProductFilters) ([]*models.Product, error) {
+	filter := productFilter(filters)
+	order := productOrder(filters)
+	backward := filters.Cursor != nil && filters.Cursor.Backward
 	
-	// Build options for pagination
-	opts := options.Find()
+	// Keyset pagination when a cursor is given, offsets otherwise
+	opts := options.Find().SetSort(mongoSort(order, backward))
+	if len(filters.Fields) > 0 {
+		opts.SetProjection(productProjection(withFields(filters.Fields, orderFields(order)...)))
+	}
+	if filters.Cursor != nil {
+		filter = bson.M{"$and": bson.A{filter, keysetFilter(order, filters.Cursor)}}
+	}
 	if filters.PageSize > 0 {
 		opts.SetLimit(int64(filters.PageSize))
-		opts.SetSkip(int64(filters.Page * filters.PageSize))
+		if filters.Cursor == nil {
+			opts.SetSkip(int64(filters.Page * filters.PageSize))
+		}
 	}
 	
 	cursor, err := r.collection.Find(ctx, filter, opts)
 	if err != nil {
-		return nil, err
+		return nil, wrapMongoError(err)
 	}
 	defer cursor.Close(ctx)
 	
@@ -97,13 +145,18 @@ func (r *MongoProductRepository) GetAll(ctx context.Context, filters ProductFilt
 	for cursor.Next(ctx) {
 		var product models.Product
 		if err := cursor.Decode(&product); err != nil {
-			return nil, err
+			return nil, wrapMongoError(err)
 		}
 		products = append(products, &product)
 	}
 	
 	if err := cursor.Err(); err != nil {
-		return nil, err
+		return nil, wrapMongoError(err)
+	}
+	
+	// Backward pages are read in reverse order
+	if backward {
+		slices.Reverse(products)
 	}
 	
 	return products, nil
@@ -119,25 +172,51 @@ func (r *MongoProductRepository) Create(ctx context.Context, product *models.Pro
 	
 	product.CreatedAt = time.Now()
 	product.UpdatedAt = time.Now()
+	product.Version = 1
 	
 	_, err = r.collection.InsertOne(ctx, product)
-	return err
+	if isSKUConflict(err) {
+		return ErrSKUExists
+	}
+	return wrapMongoError(err)
 }
 
-// Update modifies an existing product in MongoDB
+// Update modifies an existing product in MongoDB. The write only matches if
+// the stored version equals product.Version, so concurrent editors cannot
+// overwrite each other silently.
 func (r *MongoProductRepository) Update(ctx context.Context, product *models.Product) error {
+	expectedVersion := product.Version
 	filter := bson.M{"productId": product.ProductID}
+	if expectedVersion == 0 {
+		// Documents created before versioning have no version field
+		filter["version"] = bson.M{"$in": bson.A{0, nil}}
+	} else {
+		filter["version"] = expectedVersion
+	}
 	
 	product.UpdatedAt = time.Now()
+	product.Version = expectedVersion + 1
 	update := bson.M{"$set": product}
+	// Empty variant fields are left out of $set, so clearing one removes it
+	if unset := emptyVariantFields(product); len(unset) > 0 {
+		update["$unset"] = unset
+	}
 	
 	result, err := r.collection.UpdateOne(ctx, filter, update)
 	if err != nil {
-		return err
+		product.Version = expectedVersion
+		if isSKUConflict(err) {
+			return ErrSKUExists
+		}
+		return wrapMongoError(err)
 	}
 	
 	if result.MatchedCount == 0 {
-		return ErrProductNotFound
+		product.Version = expectedVersion
+		if _, err := r.GetByID(ctx, product.ProductID); err != nil {
+			return err
+		}
+		return ErrVersionConflict
 	}
 	
 	return nil
@@ -149,7 +228,7 @@ func (r *MongoProductRepository) Delete(ctx context.Context, productID string) e
 	
 	result, err := r.collection.DeleteOne(ctx, filter)
 	if err != nil {
-		return err
+		return wrapMongoError(err)
 	}
 	
 	if result.DeletedCount == 0 {
@@ -161,37 +240,542 @@ func (r *MongoProductRepository) Delete(ctx context.Context, productID string) e
 
 // Count returns the total number of products matching the filters
 func (r *MongoProductRepository) Count(ctx context.Context, filters ProductFilters) (int, error) {
-	filter := bson.M{}
+	filter := productFilter(filters)
 	
-	if filters.Category != "" {
-		filter["category"] = filters.Category
+	count, err := r.collection.CountDocuments(ctx, filter)
+	return int(count), wrapMongoError(err)
+}
+
+// CountByCategory groups the matching products by category on the server
+func (r *MongoProductRepository) CountByCategory(ctx context.Context, filters ProductFilters) (map[string]int, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: productFilter(filters)}},
+		{{Key: "$group", Value: bson.M{"_id": bson.M{"$ifNull": bson.A{"$category", ""}}, "count": bson.M{"$sum": 1}}}},
 	}
 	
-	if filters.Active != nil {
-		filter["active"] = *filters.Active
+	cursor, err := r.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, wrapMongoError(err)
 	}
+	defer cursor.Close(ctx)
 	
-	if filters.MinPrice != nil || filters.MaxPrice != nil {
-		priceFilter := bson.M{}
-		if filters.MinPrice != nil {
-			priceFilter["$gte"] = *filters.MinPrice
+	var groups []struct {
+		Category string `bson:"_id"`
+		Count    int    `bson:"count"`
+	}
+	if err := cursor.All(ctx, &groups); err != nil {
+		return nil, wrapMongoError(err)
+	}
+	
+	counts := make(map[string]int, len(groups))
+	for _, group := range groups {
+		counts[group.Category] = group.Count
+	}
+	return counts, nil
+}
+
+// ReserveStock decrements stock line by line with conditional $inc updates
+// (stock >= quantity). If any line cannot be satisfied, the lines already
+// applied are incremented back so the reservation is all-or-nothing.
+func (r *MongoProductRepository) ReserveStock(ctx context.Context, lines []models.StockLine) error {
+	lines = mergeStockLines(lines)
+	applied := make([]models.StockLine, 0, len(lines))
+
+	for _, line := range lines {
+		filter := bson.M{"productId": line.ProductID, "stock": bson.M{"$gte": line.Quantity}}
+		update := bson.M{
+			"$inc": bson.M{"stock": -line.Quantity, "version": 1},
+			"$set": bson.M{"updatedAt": time.Now()},
+		}
+
+		result, err := r.collection.UpdateOne(ctx, filter, update)
+		if err == nil && result.MatchedCount == 1 {
+			applied = append(applied, line)
+			continue
+		}
+
+		cause := wrapMongoError(err)
+		if err == nil {
+			cause = &StockError{ProductID: line.ProductID, Requested: line.Quantity, Err: ErrInsufficientStock}
+			if _, getErr := r.GetByID(ctx, line.ProductID); errors.Is(getErr, ErrProductNotFound) {
+				cause = &StockError{ProductID: line.ProductID, Requested: line.Quantity, Err: ErrProductNotFound}
+			}
+		}
+
+		// Roll back even if the caller's context was cancelled
+		if rollbackErr := r.incrementStock(context.WithoutCancel(ctx), applied); rollbackErr != nil {
+			return fmt.Errorf("%w (rollback failed: %v)", cause, rollbackErr)
+		}
+		return cause
+	}
+
+	return nil
+}
+
+// ReleaseStock returns reserved stock to the products
+func (r *MongoProductRepository) ReleaseStock(ctx context.Context, lines []models.StockLine) error {
+	return r.incrementStock(ctx, mergeStockLines(lines))
+}
+
+// incrementStock adds the line quantities back to each product
+func (r *MongoProductRepository) incrementStock(ctx context.Context, lines []models.StockLine) error {
+	for _, line := range lines {
+		filter := bson.M{"productId": line.ProductID}
+		update := bson.M{
+			"$inc": bson.M{"stock": line.Quantity, "version": 1},
+			"$set": bson.M{"updatedAt": time.Now()},
 		}
-		if filters.MaxPrice != nil {
-			priceFilter["$lte"] = *filters.MaxPrice
+
+		if _, err := r.collection.UpdateOne(ctx, filter, update); err != nil {
+			return wrapMongoError(err)
 		}
-		filter["price"] = priceFilter
+	}
+	return nil
+}
+
+// BulkWrite sends all products as one unordered bulk write of upserts keyed
+// on productId. Insert-only writes use $setOnInsert, so an existing product
+// matches without being modified and is reported as ErrProductExists.
+func (r *MongoProductRepository) BulkWrite(ctx context.Context, products []*models.Product, mode BulkMode) (*BulkWriteResult, error) {
+	result := &BulkWriteResult{Failed: make(map[int]error)}
+	if len(products) == 0 {
+		return result, nil
 	}
 	
-	count, err := r.collection.CountDocuments(ctx, filter)
-	return int(count), err
+	now := time.Now()
+	writes := make([]mongo.WriteModel, 0, len(products))
+	for _, product := range products {
+		stored := *product
+		stored.NormalizePrice()
+		fields := bson.M{
+			"name":        product.Name,
+			"description": product.Description,
+			"price":       models.DecimalAmount(stored.PriceMinor, stored.Currency),
+			"priceMinor":  stored.PriceMinor,
+			"currency":    stored.Currency,
+			"category":    product.Category,
+			"stock":       product.Stock,
+			"active":      product.Active,
+			"updatedAt":   now,
+		}
+		
+		// Empty variant fields are removed rather than stored empty, so a
+		// product that is no variant keeps having no parentId at all
+		unset := emptyVariantFields(product)
+		if product.ParentID != "" {
+			fields["parentId"] = product.ParentID
+		}
+		if product.SKU != "" {
+			fields["sku"] = product.SKU
+		}
+		if len(product.Attributes) > 0 {
+			fields["attributes"] = product.Attributes
+		}
+		
+		var update bson.M
+		if mode == BulkInsertOnly {
+			fields["createdAt"] = now
+			fields["version"] = 1
+			update = bson.M{"$setOnInsert": fields}
+		} else {
+			update = bson.M{
+				"$set":         fields,
+				"$setOnInsert": bson.M{"createdAt": now},
+				"$inc":         bson.M{"version": 1},
+			}
+			if len(unset) > 0 {
+				update["$unset"] = unset
+			}
+		}
+		
+		writes = append(writes, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"productId": product.ProductID}).
+			SetUpdate(update).
+			SetUpsert(true))
+	}
+	
+	bulkResult, err := r.collection.BulkWrite(ctx, writes, options.BulkWrite().SetOrdered(false))
+	if err != nil {
+		var bulkErr mongo.BulkWriteException
+		if !errors.As(err, &bulkErr) || bulkErr.WriteConcernError != nil {
+			return nil, wrapMongoError(err)
+		}
+		for _, writeErr := range bulkErr.WriteErrors {
+			switch {
+			case writeErr.Code == duplicateKeyCode && strings.Contains(writeErr.Message, skuIndexName):
+				result.Failed[writeErr.Index] = ErrSKUExists
+			case writeErr.Code == duplicateKeyCode:
+				result.Failed[writeErr.Index] = ErrProductExists
+			default:
+				result.Failed[writeErr.Index] = writeErr
+			}
+		}
+	}
+	
+	for i := range products {
+		if _, failed := result.Failed[i]; failed {
+			continue
+		}
+		switch _, inserted := bulkResult.UpsertedIDs[int64(i)]; {
+		case inserted:
+			result.Inserted++
+		case mode == BulkInsertOnly:
+			result.Failed[i] = ErrProductExists
+		default:
+			result.Updated++
+		}
+	}
+	
+	return result, nil
+}
+
+// Iterate streams the matching products from a cursor sorted by productId
+func (r *MongoProductRepository) Iterate(ctx context.Context, filters ProductFilters, yield func(*models.Product) error) error {
+	opts := options.Find().SetSort(bson.D{{Key: "productId", Value: 1}})
+	
+	cursor, err := r.collection.Find(ctx, productFilter(filters), opts)
+	if err != nil {
+		return wrapMongoError(err)
+	}
+	defer cursor.Close(ctx)
+	
+	for cursor.Next(ctx) {
+		var product models.Product
+		if err := cursor.Decode(&product); err != nil {
+			return wrapMongoError(err)
+		}
+		if err := yield(&product); err != nil {
+			return err
+		}
+	}
+	
+	return wrapMongoError(cursor.Err())
+}
+
+// emptyVariantFields lists the variant fields product leaves empty, for
+// $unset. Stored documents omit them rather than hold empty values, which
+// keeps products without a SKU out of the unique SKU index.
+func emptyVariantFields(product *models.Product) bson.M {
+	unset := bson.M{}
+	if product.ParentID == "" {
+		unset["parentId"] = ""
+	}
+	if product.SKU == "" {
+		unset["sku"] = ""
+	}
+	if len(product.Attributes) == 0 {
+		unset["attributes"] = ""
+	}
+	return unset
+}
+
+// skuIndexName names the unique SKU index, which duplicate key errors
+// mention
+const skuIndexName = "product_sku"
+
+// ensureSKUIndex creates the unique index on sku. It only covers documents
+// that have a SKU, so any number of products can go without one. The plain
+// index earlier versions of the init script created is dropped first, as
+// MongoDB refuses a second index on the same key.
+func ensureSKUIndex(collection *mongo.Collection, config configs.DatabaseConfig) error {
+	timeout := config.ConnectTimeout
+	if timeout <= 0 {
+		timeout = defaultConnectTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	
+	var commandErr mongo.CommandError
+	if _, err := collection.Indexes().DropOne(ctx, "sku_1"); err != nil && !(errors.As(err, &commandErr) && commandErr.Code == indexNotFoundCode) {
+		return wrapMongoError(err)
+	}
+	
+	_, err := collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "sku", Value: 1}},
+		Options: options.Index().
+			SetName(skuIndexName).
+			SetUnique(true).
+			SetPartialFilterExpression(bson.M{"sku": bson.M{"$exists": true}}),
+	})
+	return wrapMongoError(err)
+}
+
+// isSKUConflict reports whether err is a duplicate key on the SKU index
+func isSKUConflict(err error) bool {
+	return mongo.IsDuplicateKeyError(err) && strings.Contains(err.Error(), skuIndexName)
+}
+
+// searchIndexName names the text index, so it is not recreated under another name
+const searchIndexName = "product_search"
+
+// ensureSearchIndex creates the weighted text index Search relies on. The
+// index ignores language rules (no stemming or stop words), which keeps
+// matches identical to the in-memory index; accents and case are ignored.
+func ensureSearchIndex(collection *mongo.Collection, config configs.DatabaseConfig) error {
+	timeout := config.ConnectTimeout
+	if timeout <= 0 {
+		timeout = defaultConnectTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	
+	keys := bson.D{}
+	weights := bson.D{}
+	for _, field := range searchFields {
+		keys = append(keys, bson.E{Key: field.name, Value: "text"})
+		weights = append(weights, bson.E{Key: field.name, Value: int32(field.weight)})
+	}
+	
+	_, err := collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: keys,
+		Options: options.Index().
+			SetName(searchIndexName).
+			SetWeights(weights).
+			SetDefaultLanguage("none"),
+	})
+	return wrapMongoError(err)
+}
+
+// migrateLegacyPrices converts documents stored before prices had minor
+// units, whose price is a plain number in DefaultCurrency. Filters and
+// sorts use priceMinor, so they would skip these documents otherwise.
+// Converted documents no longer match, which makes this a no-op once done.
+func migrateLegacyPrices(collection *mongo.Collection, config configs.DatabaseConfig) error {
+	timeout := config.ConnectTimeout
+	if timeout <= 0 {
+		timeout = defaultConnectTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	
+	exponent, _ := models.CurrencyExponent(models.DefaultCurrency)
+	amount := bson.M{"$round": bson.A{bson.M{"$toDecimal": "$price"}, exponent}}
+	migration := bson.A{bson.M{"$set": bson.M{
+		"price":      amount,
+		"priceMinor": bson.M{"$toLong": bson.M{"$multiply": bson.A{amount, int64(math.Pow10(exponent))}}},
+		"currency":   models.DefaultCurrency,
+	}}}
+	
+	filter := bson.M{"priceMinor": bson.M{"$exists": false}, "price": bson.M{"$type": "number"}}
+	_, err := collection.UpdateMany(ctx, filter, migration)
+	return wrapMongoError(err)
+}
+
+// Search runs one aggregation: the text index (and a regular expression for
+// the prefix) selects the products, and a $facet stage returns the page of
+// hits, the total and the facet counts together
+func (r *MongoProductRepository) Search(ctx context.Context, search ProductSearch) (*ProductSearchResult, error) {
+	match := productFilter(search.Filters)
+	score := bson.A{}
+	if len(search.Terms) > 0 {
+		match["$text"] = bson.M{"$search": strings.Join(search.Terms, " ")}
+		score = append(score, bson.M{"$meta": "textScore"})
+	}
+	if search.Prefix != "" {
+		pattern := prefixPattern(search.Prefix)
+		prefixMatch := make(bson.A, 0, len(searchFields))
+		for _, field := range searchFields {
+			prefixMatch = append(prefixMatch, bson.M{field.name: primitive.Regex{Pattern: pattern, Options: "i"}})
+			score = append(score, bson.M{"$cond": bson.A{
+				bson.M{"$regexMatch": bson.M{"input": bson.M{"$ifNull": bson.A{"$" + field.name, ""}}, "regex": pattern, "options": "i"}},
+				field.weight,
+				0,
+			}})
+		}
+		match["$or"] = prefixMatch
+	}
+	
+	hits := bson.A{bson.M{"$sort": bson.D{{Key: "score", Value: -1}, {Key: "productId", Value: 1}}}}
+	if pageSize := search.Filters.PageSize; pageSize > 0 {
+		hits = append(hits,
+			bson.M{"$skip": search.Filters.Page * pageSize},
+			bson.M{"$limit": pageSize})
+	}
+	facets := bson.M{
+		"hits":  hits,
+		"total": bson.A{bson.M{"$count": "count"}},
+		"categories": bson.A{
+			bson.M{"$match": bson.M{"category": bson.M{"$nin": bson.A{nil, ""}}}},
+			bson.M{"$group": bson.M{"_id": "$category", "count": bson.M{"$sum": 1}}},
+		},
+	}
+	currency, bounds, minorBounds := priceFacetBounds(search)
+	if len(bounds) > 0 {
+		boundaries := bson.A{int64(0)}
+		for _, bound := range minorBounds {
+			boundaries = append(boundaries, bound)
+		}
+		facets["prices"] = bson.A{
+			bson.M{"$match": bson.M{"currency": currency}},
+			bson.M{"$bucket": bson.M{
+				"groupBy":    "$priceMinor",
+				"boundaries": boundaries,
+				"default":    "above",
+				"output":     bson.M{"count": bson.M{"$sum": 1}},
+			}},
+		}
+	}
+	
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		{{Key: "$addFields", Value: bson.M{"score": bson.M{"$add": score}}}},
+		{{Key: "$facet", Value: facets}},
+	}
+	
+	cursor, err := r.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, wrapMongoError(err)
+	}
+	defer cursor.Close(ctx)
+	
+	var output []struct {
+		Hits  []bson.Raw `bson:"hits"`
+		Total []struct {
+			Count int `bson:"count"`
+		} `bson:"total"`
+		Categories []struct {
+			Category string `bson:"_id"`
+			Count    int    `bson:"count"`
+		} `bson:"categories"`
+		Prices []struct {
+			Bucket interface{} `bson:"_id"`
+			Count  int         `bson:"count"`
+		} `bson:"prices"`
+	}
+	if err := cursor.All(ctx, &output); err != nil {
+		return nil, wrapMongoError(err)
+	}
+	
+	result := &ProductSearchResult{
+		Hits:   []ScoredProduct{},
+		Facets: models.SearchFacets{Categories: []models.CategoryFacet{}, Prices: []models.PriceFacet{}},
+	}
+	if len(output) == 0 {
+		return result, nil
+	}
+	
+	// Hits are decoded one by one, as an inlined product would skip the
+	// conversion of its stored price
+	page := output[0]
+	for _, raw := range page.Hits {
+		var product models.Product
+		if err := bson.Unmarshal(raw, &product); err != nil {
+			return nil, err
+		}
+		score, ok := raw.Lookup("score").DoubleOK()
+		if !ok {
+			whole, _ := raw.Lookup("score").AsInt64OK()
+			score = float64(whole)
+		}
+		result.Hits = append(result.Hits, ScoredProduct{Product: &product, Score: score})
+	}
+	if len(page.Total) > 0 {
+		result.Total = page.Total[0].Count
+	}
+	for _, category := range page.Categories {
+		result.Facets.Categories = append(result.Facets.Categories, models.CategoryFacet{Category: category.Category, Count: category.Count})
+	}
+	sortCategoryFacets(result.Facets.Categories)
+	
+	// $bucket labels each bucket with its lower bound, so the n-th boundary
+	// starts bucket n; the default bucket is the open one above the last bound
+	if len(bounds) > 0 {
+		result.Facets.PriceCurrency = currency
+	}
+	for _, bucket := range page.Prices {
+		index := len(bounds)
+		if lower, ok := bucket.Bucket.(int64); ok {
+			index = sort.Search(len(minorBounds), func(i int) bool { return minorBounds[i] > lower })
+		}
+		result.Facets.Prices = append(result.Facets.Prices, priceFacet(bounds, index, bucket.Count))
+	}
+	
+	return result, nil
 }
 
 // HealthCheck verifies the MongoDB connection is working
 func (r *MongoProductRepository) HealthCheck(ctx context.Context) error {
-	return r.client.Ping(ctx, nil)
+	return wrapMongoError(r.client.Ping(ctx, nil))
 }
 
 // Close closes the MongoDB connection
 func (r *MongoProductRepository) Close(ctx context.Context) error {
 	return r.client.Disconnect(ctx)
-}
\ No newline at end of file
+}
+
+const (
+	// duplicateKeyCode is the server error for a unique index violation
+	duplicateKeyCode = 11000
+	
+	// indexNotFoundCode is the server error for dropping a missing index
+	indexNotFoundCode = 27
+)
+
+// productFilter translates filters into a MongoDB query, ignoring pagination
+func productFilter(filters ProductFilters) bson.M {
+	filter := bson.M{}
+	
+	if filters.Category != "" {
+		filter["category"] = filters.Category
+	}
+	
+	if len(filters.Categories) > 0 {
+		categories := bson.M{"$in": filters.Categories}
+		if filters.Category != "" {
+			categories["$eq"] = filters.Category
+		}
+		filter["category"] = categories
+	}
+	
+	if filters.Active != nil {
+		filter["active"] = *filters.Active
+	}
+	
+	// Kept apart from the active filter, so public listings asking for
+	// inactive products match nothing
+	if filters.Visibility == VisibilityPublic {
+		filter["$and"] = bson.A{bson.M{"active": true}}
+	}
+	
+	if filters.Currency != "" {
+		filter["currency"] = filters.Currency
+	}
+	
+	// Products that are not variants have no parentId at all
+	if filters.TopLevel {
+		filter["parentId"] = nil
+	}
+	
+	if len(filters.ParentIDs) > 0 {
+		filter["parentId"] = bson.M{"$in": filters.ParentIDs}
+	}
+	
+	if filters.SKU != "" {
+		filter["sku"] = filters.SKU
+	}
+	
+	if minPrice, maxPrice := priceRange(filters); minPrice != nil || maxPrice != nil {
+		priceFilter := bson.M{}
+		if minPrice != nil {
+			priceFilter["$gte"] = *minPrice
+		}
+		if maxPrice != nil {
+			priceFilter["$lte"] = *maxPrice
+		}
+		filter["priceMinor"] = priceFilter
+	}
+	
+	return filter
+}
+
+// wrapMongoError marks connectivity failures and timeouts with
+// ErrRepositoryUnavailable so callers can tell them apart from bad queries
+func wrapMongoError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if mongo.IsNetworkError(err) || mongo.IsTimeout(err) || errors.Is(err, mongo.ErrClientDisconnected) {
+		return fmt.Errorf("%w: %v", ErrRepositoryUnavailable, err)
+	}
+	return err
+}
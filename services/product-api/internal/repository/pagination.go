@@ -0,0 +1,278 @@
+package repository
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+var (
+	ErrInvalidSort   = errors.New("invalid sort")
+	ErrInvalidCursor = errors.New("invalid cursor")
+)
+
+// SortField orders a listing by one field
+type SortField struct {
+	Field      string
+	Descending bool
+}
+
+// Cursor is a position in a sorted listing, given by the sort values of the
+// item a page starts after. Backward pages end just before that item instead.
+type Cursor struct {
+	Values   []interface{}
+	Backward bool
+}
+
+// sortKind is the type of a sortable field, needed to decode cursor values
+type sortKind int
+
+const (
+	sortString sortKind = iota
+	sortNumber
+	sortInteger
+	sortTime
+)
+
+// cursorToken is the JSON form of a cursor before base64 encoding. The sort
+// it was issued for is kept so it cannot be replayed against another order.
+type cursorToken struct {
+	Sort     string        `json:"s"`
+	Values   []interface{} `json:"v"`
+	Backward bool          `json:"b,omitempty"`
+}
+
+// parseSort turns a spec such as "price,-createdAt" into sort fields. The
+// unique idField is appended when missing so that every order is total,
+// which keyset pagination relies on.
+func parseSort(spec string, fields map[string]sortKind, idField string) ([]SortField, error) {
+	var order []SortField
+	seen := make(map[string]bool)
+
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		field := SortField{Field: strings.TrimPrefix(part, "-"), Descending: strings.HasPrefix(part, "-")}
+		if _, known := fields[field.Field]; !known {
+			return nil, fmt.Errorf("%w: cannot sort by %q", ErrInvalidSort, field.Field)
+		}
+		if seen[field.Field] {
+			return nil, fmt.Errorf("%w: %q appears more than once", ErrInvalidSort, field.Field)
+		}
+		seen[field.Field] = true
+		order = append(order, field)
+	}
+
+	if !seen[idField] {
+		order = append(order, SortField{Field: idField})
+	}
+	return order, nil
+}
+
+// formatSort is the inverse of parseSort
+func formatSort(order []SortField) string {
+	parts := make([]string, 0, len(order))
+	for _, field := range order {
+		if field.Descending {
+			parts = append(parts, "-"+field.Field)
+		} else {
+			parts = append(parts, field.Field)
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+// encodeCursor returns the opaque token for the position at values
+func encodeCursor(order []SortField, values []interface{}, backward bool) string {
+	encoded := make([]interface{}, len(values))
+	for i, value := range values {
+		if t, ok := value.(time.Time); ok {
+			value = t.UTC().Format(time.RFC3339Nano)
+		}
+		encoded[i] = value
+	}
+
+	data, _ := json.Marshal(cursorToken{Sort: formatSort(order), Values: encoded, Backward: backward})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeCursor parses a token produced by encodeCursor for the same order
+func decodeCursor(token string, order []SortField, fields map[string]sortKind) (*Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed token", ErrInvalidCursor)
+	}
+
+	var decoded cursorToken
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return nil, fmt.Errorf("%w: malformed token", ErrInvalidCursor)
+	}
+	if decoded.Sort != formatSort(order) {
+		return nil, fmt.Errorf("%w: cursor was issued for sort %q", ErrInvalidCursor, decoded.Sort)
+	}
+	if len(decoded.Values) != len(order) {
+		return nil, fmt.Errorf("%w: malformed token", ErrInvalidCursor)
+	}
+
+	cursor := &Cursor{Values: make([]interface{}, len(order)), Backward: decoded.Backward}
+	for i, field := range order {
+		value, ok := decodeSortValue(decoded.Values[i], fields[field.Field])
+		if !ok {
+			return nil, fmt.Errorf("%w: bad value for %s", ErrInvalidCursor, field.Field)
+		}
+		cursor.Values[i] = value
+	}
+	return cursor, nil
+}
+
+// decodeSortValue converts a JSON-decoded cursor value back to its Go type
+func decodeSortValue(value interface{}, kind sortKind) (interface{}, bool) {
+	switch kind {
+	case sortString:
+		s, ok := value.(string)
+		return s, ok
+	case sortNumber:
+		f, ok := value.(float64)
+		return f, ok
+	case sortInteger:
+		f, ok := value.(float64)
+		return int(f), ok && f == float64(int(f))
+	case sortTime:
+		s, ok := value.(string)
+		if !ok {
+			return nil, false
+		}
+		t, err := time.Parse(time.RFC3339Nano, s)
+		return t, err == nil
+	}
+	return nil, false
+}
+
+// compareSortValues orders two values of the same sortable field
+func compareSortValues(a, b interface{}) int {
+	switch a := a.(type) {
+	case string:
+		return strings.Compare(a, b.(string))
+	case float64:
+		b := b.(float64)
+		switch {
+		case a < b:
+			return -1
+		case a > b:
+			return 1
+		}
+	case int:
+		b := b.(int)
+		switch {
+		case a < b:
+			return -1
+		case a > b:
+			return 1
+		}
+	case time.Time:
+		return a.Compare(b.(time.Time))
+	}
+	return 0
+}
+
+// compareKeys orders two sort keys under order
+func compareKeys(order []SortField, a, b []interface{}) int {
+	for i, field := range order {
+		if result := compareSortValues(a[i], b[i]); result != 0 {
+			if field.Descending {
+				return -result
+			}
+			return result
+		}
+	}
+	return 0
+}
+
+// sortPage sorts items for the in-memory repositories and cuts out the
+// requested page: the items after or before cursor, or the offset page when
+// there is no cursor. A pageSize of zero means no limit.
+func sortPage[T any](items []*T, order []SortField, key func(*T) []interface{}, cursor *Cursor, page, pageSize int) []*T {
+	type entry struct {
+		item *T
+		key  []interface{}
+	}
+
+	entries := make([]entry, len(items))
+	for i, item := range items {
+		entries[i] = entry{item: item, key: key(item)}
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return compareKeys(order, entries[i].key, entries[j].key) < 0
+	})
+
+	start, end := 0, len(entries)
+	switch {
+	case cursor == nil:
+		if pageSize > 0 {
+			start = min(page*pageSize, end)
+			end = min(start+pageSize, end)
+		}
+	case cursor.Backward:
+		end = sort.Search(len(entries), func(i int) bool {
+			return compareKeys(order, entries[i].key, cursor.Values) >= 0
+		})
+		if pageSize > 0 {
+			start = max(end-pageSize, 0)
+		}
+	default:
+		start = sort.Search(len(entries), func(i int) bool {
+			return compareKeys(order, entries[i].key, cursor.Values) > 0
+		})
+		if pageSize > 0 {
+			end = min(start+pageSize, end)
+		}
+	}
+
+	result := make([]*T, 0, end-start)
+	for _, entry := range entries[start:end] {
+		result = append(result, entry.item)
+	}
+	return result
+}
+
+// mongoSort returns the sort document for order, reversed for backward pages
+func mongoSort(order []SortField, backward bool) bson.D {
+	document := make(bson.D, 0, len(order))
+	for _, field := range order {
+		direction := 1
+		if field.Descending != backward {
+			direction = -1
+		}
+		document = append(document, bson.E{Key: field.Field, Value: direction})
+	}
+	return document
+}
+
+// keysetFilter matches the documents after cursor in order, or before it for
+// backward pages: for a sort on (a, b) it is a > x OR (a = x AND b > y)
+func keysetFilter(order []SortField, cursor *Cursor) bson.M {
+	clauses := make(bson.A, 0, len(order))
+	for i, field := range order {
+		clause := bson.M{}
+		for j := 0; j < i; j++ {
+			clause[order[j].Field] = cursor.Values[j]
+		}
+
+		operator := "$gt"
+		if field.Descending != cursor.Backward {
+			operator = "$lt"
+		}
+		clause[field.Field] = bson.M{operator: cursor.Values[i]}
+		clauses = append(clauses, clause)
+	}
+	return bson.M{"$or": clauses}
+}
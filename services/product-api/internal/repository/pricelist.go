@@ -0,0 +1,172 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/product-api-v2/internal/models"
+)
+
+var (
+	ErrPriceListNotFound = errors.New("price list not found")
+	ErrPriceListExists   = errors.New("price list already exists")
+)
+
+// PriceListRepository defines the interface for price list storage
+type PriceListRepository interface {
+	Create(ctx context.Context, priceList *models.PriceList) error
+	GetByID(ctx context.Context, priceListID string) (*models.PriceList, error)
+	// GetAll returns every price list ordered by ID
+	GetAll(ctx context.Context) ([]*models.PriceList, error)
+	// Update replaces a price list if it is still at priceList.Version and
+	// fails with ErrVersionConflict otherwise
+	Update(ctx context.Context, priceList *models.PriceList) error
+	Delete(ctx context.Context, priceListID string) error
+	// ListEffective returns the active price lists valid at the given time,
+	// by descending priority and then by ID
+	ListEffective(ctx context.Context, at time.Time) ([]*models.PriceList, error)
+}
+
+// MemoryPriceListRepository implements PriceListRepository using in-memory storage
+type MemoryPriceListRepository struct {
+	priceLists map[string]*models.PriceList
+	mutex      sync.RWMutex
+}
+
+// NewMemoryPriceListRepository creates a new in-memory price list repository
+func NewMemoryPriceListRepository() *MemoryPriceListRepository {
+	return &MemoryPriceListRepository{
+		priceLists: make(map[string]*models.PriceList),
+	}
+}
+
+// Create stores a new price list
+func (r *MemoryPriceListRepository) Create(ctx context.Context, priceList *models.PriceList) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, exists := r.priceLists[priceList.PriceListID]; exists {
+		return ErrPriceListExists
+	}
+
+	now := time.Now()
+	priceList.CreatedAt = now
+	priceList.UpdatedAt = now
+	priceList.Version = 1
+
+	r.priceLists[priceList.PriceListID] = copyPriceList(priceList)
+	return nil
+}
+
+// GetByID retrieves a price list by its ID
+func (r *MemoryPriceListRepository) GetByID(ctx context.Context, priceListID string) (*models.PriceList, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	priceList, exists := r.priceLists[priceListID]
+	if !exists {
+		return nil, ErrPriceListNotFound
+	}
+
+	return copyPriceList(priceList), nil
+}
+
+// GetAll returns every price list ordered by ID
+func (r *MemoryPriceListRepository) GetAll(ctx context.Context) ([]*models.PriceList, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	priceLists := make([]*models.PriceList, 0, len(r.priceLists))
+	for _, priceList := range r.priceLists {
+		priceLists = append(priceLists, copyPriceList(priceList))
+	}
+
+	sort.Slice(priceLists, func(i, j int) bool {
+		return priceLists[i].PriceListID < priceLists[j].PriceListID
+	})
+	return priceLists, nil
+}
+
+// Update replaces a price list, checking its version
+func (r *MemoryPriceListRepository) Update(ctx context.Context, priceList *models.PriceList) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	existing, exists := r.priceLists[priceList.PriceListID]
+	if !exists {
+		return ErrPriceListNotFound
+	}
+
+	if existing.Version != priceList.Version {
+		return ErrVersionConflict
+	}
+
+	priceList.CreatedAt = existing.CreatedAt
+	priceList.UpdatedAt = time.Now()
+	priceList.Version++
+
+	r.priceLists[priceList.PriceListID] = copyPriceList(priceList)
+	return nil
+}
+
+// Delete removes a price list
+func (r *MemoryPriceListRepository) Delete(ctx context.Context, priceListID string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, exists := r.priceLists[priceListID]; !exists {
+		return ErrPriceListNotFound
+	}
+
+	delete(r.priceLists, priceListID)
+	return nil
+}
+
+// ListEffective returns the active price lists valid at the given time
+func (r *MemoryPriceListRepository) ListEffective(ctx context.Context, at time.Time) ([]*models.PriceList, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	var effective []*models.PriceList
+	for _, priceList := range r.priceLists {
+		if priceListEffective(priceList, at) {
+			effective = append(effective, copyPriceList(priceList))
+		}
+	}
+
+	sortByPriority(effective)
+	return effective, nil
+}
+
+// priceListEffective reports whether priceList applies at the given time
+func priceListEffective(priceList *models.PriceList, at time.Time) bool {
+	if !priceList.Active || at.Before(priceList.ValidFrom) {
+		return false
+	}
+	return priceList.ValidUntil == nil || at.Before(*priceList.ValidUntil)
+}
+
+// sortByPriority orders price lists by descending priority, then by ID
+func sortByPriority(priceLists []*models.PriceList) {
+	sort.Slice(priceLists, func(i, j int) bool {
+		if priceLists[i].Priority != priceLists[j].Priority {
+			return priceLists[i].Priority > priceLists[j].Priority
+		}
+		return priceLists[i].PriceListID < priceLists[j].PriceListID
+	})
+}
+
+// copyPriceList returns a deep copy so callers cannot modify stored state
+func copyPriceList(priceList *models.PriceList) *models.PriceList {
+	priceListCopy := *priceList
+	priceListCopy.Tiers = append([]string(nil), priceList.Tiers...)
+	priceListCopy.Rules = append([]models.PriceRule(nil), priceList.Rules...)
+	if priceList.ValidUntil != nil {
+		validUntil := *priceList.ValidUntil
+		priceListCopy.ValidUntil = &validUntil
+	}
+	return &priceListCopy
+}
@@ -0,0 +1,148 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/product-api-v2/configs"
+	"github.com/product-api-v2/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoPriceListRepository implements PriceListRepository using MongoDB
+type MongoPriceListRepository struct {
+	collection *mongo.Collection
+}
+
+// NewMongoPriceListRepository creates a price list repository that shares
+// the database connection of the product repository and stores price lists
+// in config.PriceListCollection
+func NewMongoPriceListRepository(products *MongoProductRepository, config configs.DatabaseConfig) (*MongoPriceListRepository, error) {
+	collection := products.collection.Database().Collection(config.PriceListCollection)
+
+	timeout := config.ConnectTimeout
+	if timeout <= 0 {
+		timeout = defaultConnectTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	_, err := collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "priceListId", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "active", Value: 1}, {Key: "validFrom", Value: 1}}},
+	})
+	if err != nil {
+		return nil, wrapMongoError(err)
+	}
+
+	return &MongoPriceListRepository{collection: collection}, nil
+}
+
+// Create stores a new price list in MongoDB
+func (r *MongoPriceListRepository) Create(ctx context.Context, priceList *models.PriceList) error {
+	now := time.Now()
+	priceList.CreatedAt = now
+	priceList.UpdatedAt = now
+	priceList.Version = 1
+
+	_, err := r.collection.InsertOne(ctx, priceList)
+	if mongo.IsDuplicateKeyError(err) {
+		return ErrPriceListExists
+	}
+	return wrapMongoError(err)
+}
+
+// GetByID retrieves a price list by its ID from MongoDB
+func (r *MongoPriceListRepository) GetByID(ctx context.Context, priceListID string) (*models.PriceList, error) {
+	var priceList models.PriceList
+
+	err := r.collection.FindOne(ctx, bson.M{"priceListId": priceListID}).Decode(&priceList)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrPriceListNotFound
+		}
+		return nil, wrapMongoError(err)
+	}
+
+	return &priceList, nil
+}
+
+// GetAll returns every price list ordered by ID
+func (r *MongoPriceListRepository) GetAll(ctx context.Context) ([]*models.PriceList, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "priceListId", Value: 1}})
+	return r.find(ctx, bson.M{}, opts)
+}
+
+// Update replaces a price list with a write that only matches its current
+// version. The creation time is kept from priceList, as stored.
+func (r *MongoPriceListRepository) Update(ctx context.Context, priceList *models.PriceList) error {
+	expectedVersion := priceList.Version
+	filter := bson.M{"priceListId": priceList.PriceListID, "version": expectedVersion}
+
+	priceList.UpdatedAt = time.Now()
+	priceList.Version = expectedVersion + 1
+
+	result, err := r.collection.ReplaceOne(ctx, filter, priceList)
+	if err != nil {
+		priceList.Version = expectedVersion
+		return wrapMongoError(err)
+	}
+
+	if result.MatchedCount == 0 {
+		priceList.Version = expectedVersion
+		if _, err := r.GetByID(ctx, priceList.PriceListID); err != nil {
+			return err
+		}
+		return ErrVersionConflict
+	}
+
+	return nil
+}
+
+// Delete removes a price list from MongoDB
+func (r *MongoPriceListRepository) Delete(ctx context.Context, priceListID string) error {
+	result, err := r.collection.DeleteOne(ctx, bson.M{"priceListId": priceListID})
+	if err != nil {
+		return wrapMongoError(err)
+	}
+
+	if result.DeletedCount == 0 {
+		return ErrPriceListNotFound
+	}
+
+	return nil
+}
+
+// ListEffective returns the active price lists valid at the given time
+func (r *MongoPriceListRepository) ListEffective(ctx context.Context, at time.Time) ([]*models.PriceList, error) {
+	filter := bson.M{
+		"active":    true,
+		"validFrom": bson.M{"$lte": at},
+		"$or": bson.A{
+			bson.M{"validUntil": nil},
+			bson.M{"validUntil": bson.M{"$gt": at}},
+		},
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "priority", Value: -1}, {Key: "priceListId", Value: 1}})
+	return r.find(ctx, filter, opts)
+}
+
+// find decodes every price list matching filter
+func (r *MongoPriceListRepository) find(ctx context.Context, filter bson.M, opts *options.FindOptions) ([]*models.PriceList, error) {
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, wrapMongoError(err)
+	}
+	defer cursor.Close(ctx)
+
+	priceLists := []*models.PriceList{}
+	if err := cursor.All(ctx, &priceLists); err != nil {
+		return nil, wrapMongoError(err)
+	}
+
+	return priceLists, nil
+}
@@ -3,6 +3,11 @@ package repository
 import (
 	"context"
 	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
@@ -10,50 +15,339 @@ import (
 )
 
 var (
-	ErrProductNotFound = errors.New("product not found")
-	ErrProductExists   = errors.New("product already exists")
+	ErrProductNotFound   = errors.New("product not found")
+	ErrProductExists     = errors.New("product already exists")
+	ErrInsufficientStock = errors.New("insufficient stock")
+	ErrVersionConflict   = errors.New("product was modified concurrently")
+	ErrSKUExists         = errors.New("SKU already belongs to another product")
+
+	// ErrRepositoryUnavailable marks failures caused by the backing store being
+	// unreachable or too slow, as opposed to bad input or missing data
+	ErrRepositoryUnavailable = errors.New("repository unavailable")
 )
 
 // ProductRepository defines the interface for product data operations
 type ProductRepository interface {
 	GetByID(ctx context.Context, productID string) (*models.Product, error)
+	// GetByIDFields is GetByID reading only fields; the others are left zero
+	GetByIDFields(ctx context.Context, productID string, fields []string) (*models.Product, error)
+	GetByIDs(ctx context.Context, productIDs []string) ([]*models.Product, error)
 	GetAll(ctx context.Context, filters ProductFilters) ([]*models.Product, error)
+	// Create stores a new product. Like Update and BulkWrite it fails with
+	// ErrSKUExists if another product has the same SKU.
 	Create(ctx context.Context, product *models.Product) error
+	// Update stores product only if the stored version still equals
+	// product.Version, and increments the version on success
 	Update(ctx context.Context, product *models.Product) error
 	Delete(ctx context.Context, productID string) error
 	Count(ctx context.Context, filters ProductFilters) (int, error)
+	// CountByCategory counts the products matching filters per category,
+	// under "" for products without one
+	CountByCategory(ctx context.Context, filters ProductFilters) (map[string]int, error)
+	// ReserveStock decrements stock for every line or for none of them.
+	// Like ReleaseStock it bumps the version of each product, and neither
+	// is recorded in the product history.
+	ReserveStock(ctx context.Context, lines []models.StockLine) error
+	// ReleaseStock returns previously reserved stock
+	ReleaseStock(ctx context.Context, lines []models.StockLine) error
+	// BulkWrite stores several products in one round trip. A product that
+	// cannot be written does not stop the others; its error is reported in
+	// the result.
+	BulkWrite(ctx context.Context, products []*models.Product, mode BulkMode) (*BulkWriteResult, error)
+	// Iterate calls yield for every product matching filters in product ID
+	// order, ignoring pagination, and stops at the first error
+	Iterate(ctx context.Context, filters ProductFilters, yield func(*models.Product) error) error
+	// Search returns the products matching a full-text query, most relevant first
+	Search(ctx context.Context, search ProductSearch) (*ProductSearchResult, error)
 	HealthCheck(ctx context.Context) error
 }
 
-// ProductFilters holds filtering options for product queries
+// BulkMode selects how BulkWrite treats products that already exist
+type BulkMode int
+
+const (
+	// BulkUpsert replaces existing products and inserts the others
+	BulkUpsert BulkMode = iota
+	// BulkInsertOnly rejects products that already exist with ErrProductExists
+	BulkInsertOnly
+)
+
+// BulkWriteResult counts the products a BulkWrite stored. Failed maps the
+// index of every product that was not written to the reason.
+type BulkWriteResult struct {
+	Inserted int
+	Updated  int
+	Failed   map[int]error
+}
+
+// StockError reports which product prevented a stock reservation
+type StockError struct {
+	ProductID string
+	Requested int
+	Err       error
+}
+
+// Error describes the failing product and requested quantity
+func (e *StockError) Error() string {
+	return fmt.Sprintf("%v: product %s (requested %d)", e.Err, e.ProductID, e.Requested)
+}
+
+// Unwrap returns ErrInsufficientStock or ErrProductNotFound
+func (e *StockError) Unwrap() error {
+	return e.Err
+}
+
+// Visibility decides whether a listing includes inactive records
+type Visibility string
+
+const (
+	// VisibilityPublic lists active records only
+	VisibilityPublic Visibility = "public"
+	// VisibilityAdmin lists inactive records as well
+	VisibilityAdmin Visibility = "admin"
+)
+
+// ParseVisibility accepts "public" or "admin"
+func ParseVisibility(value string) (Visibility, error) {
+	switch visibility := Visibility(value); visibility {
+	case VisibilityPublic, VisibilityAdmin:
+		return visibility, nil
+	}
+	return "", fmt.Errorf("visibility must be %s or %s", VisibilityPublic, VisibilityAdmin)
+}
+
+// ProductFilters holds filtering options for product queries. Categories
+// matches any of several categories, such as a subtree of the taxonomy.
 type ProductFilters struct {
-	Category string
+	Category   string
+	Categories []string
 	Active   *bool
-	MinPrice *float64
-	MaxPrice *float64
 	Page     int
 	PageSize int
+	
+	// Currency restricts products to one ISO 4217 currency. MinPrice and
+	// MaxPrice are decimal amounts in it; a bound with more decimals than
+	// the currency has is rounded inwards.
+	Currency string
+	MinPrice *float64
+	MaxPrice *float64
+	
+	// Visibility restricts the products a listing may return on top of the
+	// other filters. Empty applies no restriction.
+	Visibility Visibility
+	
+	// TopLevel leaves variants out, as listings show them under their
+	// parent. ParentIDs restricts products to the variants of those parents
+	// and SKU to the product with that SKU.
+	TopLevel  bool
+	ParentIDs []string
+	SKU       string
+	
+	// Fields limits the product fields GetAll reads, besides the sort keys.
+	// Empty reads every field.
+	Fields []string
+	
+	// Sort orders the results, by product ID when empty. With a Cursor the
+	// page starts after (or ends before) that position and Page is ignored.
+	Sort   []SortField
+	Cursor *Cursor
+}
+
+// productSortFields are the fields product listings can be sorted by. Fields
+// that may be missing from stored documents are left out, since keyset
+// comparisons skip documents without the field.
+var productSortFields = map[string]sortKind{
+	"productId":  sortString,
+	"name":       sortString,
+	"priceMinor": sortInteger,
+	"stock":      sortInteger,
+	"createdAt":  sortTime,
+	"updatedAt":  sortTime,
+}
+
+// productSortAliases maps sort names to the stored field they sort by.
+// Prices sort by their exact minor units, which only compare within one
+// currency.
+var productSortAliases = map[string]string{
+	"price": "priceMinor",
+}
+
+// ParseProductSort parses a sort parameter such as "price,-createdAt". The
+// product ID is always the final tie-breaker.
+func ParseProductSort(spec string) ([]SortField, error) {
+	parts := strings.Split(spec, ",")
+	for i, part := range parts {
+		part = strings.TrimSpace(part)
+		name := strings.TrimPrefix(part, "-")
+		if field, ok := productSortAliases[name]; ok {
+			parts[i] = strings.TrimSuffix(part, name) + field
+		}
+	}
+	return parseSort(strings.Join(parts, ","), productSortFields, "productId")
+}
+
+// DecodeProductCursor parses a cursor token issued for order
+func DecodeProductCursor(token string, order []SortField) (*Cursor, error) {
+	return decodeCursor(token, order, productSortFields)
+}
+
+// ProductCursor returns the token for the position of product in order.
+// A backward cursor selects the page ending just before the product.
+func ProductCursor(product *models.Product, order []SortField, backward bool) string {
+	return encodeCursor(order, productSortKey(product, order), backward)
+}
+
+// productSortKey returns the values of product for each field in order
+func productSortKey(product *models.Product, order []SortField) []interface{} {
+	key := make([]interface{}, len(order))
+	for i, field := range order {
+		switch field.Field {
+		case "productId":
+			key[i] = product.ProductID
+		case "name":
+			key[i] = product.Name
+		case "priceMinor":
+			key[i] = int(product.PriceMinor)
+		case "stock":
+			key[i] = product.Stock
+		case "createdAt":
+			key[i] = product.CreatedAt
+		case "updatedAt":
+			key[i] = product.UpdatedAt
+		}
+	}
+	return key
+}
+
+// productOrder returns the sort to apply for filters
+func productOrder(filters ProductFilters) []SortField {
+	if len(filters.Sort) > 0 {
+		return filters.Sort
+	}
+	return []SortField{{Field: "productId"}}
+}
+
+// priceRange returns the price bounds of filters in minor units of their
+// currency, rounded inwards so they admit the same prices as the decimals
+func priceRange(filters ProductFilters) (minPrice, maxPrice *int64) {
+	currency := filters.Currency
+	if currency == "" {
+		currency = models.DefaultCurrency
+	}
+	exponent, _ := models.CurrencyExponent(currency)
+	scale := math.Pow10(exponent)
+	
+	// The tolerance absorbs float error, as in 0.29*100 = 28.999999999999996
+	if filters.MinPrice != nil {
+		bound := int64(math.Ceil(*filters.MinPrice*scale - 1e-6))
+		minPrice = &bound
+	}
+	if filters.MaxPrice != nil {
+		bound := int64(math.Floor(*filters.MaxPrice*scale + 1e-6))
+		maxPrice = &bound
+	}
+	return minPrice, maxPrice
 }
 
 // MemoryProductRepository implements ProductRepository using in-memory storage
 type MemoryProductRepository struct {
 	products map[string]*models.Product
+	index    *searchIndex
 	mutex    sync.RWMutex
+	
+	// persistPath, when set, receives the full product set after every write
+	persistPath    string
+	onPersistError func(error)
 }
 
 // NewMemoryProductRepository creates a new in-memory product repository
 func NewMemoryProductRepository() *MemoryProductRepository {
 	repo := &MemoryProductRepository{
 		products: make(map[string]*models.Product),
+		index:    newSearchIndex(),
 		mutex:    sync.RWMutex{},
 	}
 	
-	// Initialize with sample data
-	repo.initializeSampleData()
-	
 	return repo
 }
 
+// Seed validates every product with validate and loads them all, or none if
+// any is invalid or duplicated. Products without timestamps or a version get
+// the values Create would assign.
+func (r *MemoryProductRepository) Seed(products []*models.Product, validate func(*models.Product) error) error {
+	seeded := make(map[string]*models.Product, len(products))
+	now := time.Now()
+	
+	for i, product := range products {
+		if product == nil {
+			return fmt.Errorf("fixture %d: empty document", i+1)
+		}
+		if err := validate(product); err != nil {
+			return fmt.Errorf("fixture %d (%s): %w", i+1, product.ProductID, err)
+		}
+		if _, duplicate := seeded[product.ProductID]; duplicate {
+			return fmt.Errorf("fixture %d: %w: %s", i+1, ErrProductExists, product.ProductID)
+		}
+		
+		productCopy := *product
+		productCopy.NormalizePrice()
+		if productCopy.CreatedAt.IsZero() {
+			productCopy.CreatedAt = now
+		}
+		if productCopy.UpdatedAt.IsZero() {
+			productCopy.UpdatedAt = productCopy.CreatedAt
+		}
+		if productCopy.Version == 0 {
+			productCopy.Version = 1
+		}
+		seeded[product.ProductID] = &productCopy
+	}
+	
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	
+	for productID, product := range seeded {
+		r.products[productID] = product
+		r.index.add(product)
+	}
+	return nil
+}
+
+// PersistTo writes the full product set to path after every change, so local
+// edits survive a restart. Write failures are reported to onError; the change
+// itself stays in memory.
+func (r *MemoryProductRepository) PersistTo(path string, onError func(error)) error {
+	if err := fixturePersistTarget(path); err != nil {
+		return err
+	}
+	
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	
+	r.persistPath = path
+	r.onPersistError = onError
+	return nil
+}
+
+// persist writes the products to persistPath. Callers hold the write lock.
+func (r *MemoryProductRepository) persist() {
+	if r.persistPath == "" {
+		return
+	}
+	
+	products := make([]*models.Product, 0, len(r.products))
+	for _, product := range r.products {
+		products = append(products, product)
+	}
+	sort.Slice(products, func(i, j int) bool {
+		return products[i].ProductID < products[j].ProductID
+	})
+	
+	if err := writeFixtureFile(r.persistPath, products); err != nil && r.onPersistError != nil {
+		r.onPersistError(fmt.Errorf("persisting products to %s: %w", r.persistPath, err))
+	}
+}
+
 // GetByID retrieves a product by its ID
 func (r *MemoryProductRepository) GetByID(ctx context.Context, productID string) (*models.Product, error) {
 	r.mutex.RLock()
@@ -69,6 +363,36 @@ func (r *MemoryProductRepository) GetByID(ctx context.Context, productID string)
 	return &productCopy, nil
 }
 
+// GetByIDFields retrieves a copy of a product holding only fields
+func (r *MemoryProductRepository) GetByIDFields(ctx context.Context, productID string, fields []string) (*models.Product, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	
+	product, exists := r.products[productID]
+	if !exists {
+		return nil, ErrProductNotFound
+	}
+	
+	return projectProduct(product, fields), nil
+}
+
+// GetByIDs retrieves every product whose ID is in productIDs. Unknown IDs are
+// skipped, so callers compare the result against the requested IDs.
+func (r *MemoryProductRepository) GetByIDs(ctx context.Context, productIDs []string) ([]*models.Product, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	products := make([]*models.Product, 0, len(productIDs))
+	for _, productID := range productIDs {
+		if product, exists := r.products[productID]; exists {
+			productCopy := *product
+			products = append(products, &productCopy)
+		}
+	}
+
+	return products, nil
+}
+
 // GetAll retrieves all products with optional filtering
 func (r *MemoryProductRepository) GetAll(ctx context.Context, filters ProductFilters) ([]*models.Product, error) {
 	r.mutex.RLock()
@@ -78,27 +402,26 @@ func (r *MemoryProductRepository) GetAll(ctx context.Context, filters ProductFil
 	
 	for _, product := range r.products {
 		if r.matchesFilters(product, filters) {
-			productCopy := *product
-			products = append(products, &productCopy)
+			products = append(products, product)
 		}
 	}
 	
-	// Apply pagination
-	if filters.PageSize > 0 {
-		start := filters.Page * filters.PageSize
-		end := start + filters.PageSize
-		
-		if start >= len(products) {
-			return []*models.Product{}, nil
-		}
-		
-		if end > len(products) {
-			end = len(products)
-		}
-		
-		products = products[start:end]
+	order := productOrder(filters)
+	key := func(product *models.Product) []interface{} {
+		return productSortKey(product, order)
 	}
+	products = sortPage(products, order, key, filters.Cursor, filters.Page, filters.PageSize)
 	
+	// Return copies to prevent external modifications
+	fields := withFields(filters.Fields, orderFields(order)...)
+	for i, product := range products {
+		if len(filters.Fields) > 0 {
+			products[i] = projectProduct(product, fields)
+		} else {
+			productCopy := *product
+			products[i] = &productCopy
+		}
+	}
 	return products, nil
 }
 
@@ -110,13 +433,20 @@ func (r *MemoryProductRepository) Create(ctx context.Context, product *models.Pr
 	if _, exists := r.products[product.ProductID]; exists {
 		return ErrProductExists
 	}
+	if r.skuTaken(product) {
+		return ErrSKUExists
+	}
 	
 	now := time.Now()
 	product.CreatedAt = now
 	product.UpdatedAt = now
+	product.Version = 1
 	
 	productCopy := *product
+	productCopy.NormalizePrice()
 	r.products[product.ProductID] = &productCopy
+	r.index.add(&productCopy)
+	r.persist()
 	
 	return nil
 }
@@ -131,11 +461,22 @@ func (r *MemoryProductRepository) Update(ctx context.Context, product *models.Pr
 		return ErrProductNotFound
 	}
 	
+	if existing.Version != product.Version {
+		return ErrVersionConflict
+	}
+	if r.skuTaken(product) {
+		return ErrSKUExists
+	}
+	
 	product.CreatedAt = existing.CreatedAt
 	product.UpdatedAt = time.Now()
+	product.Version++
 	
 	productCopy := *product
+	productCopy.NormalizePrice()
 	r.products[product.ProductID] = &productCopy
+	r.index.add(&productCopy)
+	r.persist()
 	
 	return nil
 }
@@ -150,6 +491,8 @@ func (r *MemoryProductRepository) Delete(ctx context.Context, productID string)
 	}
 	
 	delete(r.products, productID)
+	r.index.remove(productID)
+	r.persist()
 	return nil
 }
 
@@ -168,6 +511,189 @@ func (r *MemoryProductRepository) Count(ctx context.Context, filters ProductFilt
 	return count, nil
 }
 
+// CountByCategory counts the matching products per category
+func (r *MemoryProductRepository) CountByCategory(ctx context.Context, filters ProductFilters) (map[string]int, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	
+	counts := make(map[string]int)
+	for _, product := range r.products {
+		if r.matchesFilters(product, filters) {
+			counts[product.Category]++
+		}
+	}
+	
+	return counts, nil
+}
+
+// ReserveStock decrements stock for all lines under a single lock, so either
+// every line is reserved or none is
+func (r *MemoryProductRepository) ReserveStock(ctx context.Context, lines []models.StockLine) error {
+	lines = mergeStockLines(lines)
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for _, line := range lines {
+		product, exists := r.products[line.ProductID]
+		if !exists {
+			return &StockError{ProductID: line.ProductID, Requested: line.Quantity, Err: ErrProductNotFound}
+		}
+		if product.Stock < line.Quantity {
+			return &StockError{ProductID: line.ProductID, Requested: line.Quantity, Err: ErrInsufficientStock}
+		}
+	}
+
+	now := time.Now()
+	for _, line := range lines {
+		product := r.products[line.ProductID]
+		product.Stock -= line.Quantity
+		product.UpdatedAt = now
+		product.Version++
+	}
+	r.persist()
+
+	return nil
+}
+
+// ReleaseStock returns reserved stock. Products deleted in the meantime are skipped.
+func (r *MemoryProductRepository) ReleaseStock(ctx context.Context, lines []models.StockLine) error {
+	lines = mergeStockLines(lines)
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	now := time.Now()
+	for _, line := range lines {
+		if product, exists := r.products[line.ProductID]; exists {
+			product.Stock += line.Quantity
+			product.UpdatedAt = now
+			product.Version++
+		}
+	}
+	r.persist()
+
+	return nil
+}
+
+// BulkWrite stores every product under a single lock. Stored products get
+// the same timestamps and version handling as Create and Update.
+func (r *MemoryProductRepository) BulkWrite(ctx context.Context, products []*models.Product, mode BulkMode) (*BulkWriteResult, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	
+	result := &BulkWriteResult{Failed: make(map[int]error)}
+	now := time.Now()
+	
+	for i, product := range products {
+		productCopy := *product
+		productCopy.NormalizePrice()
+		productCopy.UpdatedAt = now
+		
+		existing, exists := r.products[product.ProductID]
+		switch {
+		case exists && mode == BulkInsertOnly:
+			result.Failed[i] = ErrProductExists
+			continue
+		case r.skuTaken(product):
+			result.Failed[i] = ErrSKUExists
+			continue
+		}
+		
+		if exists {
+			productCopy.CreatedAt = existing.CreatedAt
+			productCopy.Version = existing.Version + 1
+			result.Updated++
+		} else {
+			productCopy.CreatedAt = now
+			productCopy.Version = 1
+			result.Inserted++
+		}
+		
+		r.products[product.ProductID] = &productCopy
+		r.index.add(&productCopy)
+	}
+	
+	if result.Inserted+result.Updated > 0 {
+		r.persist()
+	}
+	return result, nil
+}
+
+// skuTaken reports whether another product already has the SKU of product
+func (r *MemoryProductRepository) skuTaken(product *models.Product) bool {
+	if product.SKU == "" {
+		return false
+	}
+	for _, stored := range r.products {
+		if stored.SKU == product.SKU && stored.ProductID != product.ProductID {
+			return true
+		}
+	}
+	return false
+}
+
+// Iterate walks a snapshot of the matching products, so yield may call back
+// into the repository
+func (r *MemoryProductRepository) Iterate(ctx context.Context, filters ProductFilters, yield func(*models.Product) error) error {
+	r.mutex.RLock()
+	products := make([]*models.Product, 0, len(r.products))
+	for _, product := range r.products {
+		if r.matchesFilters(product, filters) {
+			productCopy := *product
+			products = append(products, &productCopy)
+		}
+	}
+	r.mutex.RUnlock()
+	
+	sort.Slice(products, func(i, j int) bool {
+		return products[i].ProductID < products[j].ProductID
+	})
+	
+	for _, product := range products {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := yield(product); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Search looks the query up in the inverted index, then applies the filters
+// to the candidates
+func (r *MemoryProductRepository) Search(ctx context.Context, search ProductSearch) (*ProductSearchResult, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	
+	var hits []ScoredProduct
+	var matched []*models.Product
+	for productID, score := range r.index.search(search.Terms, search.Prefix) {
+		product := r.products[productID]
+		if r.matchesFilters(product, search.Filters) {
+			productCopy := *product
+			hits = append(hits, ScoredProduct{Product: &productCopy, Score: score})
+			matched = append(matched, product)
+		}
+	}
+	sortHits(hits)
+	
+	result := &ProductSearchResult{
+		Total:  len(hits),
+		Facets: searchFacets(matched, search),
+	}
+	
+	start, end := 0, len(hits)
+	if pageSize := search.Filters.PageSize; pageSize > 0 {
+		start = min(search.Filters.Page*pageSize, end)
+		end = min(start+pageSize, end)
+	}
+	result.Hits = hits[start:end]
+	
+	return result, nil
+}
+
 // HealthCheck verifies the repository is working
 func (r *MemoryProductRepository) HealthCheck(ctx context.Context) error {
 	r.mutex.RLock()
@@ -184,25 +710,60 @@ func (r *MemoryProductRepository) matchesFilters(product *models.Product, filter
 		return false
 	}
 	
+	if len(filters.Categories) > 0 && !slices.Contains(filters.Categories, product.Category) {
+		return false
+	}
+	
 	if filters.Active != nil && product.Active != *filters.Active {
 		return false
 	}
 	
-	if filters.MinPrice != nil && product.Price < *filters.MinPrice {
+	if filters.Visibility == VisibilityPublic && !product.Active {
+		return false
+	}
+	
+	if filters.Currency != "" && product.Currency != filters.Currency {
+		return false
+	}
+	
+	if filters.TopLevel && product.IsVariant() {
 		return false
 	}
 	
-	if filters.MaxPrice != nil && product.Price > *filters.MaxPrice {
+	if len(filters.ParentIDs) > 0 && !slices.Contains(filters.ParentIDs, product.ParentID) {
+		return false
+	}
+	
+	if filters.SKU != "" && product.SKU != filters.SKU {
+		return false
+	}
+	
+	minPrice, maxPrice := priceRange(filters)
+	if minPrice != nil && product.PriceMinor < *minPrice {
+		return false
+	}
+	
+	if maxPrice != nil && product.PriceMinor > *maxPrice {
 		return false
 	}
 	
 	return true
 }
 
-// initializeSampleData is deprecated - data now comes from MongoDB initialization scripts
-func (r *MemoryProductRepository) initializeSampleData() {
-	// No longer needed - MongoDB provides the expanded catalog (9 products) via init scripts
-	// including: product-1 to product-9 with categories: laptops, peripherals, monitors, 
-	// storage, components, furniture
-	// This is kept for backward compatibility but does nothing
-}
\ No newline at end of file
+// mergeStockLines combines lines for the same product so each product is
+// checked against its total requested quantity
+func mergeStockLines(lines []models.StockLine) []models.StockLine {
+	merged := make([]models.StockLine, 0, len(lines))
+	index := make(map[string]int, len(lines))
+
+	for _, line := range lines {
+		if i, seen := index[line.ProductID]; seen {
+			merged[i].Quantity += line.Quantity
+			continue
+		}
+		index[line.ProductID] = len(merged)
+		merged = append(merged, line)
+	}
+
+	return merged
+}
@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"slices"
+
+	"github.com/product-api-v2/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// withFields returns fields plus any of extra not already in it. Queries
+// add the fields they need themselves, such as the sort keys of a listing.
+func withFields(fields []string, extra ...string) []string {
+	combined := append([]string(nil), fields...)
+	for _, field := range extra {
+		if !slices.Contains(combined, field) {
+			combined = append(combined, field)
+		}
+	}
+	return combined
+}
+
+// orderFields returns the field names of order
+func orderFields(order []SortField) []string {
+	fields := make([]string, len(order))
+	for i, field := range order {
+		fields[i] = field.Field
+	}
+	return fields
+}
+
+// priceFields are read together whenever one of them is asked for, since
+// each is only meaningful with the others
+var priceFields = []string{"price", "priceMinor", "currency"}
+
+// withPriceFields completes fields with every price field if it has one
+func withPriceFields(fields []string) []string {
+	for _, field := range priceFields {
+		if slices.Contains(fields, field) {
+			return withFields(fields, priceFields...)
+		}
+	}
+	return fields
+}
+
+// productProjection is the Mongo projection reading only fields
+func productProjection(fields []string) bson.D {
+	projection := bson.D{{Key: "_id", Value: 0}}
+	for _, field := range withPriceFields(fields) {
+		if field == "variants" {
+			continue // not stored; the service reads them separately
+		}
+		projection = append(projection, bson.E{Key: field, Value: 1})
+	}
+	return projection
+}
+
+// projectProduct returns a copy of product holding only fields, the
+// in-memory counterpart of a Mongo projection
+func projectProduct(product *models.Product, fields []string) *models.Product {
+	projected := &models.Product{}
+	for _, field := range withPriceFields(fields) {
+		switch field {
+		case "productId":
+			projected.ProductID = product.ProductID
+		case "parentId":
+			projected.ParentID = product.ParentID
+		case "sku":
+			projected.SKU = product.SKU
+		case "name":
+			projected.Name = product.Name
+		case "description":
+			projected.Description = product.Description
+		case "price":
+			projected.Price = product.Price
+		case "priceMinor":
+			projected.PriceMinor = product.PriceMinor
+		case "currency":
+			projected.Currency = product.Currency
+		case "category":
+			projected.Category = product.Category
+		case "attributes":
+			projected.Attributes = product.Attributes
+		case "stock":
+			projected.Stock = product.Stock
+		case "active":
+			projected.Active = product.Active
+		case "version":
+			projected.Version = product.Version
+		case "createdAt":
+			projected.CreatedAt = product.CreatedAt
+		case "updatedAt":
+			projected.UpdatedAt = product.UpdatedAt
+		}
+	}
+	return projected
+}
@@ -0,0 +1,122 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/product-api-v2/internal/models"
+)
+
+var (
+	ErrReservationNotFound = errors.New("reservation not found")
+	ErrReservationExists   = errors.New("reservation already exists")
+	ErrReservationState    = errors.New("reservation is not in the expected state")
+)
+
+// ReservationRepository defines the interface for stock reservation records.
+// Stock levels themselves are changed through ProductRepository.
+type ReservationRepository interface {
+	Create(ctx context.Context, reservation *models.Reservation) error
+	GetByID(ctx context.Context, reservationID string) (*models.Reservation, error)
+	// UpdateStatus moves a reservation from one status to another and fails
+	// with ErrReservationState if it is no longer in the from status
+	UpdateStatus(ctx context.Context, reservationID string, from, to models.ReservationStatus) (*models.Reservation, error)
+	// ListExpired returns pending reservations whose expiry is before now
+	ListExpired(ctx context.Context, now time.Time, limit int) ([]*models.Reservation, error)
+}
+
+// MemoryReservationRepository implements ReservationRepository using in-memory storage
+type MemoryReservationRepository struct {
+	reservations map[string]*models.Reservation
+	mutex        sync.RWMutex
+}
+
+// NewMemoryReservationRepository creates a new in-memory reservation repository
+func NewMemoryReservationRepository() *MemoryReservationRepository {
+	return &MemoryReservationRepository{
+		reservations: make(map[string]*models.Reservation),
+	}
+}
+
+// Create stores a new reservation
+func (r *MemoryReservationRepository) Create(ctx context.Context, reservation *models.Reservation) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, exists := r.reservations[reservation.ReservationID]; exists {
+		return ErrReservationExists
+	}
+
+	now := time.Now()
+	reservation.CreatedAt = now
+	reservation.UpdatedAt = now
+
+	r.reservations[reservation.ReservationID] = copyReservation(reservation)
+	return nil
+}
+
+// GetByID retrieves a reservation by its ID
+func (r *MemoryReservationRepository) GetByID(ctx context.Context, reservationID string) (*models.Reservation, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	reservation, exists := r.reservations[reservationID]
+	if !exists {
+		return nil, ErrReservationNotFound
+	}
+
+	return copyReservation(reservation), nil
+}
+
+// UpdateStatus atomically moves a reservation between statuses
+func (r *MemoryReservationRepository) UpdateStatus(ctx context.Context, reservationID string, from, to models.ReservationStatus) (*models.Reservation, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	reservation, exists := r.reservations[reservationID]
+	if !exists {
+		return nil, ErrReservationNotFound
+	}
+
+	if reservation.Status != from {
+		return nil, ErrReservationState
+	}
+
+	reservation.Status = to
+	reservation.UpdatedAt = time.Now()
+
+	return copyReservation(reservation), nil
+}
+
+// ListExpired returns pending reservations that expired before now, oldest first
+func (r *MemoryReservationRepository) ListExpired(ctx context.Context, now time.Time, limit int) ([]*models.Reservation, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	var expired []*models.Reservation
+	for _, reservation := range r.reservations {
+		if reservation.Status == models.ReservationPending && reservation.ExpiresAt.Before(now) {
+			expired = append(expired, copyReservation(reservation))
+		}
+	}
+
+	sort.Slice(expired, func(i, j int) bool {
+		return expired[i].ExpiresAt.Before(expired[j].ExpiresAt)
+	})
+
+	if limit > 0 && len(expired) > limit {
+		expired = expired[:limit]
+	}
+
+	return expired, nil
+}
+
+// copyReservation returns a deep copy so callers cannot modify stored state
+func copyReservation(reservation *models.Reservation) *models.Reservation {
+	reservationCopy := *reservation
+	reservationCopy.Lines = append([]models.StockLine(nil), reservation.Lines...)
+	return &reservationCopy
+}
@@ -0,0 +1,119 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/product-api-v2/configs"
+	"github.com/product-api-v2/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoReservationRepository implements ReservationRepository using MongoDB
+type MongoReservationRepository struct {
+	collection *mongo.Collection
+}
+
+// NewMongoReservationRepository creates a reservation repository that shares
+// the database connection of the product repository and stores reservations
+// in config.ReservationCollection
+func NewMongoReservationRepository(products *MongoProductRepository, config configs.DatabaseConfig) (*MongoReservationRepository, error) {
+	collection := products.collection.Database().Collection(config.ReservationCollection)
+
+	timeout := config.ConnectTimeout
+	if timeout <= 0 {
+		timeout = defaultConnectTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	_, err := collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "reservationId", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "expiresAt", Value: 1}}},
+	})
+	if err != nil {
+		return nil, wrapMongoError(err)
+	}
+
+	return &MongoReservationRepository{collection: collection}, nil
+}
+
+// Create stores a new reservation in MongoDB
+func (r *MongoReservationRepository) Create(ctx context.Context, reservation *models.Reservation) error {
+	now := time.Now()
+	reservation.CreatedAt = now
+	reservation.UpdatedAt = now
+
+	_, err := r.collection.InsertOne(ctx, reservation)
+	if mongo.IsDuplicateKeyError(err) {
+		return ErrReservationExists
+	}
+	return wrapMongoError(err)
+}
+
+// GetByID retrieves a reservation by its ID from MongoDB
+func (r *MongoReservationRepository) GetByID(ctx context.Context, reservationID string) (*models.Reservation, error) {
+	var reservation models.Reservation
+
+	err := r.collection.FindOne(ctx, bson.M{"reservationId": reservationID}).Decode(&reservation)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrReservationNotFound
+		}
+		return nil, wrapMongoError(err)
+	}
+
+	return &reservation, nil
+}
+
+// UpdateStatus moves a reservation between statuses with a conditional update
+func (r *MongoReservationRepository) UpdateStatus(ctx context.Context, reservationID string, from, to models.ReservationStatus) (*models.Reservation, error) {
+	filter := bson.M{"reservationId": reservationID, "status": from}
+	update := bson.M{"$set": bson.M{"status": to, "updatedAt": time.Now()}}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var reservation models.Reservation
+	err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&reservation)
+	if err == nil {
+		return &reservation, nil
+	}
+
+	if !errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, wrapMongoError(err)
+	}
+
+	// Distinguish a missing reservation from one in another state
+	if _, err := r.GetByID(ctx, reservationID); err != nil {
+		return nil, err
+	}
+	return nil, ErrReservationState
+}
+
+// ListExpired returns pending reservations that expired before now, oldest first
+func (r *MongoReservationRepository) ListExpired(ctx context.Context, now time.Time, limit int) ([]*models.Reservation, error) {
+	filter := bson.M{
+		"status":    models.ReservationPending,
+		"expiresAt": bson.M{"$lt": now},
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "expiresAt", Value: 1}})
+	if limit > 0 {
+		opts.SetLimit(int64(limit))
+	}
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, wrapMongoError(err)
+	}
+	defer cursor.Close(ctx)
+
+	var reservations []*models.Reservation
+	if err := cursor.All(ctx, &reservations); err != nil {
+		return nil, wrapMongoError(err)
+	}
+
+	return reservations, nil
+}
@@ -0,0 +1,39 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// RetryWithBackoff calls attempt until it succeeds or ctx ends, doubling the
+// wait between attempts from initial up to max. onError is told about each
+// failure and the wait before the next attempt.
+func RetryWithBackoff(ctx context.Context, initial, max time.Duration, attempt func() error, onError func(attempt int, err error, wait time.Duration)) error {
+	wait := initial
+	if wait <= 0 {
+		wait = time.Second
+	}
+	for n := 1; ; n++ {
+		err := attempt()
+		if err == nil {
+			return nil
+		}
+		if onError != nil {
+			onError(n, err, wait)
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("%w (last error: %v)", ctx.Err(), err)
+		case <-timer.C:
+		}
+
+		wait *= 2
+		if wait > max {
+			wait = max
+		}
+	}
+}
@@ -0,0 +1,309 @@
+package repository
+
+import (
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/product-api-v2/internal/models"
+)
+
+// ProductSearch is a full-text product query. A product matches when it
+// contains any of Terms and, if Prefix is set, a word starting with Prefix.
+// Terms and Prefix are expected to come from SearchTokens.
+type ProductSearch struct {
+	Terms   []string
+	Prefix  string
+	Filters ProductFilters // Category, Active, currency, price range and offset pagination
+
+	// PriceBuckets are the ascending upper bounds of the price facet buckets,
+	// as decimal amounts in Filters.Currency, or in the default currency
+	// without one. Only products in that currency are counted. Prices at or
+	// above the last bound are counted in a final open bucket.
+	PriceBuckets []float64
+}
+
+// ScoredProduct is a search hit with its relevance score. Scores rank the
+// hits of one search and are not comparable across repositories.
+type ScoredProduct struct {
+	Product *models.Product
+	Score   float64
+}
+
+// ProductSearchResult holds one page of hits, by descending score, and the
+// total and facets of all matching products
+type ProductSearchResult struct {
+	Hits   []ScoredProduct
+	Total  int
+	Facets models.SearchFacets
+}
+
+// searchField is a product field covered by the text index and its weight
+type searchField struct {
+	name   string
+	weight float64
+	value  func(*models.Product) string
+}
+
+// searchFields are the indexed fields. The Mongo text index uses the same
+// weights, so both repositories rank hits alike.
+var searchFields = []searchField{
+	{name: "name", weight: 10, value: func(p *models.Product) string { return p.Name }},
+	{name: "category", weight: 5, value: func(p *models.Product) string { return p.Category }},
+	{name: "description", weight: 1, value: func(p *models.Product) string { return p.Description }},
+}
+
+// foldedRunes maps accented letters to their base letter
+var foldedRunes = map[rune]rune{
+	'á': 'a', 'à': 'a', 'â': 'a', 'ä': 'a', 'ã': 'a', 'å': 'a',
+	'é': 'e', 'è': 'e', 'ê': 'e', 'ë': 'e',
+	'í': 'i', 'ì': 'i', 'î': 'i', 'ï': 'i',
+	'ó': 'o', 'ò': 'o', 'ô': 'o', 'ö': 'o', 'õ': 'o',
+	'ú': 'u', 'ù': 'u', 'û': 'u', 'ü': 'u',
+	'ñ': 'n', 'ç': 'c',
+}
+
+// SearchTokens splits text into lowercase words with accents removed, which
+// is how both the text index and queries see it: "Cañón" becomes "canon"
+func SearchTokens(text string) []string {
+	folded := strings.Map(func(r rune) rune {
+		r = unicode.ToLower(r)
+		if base, ok := foldedRunes[r]; ok {
+			return base
+		}
+		return r
+	}, text)
+
+	return strings.FieldsFunc(folded, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
+
+// searchIndex is the inverted index of the in-memory repository, from
+// folded tokens to the products containing them
+type searchIndex struct {
+	postings map[string]map[string]posting // token -> product ID
+	tokens   map[string][]string           // product ID -> indexed tokens
+}
+
+// posting records where a token occurs in one product
+type posting struct {
+	weight float64 // field weight summed over every occurrence
+	fields uint    // bit i set when the token occurs in searchFields[i]
+}
+
+// newSearchIndex creates an empty index
+func newSearchIndex() *searchIndex {
+	return &searchIndex{
+		postings: make(map[string]map[string]posting),
+		tokens:   make(map[string][]string),
+	}
+}
+
+// add indexes product, replacing any previous entry for its ID
+func (idx *searchIndex) add(product *models.Product) {
+	idx.remove(product.ProductID)
+
+	entries := make(map[string]posting)
+	for i, field := range searchFields {
+		for _, token := range SearchTokens(field.value(product)) {
+			entry := entries[token]
+			entry.weight += field.weight
+			entry.fields |= 1 << i
+			entries[token] = entry
+		}
+	}
+
+	tokens := make([]string, 0, len(entries))
+	for token, entry := range entries {
+		if idx.postings[token] == nil {
+			idx.postings[token] = make(map[string]posting)
+		}
+		idx.postings[token][product.ProductID] = entry
+		tokens = append(tokens, token)
+	}
+	idx.tokens[product.ProductID] = tokens
+}
+
+// remove drops productID from the index
+func (idx *searchIndex) remove(productID string) {
+	for _, token := range idx.tokens[productID] {
+		delete(idx.postings[token], productID)
+		if len(idx.postings[token]) == 0 {
+			delete(idx.postings, token)
+		}
+	}
+	delete(idx.tokens, productID)
+}
+
+// search scores the products matching terms and prefix. Every occurrence of
+// a term adds the weight of its field; a prefix adds the weight of each
+// field with a word starting with it.
+func (idx *searchIndex) search(terms []string, prefix string) map[string]float64 {
+	scores := make(map[string]float64)
+	for _, term := range terms {
+		for productID, entry := range idx.postings[term] {
+			scores[productID] += entry.weight
+		}
+	}
+
+	if prefix == "" {
+		return scores
+	}
+
+	fields := make(map[string]uint)
+	for token, postings := range idx.postings {
+		if !strings.HasPrefix(token, prefix) {
+			continue
+		}
+		for productID, entry := range postings {
+			fields[productID] |= entry.fields
+		}
+	}
+
+	prefixed := make(map[string]float64, len(fields))
+	for productID, mask := range fields {
+		score, matched := scores[productID]
+		if len(terms) > 0 && !matched {
+			continue
+		}
+		for i, field := range searchFields {
+			if mask&(1<<i) != 0 {
+				score += field.weight
+			}
+		}
+		prefixed[productID] = score
+	}
+	return prefixed
+}
+
+// sortHits orders hits by descending score, then by product ID
+func sortHits(hits []ScoredProduct) {
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return hits[i].Product.ProductID < hits[j].Product.ProductID
+	})
+}
+
+// searchFacets counts products per category and per price bucket. Empty
+// categories and empty buckets are left out, as the Mongo aggregation does,
+// and there are no price facets without bounds.
+func searchFacets(products []*models.Product, search ProductSearch) models.SearchFacets {
+	currency, bounds, minorBounds := priceFacetBounds(search)
+	categories := make(map[string]int)
+	buckets := make([]int, len(bounds)+1)
+	for _, product := range products {
+		if product.Category != "" {
+			categories[product.Category]++
+		}
+		if product.Currency == currency {
+			bucket := sort.Search(len(minorBounds), func(i int) bool { return minorBounds[i] > product.PriceMinor })
+			buckets[bucket]++
+		}
+	}
+
+	facets := models.SearchFacets{
+		Categories: make([]models.CategoryFacet, 0, len(categories)),
+		Prices:     make([]models.PriceFacet, 0, len(buckets)),
+	}
+	for category, count := range categories {
+		facets.Categories = append(facets.Categories, models.CategoryFacet{Category: category, Count: count})
+	}
+	sortCategoryFacets(facets.Categories)
+
+	if len(bounds) > 0 {
+		facets.PriceCurrency = currency
+	}
+	for i, count := range buckets {
+		if count > 0 && len(bounds) > 0 {
+			facets.Prices = append(facets.Prices, priceFacet(bounds, i, count))
+		}
+	}
+	return facets
+}
+
+// priceFacetBounds returns the currency price facets are counted in and
+// the bucket bounds of search, as decimals and as the minor units a price
+// must stay below to fall under each bound. Prices in different currencies
+// cannot share buckets, so only the currency filtered on, or the default
+// one, is counted. A bound that rounds to the same minor units as the one
+// before it would make an empty bucket and is dropped.
+func priceFacetBounds(search ProductSearch) (string, []float64, []int64) {
+	currency := search.Filters.Currency
+	if currency == "" {
+		currency = models.DefaultCurrency
+	}
+	exponent, _ := models.CurrencyExponent(currency)
+	scale := math.Pow10(exponent)
+
+	var bounds []float64
+	var minorBounds []int64
+	for _, bound := range search.PriceBuckets {
+		// The tolerance absorbs float error, as priceRange does
+		minor := int64(math.Ceil(bound*scale - 1e-6))
+		if len(minorBounds) > 0 && minor <= minorBounds[len(minorBounds)-1] {
+			continue
+		}
+		bounds = append(bounds, bound)
+		minorBounds = append(minorBounds, minor)
+	}
+	return currency, bounds, minorBounds
+}
+
+// sortCategoryFacets orders categories by descending count, then by name
+func sortCategoryFacets(categories []models.CategoryFacet) {
+	sort.Slice(categories, func(i, j int) bool {
+		if categories[i].Count != categories[j].Count {
+			return categories[i].Count > categories[j].Count
+		}
+		return categories[i].Category < categories[j].Category
+	})
+}
+
+// priceFacet describes bucket i of bounds. Bucket 0 starts at zero and the
+// last bucket has no upper bound.
+func priceFacet(bounds []float64, i, count int) models.PriceFacet {
+	facet := models.PriceFacet{Count: count}
+	if i > 0 {
+		facet.Min = bounds[i-1]
+	}
+	if i < len(bounds) {
+		max := bounds[i]
+		facet.Max = &max
+	}
+	return facet
+}
+
+// accentClasses match a folded letter and its accented forms
+var accentClasses = func() map[rune]string {
+	variants := make(map[rune]string)
+	for accented, base := range foldedRunes {
+		variants[base] += string(accented)
+	}
+	classes := make(map[rune]string, len(variants))
+	for base, accented := range variants {
+		forms := []rune(accented)
+		sort.Slice(forms, func(i, j int) bool { return forms[i] < forms[j] })
+		classes[base] = "[" + string(base) + string(forms) + "]"
+	}
+	return classes
+}()
+
+// prefixPattern returns a case-insensitive regular expression matching a
+// word that starts with the folded prefix, in any accented spelling
+func prefixPattern(prefix string) string {
+	var pattern strings.Builder
+	pattern.WriteString(`(?:^|[^\p{L}\p{N}])`)
+	for _, r := range prefix {
+		if class, ok := accentClasses[r]; ok {
+			pattern.WriteString(class)
+		} else {
+			pattern.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	return pattern.String()
+}
@@ -0,0 +1,827 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/product-api-v2/internal/models"
+)
+
+// SwappableProductRepository forwards every call to a repository that can be
+// replaced at runtime, so a service started without its database can switch
+// to it once a background reconnect succeeds
+type SwappableProductRepository struct {
+	current atomic.Pointer[productRepositoryHolder]
+}
+
+// productRepositoryHolder lets an interface value live in an atomic.Pointer
+type productRepositoryHolder struct {
+	repo ProductRepository
+}
+
+// NewSwappableProductRepository creates a swappable repository backed by repo
+func NewSwappableProductRepository(repo ProductRepository) *SwappableProductRepository {
+	swappable := &SwappableProductRepository{}
+	swappable.Swap(repo)
+	return swappable
+}
+
+// Swap replaces the backing repository; calls already in flight finish on the old one
+func (r *SwappableProductRepository) Swap(repo ProductRepository) {
+	r.current.Store(&productRepositoryHolder{repo: repo})
+}
+
+// Current returns the backing repository
+func (r *SwappableProductRepository) Current() ProductRepository {
+	return r.current.Load().repo
+}
+
+// GetByID forwards to the current repository
+func (r *SwappableProductRepository) GetByID(ctx context.Context, productID string) (*models.Product, error) {
+	return r.Current().GetByID(ctx, productID)
+}
+
+// GetByIDFields forwards to the current repository
+func (r *SwappableProductRepository) GetByIDFields(ctx context.Context, productID string, fields []string) (*models.Product, error) {
+	return r.Current().GetByIDFields(ctx, productID, fields)
+}
+
+// GetByIDs forwards to the current repository
+func (r *SwappableProductRepository) GetByIDs(ctx context.Context, productIDs []string) ([]*models.Product, error) {
+	return r.Current().GetByIDs(ctx, productIDs)
+}
+
+// GetAll forwards to the current repository
+func (r *SwappableProductRepository) GetAll(ctx context.Context, filters ProductFilters) ([]*models.Product, error) {
+	return r.Current().GetAll(ctx, filters)
+}
+
+// Create forwards to the current repository
+func (r *SwappableProductRepository) Create(ctx context.Context, product *models.Product) error {
+	return r.Current().Create(ctx, product)
+}
+
+// Update forwards to the current repository
+func (r *SwappableProductRepository) Update(ctx context.Context, product *models.Product) error {
+	return r.Current().Update(ctx, product)
+}
+
+// Delete forwards to the current repository
+func (r *SwappableProductRepository) Delete(ctx context.Context, productID string) error {
+	return r.Current().Delete(ctx, productID)
+}
+
+// Count forwards to the current repository
+func (r *SwappableProductRepository) Count(ctx context.Context, filters ProductFilters) (int, error) {
+	return r.Current().Count(ctx, filters)
+}
+
+// CountByCategory forwards to the current repository
+func (r *SwappableProductRepository) CountByCategory(ctx context.Context, filters ProductFilters) (map[string]int, error) {
+	return r.Current().CountByCategory(ctx, filters)
+}
+
+// ReserveStock forwards to the current repository
+func (r *SwappableProductRepository) ReserveStock(ctx context.Context, lines []models.StockLine) error {
+	return r.Current().ReserveStock(ctx, lines)
+}
+
+// ReleaseStock forwards to the current repository
+func (r *SwappableProductRepository) ReleaseStock(ctx context.Context, lines []models.StockLine) error {
+	return r.Current().ReleaseStock(ctx, lines)
+}
+
+// BulkWrite forwards to the current repository
+func (r *SwappableProductRepository) BulkWrite(ctx context.Context, products []*models.Product, mode BulkMode) (*BulkWriteResult, error) {
+	return r.Current().BulkWrite(ctx, products, mode)
+}
+
+// Iterate forwards to the current repository
+func (r *SwappableProductRepository) Iterate(ctx context.Context, filters ProductFilters, yield func(*models.Product) error) error {
+	return r.Current().Iterate(ctx, filters, yield)
+}
+
+// Search forwards to the current repository
+func (r *SwappableProductRepository) Search(ctx context.Context, search ProductSearch) (*ProductSearchResult, error) {
+	return r.Current().Search(ctx, search)
+}
+
+// HealthCheck forwards to the current repository
+func (r *SwappableProductRepository) HealthCheck(ctx context.Context) error {
+	return r.Current().HealthCheck(ctx)
+}
+
+// SwappableReservationRepository is the ReservationRepository counterpart of
+// SwappableProductRepository
+type SwappableReservationRepository struct {
+	current atomic.Pointer[reservationRepositoryHolder]
+}
+
+// reservationRepositoryHolder lets an interface value live in an atomic.Pointer
+type reservationRepositoryHolder struct {
+	repo ReservationRepository
+}
+
+// NewSwappableReservationRepository creates a swappable repository backed by repo
+func NewSwappableReservationRepository(repo ReservationRepository) *SwappableReservationRepository {
+	swappable := &SwappableReservationRepository{}
+	swappable.Swap(repo)
+	return swappable
+}
+
+// Swap replaces the backing repository; calls already in flight finish on the old one
+func (r *SwappableReservationRepository) Swap(repo ReservationRepository) {
+	r.current.Store(&reservationRepositoryHolder{repo: repo})
+}
+
+// Current returns the backing repository
+func (r *SwappableReservationRepository) Current() ReservationRepository {
+	return r.current.Load().repo
+}
+
+// Create forwards to the current repository
+func (r *SwappableReservationRepository) Create(ctx context.Context, reservation *models.Reservation) error {
+	return r.Current().Create(ctx, reservation)
+}
+
+// GetByID forwards to the current repository
+func (r *SwappableReservationRepository) GetByID(ctx context.Context, reservationID string) (*models.Reservation, error) {
+	return r.Current().GetByID(ctx, reservationID)
+}
+
+// UpdateStatus forwards to the current repository
+func (r *SwappableReservationRepository) UpdateStatus(ctx context.Context, reservationID string, from, to models.ReservationStatus) (*models.Reservation, error) {
+	return r.Current().UpdateStatus(ctx, reservationID, from, to)
+}
+
+// ListExpired forwards to the current repository
+func (r *SwappableReservationRepository) ListExpired(ctx context.Context, now time.Time, limit int) ([]*models.Reservation, error) {
+	return r.Current().ListExpired(ctx, now, limit)
+}
+
+// SwappablePriceListRepository is the PriceListRepository counterpart of
+// SwappableProductRepository
+type SwappablePriceListRepository struct {
+	current atomic.Pointer[priceListRepositoryHolder]
+}
+
+// priceListRepositoryHolder lets an interface value live in an atomic.Pointer
+type priceListRepositoryHolder struct {
+	repo PriceListRepository
+}
+
+// NewSwappablePriceListRepository creates a swappable repository backed by repo
+func NewSwappablePriceListRepository(repo PriceListRepository) *SwappablePriceListRepository {
+	swappable := &SwappablePriceListRepository{}
+	swappable.Swap(repo)
+	return swappable
+}
+
+// Swap replaces the backing repository; calls already in flight finish on the old one
+func (r *SwappablePriceListRepository) Swap(repo PriceListRepository) {
+	r.current.Store(&priceListRepositoryHolder{repo: repo})
+}
+
+// Current returns the backing repository
+func (r *SwappablePriceListRepository) Current() PriceListRepository {
+	return r.current.Load().repo
+}
+
+// Create forwards to the current repository
+func (r *SwappablePriceListRepository) Create(ctx context.Context, priceList *models.PriceList) error {
+	return r.Current().Create(ctx, priceList)
+}
+
+// GetByID forwards to the current repository
+func (r *SwappablePriceListRepository) GetByID(ctx context.Context, priceListID string) (*models.PriceList, error) {
+	return r.Current().GetByID(ctx, priceListID)
+}
+
+// GetAll forwards to the current repository
+func (r *SwappablePriceListRepository) GetAll(ctx context.Context) ([]*models.PriceList, error) {
+	return r.Current().GetAll(ctx)
+}
+
+// Update forwards to the current repository
+func (r *SwappablePriceListRepository) Update(ctx context.Context, priceList *models.PriceList) error {
+	return r.Current().Update(ctx, priceList)
+}
+
+// Delete forwards to the current repository
+func (r *SwappablePriceListRepository) Delete(ctx context.Context, priceListID string) error {
+	return r.Current().Delete(ctx, priceListID)
+}
+
+// ListEffective forwards to the current repository
+func (r *SwappablePriceListRepository) ListEffective(ctx context.Context, at time.Time) ([]*models.PriceList, error) {
+	return r.Current().ListEffective(ctx, at)
+}
+
+// SwappableCategoryRepository is the CategoryRepository counterpart of
+// SwappableProductRepository
+type SwappableCategoryRepository struct {
+	current atomic.Pointer[categoryRepositoryHolder]
+}
+
+// categoryRepositoryHolder lets an interface value live in an atomic.Pointer
+type categoryRepositoryHolder struct {
+	repo CategoryRepository
+}
+
+// NewSwappableCategoryRepository creates a swappable repository backed by repo
+func NewSwappableCategoryRepository(repo CategoryRepository) *SwappableCategoryRepository {
+	swappable := &SwappableCategoryRepository{}
+	swappable.Swap(repo)
+	return swappable
+}
+
+// Swap replaces the backing repository; calls already in flight finish on the old one
+func (r *SwappableCategoryRepository) Swap(repo CategoryRepository) {
+	r.current.Store(&categoryRepositoryHolder{repo: repo})
+}
+
+// Current returns the backing repository
+func (r *SwappableCategoryRepository) Current() CategoryRepository {
+	return r.current.Load().repo
+}
+
+// Create forwards to the current repository
+func (r *SwappableCategoryRepository) Create(ctx context.Context, category *models.Category) error {
+	return r.Current().Create(ctx, category)
+}
+
+// GetBySlug forwards to the current repository
+func (r *SwappableCategoryRepository) GetBySlug(ctx context.Context, slug string) (*models.Category, error) {
+	return r.Current().GetBySlug(ctx, slug)
+}
+
+// GetAll forwards to the current repository
+func (r *SwappableCategoryRepository) GetAll(ctx context.Context) ([]*models.Category, error) {
+	return r.Current().GetAll(ctx)
+}
+
+// Update forwards to the current repository
+func (r *SwappableCategoryRepository) Update(ctx context.Context, category *models.Category) error {
+	return r.Current().Update(ctx, category)
+}
+
+// Delete forwards to the current repository
+func (r *SwappableCategoryRepository) Delete(ctx context.Context, slug string) error {
+	return r.Current().Delete(ctx, slug)
+}
+
+// SwappableProductHistoryRepository is the ProductHistoryRepository
+// counterpart of SwappableProductRepository
+type SwappableProductHistoryRepository struct {
+	current atomic.Pointer[productHistoryRepositoryHolder]
+}
+
+// productHistoryRepositoryHolder lets an interface value live in an atomic.Pointer
+type productHistoryRepositoryHolder struct {
+	repo ProductHistoryRepository
+}
+
+// NewSwappableProductHistoryRepository creates a swappable repository backed by repo
+func NewSwappableProductHistoryRepository(repo ProductHistoryRepository) *SwappableProductHistoryRepository {
+	swappable := &SwappableProductHistoryRepository{}
+	swappable.Swap(repo)
+	return swappable
+}
+
+// Swap replaces the backing repository; calls already in flight finish on the old one
+func (r *SwappableProductHistoryRepository) Swap(repo ProductHistoryRepository) {
+	r.current.Store(&productHistoryRepositoryHolder{repo: repo})
+}
+
+// Current returns the backing repository
+func (r *SwappableProductHistoryRepository) Current() ProductHistoryRepository {
+	return r.current.Load().repo
+}
+
+// Append forwards to the current repository
+func (r *SwappableProductHistoryRepository) Append(ctx context.Context, changes ...*models.ProductChange) error {
+	return r.Current().Append(ctx, changes...)
+}
+
+// List forwards to the current repository
+func (r *SwappableProductHistoryRepository) List(ctx context.Context, productID string) ([]*models.ProductChange, error) {
+	return r.Current().List(ctx, productID)
+}
+
+// GetVersion forwards to the current repository
+func (r *SwappableProductHistoryRepository) GetVersion(ctx context.Context, productID string, version int64) (*models.ProductChange, error) {
+	return r.Current().GetVersion(ctx, productID, version)
+}
+
+// SwappableOutboxRepository is the OutboxRepository counterpart of
+// SwappableProductRepository
+type SwappableOutboxRepository struct {
+	current atomic.Pointer[outboxRepositoryHolder]
+}
+
+// outboxRepositoryHolder lets an interface value live in an atomic.Pointer
+type outboxRepositoryHolder struct {
+	repo OutboxRepository
+}
+
+// NewSwappableOutboxRepository creates a swappable repository backed by repo
+func NewSwappableOutboxRepository(repo OutboxRepository) *SwappableOutboxRepository {
+	swappable := &SwappableOutboxRepository{}
+	swappable.Swap(repo)
+	return swappable
+}
+
+// Swap replaces the backing repository; calls already in flight finish on the old one
+func (r *SwappableOutboxRepository) Swap(repo OutboxRepository) {
+	r.current.Store(&outboxRepositoryHolder{repo: repo})
+}
+
+// Current returns the backing repository
+func (r *SwappableOutboxRepository) Current() OutboxRepository {
+	return r.current.Load().repo
+}
+
+// Append forwards to the current repository
+func (r *SwappableOutboxRepository) Append(ctx context.Context, messages ...*models.OutboxMessage) error {
+	return r.Current().Append(ctx, messages...)
+}
+
+// Pending forwards to the current repository
+func (r *SwappableOutboxRepository) Pending(ctx context.Context, limit int) ([]*models.OutboxMessage, error) {
+	return r.Current().Pending(ctx, limit)
+}
+
+// MarkPublished forwards to the current repository
+func (r *SwappableOutboxRepository) MarkPublished(ctx context.Context, eventIDs []string, publishedAt time.Time) error {
+	return r.Current().MarkPublished(ctx, eventIDs, publishedAt)
+}
+
+// RecordFailure forwards to the current repository
+func (r *SwappableOutboxRepository) RecordFailure(ctx context.Context, eventID string, cause string) error {
+	return r.Current().RecordFailure(ctx, eventID, cause)
+}
+
+// MarkDead forwards to the current repository
+func (r *SwappableOutboxRepository) MarkDead(ctx context.Context, eventID string, cause string, deadAt time.Time) error {
+	return r.Current().MarkDead(ctx, eventID, cause, deadAt)
+}
+
+// SwappableTransactor is the Transactor counterpart of
+// SwappableProductRepository
+type SwappableTransactor struct {
+	current atomic.Pointer[transactorHolder]
+}
+
+// transactorHolder lets an interface value live in an atomic.Pointer
+type transactorHolder struct {
+	transactor Transactor
+}
+
+// NewSwappableTransactor creates a swappable transactor backed by transactor
+func NewSwappableTransactor(transactor Transactor) *SwappableTransactor {
+	swappable := &SwappableTransactor{}
+	swappable.Swap(transactor)
+	return swappable
+}
+
+// Swap replaces the backing transactor; work already running finishes on the old one
+func (t *SwappableTransactor) Swap(transactor Transactor) {
+	t.current.Store(&transactorHolder{transactor: transactor})
+}
+
+// Current returns the backing transactor
+func (t *SwappableTransactor) Current() Transactor {
+	return t.current.Load().transactor
+}
+
+// WithinTransaction forwards to the current transactor
+func (t *SwappableTransactor) WithinTransaction(ctx context.Context, work func(ctx context.Context) error) error {
+	return t.Current().WithinTransaction(ctx, work)
+}
+
+// SwappableWebhookRepository is the WebhookRepository counterpart of
+// SwappableProductRepository
+type SwappableWebhookRepository struct {
+	current atomic.Pointer[webhookRepositoryHolder]
+}
+
+// webhookRepositoryHolder lets an interface value live in an atomic.Pointer
+type webhookRepositoryHolder struct {
+	repo WebhookRepository
+}
+
+// NewSwappableWebhookRepository creates a swappable repository backed by repo
+func NewSwappableWebhookRepository(repo WebhookRepository) *SwappableWebhookRepository {
+	swappable := &SwappableWebhookRepository{}
+	swappable.Swap(repo)
+	return swappable
+}
+
+// Swap replaces the backing repository; calls already in flight finish on the old one
+func (r *SwappableWebhookRepository) Swap(repo WebhookRepository) {
+	r.current.Store(&webhookRepositoryHolder{repo: repo})
+}
+
+// Current returns the backing repository
+func (r *SwappableWebhookRepository) Current() WebhookRepository {
+	return r.current.Load().repo
+}
+
+// Create forwards to the current repository
+func (r *SwappableWebhookRepository) Create(ctx context.Context, webhook *models.WebhookSubscription) error {
+	return r.Current().Create(ctx, webhook)
+}
+
+// GetByID forwards to the current repository
+func (r *SwappableWebhookRepository) GetByID(ctx context.Context, webhookID string) (*models.WebhookSubscription, error) {
+	return r.Current().GetByID(ctx, webhookID)
+}
+
+// GetAll forwards to the current repository
+func (r *SwappableWebhookRepository) GetAll(ctx context.Context) ([]*models.WebhookSubscription, error) {
+	return r.Current().GetAll(ctx)
+}
+
+// Delete forwards to the current repository
+func (r *SwappableWebhookRepository) Delete(ctx context.Context, webhookID string) error {
+	return r.Current().Delete(ctx, webhookID)
+}
+
+// SwappableWebhookDeliveryRepository is the WebhookDeliveryRepository counterpart of
+// SwappableProductRepository
+type SwappableWebhookDeliveryRepository struct {
+	current atomic.Pointer[webhookDeliveryRepositoryHolder]
+}
+
+// webhookDeliveryRepositoryHolder lets an interface value live in an atomic.Pointer
+type webhookDeliveryRepositoryHolder struct {
+	repo WebhookDeliveryRepository
+}
+
+// NewSwappableWebhookDeliveryRepository creates a swappable repository backed by repo
+func NewSwappableWebhookDeliveryRepository(repo WebhookDeliveryRepository) *SwappableWebhookDeliveryRepository {
+	swappable := &SwappableWebhookDeliveryRepository{}
+	swappable.Swap(repo)
+	return swappable
+}
+
+// Swap replaces the backing repository; calls already in flight finish on the old one
+func (r *SwappableWebhookDeliveryRepository) Swap(repo WebhookDeliveryRepository) {
+	r.current.Store(&webhookDeliveryRepositoryHolder{repo: repo})
+}
+
+// Current returns the backing repository
+func (r *SwappableWebhookDeliveryRepository) Current() WebhookDeliveryRepository {
+	return r.current.Load().repo
+}
+
+// Append forwards to the current repository
+func (r *SwappableWebhookDeliveryRepository) Append(ctx context.Context, deliveries ...*models.WebhookDelivery) error {
+	return r.Current().Append(ctx, deliveries...)
+}
+
+// ClaimDue forwards to the current repository
+func (r *SwappableWebhookDeliveryRepository) ClaimDue(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*models.WebhookDelivery, error) {
+	return r.Current().ClaimDue(ctx, now, lease, limit)
+}
+
+// GetByID forwards to the current repository
+func (r *SwappableWebhookDeliveryRepository) GetByID(ctx context.Context, deliveryID string) (*models.WebhookDelivery, error) {
+	return r.Current().GetByID(ctx, deliveryID)
+}
+
+// Update forwards to the current repository
+func (r *SwappableWebhookDeliveryRepository) Update(ctx context.Context, delivery *models.WebhookDelivery) error {
+	return r.Current().Update(ctx, delivery)
+}
+
+// List forwards to the current repository
+func (r *SwappableWebhookDeliveryRepository) List(ctx context.Context, filter WebhookDeliveryFilter) ([]*models.WebhookDelivery, error) {
+	return r.Current().List(ctx, filter)
+}
+
+// UnavailableProductRepository stands in for a database that could not be
+// reached. Every call fails with ErrRepositoryUnavailable, which the services
+// report as 503, and HealthCheck fails so readiness stays off.
+type UnavailableProductRepository struct {
+	err error
+}
+
+// NewUnavailableProductRepository creates a repository that fails every call with cause
+func NewUnavailableProductRepository(cause error) *UnavailableProductRepository {
+	return &UnavailableProductRepository{err: fmt.Errorf("%w: %v", ErrRepositoryUnavailable, cause)}
+}
+
+// GetByID always fails
+func (r *UnavailableProductRepository) GetByID(ctx context.Context, productID string) (*models.Product, error) {
+	return nil, r.err
+}
+
+// GetByIDFields always fails
+func (r *UnavailableProductRepository) GetByIDFields(ctx context.Context, productID string, fields []string) (*models.Product, error) {
+	return nil, r.err
+}
+
+// GetByIDs always fails
+func (r *UnavailableProductRepository) GetByIDs(ctx context.Context, productIDs []string) ([]*models.Product, error) {
+	return nil, r.err
+}
+
+// GetAll always fails
+func (r *UnavailableProductRepository) GetAll(ctx context.Context, filters ProductFilters) ([]*models.Product, error) {
+	return nil, r.err
+}
+
+// Create always fails
+func (r *UnavailableProductRepository) Create(ctx context.Context, product *models.Product) error {
+	return r.err
+}
+
+// Update always fails
+func (r *UnavailableProductRepository) Update(ctx context.Context, product *models.Product) error {
+	return r.err
+}
+
+// Delete always fails
+func (r *UnavailableProductRepository) Delete(ctx context.Context, productID string) error {
+	return r.err
+}
+
+// Count always fails
+func (r *UnavailableProductRepository) Count(ctx context.Context, filters ProductFilters) (int, error) {
+	return 0, r.err
+}
+
+// CountByCategory always fails
+func (r *UnavailableProductRepository) CountByCategory(ctx context.Context, filters ProductFilters) (map[string]int, error) {
+	return nil, r.err
+}
+
+// ReserveStock always fails
+func (r *UnavailableProductRepository) ReserveStock(ctx context.Context, lines []models.StockLine) error {
+	return r.err
+}
+
+// ReleaseStock always fails
+func (r *UnavailableProductRepository) ReleaseStock(ctx context.Context, lines []models.StockLine) error {
+	return r.err
+}
+
+// BulkWrite always fails
+func (r *UnavailableProductRepository) BulkWrite(ctx context.Context, products []*models.Product, mode BulkMode) (*BulkWriteResult, error) {
+	return nil, r.err
+}
+
+// Iterate always fails
+func (r *UnavailableProductRepository) Iterate(ctx context.Context, filters ProductFilters, yield func(*models.Product) error) error {
+	return r.err
+}
+
+// Search always fails
+func (r *UnavailableProductRepository) Search(ctx context.Context, search ProductSearch) (*ProductSearchResult, error) {
+	return nil, r.err
+}
+
+// HealthCheck always fails
+func (r *UnavailableProductRepository) HealthCheck(ctx context.Context) error {
+	return r.err
+}
+
+// UnavailableReservationRepository is the ReservationRepository counterpart
+// of UnavailableProductRepository
+type UnavailableReservationRepository struct {
+	err error
+}
+
+// NewUnavailableReservationRepository creates a repository that fails every call with cause
+func NewUnavailableReservationRepository(cause error) *UnavailableReservationRepository {
+	return &UnavailableReservationRepository{err: fmt.Errorf("%w: %v", ErrRepositoryUnavailable, cause)}
+}
+
+// Create always fails
+func (r *UnavailableReservationRepository) Create(ctx context.Context, reservation *models.Reservation) error {
+	return r.err
+}
+
+// GetByID always fails
+func (r *UnavailableReservationRepository) GetByID(ctx context.Context, reservationID string) (*models.Reservation, error) {
+	return nil, r.err
+}
+
+// UpdateStatus always fails
+func (r *UnavailableReservationRepository) UpdateStatus(ctx context.Context, reservationID string, from, to models.ReservationStatus) (*models.Reservation, error) {
+	return nil, r.err
+}
+
+// ListExpired always fails
+func (r *UnavailableReservationRepository) ListExpired(ctx context.Context, now time.Time, limit int) ([]*models.Reservation, error) {
+	return nil, r.err
+}
+
+// UnavailablePriceListRepository is the PriceListRepository counterpart of
+// UnavailableProductRepository
+type UnavailablePriceListRepository struct {
+	err error
+}
+
+// NewUnavailablePriceListRepository creates a repository that fails every call with cause
+func NewUnavailablePriceListRepository(cause error) *UnavailablePriceListRepository {
+	return &UnavailablePriceListRepository{err: fmt.Errorf("%w: %v", ErrRepositoryUnavailable, cause)}
+}
+
+// Create always fails
+func (r *UnavailablePriceListRepository) Create(ctx context.Context, priceList *models.PriceList) error {
+	return r.err
+}
+
+// GetByID always fails
+func (r *UnavailablePriceListRepository) GetByID(ctx context.Context, priceListID string) (*models.PriceList, error) {
+	return nil, r.err
+}
+
+// GetAll always fails
+func (r *UnavailablePriceListRepository) GetAll(ctx context.Context) ([]*models.PriceList, error) {
+	return nil, r.err
+}
+
+// Update always fails
+func (r *UnavailablePriceListRepository) Update(ctx context.Context, priceList *models.PriceList) error {
+	return r.err
+}
+
+// Delete always fails
+func (r *UnavailablePriceListRepository) Delete(ctx context.Context, priceListID string) error {
+	return r.err
+}
+
+// ListEffective always fails
+func (r *UnavailablePriceListRepository) ListEffective(ctx context.Context, at time.Time) ([]*models.PriceList, error) {
+	return nil, r.err
+}
+
+// UnavailableCategoryRepository is the CategoryRepository counterpart of
+// UnavailableProductRepository
+type UnavailableCategoryRepository struct {
+	err error
+}
+
+// NewUnavailableCategoryRepository creates a repository that fails every call with cause
+func NewUnavailableCategoryRepository(cause error) *UnavailableCategoryRepository {
+	return &UnavailableCategoryRepository{err: fmt.Errorf("%w: %v", ErrRepositoryUnavailable, cause)}
+}
+
+// Create always fails
+func (r *UnavailableCategoryRepository) Create(ctx context.Context, category *models.Category) error {
+	return r.err
+}
+
+// GetBySlug always fails
+func (r *UnavailableCategoryRepository) GetBySlug(ctx context.Context, slug string) (*models.Category, error) {
+	return nil, r.err
+}
+
+// GetAll always fails
+func (r *UnavailableCategoryRepository) GetAll(ctx context.Context) ([]*models.Category, error) {
+	return nil, r.err
+}
+
+// Update always fails
+func (r *UnavailableCategoryRepository) Update(ctx context.Context, category *models.Category) error {
+	return r.err
+}
+
+// Delete always fails
+func (r *UnavailableCategoryRepository) Delete(ctx context.Context, slug string) error {
+	return r.err
+}
+
+// UnavailableProductHistoryRepository is the ProductHistoryRepository
+// counterpart of UnavailableProductRepository
+type UnavailableProductHistoryRepository struct {
+	err error
+}
+
+// NewUnavailableProductHistoryRepository creates a repository that fails every call with cause
+func NewUnavailableProductHistoryRepository(cause error) *UnavailableProductHistoryRepository {
+	return &UnavailableProductHistoryRepository{err: fmt.Errorf("%w: %v", ErrRepositoryUnavailable, cause)}
+}
+
+// Append always fails
+func (r *UnavailableProductHistoryRepository) Append(ctx context.Context, changes ...*models.ProductChange) error {
+	return r.err
+}
+
+// List always fails
+func (r *UnavailableProductHistoryRepository) List(ctx context.Context, productID string) ([]*models.ProductChange, error) {
+	return nil, r.err
+}
+
+// GetVersion always fails
+func (r *UnavailableProductHistoryRepository) GetVersion(ctx context.Context, productID string, version int64) (*models.ProductChange, error) {
+	return nil, r.err
+}
+
+// UnavailableOutboxRepository is the OutboxRepository counterpart of
+// UnavailableProductRepository
+type UnavailableOutboxRepository struct {
+	err error
+}
+
+// NewUnavailableOutboxRepository creates a repository that fails every call with cause
+func NewUnavailableOutboxRepository(cause error) *UnavailableOutboxRepository {
+	return &UnavailableOutboxRepository{err: fmt.Errorf("%w: %v", ErrRepositoryUnavailable, cause)}
+}
+
+// Append always fails
+func (r *UnavailableOutboxRepository) Append(ctx context.Context, messages ...*models.OutboxMessage) error {
+	return r.err
+}
+
+// Pending always fails
+func (r *UnavailableOutboxRepository) Pending(ctx context.Context, limit int) ([]*models.OutboxMessage, error) {
+	return nil, r.err
+}
+
+// MarkPublished always fails
+func (r *UnavailableOutboxRepository) MarkPublished(ctx context.Context, eventIDs []string, publishedAt time.Time) error {
+	return r.err
+}
+
+// RecordFailure always fails
+func (r *UnavailableOutboxRepository) RecordFailure(ctx context.Context, eventID string, cause string) error {
+	return r.err
+}
+
+// MarkDead always fails
+func (r *UnavailableOutboxRepository) MarkDead(ctx context.Context, eventID string, cause string, deadAt time.Time) error {
+	return r.err
+}
+
+// UnavailableWebhookRepository is the WebhookRepository counterpart of
+// UnavailableProductRepository
+type UnavailableWebhookRepository struct {
+	err error
+}
+
+// NewUnavailableWebhookRepository creates a repository that fails every call with cause
+func NewUnavailableWebhookRepository(cause error) *UnavailableWebhookRepository {
+	return &UnavailableWebhookRepository{err: fmt.Errorf("%w: %v", ErrRepositoryUnavailable, cause)}
+}
+
+// Create always fails
+func (r *UnavailableWebhookRepository) Create(ctx context.Context, webhook *models.WebhookSubscription) error {
+	return r.err
+}
+
+// GetByID always fails
+func (r *UnavailableWebhookRepository) GetByID(ctx context.Context, webhookID string) (*models.WebhookSubscription, error) {
+	return nil, r.err
+}
+
+// GetAll always fails
+func (r *UnavailableWebhookRepository) GetAll(ctx context.Context) ([]*models.WebhookSubscription, error) {
+	return nil, r.err
+}
+
+// Delete always fails
+func (r *UnavailableWebhookRepository) Delete(ctx context.Context, webhookID string) error {
+	return r.err
+}
+
+// UnavailableWebhookDeliveryRepository is the WebhookDeliveryRepository counterpart of
+// UnavailableProductRepository
+type UnavailableWebhookDeliveryRepository struct {
+	err error
+}
+
+// NewUnavailableWebhookDeliveryRepository creates a repository that fails every call with cause
+func NewUnavailableWebhookDeliveryRepository(cause error) *UnavailableWebhookDeliveryRepository {
+	return &UnavailableWebhookDeliveryRepository{err: fmt.Errorf("%w: %v", ErrRepositoryUnavailable, cause)}
+}
+
+// Append always fails
+func (r *UnavailableWebhookDeliveryRepository) Append(ctx context.Context, deliveries ...*models.WebhookDelivery) error {
+	return r.err
+}
+
+// ClaimDue always fails
+func (r *UnavailableWebhookDeliveryRepository) ClaimDue(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*models.WebhookDelivery, error) {
+	return nil, r.err
+}
+
+// GetByID always fails
+func (r *UnavailableWebhookDeliveryRepository) GetByID(ctx context.Context, deliveryID string) (*models.WebhookDelivery, error) {
+	return nil, r.err
+}
+
+// Update always fails
+func (r *UnavailableWebhookDeliveryRepository) Update(ctx context.Context, delivery *models.WebhookDelivery) error {
+	return r.err
+}
+
+// List always fails
+func (r *UnavailableWebhookDeliveryRepository) List(ctx context.Context, filter WebhookDeliveryFilter) ([]*models.WebhookDelivery, error) {
+	return nil, r.err
+}
@@ -0,0 +1,139 @@
+package repository
+
+import (
+	"context"
+	"sync"
+
+	"github.com/product-api-v2/configs"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Transactor runs a unit of work so that the repository writes it makes
+// through the context it is given are committed together or not at all.
+// Work may be run more than once when a transaction is retried.
+type Transactor interface {
+	WithinTransaction(ctx context.Context, work func(ctx context.Context) error) error
+}
+
+// transactionKey marks the context of work running in a transaction
+type transactionKey struct{}
+
+// transactionState collects what has to happen once a transaction ends
+type transactionState struct {
+	mutex sync.Mutex
+	ended []func()
+}
+
+// inTransaction reports whether ctx belongs to work running in a
+// transaction, whose reads may see writes that are not committed yet
+func inTransaction(ctx context.Context) bool {
+	_, ok := ctx.Value(transactionKey{}).(*transactionState)
+	return ok
+}
+
+// afterTransaction runs fn when the transaction of ctx ends, whether it
+// committed or not, or right away outside a transaction
+func afterTransaction(ctx context.Context, fn func()) {
+	state, ok := ctx.Value(transactionKey{}).(*transactionState)
+	if !ok {
+		fn()
+		return
+	}
+	state.mutex.Lock()
+	defer state.mutex.Unlock()
+	state.ended = append(state.ended, fn)
+}
+
+// runTransaction marks ctx as transactional for run and afterwards calls
+// what was registered with afterTransaction
+func runTransaction(ctx context.Context, run func(ctx context.Context) error) error {
+	state := &transactionState{}
+	defer func() {
+		state.mutex.Lock()
+		defer state.mutex.Unlock()
+		for _, fn := range state.ended {
+			fn()
+		}
+	}()
+	return run(context.WithValue(ctx, transactionKey{}, state))
+}
+
+// DirectTransactor runs work without a transaction. It suits the in-memory
+// repositories and databases that do not support transactions; a failure
+// partway through leaves the earlier writes in place.
+type DirectTransactor struct{}
+
+// NewDirectTransactor creates a transactor that runs work as it comes
+func NewDirectTransactor() *DirectTransactor {
+	return &DirectTransactor{}
+}
+
+// WithinTransaction runs work once
+func (t *DirectTransactor) WithinTransaction(ctx context.Context, work func(ctx context.Context) error) error {
+	return work(ctx)
+}
+
+// MongoTransactor runs work in a MongoDB transaction. Transactions need a
+// replica set or a sharded cluster; on a standalone server work runs
+// without one, like DirectTransactor.
+type MongoTransactor struct {
+	client    *mongo.Client
+	supported bool
+}
+
+// NewMongoTransactor creates a transactor on the connection of the product
+// repository, checking whether the deployment supports transactions
+func NewMongoTransactor(products *MongoProductRepository, config configs.DatabaseConfig) (*MongoTransactor, error) {
+	timeout := config.ConnectTimeout
+	if timeout <= 0 {
+		timeout = defaultConnectTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var hello struct {
+		SetName string `bson:"setName"`
+		Msg     string `bson:"msg"`
+	}
+	err := products.client.Database("admin").RunCommand(ctx, bson.D{{Key: "hello", Value: 1}}).Decode(&hello)
+	if err != nil {
+		return nil, wrapMongoError(err)
+	}
+
+	return &MongoTransactor{
+		client:    products.client,
+		supported: hello.SetName != "" || hello.Msg == "isdbgrid",
+	}, nil
+}
+
+// Supported reports whether work runs in transactions
+func (t *MongoTransactor) Supported() bool {
+	return t.supported
+}
+
+// WithinTransaction runs work in a transaction, retrying it on transient
+// transaction errors. Errors returned by work are passed through.
+func (t *MongoTransactor) WithinTransaction(ctx context.Context, work func(ctx context.Context) error) error {
+	if !t.supported {
+		return work(ctx)
+	}
+
+	session, err := t.client.StartSession()
+	if err != nil {
+		return wrapMongoError(err)
+	}
+	defer session.EndSession(context.Background())
+
+	return runTransaction(ctx, func(ctx context.Context) error {
+		var workErr error
+		_, err := session.WithTransaction(ctx, func(sessionCtx mongo.SessionContext) (interface{}, error) {
+			workErr = work(sessionCtx)
+			return nil, workErr
+		})
+		if err != nil && err == workErr {
+			return err
+		}
+		return wrapMongoError(err)
+	})
+}
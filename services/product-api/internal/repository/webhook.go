@@ -0,0 +1,245 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/product-api-v2/internal/models"
+)
+
+var (
+	ErrWebhookNotFound  = errors.New("webhook not found")
+	ErrWebhookExists    = errors.New("webhook already exists")
+	ErrDeliveryNotFound = errors.New("webhook delivery not found")
+)
+
+// WebhookRepository stores webhook subscriptions
+type WebhookRepository interface {
+	Create(ctx context.Context, webhook *models.WebhookSubscription) error
+	GetByID(ctx context.Context, webhookID string) (*models.WebhookSubscription, error)
+	// GetAll returns every subscription, oldest first
+	GetAll(ctx context.Context) ([]*models.WebhookSubscription, error)
+	Delete(ctx context.Context, webhookID string) error
+}
+
+// WebhookDeliveryFilter selects deliveries from the log. Empty fields match
+// every delivery; Limit 0 means no limit.
+type WebhookDeliveryFilter struct {
+	WebhookID string
+	Status    string
+	Limit     int
+}
+
+// WebhookDeliveryRepository stores webhook deliveries and their outcome
+type WebhookDeliveryRepository interface {
+	Append(ctx context.Context, deliveries ...*models.WebhookDelivery) error
+	// ClaimDue returns up to limit pending deliveries due at now, earliest
+	// first, and moves their next attempt lease later so that concurrent
+	// dispatchers skip them meanwhile
+	ClaimDue(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*models.WebhookDelivery, error)
+	GetByID(ctx context.Context, deliveryID string) (*models.WebhookDelivery, error)
+	// Update replaces a delivery, or fails with ErrDeliveryNotFound
+	Update(ctx context.Context, delivery *models.WebhookDelivery) error
+	// List returns the deliveries matching filter, newest first
+	List(ctx context.Context, filter WebhookDeliveryFilter) ([]*models.WebhookDelivery, error)
+}
+
+// MemoryWebhookRepository implements WebhookRepository using in-memory storage
+type MemoryWebhookRepository struct {
+	webhooks map[string]*models.WebhookSubscription
+	mutex    sync.RWMutex
+}
+
+// NewMemoryWebhookRepository creates a new in-memory webhook repository
+func NewMemoryWebhookRepository() *MemoryWebhookRepository {
+	return &MemoryWebhookRepository{
+		webhooks: make(map[string]*models.WebhookSubscription),
+	}
+}
+
+// Create stores a new subscription
+func (r *MemoryWebhookRepository) Create(ctx context.Context, webhook *models.WebhookSubscription) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, exists := r.webhooks[webhook.WebhookID]; exists {
+		return ErrWebhookExists
+	}
+	r.webhooks[webhook.WebhookID] = copyWebhook(webhook)
+	return nil
+}
+
+// GetByID returns a copy of a subscription
+func (r *MemoryWebhookRepository) GetByID(ctx context.Context, webhookID string) (*models.WebhookSubscription, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	webhook, exists := r.webhooks[webhookID]
+	if !exists {
+		return nil, ErrWebhookNotFound
+	}
+	return copyWebhook(webhook), nil
+}
+
+// GetAll returns copies of every subscription, oldest first
+func (r *MemoryWebhookRepository) GetAll(ctx context.Context) ([]*models.WebhookSubscription, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	webhooks := make([]*models.WebhookSubscription, 0, len(r.webhooks))
+	for _, webhook := range r.webhooks {
+		webhooks = append(webhooks, copyWebhook(webhook))
+	}
+	sort.Slice(webhooks, func(i, j int) bool {
+		if !webhooks[i].CreatedAt.Equal(webhooks[j].CreatedAt) {
+			return webhooks[i].CreatedAt.Before(webhooks[j].CreatedAt)
+		}
+		return webhooks[i].WebhookID < webhooks[j].WebhookID
+	})
+	return webhooks, nil
+}
+
+// Delete removes a subscription
+func (r *MemoryWebhookRepository) Delete(ctx context.Context, webhookID string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, exists := r.webhooks[webhookID]; !exists {
+		return ErrWebhookNotFound
+	}
+	delete(r.webhooks, webhookID)
+	return nil
+}
+
+// copyWebhook returns a copy so callers cannot modify stored state
+func copyWebhook(webhook *models.WebhookSubscription) *models.WebhookSubscription {
+	webhookCopy := *webhook
+	webhookCopy.EventTypes = append([]string(nil), webhook.EventTypes...)
+	return &webhookCopy
+}
+
+// deliveryRetention is how long successful deliveries stay in the log
+// before they are removed. Dead-lettered deliveries are kept.
+const deliveryRetention = 30 * 24 * time.Hour
+
+// MemoryWebhookDeliveryRepository implements WebhookDeliveryRepository using
+// in-memory storage
+type MemoryWebhookDeliveryRepository struct {
+	deliveries []*models.WebhookDelivery // in the order they were appended
+	mutex      sync.RWMutex
+}
+
+// NewMemoryWebhookDeliveryRepository creates a new in-memory delivery repository
+func NewMemoryWebhookDeliveryRepository() *MemoryWebhookDeliveryRepository {
+	return &MemoryWebhookDeliveryRepository{}
+}
+
+// Append stores deliveries after those already recorded
+func (r *MemoryWebhookDeliveryRepository) Append(ctx context.Context, deliveries ...*models.WebhookDelivery) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.prune(time.Now())
+	for _, delivery := range deliveries {
+		r.deliveries = append(r.deliveries, copyDelivery(delivery))
+	}
+	return nil
+}
+
+// prune drops the deliveries that succeeded more than deliveryRetention
+// before now, as the TTL index does in MongoDB
+func (r *MemoryWebhookDeliveryRepository) prune(now time.Time) {
+	cutoff := now.Add(-deliveryRetention)
+	r.deliveries = slices.DeleteFunc(r.deliveries, func(delivery *models.WebhookDelivery) bool {
+		return delivery.DeliveredAt != nil && delivery.DeliveredAt.Before(cutoff)
+	})
+}
+
+// ClaimDue returns copies of the pending deliveries due at now and leases them
+func (r *MemoryWebhookDeliveryRepository) ClaimDue(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*models.WebhookDelivery, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	due := []*models.WebhookDelivery{}
+	for _, delivery := range r.deliveries {
+		if delivery.Status == models.DeliveryPending && !delivery.NextAttemptAt.After(now) {
+			due = append(due, delivery)
+		}
+	}
+	sort.SliceStable(due, func(i, j int) bool {
+		return due[i].NextAttemptAt.Before(due[j].NextAttemptAt)
+	})
+	if limit > 0 && len(due) > limit {
+		due = due[:limit]
+	}
+
+	claimed := make([]*models.WebhookDelivery, 0, len(due))
+	for _, delivery := range due {
+		claimed = append(claimed, copyDelivery(delivery))
+		delivery.NextAttemptAt = now.Add(lease)
+	}
+	return claimed, nil
+}
+
+// GetByID returns a copy of a delivery
+func (r *MemoryWebhookDeliveryRepository) GetByID(ctx context.Context, deliveryID string) (*models.WebhookDelivery, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	for _, delivery := range r.deliveries {
+		if delivery.DeliveryID == deliveryID {
+			return copyDelivery(delivery), nil
+		}
+	}
+	return nil, ErrDeliveryNotFound
+}
+
+// Update replaces a stored delivery
+func (r *MemoryWebhookDeliveryRepository) Update(ctx context.Context, delivery *models.WebhookDelivery) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for i, stored := range r.deliveries {
+		if stored.DeliveryID == delivery.DeliveryID {
+			r.deliveries[i] = copyDelivery(delivery)
+			return nil
+		}
+	}
+	return ErrDeliveryNotFound
+}
+
+// List returns copies of the matching deliveries, newest first
+func (r *MemoryWebhookDeliveryRepository) List(ctx context.Context, filter WebhookDeliveryFilter) ([]*models.WebhookDelivery, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	deliveries := []*models.WebhookDelivery{}
+	for i := len(r.deliveries) - 1; i >= 0; i-- {
+		delivery := r.deliveries[i]
+		if filter.WebhookID != "" && delivery.WebhookID != filter.WebhookID {
+			continue
+		}
+		if filter.Status != "" && delivery.Status != filter.Status {
+			continue
+		}
+		deliveries = append(deliveries, copyDelivery(delivery))
+		if filter.Limit > 0 && len(deliveries) == filter.Limit {
+			break
+		}
+	}
+	return deliveries, nil
+}
+
+// copyDelivery returns a copy so callers cannot modify stored state
+func copyDelivery(delivery *models.WebhookDelivery) *models.WebhookDelivery {
+	deliveryCopy := *delivery
+	if delivery.DeliveredAt != nil {
+		deliveredAt := *delivery.DeliveredAt
+		deliveryCopy.DeliveredAt = &deliveredAt
+	}
+	return &deliveryCopy
+}
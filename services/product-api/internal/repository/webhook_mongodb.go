@@ -0,0 +1,222 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/product-api-v2/configs"
+	"github.com/product-api-v2/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoWebhookRepository implements WebhookRepository using MongoDB
+type MongoWebhookRepository struct {
+	collection *mongo.Collection
+}
+
+// NewMongoWebhookRepository creates a webhook repository that shares the
+// database connection of the product repository and stores subscriptions
+// in config.WebhookCollection
+func NewMongoWebhookRepository(products *MongoProductRepository, config configs.DatabaseConfig) (*MongoWebhookRepository, error) {
+	collection := products.collection.Database().Collection(config.WebhookCollection)
+
+	timeout := config.ConnectTimeout
+	if timeout <= 0 {
+		timeout = defaultConnectTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	_, err := collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "webhookId", Value: 1}}, Options: options.Index().SetUnique(true)},
+	})
+	if err != nil {
+		return nil, wrapMongoError(err)
+	}
+
+	return &MongoWebhookRepository{collection: collection}, nil
+}
+
+// Create stores a new subscription in MongoDB
+func (r *MongoWebhookRepository) Create(ctx context.Context, webhook *models.WebhookSubscription) error {
+	_, err := r.collection.InsertOne(ctx, webhook)
+	if mongo.IsDuplicateKeyError(err) {
+		return ErrWebhookExists
+	}
+	return wrapMongoError(err)
+}
+
+// GetByID retrieves a subscription from MongoDB
+func (r *MongoWebhookRepository) GetByID(ctx context.Context, webhookID string) (*models.WebhookSubscription, error) {
+	var webhook models.WebhookSubscription
+
+	err := r.collection.FindOne(ctx, bson.M{"webhookId": webhookID}).Decode(&webhook)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrWebhookNotFound
+		}
+		return nil, wrapMongoError(err)
+	}
+
+	return &webhook, nil
+}
+
+// GetAll returns every subscription, oldest first
+func (r *MongoWebhookRepository) GetAll(ctx context.Context) ([]*models.WebhookSubscription, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "createdAt", Value: 1}, {Key: "webhookId", Value: 1}})
+	cursor, err := r.collection.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, wrapMongoError(err)
+	}
+	defer cursor.Close(ctx)
+
+	webhooks := []*models.WebhookSubscription{}
+	if err := cursor.All(ctx, &webhooks); err != nil {
+		return nil, wrapMongoError(err)
+	}
+
+	return webhooks, nil
+}
+
+// Delete removes a subscription from MongoDB
+func (r *MongoWebhookRepository) Delete(ctx context.Context, webhookID string) error {
+	result, err := r.collection.DeleteOne(ctx, bson.M{"webhookId": webhookID})
+	if err != nil {
+		return wrapMongoError(err)
+	}
+	if result.DeletedCount == 0 {
+		return ErrWebhookNotFound
+	}
+	return nil
+}
+
+// MongoWebhookDeliveryRepository implements WebhookDeliveryRepository using
+// MongoDB
+type MongoWebhookDeliveryRepository struct {
+	collection *mongo.Collection
+}
+
+// NewMongoWebhookDeliveryRepository creates a delivery repository that
+// shares the database connection of the product repository and stores
+// deliveries in config.DeliveryCollection
+func NewMongoWebhookDeliveryRepository(products *MongoProductRepository, config configs.DatabaseConfig) (*MongoWebhookDeliveryRepository, error) {
+	collection := products.collection.Database().Collection(config.DeliveryCollection)
+
+	timeout := config.ConnectTimeout
+	if timeout <= 0 {
+		timeout = defaultConnectTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	_, err := collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "deliveryId", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "nextAttemptAt", Value: 1}}},
+		{Keys: bson.D{{Key: "webhookId", Value: 1}, {Key: "createdAt", Value: -1}}},
+		{
+			Keys:    bson.D{{Key: "deliveredAt", Value: 1}},
+			Options: options.Index().SetName("delivery_retention").SetExpireAfterSeconds(int32(deliveryRetention.Seconds())),
+		},
+	})
+	if err != nil {
+		return nil, wrapMongoError(err)
+	}
+
+	return &MongoWebhookDeliveryRepository{collection: collection}, nil
+}
+
+// Append inserts deliveries in order
+func (r *MongoWebhookDeliveryRepository) Append(ctx context.Context, deliveries ...*models.WebhookDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+
+	documents := make([]interface{}, 0, len(deliveries))
+	for _, delivery := range deliveries {
+		documents = append(documents, delivery)
+	}
+
+	_, err := r.collection.InsertMany(ctx, documents)
+	return wrapMongoError(err)
+}
+
+// ClaimDue leases due deliveries one at a time, so that each is claimed by
+// a single dispatcher
+func (r *MongoWebhookDeliveryRepository) ClaimDue(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*models.WebhookDelivery, error) {
+	filter := bson.M{"status": models.DeliveryPending, "nextAttemptAt": bson.M{"$lte": now}}
+	update := bson.M{"$set": bson.M{"nextAttemptAt": now.Add(lease)}}
+	opts := options.FindOneAndUpdate().SetSort(bson.D{{Key: "nextAttemptAt", Value: 1}})
+
+	claimed := []*models.WebhookDelivery{}
+	for limit <= 0 || len(claimed) < limit {
+		var delivery models.WebhookDelivery
+		err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&delivery)
+		if err != nil {
+			if errors.Is(err, mongo.ErrNoDocuments) {
+				break
+			}
+			return claimed, wrapMongoError(err)
+		}
+		claimed = append(claimed, &delivery)
+	}
+
+	return claimed, nil
+}
+
+// GetByID retrieves a delivery from MongoDB
+func (r *MongoWebhookDeliveryRepository) GetByID(ctx context.Context, deliveryID string) (*models.WebhookDelivery, error) {
+	var delivery models.WebhookDelivery
+
+	err := r.collection.FindOne(ctx, bson.M{"deliveryId": deliveryID}).Decode(&delivery)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrDeliveryNotFound
+		}
+		return nil, wrapMongoError(err)
+	}
+
+	return &delivery, nil
+}
+
+// Update replaces a delivery in MongoDB
+func (r *MongoWebhookDeliveryRepository) Update(ctx context.Context, delivery *models.WebhookDelivery) error {
+	result, err := r.collection.ReplaceOne(ctx, bson.M{"deliveryId": delivery.DeliveryID}, delivery)
+	if err != nil {
+		return wrapMongoError(err)
+	}
+	if result.MatchedCount == 0 {
+		return ErrDeliveryNotFound
+	}
+	return nil
+}
+
+// List returns the matching deliveries, newest first
+func (r *MongoWebhookDeliveryRepository) List(ctx context.Context, filter WebhookDeliveryFilter) ([]*models.WebhookDelivery, error) {
+	query := bson.M{}
+	if filter.WebhookID != "" {
+		query["webhookId"] = filter.WebhookID
+	}
+	if filter.Status != "" {
+		query["status"] = filter.Status
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "createdAt", Value: -1}, {Key: "_id", Value: -1}})
+	if filter.Limit > 0 {
+		opts.SetLimit(int64(filter.Limit))
+	}
+	cursor, err := r.collection.Find(ctx, query, opts)
+	if err != nil {
+		return nil, wrapMongoError(err)
+	}
+	defer cursor.Close(ctx)
+
+	deliveries := []*models.WebhookDelivery{}
+	if err := cursor.All(ctx, &deliveries); err != nil {
+		return nil, wrapMongoError(err)
+	}
+
+	return deliveries, nil
+}
@@ -0,0 +1,665 @@
+package services
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/product-api-v2/internal/models"
+	"github.com/product-api-v2/internal/repository"
+	"github.com/sirupsen/logrus"
+)
+
+// Import modes
+const (
+	ImportUpsert = "upsert"
+	ImportInsert = "insert"
+	ImportDryRun = "dry-run"
+)
+
+// Catalog file formats accepted by import and produced by export
+const (
+	FormatCSV    = "csv"
+	FormatNDJSON = "ndjson"
+)
+
+// Defaults used when the import configuration leaves them unset
+const (
+	defaultImportChunkSize   = 500
+	defaultMaxReportedErrors = 1000
+)
+
+// exportFlushRows is how many exported products are buffered before they are
+// pushed to the client
+const exportFlushRows = 100
+
+// productColumns are the CSV columns in export order. Imports must include
+// productId, name and price, a decimal amount in currency (the default
+// currency when the column is missing or empty); a variant may leave its
+// name and price empty to take its parent's. Attributes are a JSON object
+// of strings. The read-only columns are accepted so an export can be edited
+// and imported again, but their values are ignored.
+var (
+	productColumns  = []string{"productId", "parentId", "sku", "name", "description", "price", "currency", "category", "attributes", "stock", "active", "version", "createdAt", "updatedAt"}
+	requiredColumns = []string{"productId", "name", "price"}
+	readOnlyColumns = map[string]bool{"version": true, "createdAt": true, "updatedAt": true}
+)
+
+// importRow is one decoded input record. err is set when the record could
+// not be decoded, in which case product may be nil.
+type importRow struct {
+	line    int
+	product *models.Product
+	err     error
+}
+
+// rowReader yields import rows until io.EOF. Any other error means the input
+// cannot be read any further.
+type rowReader interface {
+	next() (*importRow, error)
+}
+
+// newRowReader returns the decoder for format
+func newRowReader(body io.Reader, format string) (rowReader, error) {
+	switch format {
+	case FormatCSV:
+		return newCSVRowReader(body)
+	case FormatNDJSON:
+		return newNDJSONRowReader(body), nil
+	default:
+		return nil, fmt.Errorf("format must be %s or %s", FormatCSV, FormatNDJSON)
+	}
+}
+
+// csvRowReader decodes CSV with a header row naming the columns
+type csvRowReader struct {
+	reader  *csv.Reader
+	columns []string
+}
+
+// newCSVRowReader reads and checks the header row
+func newCSVRowReader(body io.Reader) (*csvRowReader, error) {
+	reader := csv.NewReader(body)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("CSV input is empty, a header row is required")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid CSV header: %w", err)
+	}
+
+	known := make(map[string]bool, len(productColumns))
+	for _, column := range productColumns {
+		known[column] = true
+	}
+
+	seen := make(map[string]bool, len(header))
+	for i, column := range header {
+		column = strings.TrimSpace(strings.TrimPrefix(column, "\ufeff"))
+		if !known[column] {
+			return nil, fmt.Errorf("unknown CSV column %q", column)
+		}
+		if seen[column] {
+			return nil, fmt.Errorf("duplicate CSV column %q", column)
+		}
+		seen[column] = true
+		header[i] = column
+	}
+	for _, column := range requiredColumns {
+		if !seen[column] {
+			return nil, fmt.Errorf("CSV column %q is required", column)
+		}
+	}
+
+	reader.FieldsPerRecord = len(header)
+	return &csvRowReader{reader: reader, columns: header}, nil
+}
+
+// next decodes the next CSV record. Malformed records become row errors so
+// the rest of the file is still imported.
+func (r *csvRowReader) next() (*importRow, error) {
+	record, err := r.reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, io.EOF
+	}
+
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) {
+		if errors.Is(parseErr.Err, csv.ErrFieldCount) {
+			return &importRow{line: parseErr.StartLine, err: fmt.Errorf("expected %d fields, got %d", len(r.columns), len(record))}, nil
+		}
+		return &importRow{line: parseErr.StartLine, err: parseErr.Err}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	line, _ := r.reader.FieldPos(0)
+	product, err := r.decode(record)
+	return &importRow{line: line, product: product, err: err}, nil
+}
+
+// decode maps a record onto a product. Products are active unless the
+// active column says otherwise.
+func (r *csvRowReader) decode(record []string) (*models.Product, error) {
+	product := &models.Product{Active: true}
+
+	for i, column := range r.columns {
+		value := strings.TrimSpace(record[i])
+		if readOnlyColumns[column] {
+			continue
+		}
+
+		switch column {
+		case "productId":
+			product.ProductID = value
+		case "parentId":
+			product.ParentID = value
+		case "sku":
+			product.SKU = value
+		case "name":
+			product.Name = value
+		case "description":
+			product.Description = value
+		case "category":
+			product.Category = value
+		case "attributes":
+			if value == "" {
+				continue
+			}
+			if err := json.Unmarshal([]byte(value), &product.Attributes); err != nil {
+				return product, fmt.Errorf("invalid attributes %q", value)
+			}
+		case "price":
+			if value == "" {
+				continue
+			}
+			price, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return product, fmt.Errorf("invalid price %q", value)
+			}
+			product.Price = price
+		case "currency":
+			product.Currency = value
+		case "stock":
+			if value == "" {
+				continue
+			}
+			stock, err := strconv.Atoi(value)
+			if err != nil {
+				return product, fmt.Errorf("invalid stock %q", value)
+			}
+			product.Stock = stock
+		case "active":
+			if value == "" {
+				continue
+			}
+			active, err := strconv.ParseBool(value)
+			if err != nil {
+				return product, fmt.Errorf("invalid active flag %q", value)
+			}
+			product.Active = active
+		}
+	}
+
+	return product, nil
+}
+
+// ndjsonRowReader decodes one JSON product per line, skipping blank lines
+type ndjsonRowReader struct {
+	scanner *bufio.Scanner
+	line    int
+}
+
+// newNDJSONRowReader creates a reader accepting lines of up to 1 MiB
+func newNDJSONRowReader(body io.Reader) *ndjsonRowReader {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	return &ndjsonRowReader{scanner: scanner}
+}
+
+// next decodes the next non-blank line
+func (r *ndjsonRowReader) next() (*importRow, error) {
+	for r.scanner.Scan() {
+		r.line++
+		text := strings.TrimSpace(r.scanner.Text())
+		if text == "" {
+			continue
+		}
+
+		product := &models.Product{Active: true}
+		if err := json.Unmarshal([]byte(text), product); err != nil {
+			return &importRow{line: r.line, err: fmt.Errorf("invalid JSON: %w", err)}, nil
+		}
+		return &importRow{line: r.line, product: product}, nil
+	}
+
+	if err := r.scanner.Err(); err != nil {
+		return nil, fmt.Errorf("line %d: %w", r.line+1, err)
+	}
+	return nil, io.EOF
+}
+
+// productImport accumulates the report of one ImportProducts call
+type productImport struct {
+	report    *models.ImportReport
+	maxErrors int
+}
+
+// fail records a row that was not imported
+func (i *productImport) fail(line int, productID string, err error) {
+	i.report.Failed++
+	if len(i.report.Errors) >= i.maxErrors {
+		i.report.ErrorsTruncated = true
+		return
+	}
+	i.report.Errors = append(i.report.Errors, models.ImportRowError{
+		Line:      line,
+		ProductID: productID,
+		Error:     err.Error(),
+	})
+}
+
+// ImportProducts reads products from body in the given format and stores
+// them according to mode. Every row is checked with ValidateProduct, and
+// rows that fail are listed in the report instead of stopping the import.
+// Variant parents are not checked against the catalog, so an import can
+// restore a parent and its variants in any order; a row whose SKU belongs
+// to another product fails. Every product written is recorded in its
+// history and announced with a ProductChanged event like any other write.
+// Valid rows are written in chunks of the configured size, so a repository
+// failure leaves earlier chunks in place; the partial report is returned
+// together with the error.
+func (s *ProductService) ImportProducts(ctx context.Context, body io.Reader, format, mode string) (*models.ImportReport, error) {
+	s.stats.request("ImportProducts")
+
+	logger := s.logger.WithFields(logrus.Fields{
+		"operation": "ImportProducts",
+		"format":    format,
+		"mode":      mode,
+		"requestId": ctx.Value("requestId"),
+	})
+
+	logger.Info("📥 Starting product import")
+
+	var bulkMode repository.BulkMode
+	switch mode {
+	case ImportUpsert, ImportDryRun:
+		bulkMode = repository.BulkUpsert
+	case ImportInsert:
+		bulkMode = repository.BulkInsertOnly
+	default:
+		s.stats.failure("ImportProducts")
+		logger.Error("💥 Unknown import mode")
+		return nil, validationError(fmt.Errorf("mode must be %s, %s or %s", ImportUpsert, ImportInsert, ImportDryRun))
+	}
+
+	rows, err := newRowReader(body, format)
+	if err != nil {
+		s.stats.failure("ImportProducts")
+		logger.WithError(err).Error("💥 Unreadable import input")
+		return nil, validationError(err)
+	}
+
+	chunkSize := s.config.Import.ChunkSize
+	if chunkSize <= 0 {
+		chunkSize = defaultImportChunkSize
+	}
+	maxErrors := s.config.Import.MaxReportedErrors
+	if maxErrors <= 0 {
+		maxErrors = defaultMaxReportedErrors
+	}
+
+	run := &productImport{
+		report: &models.ImportReport{
+			Mode:   mode,
+			Format: format,
+			Errors: []models.ImportRowError{},
+		},
+		maxErrors: maxErrors,
+	}
+	firstSeen := make(map[string]int)
+	chunk := make([]*importRow, 0, chunkSize)
+
+	for {
+		row, err := rows.next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			run.report.Aborted = err.Error()
+			logger.WithError(err).Warn("⚠️ Import input ended early")
+			break
+		}
+
+		run.report.Rows++
+		productID := ""
+		if row.product != nil {
+			productID = row.product.ProductID
+		}
+
+		if row.err == nil {
+			row.err = ValidateProduct(row.product)
+		}
+		if row.err == nil {
+			if line, duplicate := firstSeen[productID]; duplicate {
+				row.err = fmt.Errorf("duplicate productId, first seen on line %d", line)
+			} else {
+				firstSeen[productID] = row.line
+			}
+		}
+		if row.err != nil {
+			run.fail(row.line, productID, row.err)
+			continue
+		}
+		prepareProduct(row.product)
+
+		chunk = append(chunk, row)
+		if len(chunk) < chunkSize {
+			continue
+		}
+		if err := s.importChunk(ctx, run, chunk, bulkMode, mode == ImportDryRun); err != nil {
+			return s.abortImport(logger, run, err)
+		}
+		chunk = chunk[:0]
+	}
+
+	if len(chunk) > 0 {
+		if err := s.importChunk(ctx, run, chunk, bulkMode, mode == ImportDryRun); err != nil {
+			return s.abortImport(logger, run, err)
+		}
+	}
+
+	logger.WithFields(logrus.Fields{
+		"rows":     run.report.Rows,
+		"inserted": run.report.Inserted,
+		"updated":  run.report.Updated,
+		"failed":   run.report.Failed,
+	}).Info("✅ Product import completed")
+
+	return run.report, nil
+}
+
+// importChunk writes one chunk of validated rows, or in a dry run only looks
+// up which of them already exist
+func (s *ProductService) importChunk(ctx context.Context, run *productImport, chunk []*importRow, mode repository.BulkMode, dryRun bool) error {
+	products := make([]*models.Product, 0, len(chunk))
+	for _, row := range chunk {
+		products = append(products, row.product)
+	}
+
+	if dryRun {
+		existing, err := s.repo.GetByIDs(ctx, productIDsOf(products))
+		if err != nil {
+			return err
+		}
+		run.report.Updated += len(existing)
+		run.report.Inserted += len(products) - len(existing)
+		return nil
+	}
+
+	before, err := s.repo.GetByIDs(ctx, productIDsOf(products))
+	if err != nil {
+		return err
+	}
+
+	result, err := s.repo.BulkWrite(ctx, products, mode)
+	if err != nil {
+		return err
+	}
+	if s.recordsChanges() {
+		if err := s.recordImport(ctx, products, result, before); err != nil {
+			return err
+		}
+	}
+	if err := s.cascadeImport(ctx, products, result, before); err != nil {
+		return err
+	}
+
+	run.report.Inserted += result.Inserted
+	run.report.Updated += result.Updated
+	for i, row := range chunk {
+		if cause, failed := result.Failed[i]; failed {
+			switch {
+			case errors.Is(cause, repository.ErrProductExists):
+				cause = fmt.Errorf("product %s already exists", row.product.ProductID)
+			case errors.Is(cause, repository.ErrSKUExists):
+				cause = fmt.Errorf("SKU %s already belongs to another product", row.product.SKU)
+			}
+			run.fail(row.line, row.product.ProductID, cause)
+		}
+	}
+	return nil
+}
+
+// recordImport records the products a chunk wrote in their history and
+// queues their events. The stored products are read back, since an upsert
+// keeps the fields an import does not carry. A bulk write reports failed
+// rows instead of failing as a whole, which a transaction cannot do, so
+// the changes are recorded after the chunk is written.
+func (s *ProductService) recordImport(ctx context.Context, products []*models.Product, result *repository.BulkWriteResult, before []*models.Product) error {
+	written := make([]string, 0, len(products))
+	for i, product := range products {
+		if _, failed := result.Failed[i]; !failed {
+			written = append(written, product.ProductID)
+		}
+	}
+	if len(written) == 0 {
+		return nil
+	}
+
+	after, err := s.repo.GetByIDs(ctx, written)
+	if err != nil {
+		return err
+	}
+
+	previous := make(map[string]*models.Product, len(before))
+	for _, product := range before {
+		previous[product.ProductID] = product
+	}
+
+	changes := make([]*models.ProductChange, 0, len(after))
+	for _, product := range after {
+		if existing, ok := previous[product.ProductID]; ok {
+			changes = append(changes, newProductChange(ctx, models.AuditUpdate, existing, product))
+		} else {
+			changes = append(changes, newProductChange(ctx, models.AuditCreate, nil, product))
+		}
+	}
+	return s.recordChanges(ctx, changes...)
+}
+
+// cascadeImport passes the new categories of the parents a chunk updated
+// on to their variants, and records the variants it changed
+func (s *ProductService) cascadeImport(ctx context.Context, products []*models.Product, result *repository.BulkWriteResult, before []*models.Product) error {
+	logger := s.logger.WithFields(logrus.Fields{
+		"operation": "ImportProducts",
+		"requestId": ctx.Value("requestId"),
+	})
+
+	previous := make(map[string]string, len(before))
+	for _, product := range before {
+		previous[product.ProductID] = product.Category
+	}
+	recategorized := []*models.Product{}
+	for i, product := range products {
+		category, existed := previous[product.ProductID]
+		if _, failed := result.Failed[i]; !failed && existed && category != product.Category {
+			recategorized = append(recategorized, product)
+		}
+	}
+
+	changes, err := s.cascadeCategory(ctx, logger, "ImportProducts", recategorized...)
+	if err != nil {
+		return err
+	}
+	return s.recordChanges(ctx, changes...)
+}
+
+// productIDsOf returns the IDs of products
+func productIDsOf(products []*models.Product) []string {
+	productIDs := make([]string, 0, len(products))
+	for _, product := range products {
+		productIDs = append(productIDs, product.ProductID)
+	}
+	return productIDs
+}
+
+// abortImport ends an import whose chunk could not be written
+func (s *ProductService) abortImport(logger *logrus.Entry, run *productImport, err error) (*models.ImportReport, error) {
+	s.stats.failure("ImportProducts")
+	logger.WithError(err).WithFields(logrus.Fields{
+		"inserted": run.report.Inserted,
+		"updated":  run.report.Updated,
+	}).Error("💥 Product import aborted")
+
+	run.report.Aborted = "repository write failed; rows after the last completed chunk were not imported"
+	return run.report, repositoryError(err, "failed to import products")
+}
+
+// ExportProducts writes every product matching filters to w in product ID
+// order, regardless of pagination, and returns how many were written. Once
+// output has started an error can only cut the stream short, so callers
+// should check the format before sending response headers.
+func (s *ProductService) ExportProducts(ctx context.Context, w io.Writer, format string, filters repository.ProductFilters) (int, error) {
+	s.stats.request("ExportProducts")
+
+	logger := s.logger.WithFields(logrus.Fields{
+		"operation": "ExportProducts",
+		"format":    format,
+		"requestId": ctx.Value("requestId"),
+	})
+
+	logger.Info("📤 Starting product export")
+
+	encoder, err := newProductEncoder(w, format)
+	if err != nil {
+		s.stats.failure("ExportProducts")
+		return 0, validationError(err)
+	}
+
+	if err := s.expandCategory(ctx, &filters); err != nil {
+		s.stats.failure("ExportProducts")
+		logger.WithError(err).Error("💥 Failed to resolve category filter")
+		return 0, err
+	}
+
+	count := 0
+	err = s.repo.Iterate(ctx, filters, func(product *models.Product) error {
+		if err := encoder.encode(product); err != nil {
+			return err
+		}
+		count++
+		if count%exportFlushRows == 0 {
+			return encoder.flush()
+		}
+		return nil
+	})
+	if err == nil {
+		err = encoder.flush()
+	}
+	if err != nil {
+		s.stats.failure("ExportProducts")
+		logger.WithError(err).WithField("exported", count).Error("💥 Product export failed")
+		return count, repositoryError(err, "failed to export products")
+	}
+
+	logger.WithField("exported", count).Info("✅ Product export completed")
+
+	return count, nil
+}
+
+// productEncoder writes products in one export format
+type productEncoder struct {
+	output  io.Writer
+	csv     *csv.Writer
+	ndjson  *bufio.Writer
+	encoder *json.Encoder
+}
+
+// newProductEncoder creates an encoder for format. CSV output starts with
+// the header row.
+func newProductEncoder(w io.Writer, format string) (*productEncoder, error) {
+	encoder := &productEncoder{output: w}
+
+	switch format {
+	case FormatCSV:
+		encoder.csv = csv.NewWriter(w)
+		if err := encoder.csv.Write(productColumns); err != nil {
+			return nil, err
+		}
+	case FormatNDJSON:
+		encoder.ndjson = bufio.NewWriter(w)
+		encoder.encoder = json.NewEncoder(encoder.ndjson)
+	default:
+		return nil, fmt.Errorf("format must be %s or %s", FormatCSV, FormatNDJSON)
+	}
+
+	return encoder, nil
+}
+
+// encode writes one product
+func (e *productEncoder) encode(product *models.Product) error {
+	if e.csv == nil {
+		return e.encoder.Encode(product)
+	}
+
+	// A variant without its own price takes its parent's, which an empty
+	// price column keeps on import
+	price := ""
+	if product.PriceMinor != 0 {
+		price = models.FormatAmount(product.PriceMinor, product.Currency)
+	}
+	attributes := ""
+	if len(product.Attributes) > 0 {
+		encoded, err := json.Marshal(product.Attributes)
+		if err != nil {
+			return err
+		}
+		attributes = string(encoded)
+	}
+
+	return e.csv.Write([]string{
+		product.ProductID,
+		product.ParentID,
+		product.SKU,
+		product.Name,
+		product.Description,
+		price,
+		product.Currency,
+		product.Category,
+		attributes,
+		strconv.Itoa(product.Stock),
+		strconv.FormatBool(product.Active),
+		strconv.FormatInt(product.Version, 10),
+		product.CreatedAt.UTC().Format(time.RFC3339),
+		product.UpdatedAt.UTC().Format(time.RFC3339),
+	})
+}
+
+// flush pushes buffered products to the underlying writer and, when it
+// supports flushing, on to the client
+func (e *productEncoder) flush() error {
+	if e.csv != nil {
+		e.csv.Flush()
+		if err := e.csv.Error(); err != nil {
+			return err
+		}
+	} else if err := e.ndjson.Flush(); err != nil {
+		return err
+	}
+
+	if flusher, ok := e.output.(interface{ Flush() }); ok {
+		flusher.Flush()
+	}
+	return nil
+}
@@ -0,0 +1,242 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/product-api-v2/internal/models"
+	"github.com/product-api-v2/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// createTestImportService returns a service over a memory repository holding
+// test-product-1, writing imports in chunks of two
+func createTestImportService(t *testing.T) (*ProductService, *repository.MemoryProductRepository) {
+	repo := repository.NewMemoryProductRepository()
+	assert.NoError(t, repo.Create(context.Background(), createTestProduct()))
+
+	service := createTestProductService(repo)
+	service.config.Import.ChunkSize = 2
+	return service, repo
+}
+
+func TestProductService_ImportProducts_CSVUpsert(t *testing.T) {
+	ctx := context.Background()
+	service, repo := createTestImportService(t)
+
+	input := strings.Join([]string{
+		"productId,name,price,stock,active",
+		"test-product-1,Renamed Product,89.50,7,true",
+		"product-2,Mouse,29.99,200,",
+		"product-3,Keyboard,not-a-price,10,true",
+		"product-4,,15,1,true",
+		"product-2,Mouse again,31,1,true",
+		"product-5,Monitor,349,5,false",
+		"product-6,Too,many,fields,here,now",
+	}, "\n")
+
+	report, err := service.ImportProducts(ctx, strings.NewReader(input), FormatCSV, ImportUpsert)
+	assert.NoError(t, err)
+	assert.Equal(t, 7, report.Rows)
+	assert.Equal(t, 2, report.Inserted)
+	assert.Equal(t, 1, report.Updated)
+	assert.Equal(t, 4, report.Failed)
+	if assert.Len(t, report.Errors, 4) {
+		assert.Equal(t, models.ImportRowError{Line: 4, ProductID: "product-3", Error: `invalid price "not-a-price"`}, report.Errors[0])
+		assert.Equal(t, 5, report.Errors[1].Line)
+		assert.Contains(t, report.Errors[1].Error, "name is required")
+		assert.Contains(t, report.Errors[2].Error, "first seen on line 3")
+		assert.Equal(t, 8, report.Errors[3].Line)
+	}
+
+	updated, err := repo.GetByID(ctx, "test-product-1")
+	assert.NoError(t, err)
+	assert.Equal(t, "Renamed Product", updated.Name)
+	assert.Equal(t, int64(2), updated.Version)
+
+	inserted, err := repo.GetByID(ctx, "product-2")
+	assert.NoError(t, err)
+	assert.True(t, inserted.Active, "a blank active column defaults to active")
+
+	inactive, err := repo.GetByID(ctx, "product-5")
+	assert.NoError(t, err)
+	assert.False(t, inactive.Active)
+}
+
+func TestProductService_ImportProducts_NDJSONInsertOnly(t *testing.T) {
+	ctx := context.Background()
+	service, repo := createTestImportService(t)
+
+	input := `{"productId":"test-product-1","name":"Changed","price":1}
+{"productId":"product-2","name":"Mouse","price":29.99,"stock":200}
+
+{broken
+`
+	report, err := service.ImportProducts(ctx, strings.NewReader(input), FormatNDJSON, ImportInsert)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, report.Rows)
+	assert.Equal(t, 1, report.Inserted)
+	assert.Equal(t, 0, report.Updated)
+	if assert.Len(t, report.Errors, 2) {
+		assert.Equal(t, models.ImportRowError{Line: 1, ProductID: "test-product-1", Error: "product test-product-1 already exists"}, report.Errors[0])
+		assert.Equal(t, 4, report.Errors[1].Line)
+	}
+
+	existing, err := repo.GetByID(ctx, "test-product-1")
+	assert.NoError(t, err)
+	assert.Equal(t, "Test Product", existing.Name)
+}
+
+func TestProductService_ImportProducts_DuplicateSKU(t *testing.T) {
+	ctx := context.Background()
+	service, _ := createTestImportService(t)
+
+	input := `{"productId":"product-2","sku":"KB-1","name":"Keyboard","price":49.99}
+{"productId":"product-3","sku":"KB-1","name":"Keyboard Pro","price":89.99}
+`
+	report, err := service.ImportProducts(ctx, strings.NewReader(input), FormatNDJSON, ImportUpsert)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, report.Inserted)
+	if assert.Len(t, report.Errors, 1) {
+		assert.Equal(t, models.ImportRowError{Line: 2, ProductID: "product-3", Error: "SKU KB-1 already belongs to another product"}, report.Errors[0])
+	}
+}
+
+func TestProductService_ImportProducts_DryRunWritesNothing(t *testing.T) {
+	ctx := context.Background()
+	service, repo := createTestImportService(t)
+
+	input := "productId,name,price\ntest-product-1,Renamed,1\nproduct-2,Mouse,2\nproduct-3,Cable,3\n"
+	report, err := service.ImportProducts(ctx, strings.NewReader(input), FormatCSV, ImportDryRun)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, report.Inserted)
+	assert.Equal(t, 1, report.Updated)
+	assert.Empty(t, report.Errors)
+
+	count, err := repo.Count(ctx, repository.ProductFilters{})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+}
+
+func TestProductService_ImportProducts_RejectsBadInput(t *testing.T) {
+	service, _ := createTestImportService(t)
+
+	tests := []struct {
+		name   string
+		input  string
+		format string
+		mode   string
+	}{
+		{"unknown mode", "productId,name,price\n", FormatCSV, "replace"},
+		{"unknown format", "", "xlsx", ImportUpsert},
+		{"empty CSV", "", FormatCSV, ImportUpsert},
+		{"unknown column", "productId,name,price,colour\n", FormatCSV, ImportUpsert},
+		{"missing column", "productId,name\n", FormatCSV, ImportUpsert},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report, err := service.ImportProducts(context.Background(), strings.NewReader(tt.input), tt.format, tt.mode)
+			assert.Nil(t, report)
+			assert.ErrorIs(t, err, ErrValidation)
+		})
+	}
+}
+
+func TestProductService_ImportProducts_AbortsOnRepositoryFailure(t *testing.T) {
+	mockRepo := &MockProductRepository{}
+	service := createTestProductService(mockRepo)
+	service.config.Import.ChunkSize = 1
+
+	mockRepo.On("GetByIDs", mock.Anything, mock.Anything).Return([]*models.Product{}, nil)
+	mockRepo.On("BulkWrite", mock.Anything, mock.Anything, repository.BulkUpsert).
+		Return(&repository.BulkWriteResult{Inserted: 1, Failed: map[int]error{}}, nil).Once()
+	mockRepo.On("BulkWrite", mock.Anything, mock.Anything, repository.BulkUpsert).
+		Return(nil, repository.ErrRepositoryUnavailable).Once()
+
+	input := "productId,name,price\nproduct-1,One,1\nproduct-2,Two,2\nproduct-3,Three,3\n"
+	report, err := service.ImportProducts(context.Background(), strings.NewReader(input), FormatCSV, ImportUpsert)
+	assert.ErrorIs(t, err, ErrUnavailable)
+	if assert.NotNil(t, report) {
+		assert.Equal(t, 1, report.Inserted)
+		assert.Equal(t, 2, report.Rows)
+		assert.NotEmpty(t, report.Aborted)
+	}
+	mockRepo.AssertExpectations(t)
+}
+
+func TestProductService_ExportProducts_RoundTrip(t *testing.T) {
+	ctx := context.Background()
+	service, repo := createTestImportService(t)
+	assert.NoError(t, repo.Create(ctx, &models.Product{ProductID: "product-2", Name: "Mouse, wireless", Price: 29.99, Active: false}))
+
+	var csvOutput bytes.Buffer
+	count, err := service.ExportProducts(ctx, &csvOutput, FormatCSV, repository.ProductFilters{})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, count)
+
+	lines := strings.Split(strings.TrimSpace(csvOutput.String()), "\n")
+	assert.Equal(t, "productId,parentId,sku,name,description,price,currency,category,attributes,stock,active,version,createdAt,updatedAt", lines[0])
+	assert.True(t, strings.HasPrefix(lines[1], `product-2,,,"Mouse, wireless",,29.99,USD,,,0,false,1,`))
+
+	// An unchanged export imports as updates of the same products
+	report, err := service.ImportProducts(ctx, &csvOutput, FormatCSV, ImportUpsert)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, report.Updated)
+	assert.Empty(t, report.Errors)
+
+	active := true
+	var ndjsonOutput bytes.Buffer
+	count, err = service.ExportProducts(ctx, &ndjsonOutput, FormatNDJSON, repository.ProductFilters{Active: &active})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+	assert.Contains(t, ndjsonOutput.String(), `"productId":"test-product-1"`)
+}
+
+func TestProductService_ExportProducts_VariantRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	service, repo := createTestImportService(t)
+	assert.NoError(t, repo.Create(ctx, &models.Product{
+		ProductID:  "test-product-1-large",
+		ParentID:   "test-product-1",
+		SKU:        "TP1-L",
+		Attributes: map[string]string{"size": "large", "color": "blue, dark"},
+		Stock:      3,
+		Active:     true,
+	}))
+
+	var output bytes.Buffer
+	count, err := service.ExportProducts(ctx, &output, FormatCSV, repository.ProductFilters{})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, count)
+
+	// The variant comes before its parent in a fresh catalog
+	lines := strings.Split(strings.TrimSpace(output.String()), "\n")
+	input := strings.Join([]string{lines[0], lines[2], lines[1]}, "\n")
+
+	target := repository.NewMemoryProductRepository()
+	report, err := createTestProductService(target).ImportProducts(ctx, strings.NewReader(input), FormatCSV, ImportInsert)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, report.Inserted)
+	assert.Empty(t, report.Errors)
+
+	variant, err := target.GetByID(ctx, "test-product-1-large")
+	assert.NoError(t, err)
+	assert.Equal(t, "test-product-1", variant.ParentID)
+	assert.Equal(t, "TP1-L", variant.SKU)
+	assert.Equal(t, map[string]string{"size": "large", "color": "blue, dark"}, variant.Attributes)
+	assert.Zero(t, variant.PriceMinor, "the variant keeps taking its parent's price")
+	assert.Equal(t, 3, variant.Stock)
+}
+
+func TestProductService_ExportProducts_RepositoryError(t *testing.T) {
+	service := createTestProductService(repository.NewUnavailableProductRepository(errors.New("connection refused")))
+
+	var output bytes.Buffer
+	_, err := service.ExportProducts(context.Background(), &output, FormatNDJSON, repository.ProductFilters{})
+	assert.ErrorIs(t, err, ErrUnavailable)
+}
@@ -0,0 +1,319 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+
+	"github.com/product-api-v2/configs"
+	"github.com/product-api-v2/internal/models"
+	"github.com/product-api-v2/internal/repository"
+	"github.com/sirupsen/logrus"
+)
+
+// maxCategorySlugLength caps the length of category slugs
+const maxCategorySlugLength = 64
+
+// categorySlugPattern matches lowercase words joined by single hyphens
+var categorySlugPattern = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+// CategoryService manages the category tree products are assigned to
+type CategoryService struct {
+	categories repository.CategoryRepository
+	products   repository.ProductRepository
+	config     *configs.Config
+	logger     *logrus.Logger
+}
+
+// NewCategoryService creates a new category service
+func NewCategoryService(categories repository.CategoryRepository, products repository.ProductRepository, config *configs.Config, logger *logrus.Logger) *CategoryService {
+	return &CategoryService{
+		categories: categories,
+		products:   products,
+		config:     config,
+		logger:     logger,
+	}
+}
+
+// CreateCategory validates and stores a new category. Its parent, if any,
+// must already exist.
+func (s *CategoryService) CreateCategory(ctx context.Context, category *models.Category) error {
+	logger := s.logger.WithFields(logrus.Fields{
+		"operation": "CreateCategory",
+		"slug":      category.Slug,
+		"requestId": ctx.Value("requestId"),
+	})
+
+	logger.Info("🗂️ Creating category")
+
+	if err := validateCategory(category); err != nil {
+		logger.WithError(err).Warn("⚠️ Invalid category")
+		return validationError(err)
+	}
+
+	if category.Parent != "" {
+		if _, err := s.categories.GetBySlug(ctx, category.Parent); err != nil {
+			return parentCategoryError(err, category.Parent)
+		}
+	}
+
+	if err := s.categories.Create(ctx, category); err != nil {
+		if errors.Is(err, repository.ErrCategoryExists) {
+			return newError(ErrConflict, "category_exists", nil, "category %s already exists", category.Slug)
+		}
+		logger.WithError(err).Error("💥 Failed to create category")
+		return repositoryError(err, "failed to create category")
+	}
+
+	logger.WithField("parent", category.Parent).Info("✅ Category created")
+	return nil
+}
+
+// GetCategory retrieves a category by slug
+func (s *CategoryService) GetCategory(ctx context.Context, slug string) (*models.Category, error) {
+	category, err := s.categories.GetBySlug(ctx, slug)
+	if err != nil {
+		return nil, categoryLookupError(err, slug)
+	}
+	return category, nil
+}
+
+// GetCategoryTree returns the category tree with the number of publicly
+// listed products in each node and its subtree. Variants are counted
+// through their parent, as in listings.
+func (s *CategoryService) GetCategoryTree(ctx context.Context) (*models.CategoryTreeResponse, error) {
+	logger := s.logger.WithFields(logrus.Fields{
+		"operation": "GetCategoryTree",
+		"requestId": ctx.Value("requestId"),
+	})
+
+	categories, err := s.categories.GetAll(ctx)
+	if err != nil {
+		logger.WithError(err).Error("💥 Failed to list categories")
+		return nil, repositoryError(err, "failed to retrieve categories")
+	}
+
+	counts, err := s.products.CountByCategory(ctx, repository.ProductFilters{
+		Visibility: repository.VisibilityPublic,
+		TopLevel:   true,
+	})
+	if err != nil {
+		logger.WithError(err).Error("💥 Failed to count products by category")
+		return nil, repositoryError(err, "failed to count products")
+	}
+
+	nodes := make(map[string]*models.CategoryNode, len(categories))
+	for _, category := range categories {
+		nodes[category.Slug] = &models.CategoryNode{
+			Category:     *category,
+			ProductCount: counts[category.Slug],
+			Children:     []*models.CategoryNode{},
+		}
+	}
+
+	// Categories come ordered by slug, so siblings are too
+	response := &models.CategoryTreeResponse{Categories: []*models.CategoryNode{}}
+	for _, category := range categories {
+		node := nodes[category.Slug]
+		if parent, ok := nodes[category.Parent]; ok {
+			parent.Children = append(parent.Children, node)
+		} else {
+			response.Categories = append(response.Categories, node)
+		}
+	}
+	for _, root := range response.Categories {
+		countSubtree(root)
+	}
+
+	for slug, count := range counts {
+		response.Total += count
+		if _, ok := nodes[slug]; !ok {
+			response.Uncategorized += count
+		}
+	}
+
+	logger.WithFields(logrus.Fields{
+		"categories": len(categories),
+		"total":      response.Total,
+	}).Info("✅ Category tree retrieved")
+	return response, nil
+}
+
+// UpdateCategory replaces an existing category. The slug cannot change and
+// the category cannot be moved under its own subtree. If ifMatch is set the
+// category must still be at that version.
+func (s *CategoryService) UpdateCategory(ctx context.Context, slug string, category *models.Category, ifMatch *int64) (*models.Category, error) {
+	logger := s.logger.WithFields(logrus.Fields{
+		"operation": "UpdateCategory",
+		"slug":      slug,
+		"requestId": ctx.Value("requestId"),
+	})
+
+	logger.Info("✏️ Updating category")
+
+	if category.Slug == "" {
+		category.Slug = slug
+	}
+	if category.Slug != slug {
+		return nil, validationError(fmt.Errorf("category slug cannot be changed"))
+	}
+
+	if err := validateCategory(category); err != nil {
+		logger.WithError(err).Warn("⚠️ Invalid category")
+		return nil, validationError(err)
+	}
+
+	categories, err := s.categories.GetAll(ctx)
+	if err != nil {
+		logger.WithError(err).Error("💥 Failed to list categories")
+		return nil, repositoryError(err, "failed to retrieve categories")
+	}
+
+	existing := findCategory(categories, slug)
+	if existing == nil {
+		return nil, categoryLookupError(repository.ErrCategoryNotFound, slug)
+	}
+
+	if err := checkVersion(ifMatch, existing.Version); err != nil {
+		logger.WithField("version", existing.Version).Warn("⚠️ Stale category version")
+		return nil, err
+	}
+
+	if category.Parent != "" {
+		if findCategory(categories, category.Parent) == nil {
+			return nil, parentCategoryError(repository.ErrCategoryNotFound, category.Parent)
+		}
+		for _, descendant := range categorySubtree(categories, slug) {
+			if descendant == category.Parent {
+				return nil, validationError(fmt.Errorf("category %s cannot be moved under its own subtree", slug))
+			}
+		}
+	}
+
+	category.Version = existing.Version
+	category.CreatedAt = existing.CreatedAt
+	if err := s.categories.Update(ctx, category); err != nil {
+		if errors.Is(err, repository.ErrVersionConflict) {
+			logger.Warn("⚠️ Concurrent category update")
+			return nil, versionConflictError(ifMatch)
+		}
+		return nil, categoryLookupError(err, slug)
+	}
+
+	logger.WithField("version", category.Version).Info("✅ Category updated")
+	return category, nil
+}
+
+// DeleteCategory removes a category that has no subcategories and no
+// products, active or not
+func (s *CategoryService) DeleteCategory(ctx context.Context, slug string) error {
+	logger := s.logger.WithFields(logrus.Fields{
+		"operation": "DeleteCategory",
+		"slug":      slug,
+		"requestId": ctx.Value("requestId"),
+	})
+
+	categories, err := s.categories.GetAll(ctx)
+	if err != nil {
+		logger.WithError(err).Error("💥 Failed to list categories")
+		return repositoryError(err, "failed to retrieve categories")
+	}
+	if findCategory(categories, slug) == nil {
+		return categoryLookupError(repository.ErrCategoryNotFound, slug)
+	}
+	if len(categorySubtree(categories, slug)) > 1 {
+		return newError(ErrConflict, "category_has_children", nil, "category %s has subcategories", slug)
+	}
+
+	count, err := s.products.Count(ctx, repository.ProductFilters{Category: slug, Visibility: repository.VisibilityAdmin})
+	if err != nil {
+		logger.WithError(err).Error("💥 Failed to count products")
+		return repositoryError(err, "failed to count products")
+	}
+	if count > 0 {
+		return newError(ErrConflict, "category_in_use", nil, "category %s is assigned to %d products", slug, count)
+	}
+
+	if err := s.categories.Delete(ctx, slug); err != nil {
+		return categoryLookupError(err, slug)
+	}
+
+	logger.Info("🗑️ Category deleted")
+	return nil
+}
+
+// countSubtree sets the subtree counts of node and its descendants and
+// returns that of node
+func countSubtree(node *models.CategoryNode) int {
+	node.SubtreeCount = node.ProductCount
+	for _, child := range node.Children {
+		node.SubtreeCount += countSubtree(child)
+	}
+	return node.SubtreeCount
+}
+
+// findCategory returns the category with the given slug, or nil
+func findCategory(categories []*models.Category, slug string) *models.Category {
+	for _, category := range categories {
+		if category.Slug == slug {
+			return category
+		}
+	}
+	return nil
+}
+
+// categorySubtree returns slug followed by the slugs of all its descendants
+func categorySubtree(categories []*models.Category, slug string) []string {
+	children := make(map[string][]string)
+	for _, category := range categories {
+		if category.Parent != "" {
+			children[category.Parent] = append(children[category.Parent], category.Slug)
+		}
+	}
+
+	subtree := []string{slug}
+	for i := 0; i < len(subtree); i++ {
+		subtree = append(subtree, children[subtree[i]]...)
+	}
+	return subtree
+}
+
+// validateCategory checks the fields of a category on their own
+func validateCategory(category *models.Category) error {
+	if category.Slug == "" {
+		return fmt.Errorf("category slug is required")
+	}
+	if len(category.Slug) > maxCategorySlugLength {
+		return fmt.Errorf("category slug cannot exceed %d characters", maxCategorySlugLength)
+	}
+	if !categorySlugPattern.MatchString(category.Slug) {
+		return fmt.Errorf("category slug must be lowercase letters and digits separated by single hyphens")
+	}
+	if category.Name == "" {
+		return fmt.Errorf("category name is required")
+	}
+	if len(category.Name) > 255 {
+		return fmt.Errorf("category name cannot exceed 255 characters")
+	}
+	if category.Parent == category.Slug {
+		return fmt.Errorf("category cannot be its own parent")
+	}
+	return nil
+}
+
+// parentCategoryError maps a failed parent lookup to a service error
+func parentCategoryError(err error, parent string) error {
+	if errors.Is(err, repository.ErrCategoryNotFound) {
+		return validationError(fmt.Errorf("parent category %s does not exist", parent))
+	}
+	return repositoryError(err, "failed to retrieve parent category")
+}
+
+// categoryLookupError maps a failed category lookup to a service error
+func categoryLookupError(err error, slug string) error {
+	if errors.Is(err, repository.ErrCategoryNotFound) {
+		return newError(ErrNotFound, "category_not_found", nil, "category %s not found", slug)
+	}
+	return repositoryError(err, "failed to retrieve category")
+}
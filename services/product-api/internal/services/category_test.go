@@ -0,0 +1,197 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/product-api-v2/configs"
+	"github.com/product-api-v2/internal/models"
+	"github.com/product-api-v2/internal/repository"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// createTestTaxonomy returns category and product services sharing a tree
+// of computers (laptops, storage) and accessories, with a laptop, a disk, an
+// inactive mouse and a product left in a legacy free-text category
+func createTestTaxonomy(t *testing.T) (*CategoryService, *ProductService) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+
+	categories := repository.NewMemoryCategoryRepository()
+	require.NoError(t, categories.Seed([]*models.Category{
+		{Slug: "computers", Name: "Computers", Active: true},
+		{Slug: "laptops", Name: "Laptops", Parent: "computers", Active: true},
+		{Slug: "storage", Name: "Storage", Parent: "computers", Active: true},
+		{Slug: "accessories", Name: "Accessories", Active: true},
+		{Slug: "retired", Name: "Retired", Active: false},
+	}))
+
+	products := repository.NewMemoryProductRepository()
+	require.NoError(t, products.Seed([]*models.Product{
+		{ProductID: "laptop", Name: "Laptop", PriceMinor: 99900, Category: "laptops", Active: true},
+		{ProductID: "disk", Name: "Disk", PriceMinor: 8900, Category: "storage", Active: true},
+		{ProductID: "mouse", Name: "Mouse", PriceMinor: 2900, Category: "accessories", Active: false},
+		{ProductID: "legacy", Name: "Legacy", PriceMinor: 100, Category: "gadgets", Active: true},
+	}, ValidateProduct))
+
+	config := &configs.Config{}
+	return NewCategoryService(categories, products, config, logger), NewProductService(products, categories, nil, nil, nil, nil, config, logger)
+}
+
+func TestProductService_GetProducts_CategorySubtree(t *testing.T) {
+	_, service := createTestTaxonomy(t)
+	ctx := context.Background()
+
+	response, err := service.GetProducts(ctx, repository.ProductFilters{Category: "computers"})
+	require.NoError(t, err)
+	assert.Equal(t, 2, response.Total)
+	assert.Equal(t, "disk", response.Products[0].ProductID)
+	assert.Equal(t, "laptop", response.Products[1].ProductID)
+
+	response, err = service.GetProducts(ctx, repository.ProductFilters{Category: "laptops"})
+	require.NoError(t, err)
+	assert.Equal(t, 1, response.Total)
+
+	// Categories outside the tree still match exactly
+	response, err = service.GetProducts(ctx, repository.ProductFilters{Category: "gadgets"})
+	require.NoError(t, err)
+	assert.Equal(t, 1, response.Total)
+}
+
+func TestProductService_CreateProduct_Category(t *testing.T) {
+	_, service := createTestTaxonomy(t)
+	ctx := context.Background()
+
+	tests := []struct {
+		name     string
+		category string
+		kind     error
+	}{
+		{"existing category", "laptops", nil},
+		{"no category", "", nil},
+		{"unknown category", "laptop", ErrValidation},
+		{"inactive category", "retired", ErrValidation},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := service.CreateProduct(ctx, &models.Product{
+				ProductID: "product-" + tt.name, Name: "Product", PriceMinor: 100, Category: tt.category, Active: true,
+			})
+			if tt.kind == nil {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorIs(t, err, tt.kind)
+		})
+	}
+}
+
+func TestProductService_CreateProduct_NoCategoryTree(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+	service := NewProductService(repository.NewMemoryProductRepository(), repository.NewMemoryCategoryRepository(), nil, nil, nil, nil, &configs.Config{}, logger)
+
+	// Without a tree, as in a memory run without category fixtures, any
+	// category is accepted
+	err := service.CreateProduct(context.Background(), &models.Product{
+		ProductID: "product-1", Name: "Product", PriceMinor: 100, Category: "gadgets", Active: true,
+	})
+	assert.NoError(t, err)
+}
+
+func TestCategoryService_GetCategoryTree(t *testing.T) {
+	service, _ := createTestTaxonomy(t)
+
+	tree, err := service.GetCategoryTree(context.Background())
+	require.NoError(t, err)
+
+	// Inactive products are not counted
+	assert.Equal(t, 3, tree.Total)
+	assert.Equal(t, 1, tree.Uncategorized)
+	require.Len(t, tree.Categories, 3)
+
+	accessories, computers := tree.Categories[0], tree.Categories[1]
+	assert.Equal(t, "accessories", accessories.Slug)
+	assert.Equal(t, 0, accessories.SubtreeCount)
+	assert.Equal(t, "computers", computers.Slug)
+	assert.Equal(t, 0, computers.ProductCount)
+	assert.Equal(t, 2, computers.SubtreeCount)
+	require.Len(t, computers.Children, 2)
+	assert.Equal(t, "laptops", computers.Children[0].Slug)
+	assert.Equal(t, 1, computers.Children[0].ProductCount)
+	assert.Equal(t, "storage", computers.Children[1].Slug)
+}
+
+func TestCategoryService_CreateCategory(t *testing.T) {
+	service, _ := createTestTaxonomy(t)
+	ctx := context.Background()
+
+	tests := []struct {
+		name     string
+		category models.Category
+		kind     error
+	}{
+		{"subcategory", models.Category{Slug: "gaming-laptops", Name: "Gaming", Parent: "laptops"}, nil},
+		{"missing name", models.Category{Slug: "tablets"}, ErrValidation},
+		{"uppercase slug", models.Category{Slug: "Tablets", Name: "Tablets"}, ErrValidation},
+		{"double hyphen", models.Category{Slug: "e--readers", Name: "E-readers"}, ErrValidation},
+		{"missing parent", models.Category{Slug: "tablets", Name: "Tablets", Parent: "mobile"}, ErrValidation},
+		{"own parent", models.Category{Slug: "tablets", Name: "Tablets", Parent: "tablets"}, ErrValidation},
+		{"duplicate slug", models.Category{Slug: "storage", Name: "Storage"}, ErrConflict},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			category := tt.category
+			err := service.CreateCategory(ctx, &category)
+			if tt.kind == nil {
+				assert.NoError(t, err)
+				assert.Equal(t, int64(1), category.Version)
+				return
+			}
+			assert.ErrorIs(t, err, tt.kind)
+		})
+	}
+}
+
+func TestCategoryService_UpdateCategory(t *testing.T) {
+	service, _ := createTestTaxonomy(t)
+	ctx := context.Background()
+
+	// A category cannot move under its own subtree
+	_, err := service.UpdateCategory(ctx, "computers", &models.Category{Name: "Computers", Parent: "laptops", Active: true}, nil)
+	assert.ErrorIs(t, err, ErrValidation)
+
+	_, err = service.UpdateCategory(ctx, "storage", &models.Category{Slug: "disks", Name: "Disks", Active: true}, nil)
+	assert.ErrorIs(t, err, ErrValidation)
+
+	stale := int64(2)
+	_, err = service.UpdateCategory(ctx, "storage", &models.Category{Name: "Storage", Active: true}, &stale)
+	assert.ErrorIs(t, err, ErrPreconditionFailed)
+
+	current := int64(1)
+	updated, err := service.UpdateCategory(ctx, "storage", &models.Category{Name: "Storage", Parent: "accessories", Active: true}, &current)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), updated.Version)
+	assert.Equal(t, "accessories", updated.Parent)
+
+	_, err = service.UpdateCategory(ctx, "missing", &models.Category{Name: "Missing"}, nil)
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestCategoryService_DeleteCategory(t *testing.T) {
+	service, _ := createTestTaxonomy(t)
+	ctx := context.Background()
+
+	assert.ErrorIs(t, service.DeleteCategory(ctx, "computers"), ErrConflict)
+	// Inactive products keep their category in use
+	assert.ErrorIs(t, service.DeleteCategory(ctx, "accessories"), ErrConflict)
+	assert.ErrorIs(t, service.DeleteCategory(ctx, "missing"), ErrNotFound)
+
+	require.NoError(t, service.DeleteCategory(ctx, "retired"))
+	_, err := service.GetCategory(ctx, "retired")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
@@ -0,0 +1,286 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/product-api-v2/configs"
+	"github.com/product-api-v2/internal/events"
+	"github.com/product-api-v2/internal/models"
+	"github.com/product-api-v2/internal/repository"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// eventSource names this service in the events it publishes
+	eventSource = "product-api"
+
+	// defaultEventBatchSize is used when no batch size is configured
+	defaultEventBatchSize = 100
+
+	// defaultEventMaxAttempts is used when no attempt limit is configured
+	defaultEventMaxAttempts = 20
+)
+
+// EventRelay publishes the events waiting in the outbox to a sink in the
+// order they were recorded. Delivery is at least once: an event whose
+// publication is not confirmed is published again, so consumers
+// deduplicate on its idempotency key.
+type EventRelay struct {
+	outbox repository.OutboxRepository
+	sink   events.Sink
+	config *configs.Config
+	logger *logrus.Logger
+}
+
+// NewEventRelay creates a relay from outbox to sink
+func NewEventRelay(outbox repository.OutboxRepository, sink events.Sink, config *configs.Config, logger *logrus.Logger) *EventRelay {
+	return &EventRelay{
+		outbox: outbox,
+		sink:   sink,
+		config: config,
+		logger: logger,
+	}
+}
+
+// PublishPending publishes waiting events in batches until none is left and
+// returns how many were published. When the sink rejects a batch, its
+// events are published one at a time instead, so the failure is recorded
+// on the events that actually fail and the others still go out. A failed
+// event holds back the later events of its entity until the next run, which
+// keeps each entity's events in order, and one that has failed the
+// configured number of times is parked as a dead letter. The run stops
+// after a batch with failures, whose errors are returned joined.
+func (r *EventRelay) PublishPending(ctx context.Context) (int, error) {
+	batchSize := r.config.Events.BatchSize
+	if batchSize <= 0 {
+		batchSize = defaultEventBatchSize
+	}
+
+	published := 0
+	for {
+		messages, err := r.outbox.Pending(ctx, batchSize)
+		if err != nil {
+			return published, err
+		}
+		if len(messages) == 0 {
+			return published, nil
+		}
+
+		sent, err := r.publishBatch(ctx, messages)
+		published += sent
+		if err != nil {
+			return published, err
+		}
+
+		if len(messages) < batchSize {
+			return published, nil
+		}
+	}
+}
+
+// publishBatch publishes messages with a single sink call, falling back to
+// one call per message when the sink rejects the batch, and returns how
+// many were published
+func (r *EventRelay) publishBatch(ctx context.Context, messages []*models.OutboxMessage) (int, error) {
+	err := r.sink.Publish(ctx, messages)
+	if err == nil {
+		return len(messages), r.markPublished(ctx, messages)
+	}
+	if len(messages) == 1 {
+		r.recordFailure(ctx, messages[0], err)
+		return 0, err
+	}
+
+	var failures []error
+	held := make(map[string]bool)
+	published := make([]*models.OutboxMessage, 0, len(messages))
+	for _, message := range messages {
+		if ctx.Err() != nil {
+			failures = append(failures, ctx.Err())
+			break
+		}
+		if held[message.Key] {
+			continue
+		}
+		if err := r.sink.Publish(ctx, []*models.OutboxMessage{message}); err != nil {
+			held[message.Key] = true
+			r.recordFailure(ctx, message, err)
+			failures = append(failures, fmt.Errorf("event %s: %w", message.EventID, err))
+			continue
+		}
+		published = append(published, message)
+	}
+
+	if err := r.markPublished(ctx, published); err != nil {
+		return 0, err
+	}
+	return len(published), errors.Join(failures...)
+}
+
+// markPublished records that messages reached the sink
+func (r *EventRelay) markPublished(ctx context.Context, messages []*models.OutboxMessage) error {
+	eventIDs := make([]string, 0, len(messages))
+	for _, message := range messages {
+		eventIDs = append(eventIDs, message.EventID)
+	}
+	return r.outbox.MarkPublished(ctx, eventIDs, time.Now())
+}
+
+// recordFailure counts a failed attempt to publish message, parking it as a
+// dead letter once it has run out of attempts
+func (r *EventRelay) recordFailure(ctx context.Context, message *models.OutboxMessage, cause error) {
+	maxAttempts := r.config.Events.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = defaultEventMaxAttempts
+	}
+
+	logger := r.logger.WithError(cause).WithFields(logrus.Fields{
+		"eventId":  message.EventID,
+		"key":      message.Key,
+		"attempts": message.Attempts + 1,
+	})
+
+	var err error
+	if message.Attempts+1 >= maxAttempts {
+		err = r.outbox.MarkDead(ctx, message.EventID, cause.Error(), time.Now())
+		if err == nil {
+			logger.Error("☠️ Event dead-lettered")
+		}
+	} else {
+		err = r.outbox.RecordFailure(ctx, message.EventID, cause.Error())
+	}
+	if err != nil {
+		r.logger.WithError(err).Warn("⚠️ Failed to record event publication failure")
+	}
+}
+
+// Run publishes events until ctx is cancelled. While the outbox or the sink
+// fails, the wait between attempts doubles up to the configured maximum.
+func (r *EventRelay) Run(ctx context.Context) {
+	interval := r.config.Events.PollInterval
+	if interval <= 0 {
+		interval = time.Second
+	}
+	maxBackoff := max(r.config.Events.MaxBackoff, interval)
+
+	wait := interval
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		}
+
+		published, err := r.PublishPending(ctx)
+		switch {
+		case ctx.Err() != nil:
+			return
+		case err != nil:
+			wait = min(wait*2, maxBackoff)
+			r.logger.WithError(err).WithFields(logrus.Fields{
+				"published": published,
+				"retryIn":   wait.String(),
+			}).Warn("⚠️ Event publication failed")
+		default:
+			wait = interval
+			if published > 0 {
+				r.logger.WithField("published", published).Debug("📣 Published product events")
+			}
+		}
+		timer.Reset(wait)
+	}
+}
+
+// newProductChangedEvent describes change as a ProductChanged event. The
+// change must already carry its ID.
+func newProductChangedEvent(change *models.ProductChange) (*models.ProductChangedEvent, error) {
+	eventID, err := newEventID()
+	if err != nil {
+		return nil, err
+	}
+
+	changedFields := make([]string, 0, len(change.Changes))
+	for _, field := range change.Changes {
+		changedFields = append(changedFields, field.Field)
+	}
+
+	return &models.ProductChangedEvent{
+		EventID:        eventID,
+		Type:           models.EventProductChanged,
+		SchemaVersion:  models.ProductChangedSchemaVersion,
+		Source:         eventSource,
+		IdempotencyKey: change.ChangeID,
+		OccurredAt:     change.ChangedAt,
+		ProductID:      change.ProductID,
+		Version:        change.Version,
+		Action:         change.Action,
+		Actor:          change.Actor,
+		RequestID:      change.RequestID,
+		ChangedFields:  changedFields,
+		Product:        change.Snapshot,
+	}, nil
+}
+
+// queueEvents appends events to outbox and queues the webhook deliveries
+// they trigger. Either may be nil. Callers run it in the transaction of the
+// write the events describe.
+func queueEvents(ctx context.Context, outbox repository.OutboxRepository, webhooks *WebhookService, events ...*models.ProductChangedEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	if outbox != nil {
+		messages := make([]*models.OutboxMessage, 0, len(events))
+		for _, event := range events {
+			message, err := newOutboxMessage(event)
+			if err != nil {
+				return err
+			}
+			messages = append(messages, message)
+		}
+		if err := outbox.Append(ctx, messages...); err != nil {
+			return err
+		}
+	}
+
+	if webhooks != nil {
+		return webhooks.enqueue(ctx, events...)
+	}
+	return nil
+}
+
+// newOutboxMessage encodes event ready for the outbox
+func newOutboxMessage(event *models.ProductChangedEvent) (*models.OutboxMessage, error) {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.OutboxMessage{
+		EventID:        event.EventID,
+		Type:           event.Type,
+		SchemaVersion:  event.SchemaVersion,
+		Key:            event.ProductID,
+		IdempotencyKey: event.IdempotencyKey,
+		Payload:        string(payload),
+		CreatedAt:      event.OccurredAt,
+	}, nil
+}
+
+// newEventID generates a random event identifier
+func newEventID() (string, error) {
+	buf := make([]byte, 12)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return "evt-" + hex.EncodeToString(buf), nil
+}
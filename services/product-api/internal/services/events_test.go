@@ -0,0 +1,170 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/product-api-v2/configs"
+	"github.com/product-api-v2/internal/models"
+	"github.com/product-api-v2/internal/repository"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingSink keeps what it is asked to publish, failing while err is set
+// and for any call that includes a message with a rejected key
+type recordingSink struct {
+	published []*models.OutboxMessage
+	err       error
+	rejected  map[string]bool
+}
+
+func (s *recordingSink) Publish(ctx context.Context, messages []*models.OutboxMessage) error {
+	if s.err != nil {
+		return s.err
+	}
+	for _, message := range messages {
+		if s.rejected[message.Key] {
+			return errors.New("message too large")
+		}
+	}
+	s.published = append(s.published, messages...)
+	return nil
+}
+
+func (s *recordingSink) Close() error {
+	return nil
+}
+
+// createTestEventService returns a service that records history and events
+// and a relay publishing those events to sink
+func createTestEventService(sink *recordingSink) (*ProductService, *EventRelay, repository.OutboxRepository) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+
+	config := &configs.Config{Events: configs.EventsConfig{BatchSize: 2, MaxAttempts: 2}}
+	outbox := repository.NewMemoryOutboxRepository()
+	service := NewProductService(repository.NewMemoryProductRepository(), nil, repository.NewMemoryProductHistoryRepository(), outbox, nil, repository.NewDirectTransactor(), config, logger)
+	return service, NewEventRelay(outbox, sink, config, logger), outbox
+}
+
+func decodeProductChanged(t *testing.T, message *models.OutboxMessage) models.ProductChangedEvent {
+	var event models.ProductChangedEvent
+	require.NoError(t, json.Unmarshal([]byte(message.Payload), &event))
+	return event
+}
+
+func TestEventRelay_PublishesChangesInOrder(t *testing.T) {
+	sink := &recordingSink{}
+	service, relay, outbox := createTestEventService(sink)
+
+	ctx := context.WithValue(context.Background(), "actor", "alice")
+	require.NoError(t, service.CreateProduct(ctx, &models.Product{
+		ProductID: "keyboard", Name: "Keyboard", PriceMinor: 4999, Currency: "EUR", Stock: 10, Active: true,
+	}))
+	_, err := service.PatchProduct(ctx, "keyboard", map[string]interface{}{"stock": 0}, nil)
+	require.NoError(t, err)
+	require.NoError(t, service.DeleteProduct(ctx, "keyboard", true, nil))
+
+	published, err := relay.PublishPending(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 3, published)
+	require.Len(t, sink.published, 3)
+
+	history, err := service.GetProductHistory(ctx, "keyboard")
+	require.NoError(t, err)
+	for i, message := range sink.published {
+		event := decodeProductChanged(t, message)
+		assert.Equal(t, models.EventProductChanged, event.Type)
+		assert.Equal(t, models.ProductChangedSchemaVersion, event.SchemaVersion)
+		assert.Equal(t, eventSource, event.Source)
+		assert.Equal(t, "keyboard", message.Key)
+		assert.Equal(t, "alice", event.Actor)
+		assert.Equal(t, history.Changes[i].ChangeID, event.IdempotencyKey)
+		assert.Equal(t, history.Changes[i].Action, event.Action)
+		assert.Equal(t, message.EventID, event.EventID)
+	}
+
+	patched := decodeProductChanged(t, sink.published[1])
+	assert.Equal(t, int64(2), patched.Version)
+	assert.Equal(t, []string{"stock"}, patched.ChangedFields)
+	require.NotNil(t, patched.Product)
+	assert.Equal(t, 0, patched.Product.Stock)
+	assert.Nil(t, decodeProductChanged(t, sink.published[2]).Product)
+
+	pending, err := outbox.Pending(ctx, 10)
+	require.NoError(t, err)
+	assert.Empty(t, pending)
+}
+
+func TestEventRelay_FailureKeepsEventsPending(t *testing.T) {
+	sink := &recordingSink{err: errors.New("broker down")}
+	service, relay, outbox := createTestEventService(sink)
+
+	ctx := context.Background()
+	require.NoError(t, service.CreateProduct(ctx, &models.Product{
+		ProductID: "mouse", Name: "Mouse", PriceMinor: 1999, Currency: "EUR", Stock: 5, Active: true,
+	}))
+
+	published, err := relay.PublishPending(ctx)
+	assert.Error(t, err)
+	assert.Equal(t, 0, published)
+
+	pending, err := outbox.Pending(ctx, 10)
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+	assert.Equal(t, 1, pending[0].Attempts)
+	assert.Equal(t, "broker down", pending[0].LastError)
+
+	sink.err = nil
+	published, err = relay.PublishPending(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, published)
+	assert.Equal(t, pending[0].EventID, sink.published[0].EventID)
+}
+
+func TestEventRelay_RejectedEventIsDeadLettered(t *testing.T) {
+	sink := &recordingSink{rejected: map[string]bool{"keyboard": true}}
+	service, relay, outbox := createTestEventService(sink)
+
+	ctx := context.Background()
+	for _, productID := range []string{"keyboard", "mouse"} {
+		require.NoError(t, service.CreateProduct(ctx, &models.Product{
+			ProductID: productID, Name: productID, PriceMinor: 1999, Currency: "EUR", Active: true,
+		}))
+	}
+	_, err := service.PatchProduct(ctx, "keyboard", map[string]interface{}{"name": "Keyboard"}, nil)
+	require.NoError(t, err)
+
+	// The keyboard's events wait behind the rejected one; the mouse's is
+	// still published
+	published, err := relay.PublishPending(ctx)
+	assert.ErrorContains(t, err, "message too large")
+	assert.Equal(t, 1, published)
+	require.Len(t, sink.published, 1)
+	assert.Equal(t, "mouse", sink.published[0].Key)
+
+	pending, err := outbox.Pending(ctx, 10)
+	require.NoError(t, err)
+	require.Len(t, pending, 2)
+	assert.Equal(t, 1, pending[0].Attempts)
+	assert.Equal(t, "message too large", pending[0].LastError)
+	assert.Equal(t, 0, pending[1].Attempts)
+
+	// Out of attempts, the rejected event is parked and the next one of
+	// the keyboard goes out once the sink accepts it
+	_, err = relay.PublishPending(ctx)
+	assert.Error(t, err)
+	pending, err = outbox.Pending(ctx, 10)
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+	assert.Equal(t, int64(2), decodeProductChanged(t, pending[0]).Version)
+
+	delete(sink.rejected, "keyboard")
+	published, err = relay.PublishPending(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, published)
+}
@@ -0,0 +1,347 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"maps"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/product-api-v2/internal/models"
+	"github.com/product-api-v2/internal/repository"
+	"github.com/sirupsen/logrus"
+)
+
+// systemActor is recorded for changes made outside a request
+const systemActor = "system"
+
+// historyIgnoredFields are maintained by the repository on every write and
+// left out of change diffs
+var historyIgnoredFields = map[string]bool{"version": true, "createdAt": true, "updatedAt": true}
+
+// GetProductHistory returns every recorded change of a product, oldest
+// first. The history outlives the product, so deleted products keep theirs;
+// an existing product without recorded changes has an empty history.
+func (s *ProductService) GetProductHistory(ctx context.Context, productID string) (*models.ProductHistoryResponse, error) {
+	s.stats.request("GetProductHistory")
+
+	logger := s.logger.WithFields(logrus.Fields{
+		"operation": "GetProductHistory",
+		"productId": productID,
+		"requestId": ctx.Value("requestId"),
+	})
+
+	changes := []*models.ProductChange{}
+	if s.history != nil {
+		recorded, err := s.history.List(ctx, productID)
+		if err != nil {
+			s.stats.failure("GetProductHistory")
+			logger.WithError(err).Error("💥 Failed to read product history")
+			return nil, repositoryError(err, "failed to retrieve product history")
+		}
+		changes = recorded
+	}
+
+	if len(changes) == 0 {
+		if _, err := s.getExistingProduct(ctx, logger, "GetProductHistory", productID); err != nil {
+			return nil, err
+		}
+	}
+
+	logger.WithField("changes", len(changes)).Info("📜 Product history retrieved")
+
+	return &models.ProductHistoryResponse{
+		ProductID: productID,
+		Changes:   changes,
+		Total:     len(changes),
+	}, nil
+}
+
+// RestoreProduct brings the catalog fields of a product back to a version
+// recorded in its history: name, description, price, category, attributes
+// and the active flag. Stock, parent and SKU stay as they are, since stock
+// moves with reservations after the version was recorded. The restored
+// state is written as a new version and validated like an update, so a
+// version whose category has since gone cannot be restored. If ifMatch is
+// set the product must still be at that version. Hard-deleted products
+// cannot be restored.
+//
+// Stock reservations and releases bump the version without a history
+// entry, so the recorded versions of a product can have gaps; only
+// recorded versions can be restored.
+func (s *ProductService) RestoreProduct(ctx context.Context, productID string, version int64, ifMatch *int64) (*models.Product, error) {
+	s.stats.request("RestoreProduct")
+
+	logger := s.logger.WithFields(logrus.Fields{
+		"operation":   "RestoreProduct",
+		"productId":   productID,
+		"fromVersion": version,
+		"requestId":   ctx.Value("requestId"),
+	})
+
+	logger.Info("⏪ Restoring product version")
+
+	existing, err := s.getExistingProduct(ctx, logger, "RestoreProduct", productID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkVersion(ifMatch, existing.Version); err != nil {
+		logger.WithField("version", existing.Version).Warn("⚠️ Stale product version")
+		return nil, err
+	}
+
+	if version == existing.Version {
+		logger.Info("ℹ️ Product already at requested version")
+		return existing, nil
+	}
+
+	change, err := s.getRecordedVersion(ctx, productID, version)
+	if err != nil {
+		if errors.Is(err, repository.ErrChangeNotFound) {
+			logger.WithField("reason", "version_not_found").Warn("⚠️ Version not in history")
+			return nil, newError(ErrNotFound, "version_not_found", nil, "product %s has no recorded version %d", productID, version)
+		}
+		s.stats.failure("RestoreProduct")
+		logger.WithError(err).Error("💥 Failed to read product history")
+		return nil, repositoryError(err, "failed to retrieve product history")
+	}
+
+	product := *existing
+	restoreCatalogFields(&product, change.Snapshot)
+
+	if err := s.checkHierarchy(ctx, logger, &product); err != nil {
+		s.stats.failure("RestoreProduct")
+		logger.WithError(err).Error("💥 Product hierarchy check failed")
+		return nil, err
+	}
+
+	if err := s.validateProduct(ctx, &product); err != nil {
+		s.stats.failure("RestoreProduct")
+		logger.WithError(err).Error("💥 Product validation failed")
+		return nil, err
+	}
+	prepareProduct(&product)
+
+	err = s.commit(ctx, logger, "RestoreProduct", func(ctx context.Context) ([]*models.ProductChange, error) {
+		product.Version = existing.Version
+		if err := s.saveProduct(ctx, logger, "RestoreProduct", &product, ifMatch); err != nil {
+			return nil, err
+		}
+		restore := newProductChange(ctx, models.AuditRestore, existing, &product)
+		restore.RestoredFrom = version
+		if product.Category == existing.Category {
+			return []*models.ProductChange{restore}, nil
+		}
+		cascaded, err := s.cascadeCategory(ctx, logger, "RestoreProduct", &product)
+		if err != nil {
+			return nil, err
+		}
+		return append([]*models.ProductChange{restore}, cascaded...), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	logger.WithField("version", product.Version).Info("✅ Product restored")
+
+	return &product, nil
+}
+
+// restoreCatalogFields copies the catalog fields of snapshot onto product
+func restoreCatalogFields(product, snapshot *models.Product) {
+	product.Name = snapshot.Name
+	product.Description = snapshot.Description
+	product.Price = snapshot.Price
+	product.PriceMinor = snapshot.PriceMinor
+	product.Currency = snapshot.Currency
+	product.Category = snapshot.Category
+	product.Attributes = maps.Clone(snapshot.Attributes)
+	product.Active = snapshot.Active
+}
+
+// getRecordedVersion returns the change that produced version of a product
+func (s *ProductService) getRecordedVersion(ctx context.Context, productID string, version int64) (*models.ProductChange, error) {
+	if s.history == nil {
+		return nil, repository.ErrChangeNotFound
+	}
+	return s.history.GetVersion(ctx, productID, version)
+}
+
+// commit runs write and records the changes it made in one transaction, so
+// a write is kept only if its history entries and events are kept too.
+// Errors returned by write are passed through.
+func (s *ProductService) commit(ctx context.Context, logger *logrus.Entry, operation string, write func(ctx context.Context) ([]*models.ProductChange, error)) error {
+	work := func(ctx context.Context) error {
+		changes, err := write(ctx)
+		if err != nil {
+			return err
+		}
+		return s.recordChanges(ctx, changes...)
+	}
+
+	var err error
+	if s.transactor != nil {
+		err = s.transactor.WithinTransaction(ctx, work)
+	} else {
+		err = work(ctx)
+	}
+	if err == nil {
+		return nil
+	}
+
+	var serviceErr *Error
+	if errors.As(err, &serviceErr) {
+		return err
+	}
+	s.stats.failure(operation)
+	logger.WithError(err).Error("💥 Failed to record product changes")
+	return repositoryError(err, "failed to record product changes")
+}
+
+// recordsChanges reports whether writes are recorded anywhere
+func (s *ProductService) recordsChanges() bool {
+	return s.history != nil || s.outbox != nil || s.webhooks != nil
+}
+
+// recordChanges appends changes to the product history, queues a
+// ProductChanged event for each and queues the webhook deliveries they
+// trigger
+func (s *ProductService) recordChanges(ctx context.Context, changes ...*models.ProductChange) error {
+	if !s.recordsChanges() || len(changes) == 0 {
+		return nil
+	}
+
+	for _, change := range changes {
+		changeID, err := newChangeID()
+		if err != nil {
+			return err
+		}
+		change.ChangeID = changeID
+	}
+
+	if s.history != nil {
+		if err := s.history.Append(ctx, changes...); err != nil {
+			return err
+		}
+	}
+
+	if s.outbox == nil && s.webhooks == nil {
+		return nil
+	}
+
+	events := make([]*models.ProductChangedEvent, 0, len(changes))
+	for _, change := range changes {
+		event, err := newProductChangedEvent(change)
+		if err != nil {
+			return err
+		}
+		events = append(events, event)
+	}
+
+	return queueEvents(ctx, s.outbox, s.webhooks, events...)
+}
+
+// newProductChange describes a write that turned before into after. Either
+// may be nil, for a creation or a deletion. The actor and request ID come
+// from ctx.
+func newProductChange(ctx context.Context, action string, before, after *models.Product) *models.ProductChange {
+	change := &models.ProductChange{
+		Action:    action,
+		Actor:     actorFrom(ctx),
+		ChangedAt: time.Now(),
+	}
+	if requestID, ok := ctx.Value("requestId").(string); ok {
+		change.RequestID = requestID
+	}
+
+	var beforeFields, afterFields map[string]interface{}
+	if before != nil {
+		change.ProductID = before.ProductID
+		change.Version = before.Version
+		beforeFields = flattenFields(before)
+	}
+	if after != nil {
+		snapshot := *after
+		snapshot.Variants = nil
+		change.ProductID = after.ProductID
+		change.Version = after.Version
+		change.Snapshot = &snapshot
+		afterFields = flattenFields(&snapshot)
+	}
+	change.Changes = diffFields(beforeFields, afterFields)
+	return change
+}
+
+// actorFrom returns who is making the request in ctx
+func actorFrom(ctx context.Context) string {
+	if actor, ok := ctx.Value("actor").(string); ok && actor != "" {
+		return actor
+	}
+	return systemActor
+}
+
+// flattenFields returns the JSON fields of value by path, with nested
+// objects flattened into dotted paths
+func flattenFields(value interface{}) map[string]interface{} {
+	// Models always encode, so errors cannot occur
+	data, _ := json.Marshal(value)
+	var document map[string]interface{}
+	_ = json.Unmarshal(data, &document)
+
+	fields := make(map[string]interface{})
+	flattenInto(fields, "", document)
+	for field := range historyIgnoredFields {
+		delete(fields, field)
+	}
+	return fields
+}
+
+// flattenInto adds the fields of document to fields, prefixing their names
+func flattenInto(fields map[string]interface{}, prefix string, document map[string]interface{}) {
+	for name, value := range document {
+		if nested, ok := value.(map[string]interface{}); ok {
+			flattenInto(fields, prefix+name+".", nested)
+			continue
+		}
+		fields[prefix+name] = value
+	}
+}
+
+// diffFields lists the fields whose values differ between before and
+// after, by name
+func diffFields(before, after map[string]interface{}) []models.FieldChange {
+	names := make([]string, 0, len(before)+len(after))
+	for name := range before {
+		names = append(names, name)
+	}
+	for name := range after {
+		if _, seen := before[name]; !seen {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	changes := []models.FieldChange{}
+	for _, name := range names {
+		beforeValue, afterValue := before[name], after[name]
+		if reflect.DeepEqual(beforeValue, afterValue) {
+			continue
+		}
+		changes = append(changes, models.FieldChange{Field: name, Before: beforeValue, After: afterValue})
+	}
+	return changes
+}
+
+// newChangeID generates a random history entry identifier
+func newChangeID() (string, error) {
+	buf := make([]byte, 12)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return "chg-" + hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,128 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/product-api-v2/configs"
+	"github.com/product-api-v2/internal/models"
+	"github.com/product-api-v2/internal/repository"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// createTestHistoryService returns a service that records history, with
+// one product created by alice
+func createTestHistoryService(t *testing.T) (*ProductService, context.Context) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+
+	service := NewProductService(repository.NewMemoryProductRepository(), nil, repository.NewMemoryProductHistoryRepository(), nil, nil, nil, &configs.Config{}, logger)
+
+	ctx := context.WithValue(context.Background(), "actor", "alice")
+	ctx = context.WithValue(ctx, "requestId", "req-1")
+	require.NoError(t, service.CreateProduct(ctx, &models.Product{
+		ProductID: "keyboard", Name: "Keyboard", PriceMinor: 4999, Currency: "EUR",
+		Attributes: map[string]string{"layout": "ES"}, Stock: 10, Active: true,
+	}))
+	return service, ctx
+}
+
+func TestProductService_History_RecordsWrites(t *testing.T) {
+	service, ctx := createTestHistoryService(t)
+
+	bob := context.WithValue(context.Background(), "actor", "bob")
+	_, err := service.PatchProduct(bob, "keyboard", map[string]interface{}{
+		"priceMinor": 3999, "attributes": map[string]interface{}{"layout": "US"},
+	}, nil)
+	require.NoError(t, err)
+	_, err = service.SetProductActive(ctx, "keyboard", false, nil)
+	require.NoError(t, err)
+
+	history, err := service.GetProductHistory(ctx, "keyboard")
+	require.NoError(t, err)
+	require.Equal(t, 3, history.Total)
+
+	created := history.Changes[0]
+	assert.Equal(t, models.AuditCreate, created.Action)
+	assert.Equal(t, "alice", created.Actor)
+	assert.Equal(t, "req-1", created.RequestID)
+	assert.Equal(t, int64(1), created.Version)
+	assert.Contains(t, created.Changes, models.FieldChange{Field: "name", After: "Keyboard"})
+
+	patched := history.Changes[1]
+	assert.Equal(t, "bob", patched.Actor)
+	assert.Equal(t, int64(2), patched.Version)
+	assert.Equal(t, []models.FieldChange{
+		{Field: "attributes.layout", Before: "ES", After: "US"},
+		{Field: "price", Before: 49.99, After: 39.99},
+		{Field: "priceMinor", Before: float64(4999), After: float64(3999)},
+	}, patched.Changes)
+
+	deactivated := history.Changes[2]
+	assert.Equal(t, []models.FieldChange{{Field: "active", Before: true, After: false}}, deactivated.Changes)
+}
+
+func TestProductService_RestoreProduct(t *testing.T) {
+	service, ctx := createTestHistoryService(t)
+
+	_, err := service.PatchProduct(ctx, "keyboard", map[string]interface{}{"name": "Broken", "stock": 0}, nil)
+	require.NoError(t, err)
+
+	stale := int64(1)
+	_, err = service.RestoreProduct(ctx, "keyboard", 1, &stale)
+	assert.ErrorIs(t, err, ErrPreconditionFailed)
+	_, err = service.RestoreProduct(ctx, "keyboard", 7, nil)
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	current := int64(2)
+	restored, err := service.RestoreProduct(ctx, "keyboard", 1, &current)
+	require.NoError(t, err)
+	assert.Equal(t, "Keyboard", restored.Name)
+	assert.Equal(t, 0, restored.Stock, "stock is not restored")
+	assert.Equal(t, int64(3), restored.Version)
+
+	history, err := service.GetProductHistory(ctx, "keyboard")
+	require.NoError(t, err)
+	require.Equal(t, 3, history.Total)
+	assert.Equal(t, models.AuditRestore, history.Changes[2].Action)
+	assert.Equal(t, int64(1), history.Changes[2].RestoredFrom)
+}
+
+func TestProductService_RestoreProduct_KeepsReservedStock(t *testing.T) {
+	service, ctx := createTestHistoryService(t)
+
+	_, err := service.PatchProduct(ctx, "keyboard", map[string]interface{}{"name": "Renamed"}, nil)
+	require.NoError(t, err)
+	require.NoError(t, service.repo.ReserveStock(ctx, []models.StockLine{{ProductID: "keyboard", Quantity: 4}}))
+
+	restored, err := service.RestoreProduct(ctx, "keyboard", 1, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "Keyboard", restored.Name)
+	assert.Equal(t, 6, restored.Stock)
+
+	stored, err := service.repo.GetByID(ctx, "keyboard")
+	require.NoError(t, err)
+	assert.Equal(t, 6, stored.Stock)
+	assert.Equal(t, map[string]string{"layout": "ES"}, stored.Attributes)
+}
+
+func TestProductService_History_OutlivesDeletion(t *testing.T) {
+	service, ctx := createTestHistoryService(t)
+
+	require.NoError(t, service.DeleteProduct(ctx, "keyboard", true, nil))
+
+	history, err := service.GetProductHistory(ctx, "keyboard")
+	require.NoError(t, err)
+	require.Equal(t, 2, history.Total)
+	deleted := history.Changes[1]
+	assert.Equal(t, models.AuditDelete, deleted.Action)
+	assert.Nil(t, deleted.Snapshot)
+	assert.Contains(t, deleted.Changes, models.FieldChange{Field: "name", Before: "Keyboard"})
+
+	_, err = service.RestoreProduct(ctx, "keyboard", 1, nil)
+	assert.ErrorIs(t, err, ErrNotFound)
+	_, err = service.GetProductHistory(ctx, "missing")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
@@ -0,0 +1,58 @@
+package services
+
+import (
+	"github.com/product-api-v2/internal/repository"
+)
+
+// defaultPageSize applies when a cursor is given without a page size
+const defaultPageSize = 20
+
+// pagination describes the page a listing asked for
+type pagination struct {
+	page     int
+	pageSize int
+	cursor   *repository.Cursor
+}
+
+// fetchSize is the limit to query the repository with. Keyset pages and the
+// first offset page fetch one item more than they show, which tells whether
+// another page follows without counting. Later offset pages cannot, since
+// the repository derives the offset from the limit.
+func (p pagination) fetchSize() int {
+	if p.pageSize > 0 && (p.cursor != nil || p.page == 0) {
+		return p.pageSize + 1
+	}
+	return p.pageSize
+}
+
+// pageWindow trims items fetched with p.fetchSize to the page and reports
+// whether pages exist before and after it. total is only used for offset
+// pages past the first.
+func pageWindow[T any](p pagination, items []*T, total int) (page []*T, hasPrev, hasNext bool) {
+	if p.pageSize == 0 {
+		return items, false, false
+	}
+
+	backward := p.cursor != nil && p.cursor.Backward
+	more := false
+	if p.fetchSize() > p.pageSize {
+		more = len(items) > p.pageSize
+		if more && backward {
+			items = items[len(items)-p.pageSize:]
+		} else if more {
+			items = items[:p.pageSize]
+		}
+	} else {
+		more = (p.page+1)*p.pageSize < total
+	}
+
+	switch {
+	case backward:
+		// A backward page was reached from the page after it
+		return items, more, true
+	case p.cursor != nil:
+		return items, true, more
+	default:
+		return items, p.page > 0, more
+	}
+}
@@ -0,0 +1,68 @@
+package services
+
+import (
+	"encoding/json"
+)
+
+// applyMergePatch applies an RFC 7386 JSON merge patch to target in place.
+// Keys set to null are removed, nested objects are merged recursively and
+// any other value replaces the existing one.
+func applyMergePatch(target, patch map[string]interface{}) map[string]interface{} {
+	if target == nil {
+		target = make(map[string]interface{}, len(patch))
+	}
+
+	for key, value := range patch {
+		if value == nil {
+			delete(target, key)
+			continue
+		}
+
+		if patchObject, ok := value.(map[string]interface{}); ok {
+			targetObject, _ := target[key].(map[string]interface{})
+			target[key] = applyMergePatch(targetObject, patchObject)
+			continue
+		}
+
+		target[key] = value
+	}
+
+	return target
+}
+
+// mergePatchInto applies patch to the JSON representation of current and
+// decodes the result into out
+func mergePatchInto(current interface{}, patch map[string]interface{}, out interface{}) error {
+	raw, err := json.Marshal(current)
+	if err != nil {
+		return err
+	}
+
+	var document map[string]interface{}
+	if err := json.Unmarshal(raw, &document); err != nil {
+		return err
+	}
+
+	merged, err := json.Marshal(applyMergePatch(document, patch))
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(merged, out)
+}
+
+// settlePricePatch makes the price in patch replace the stored one whichever
+// way it is given. The legacy decimal price is derived from priceMinor, so
+// it is dropped unless the patch sets it, and then it replaces priceMinor
+// unless the patch sets that too.
+func settlePricePatch(patch map[string]interface{}) {
+	_, hasPrice := patch["price"]
+	_, hasMinor := patch["priceMinor"]
+
+	switch {
+	case !hasPrice:
+		patch["price"] = nil
+	case !hasMinor:
+		patch["priceMinor"] = nil
+	}
+}
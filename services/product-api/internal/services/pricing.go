@@ -0,0 +1,388 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/product-api-v2/configs"
+	"github.com/product-api-v2/internal/models"
+	"github.com/product-api-v2/internal/repository"
+	"github.com/sirupsen/logrus"
+)
+
+// maxPriceRules caps the number of rules in a single price list
+const maxPriceRules = 1000
+
+// PricingService manages price lists and resolves the effective price of a
+// product from the lists that apply at a given time
+type PricingService struct {
+	priceLists repository.PriceListRepository
+	products   repository.ProductRepository
+	config     *configs.Config
+	logger     *logrus.Logger
+}
+
+// NewPricingService creates a new pricing service
+func NewPricingService(priceLists repository.PriceListRepository, products repository.ProductRepository, config *configs.Config, logger *logrus.Logger) *PricingService {
+	return &PricingService{
+		priceLists: priceLists,
+		products:   products,
+		config:     config,
+		logger:     logger,
+	}
+}
+
+// CreatePriceList validates and stores a new price list
+func (s *PricingService) CreatePriceList(ctx context.Context, priceList *models.PriceList) error {
+	logger := s.logger.WithFields(logrus.Fields{
+		"operation":   "CreatePriceList",
+		"priceListId": priceList.PriceListID,
+		"requestId":   ctx.Value("requestId"),
+	})
+
+	logger.Info("🏷️ Creating price list")
+
+	if err := validatePriceList(priceList); err != nil {
+		logger.WithError(err).Warn("⚠️ Invalid price list")
+		return validationError(err)
+	}
+
+	if err := s.priceLists.Create(ctx, priceList); err != nil {
+		if errors.Is(err, repository.ErrPriceListExists) {
+			return newError(ErrConflict, "price_list_exists", nil, "price list %s already exists", priceList.PriceListID)
+		}
+		logger.WithError(err).Error("💥 Failed to create price list")
+		return repositoryError(err, "failed to create price list")
+	}
+
+	logger.WithField("rules", len(priceList.Rules)).Info("✅ Price list created")
+	return nil
+}
+
+// GetPriceList retrieves a price list by ID
+func (s *PricingService) GetPriceList(ctx context.Context, priceListID string) (*models.PriceList, error) {
+	priceList, err := s.priceLists.GetByID(ctx, priceListID)
+	if err != nil {
+		return nil, priceListLookupError(err, priceListID)
+	}
+	return priceList, nil
+}
+
+// GetPriceLists returns every price list, whether or not it applies now
+func (s *PricingService) GetPriceLists(ctx context.Context) (*models.PriceListResponse, error) {
+	priceLists, err := s.priceLists.GetAll(ctx)
+	if err != nil {
+		s.logger.WithError(err).WithField("requestId", ctx.Value("requestId")).Error("💥 Failed to list price lists")
+		return nil, repositoryError(err, "failed to retrieve price lists")
+	}
+
+	return &models.PriceListResponse{PriceLists: priceLists, Total: len(priceLists)}, nil
+}
+
+// UpdatePriceList replaces an existing price list. If ifMatch is set the
+// list must still be at that version.
+func (s *PricingService) UpdatePriceList(ctx context.Context, priceListID string, priceList *models.PriceList, ifMatch *int64) (*models.PriceList, error) {
+	logger := s.logger.WithFields(logrus.Fields{
+		"operation":   "UpdatePriceList",
+		"priceListId": priceListID,
+		"requestId":   ctx.Value("requestId"),
+	})
+
+	logger.Info("✏️ Updating price list")
+
+	if priceList.PriceListID == "" {
+		priceList.PriceListID = priceListID
+	}
+	if priceList.PriceListID != priceListID {
+		return nil, validationError(fmt.Errorf("price list ID in body does not match %s", priceListID))
+	}
+
+	if err := validatePriceList(priceList); err != nil {
+		logger.WithError(err).Warn("⚠️ Invalid price list")
+		return nil, validationError(err)
+	}
+
+	existing, err := s.GetPriceList(ctx, priceListID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkVersion(ifMatch, existing.Version); err != nil {
+		logger.WithField("version", existing.Version).Warn("⚠️ Stale price list version")
+		return nil, err
+	}
+
+	priceList.Version = existing.Version
+	priceList.CreatedAt = existing.CreatedAt
+	if err := s.priceLists.Update(ctx, priceList); err != nil {
+		if errors.Is(err, repository.ErrVersionConflict) {
+			logger.Warn("⚠️ Concurrent price list update")
+			return nil, versionConflictError(ifMatch)
+		}
+		return nil, priceListLookupError(err, priceListID)
+	}
+
+	logger.WithField("version", priceList.Version).Info("✅ Price list updated")
+	return priceList, nil
+}
+
+// DeletePriceList removes a price list. Prices resolved before keep the
+// rule they were given, but the list no longer applies to new lookups.
+func (s *PricingService) DeletePriceList(ctx context.Context, priceListID string) error {
+	if err := s.priceLists.Delete(ctx, priceListID); err != nil {
+		return priceListLookupError(err, priceListID)
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"operation":   "DeletePriceList",
+		"priceListId": priceListID,
+		"requestId":   ctx.Value("requestId"),
+	}).Info("🗑️ Price list deleted")
+	return nil
+}
+
+// ResolvePrice returns the effective unit price of quantity units of a
+// product for a customer tier, as of at. Of the rules that apply, the one
+// from the highest priority price list wins; among equal priorities the
+// lowest price wins, then the most specific rule. Base prices have no
+// history, so the current product price is the starting point for any at.
+func (s *PricingService) ResolvePrice(ctx context.Context, productID string, at time.Time, tier string, quantity int) (*models.ProductPrice, error) {
+	logger := s.logger.WithFields(logrus.Fields{
+		"operation": "ResolvePrice",
+		"productId": productID,
+		"at":        at,
+		"tier":      tier,
+		"quantity":  quantity,
+		"requestId": ctx.Value("requestId"),
+	})
+
+	if quantity < 1 {
+		return nil, validationError(fmt.Errorf("quantity must be at least 1"))
+	}
+
+	product, err := s.products.GetByID(ctx, productID)
+	if err != nil {
+		if errors.Is(err, repository.ErrProductNotFound) {
+			return nil, newError(ErrNotFound, "product_not_found", nil, "product with ID %s not found", productID)
+		}
+		logger.WithError(err).Error("💥 Failed to retrieve product")
+		return nil, repositoryError(err, "failed to retrieve product")
+	}
+	if err := inheritFromParents(ctx, s.products, product); err != nil {
+		logger.WithError(err).Error("💥 Failed to retrieve parent product")
+		return nil, repositoryError(err, "failed to retrieve parent product")
+	}
+	if !product.Active {
+		return nil, newError(ErrInactive, "product_unavailable", nil, "product %s is not available", productID)
+	}
+
+	base := *product
+	base.NormalizePrice()
+
+	priceLists, err := s.priceLists.ListEffective(ctx, at)
+	if err != nil {
+		logger.WithError(err).Error("💥 Failed to retrieve price lists")
+		return nil, repositoryError(err, "failed to retrieve price lists")
+	}
+
+	unit := base.PriceMinor
+	rule := selectPriceRule(priceLists, &base, tier, quantity)
+	if rule != nil {
+		unit -= rule.DiscountMinor
+	}
+
+	price := &models.ProductPrice{
+		ProductID:      base.ProductID,
+		Currency:       base.Currency,
+		At:             at,
+		Tier:           tier,
+		Quantity:       quantity,
+		BasePrice:      base.Price,
+		BasePriceMinor: base.PriceMinor,
+		Price:          models.FromMinorUnits(unit, base.Currency),
+		PriceMinor:     unit,
+		TotalMinor:     unit * int64(quantity),
+		Rule:           rule,
+	}
+
+	fields := logrus.Fields{"priceMinor": unit}
+	if rule != nil {
+		fields["priceListId"] = rule.PriceListID
+	}
+	logger.WithFields(fields).Debug("💲 Price resolved")
+
+	return price, nil
+}
+
+// selectPriceRule picks the rule that prices product among priceLists,
+// which are ordered by descending priority. It returns nil when no rule
+// applies.
+func selectPriceRule(priceLists []*models.PriceList, product *models.Product, tier string, quantity int) *models.AppliedRule {
+	var best *models.AppliedRule
+	for _, priceList := range priceLists {
+		if best != nil && priceList.Priority < best.Priority {
+			break
+		}
+		if len(priceList.Tiers) > 0 && !slices.Contains(priceList.Tiers, tier) {
+			continue
+		}
+
+		for i, rule := range priceList.Rules {
+			if !priceRuleMatches(rule, product, quantity) {
+				continue
+			}
+
+			candidate := &models.AppliedRule{
+				PriceListID:   priceList.PriceListID,
+				PriceListName: priceList.Name,
+				Priority:      priceList.Priority,
+				RuleIndex:     i,
+				Rule:          rule,
+				DiscountMinor: priceRuleDiscount(rule, product.PriceMinor),
+			}
+			if best == nil || betterPriceRule(candidate, best) {
+				best = candidate
+			}
+		}
+	}
+	return best
+}
+
+// betterPriceRule reports whether candidate beats best, both from lists of
+// the same priority: a bigger discount, or an equal one from a more
+// specific rule
+func betterPriceRule(candidate, best *models.AppliedRule) bool {
+	if candidate.DiscountMinor != best.DiscountMinor {
+		return candidate.DiscountMinor > best.DiscountMinor
+	}
+	return priceRuleSpecificity(candidate.Rule) > priceRuleSpecificity(best.Rule)
+}
+
+// priceRuleSpecificity ranks product rules over category rules over rules
+// for every product
+func priceRuleSpecificity(rule models.PriceRule) int {
+	switch {
+	case rule.ProductID != "":
+		return 2
+	case rule.Category != "":
+		return 1
+	}
+	return 0
+}
+
+// priceRuleMatches reports whether rule applies to quantity units of
+// product. A rule for a parent product applies to its variants too.
+func priceRuleMatches(rule models.PriceRule, product *models.Product, quantity int) bool {
+	if rule.ProductID != "" && rule.ProductID != product.ProductID && rule.ProductID != product.ParentID {
+		return false
+	}
+	if rule.Category != "" && rule.Category != product.Category {
+		return false
+	}
+	if quantity < rule.MinQuantity {
+		return false
+	}
+	return rule.Type != models.DiscountFixed || rule.Currency == product.Currency
+}
+
+// priceRuleDiscount is the discount rule gives on a unit price, in minor
+// units. It never exceeds the price itself.
+func priceRuleDiscount(rule models.PriceRule, priceMinor int64) int64 {
+	var discount int64
+	switch rule.Type {
+	case models.DiscountPercentage:
+		discount = int64(math.Round(float64(priceMinor) * rule.Percent / 100))
+	case models.DiscountFixed:
+		discount = rule.AmountMinor
+	}
+	return min(discount, priceMinor)
+}
+
+// validatePriceList checks a price list before it is stored
+func validatePriceList(priceList *models.PriceList) error {
+	if priceList.PriceListID == "" {
+		return fmt.Errorf("price list ID is required")
+	}
+
+	if strings.TrimSpace(priceList.Name) == "" {
+		return fmt.Errorf("price list name is required")
+	}
+
+	if priceList.ValidFrom.IsZero() {
+		return fmt.Errorf("validFrom is required")
+	}
+
+	if priceList.ValidUntil != nil && !priceList.ValidUntil.After(priceList.ValidFrom) {
+		return fmt.Errorf("validUntil must be after validFrom")
+	}
+
+	for _, tier := range priceList.Tiers {
+		if strings.TrimSpace(tier) == "" {
+			return fmt.Errorf("tiers cannot contain empty values")
+		}
+	}
+
+	if len(priceList.Rules) == 0 {
+		return fmt.Errorf("price list must have at least one rule")
+	}
+
+	if len(priceList.Rules) > maxPriceRules {
+		return fmt.Errorf("price list cannot have more than %d rules", maxPriceRules)
+	}
+
+	for i, rule := range priceList.Rules {
+		if err := validatePriceRule(rule); err != nil {
+			return fmt.Errorf("rules[%d]: %w", i, err)
+		}
+	}
+
+	return nil
+}
+
+// validatePriceRule checks one rule of a price list
+func validatePriceRule(rule models.PriceRule) error {
+	if rule.ProductID != "" && rule.Category != "" {
+		return fmt.Errorf("productId and category cannot both be set")
+	}
+
+	if rule.MinQuantity < 0 {
+		return fmt.Errorf("minQuantity cannot be negative")
+	}
+
+	switch rule.Type {
+	case models.DiscountPercentage:
+		if rule.Percent <= 0 || rule.Percent > 100 {
+			return fmt.Errorf("percent must be greater than 0 and at most 100")
+		}
+		if rule.AmountMinor != 0 || rule.Currency != "" {
+			return fmt.Errorf("percentage discounts take no amountMinor or currency")
+		}
+	case models.DiscountFixed:
+		if rule.AmountMinor <= 0 {
+			return fmt.Errorf("amountMinor must be greater than 0")
+		}
+		if _, ok := models.CurrencyExponent(rule.Currency); !ok {
+			return fmt.Errorf("currency %q is not a supported ISO 4217 code", rule.Currency)
+		}
+		if rule.Percent != 0 {
+			return fmt.Errorf("fixed discounts take no percent")
+		}
+	default:
+		return fmt.Errorf("type must be %s or %s", models.DiscountPercentage, models.DiscountFixed)
+	}
+
+	return nil
+}
+
+// priceListLookupError maps a failed price list lookup to a service error
+func priceListLookupError(err error, priceListID string) error {
+	if errors.Is(err, repository.ErrPriceListNotFound) {
+		return newError(ErrNotFound, "price_list_not_found", nil, "price list %s not found", priceListID)
+	}
+	return repositoryError(err, "failed to retrieve price list")
+}
@@ -0,0 +1,250 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/product-api-v2/configs"
+	"github.com/product-api-v2/internal/models"
+	"github.com/product-api-v2/internal/repository"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var pricingStart = time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// Helper function to create a pricing service backed by memory repositories
+func createTestPricingService(t *testing.T) *PricingService {
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+
+	products := repository.NewMemoryProductRepository()
+	for _, product := range []*models.Product{
+		{ProductID: "product-1", Name: "Laptop", PriceMinor: 100000, Currency: "USD", Category: "electronics", Active: true},
+		{ProductID: "product-2", Name: "Headphones", PriceMinor: 5000, Currency: "EUR", Category: "electronics", Active: true},
+		{ProductID: "product-3", Name: "Retired", PriceMinor: 999, Currency: "USD", Active: false},
+	} {
+		require.NoError(t, products.Create(context.Background(), product))
+	}
+
+	return NewPricingService(repository.NewMemoryPriceListRepository(), products, &configs.Config{}, logger)
+}
+
+// createPriceList stores a price list starting at pricingStart
+func createPriceList(t *testing.T, service *PricingService, priceList *models.PriceList) {
+	priceList.Name = priceList.PriceListID
+	priceList.Active = true
+	if priceList.ValidFrom.IsZero() {
+		priceList.ValidFrom = pricingStart
+	}
+	require.NoError(t, service.CreatePriceList(context.Background(), priceList))
+}
+
+func TestPricingService_ResolvePrice_NoPriceList(t *testing.T) {
+	service := createTestPricingService(t)
+
+	price, err := service.ResolvePrice(context.Background(), "product-1", pricingStart, "", 3)
+
+	require.NoError(t, err)
+	assert.Equal(t, int64(100000), price.PriceMinor)
+	assert.Equal(t, 1000.0, price.Price)
+	assert.Equal(t, int64(300000), price.TotalMinor)
+	assert.Nil(t, price.Rule)
+}
+
+func TestPricingService_ResolvePrice_HighestPriorityWins(t *testing.T) {
+	service := createTestPricingService(t)
+	ctx := context.Background()
+
+	createPriceList(t, service, &models.PriceList{
+		PriceListID: "clearance",
+		Priority:    1,
+		Rules:       []models.PriceRule{{Type: models.DiscountPercentage, Percent: 50}},
+	})
+	createPriceList(t, service, &models.PriceList{
+		PriceListID: "electronics",
+		Priority:    5,
+		Rules: []models.PriceRule{
+			{Category: "electronics", Type: models.DiscountPercentage, Percent: 10},
+			{ProductID: "product-1", Type: models.DiscountFixed, AmountMinor: 10000, Currency: "USD"},
+		},
+	})
+
+	price, err := service.ResolvePrice(ctx, "product-1", pricingStart, "", 1)
+
+	require.NoError(t, err)
+	assert.Equal(t, int64(90000), price.PriceMinor)
+	require.NotNil(t, price.Rule)
+	assert.Equal(t, "electronics", price.Rule.PriceListID)
+	assert.Equal(t, 1, price.Rule.RuleIndex, "equal discounts go to the most specific rule")
+	assert.Equal(t, int64(10000), price.Rule.DiscountMinor)
+}
+
+func TestPricingService_ResolvePrice_ValidityWindow(t *testing.T) {
+	service := createTestPricingService(t)
+	ctx := context.Background()
+
+	until := pricingStart.Add(24 * time.Hour)
+	createPriceList(t, service, &models.PriceList{
+		PriceListID: "new-year",
+		ValidUntil:  &until,
+		Rules:       []models.PriceRule{{Type: models.DiscountPercentage, Percent: 20}},
+	})
+
+	tests := []struct {
+		name     string
+		at       time.Time
+		expected int64
+	}{
+		{"before the window", pricingStart.Add(-time.Second), 100000},
+		{"at the start", pricingStart, 80000},
+		{"at the end", until, 100000},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			price, err := service.ResolvePrice(ctx, "product-1", tt.at, "", 1)
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, price.PriceMinor)
+		})
+	}
+}
+
+func TestPricingService_ResolvePrice_TiersAndQuantity(t *testing.T) {
+	service := createTestPricingService(t)
+	ctx := context.Background()
+
+	createPriceList(t, service, &models.PriceList{
+		PriceListID: "vip",
+		Tiers:       []string{"VIP"},
+		Rules: []models.PriceRule{
+			{Type: models.DiscountPercentage, Percent: 5},
+			{Type: models.DiscountPercentage, Percent: 15, MinQuantity: 10},
+		},
+	})
+
+	price, err := service.ResolvePrice(ctx, "product-1", pricingStart, "STANDARD", 10)
+	require.NoError(t, err)
+	assert.Nil(t, price.Rule)
+
+	price, err = service.ResolvePrice(ctx, "product-1", pricingStart, "VIP", 2)
+	require.NoError(t, err)
+	assert.Equal(t, int64(95000), price.PriceMinor)
+
+	price, err = service.ResolvePrice(ctx, "product-1", pricingStart, "VIP", 10)
+	require.NoError(t, err)
+	assert.Equal(t, int64(85000), price.PriceMinor)
+	assert.Equal(t, int64(850000), price.TotalMinor)
+}
+
+func TestPricingService_ResolvePrice_FixedDiscountCurrency(t *testing.T) {
+	service := createTestPricingService(t)
+	ctx := context.Background()
+
+	createPriceList(t, service, &models.PriceList{
+		PriceListID: "euro-deal",
+		Rules:       []models.PriceRule{{Type: models.DiscountFixed, AmountMinor: 9000, Currency: "EUR"}},
+	})
+
+	price, err := service.ResolvePrice(ctx, "product-1", pricingStart, "", 1)
+	require.NoError(t, err)
+	assert.Nil(t, price.Rule, "fixed discounts only apply to prices in their currency")
+
+	price, err = service.ResolvePrice(ctx, "product-2", pricingStart, "", 1)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), price.PriceMinor, "discounts never exceed the price")
+	assert.Equal(t, int64(5000), price.Rule.DiscountMinor)
+}
+
+func TestPricingService_ResolvePrice_Errors(t *testing.T) {
+	service := createTestPricingService(t)
+	ctx := context.Background()
+
+	_, err := service.ResolvePrice(ctx, "product-1", pricingStart, "", 0)
+	assert.ErrorIs(t, err, ErrValidation)
+
+	_, err = service.ResolvePrice(ctx, "missing", pricingStart, "", 1)
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	_, err = service.ResolvePrice(ctx, "product-3", pricingStart, "", 1)
+	assert.ErrorIs(t, err, ErrInactive)
+}
+
+func TestPricingService_ValidatePriceList(t *testing.T) {
+	until := pricingStart.Add(-time.Hour)
+
+	tests := []struct {
+		name      string
+		priceList models.PriceList
+		errorMsg  string
+	}{
+		{
+			name:      "missing name",
+			priceList: models.PriceList{PriceListID: "list", ValidFrom: pricingStart},
+			errorMsg:  "price list name is required",
+		},
+		{
+			name:      "window ends before it starts",
+			priceList: models.PriceList{PriceListID: "list", Name: "List", ValidFrom: pricingStart, ValidUntil: &until},
+			errorMsg:  "validUntil must be after validFrom",
+		},
+		{
+			name:      "no rules",
+			priceList: models.PriceList{PriceListID: "list", Name: "List", ValidFrom: pricingStart},
+			errorMsg:  "price list must have at least one rule",
+		},
+		{
+			name: "percentage above 100",
+			priceList: models.PriceList{PriceListID: "list", Name: "List", ValidFrom: pricingStart,
+				Rules: []models.PriceRule{{Type: models.DiscountPercentage, Percent: 150}}},
+			errorMsg: "rules[0]: percent must be greater than 0 and at most 100",
+		},
+		{
+			name: "fixed discount without currency",
+			priceList: models.PriceList{PriceListID: "list", Name: "List", ValidFrom: pricingStart,
+				Rules: []models.PriceRule{{Type: models.DiscountFixed, AmountMinor: 100}}},
+			errorMsg: `rules[0]: currency "" is not a supported ISO 4217 code`,
+		},
+		{
+			name: "product and category",
+			priceList: models.PriceList{PriceListID: "list", Name: "List", ValidFrom: pricingStart,
+				Rules: []models.PriceRule{{ProductID: "product-1", Category: "electronics", Type: models.DiscountPercentage, Percent: 10}}},
+			errorMsg: "rules[0]: productId and category cannot both be set",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validatePriceList(&tt.priceList)
+
+			require.Error(t, err)
+			assert.Equal(t, tt.errorMsg, err.Error())
+		})
+	}
+}
+
+func TestPricingService_UpdatePriceList_Version(t *testing.T) {
+	service := createTestPricingService(t)
+	ctx := context.Background()
+
+	priceList := &models.PriceList{
+		PriceListID: "summer",
+		Rules:       []models.PriceRule{{Type: models.DiscountPercentage, Percent: 10}},
+	}
+	createPriceList(t, service, priceList)
+
+	stale := int64(7)
+	update := *priceList
+	_, err := service.UpdatePriceList(ctx, "summer", &update, &stale)
+	assert.ErrorIs(t, err, ErrPreconditionFailed)
+
+	current := int64(1)
+	update.Priority = 3
+	updated, err := service.UpdatePriceList(ctx, "summer", &update, &current)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), updated.Version)
+	assert.Equal(t, 3, updated.Priority)
+}
@@ -0,0 +1,73 @@
+package services
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/product-api-v2/internal/models"
+)
+
+// Probe and dependency states
+const (
+	ProbeStatusOK       = "ok"
+	ProbeStatusNotReady = "not_ready"
+
+	DependencyHealthy   = "healthy"
+	DependencyUnhealthy = "unhealthy"
+	DependencyEnabled   = "enabled"
+)
+
+// defaultProbeTimeout bounds a dependency check when no timeout is configured
+const defaultProbeTimeout = 2 * time.Second
+
+// readiness tracks whether the process should receive traffic. It becomes
+// ready once startup has finished and stops being ready when shutdown begins,
+// so load balancers drain connections before the listener closes.
+type readiness struct {
+	started  atomic.Bool
+	draining atomic.Bool
+}
+
+// checks returns the lifecycle checks and whether they all pass
+func (r *readiness) checks() (map[string]string, bool) {
+	checks := map[string]string{"startup": "complete", "shutdown": "not_started"}
+	ready := true
+
+	if !r.started.Load() {
+		checks["startup"] = "in_progress"
+		ready = false
+	}
+	if r.draining.Load() {
+		checks["shutdown"] = "draining"
+		ready = false
+	}
+	return checks, ready
+}
+
+// probeContext bounds a probe query by timeout, or by defaultProbeTimeout
+// when none is configured
+func probeContext(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		timeout = defaultProbeTimeout
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// checkDependency runs check under timeout and reports the outcome with its latency
+func checkDependency(ctx context.Context, timeout time.Duration, check func(context.Context) error) models.DependencyHealth {
+	ctx, cancel := probeContext(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := check(ctx)
+	health := models.DependencyHealth{
+		Status:    DependencyHealthy,
+		LatencyMs: float64(time.Since(start).Microseconds()) / 1000,
+	}
+	if err != nil {
+		health.Status = DependencyUnhealthy
+		health.Error = err.Error()
+	}
+	return health
+}
@@ -1,305 +1,1034 @@
-package services
-
-import (
-	"context"
-	"fmt"
-	"math/rand"
-	"time"
-
-	"github.com/product-api-v2/configs"
-	"github.com/product-api-v2/internal/models"
-	"github.com/product-api-v2/internal/repository"
-	"github.com/sirupsen/logrus"
-)
-
-// ProductService handles business logic for products
-type ProductService struct {
-	repo   repository.ProductRepository
-	config *configs.Config
-	logger *logrus.Logger
-	
-	// Metrics
-	startTime time.Time
-	requests  int64
-	errors    int64
-}
-
-// NewProductService creates a new product service
-func NewProductService(repo repository.ProductRepository, config *configs.Config, logger *logrus.Logger) *ProductService {
-	return &ProductService{
-		repo:      repo,
-		config:    config,
-		logger:    logger,
-		startTime: time.Now(),
-	}
-}
-
-// GetProduct retrieves a product by ID with business logic and error simulation
-func (s *ProductService) GetProduct(ctx context.Context, productID string) (*models.Product, error) {
-	s.requests++
-	
-	logger := s.logger.WithFields(logrus.Fields{
-		"operation": "GetProduct",
-		"productId": productID,
-		"requestId": ctx.Value("requestId"),
-	})
-	
-	logger.Info("🔍 Starting product lookup")
-	
-	// Simulate latency for testing if enabled
-	if s.config.Features.SimulateLatency {
-		delay := time.Duration(rand.Intn(s.config.Features.MaxLatencyMs)+50) * time.Millisecond
-		logger.WithField("latency", delay).Info("💤 Simulating latency")
-		time.Sleep(delay)
-	}
-	
-	// Simulate errors for testing if enabled
-	if s.config.Features.SimulateErrors && rand.Float64() < s.config.Features.ErrorRate {
-		s.errors++
-		logger.Error("💥 Simulating error for testing")
-		return nil, fmt.Errorf("simulated error for testing")
-	}
-	
-	// Special case to always return error (for testing)
-	if productID == "product-error" {
-		s.errors++
-		logger.WithField("reason", "test_product").Error("💥 Test product error")
-		return nil, fmt.Errorf("this product always returns an error")
-	}
-	
-	// Get product from repository
-	product, err := s.repo.GetByID(ctx, productID)
-	if err != nil {
-		if err == repository.ErrProductNotFound {
-			logger.WithField("reason", "not_found").Warn("⚠️ Product not found")
-			return nil, fmt.Errorf("product with ID %s not found", productID)
-		}
-		s.errors++
-		logger.WithError(err).Error("💥 Repository error")
-		return nil, fmt.Errorf("failed to retrieve product: %w", err)
-	}
-	
-	// Business logic: Check if product is active
-	if !product.Active {
-		logger.WithField("reason", "inactive").Warn("⚠️ Product is inactive")
-		return nil, fmt.Errorf("product %s is not available", productID)
-	}
-	
-	logger.WithFields(logrus.Fields{
-		"name":     product.Name,
-		"price":    product.Price,
-		"category": product.Category,
-	}).Info("✅ Product retrieved successfully")
-	
-	return product, nil
-}
-
-// GetProducts retrieves all products with filtering and pagination
-func (s *ProductService) GetProducts(ctx context.Context, filters repository.ProductFilters) (*models.ProductCatalogResponse, error) {
-	s.requests++
-	
-	logger := s.logger.WithFields(logrus.Fields{
-		"operation": "GetProducts",
-		"filters":   fmt.Sprintf("%+v", filters),
-		"requestId": ctx.Value("requestId"),
-	})
-	
-	logger.Info("📋 Getting product catalog")
-	
-	// Get products from repository
-	products, err := s.repo.GetAll(ctx, filters)
-	if err != nil {
-		s.errors++
-		logger.WithError(err).Error("💥 Failed to get products")
-		return nil, fmt.Errorf("failed to retrieve products: %w", err)
-	}
-	
-	// Get total count for pagination
-	totalCount, err := s.repo.Count(ctx, repository.ProductFilters{
-		Category: filters.Category,
-		Active:   filters.Active,
-		MinPrice: filters.MinPrice,
-		MaxPrice: filters.MaxPrice,
-	})
-	if err != nil {
-		logger.WithError(err).Warn("⚠️ Failed to get total count")
-		totalCount = len(products) // Fallback to current page count
-	}
-	
-	// Convert to summary format (exclude sensitive data)
-	summaries := make([]models.ProductSummary, 0, len(products))
-	for _, product := range products {
-		// Only include active products in listings
-		if product.Active {
-			summaries = append(summaries, models.ProductSummary{
-				ProductID: product.ProductID,
-				Name:      product.Name,
-				Price:     product.Price,
-			})
-		}
-	}
-	
-	response := &models.ProductCatalogResponse{
-		Products: summaries,
-		Total:    totalCount,
-	}
-	
-	// Include pagination info if applicable
-	if filters.PageSize > 0 {
-		response.Page = filters.Page
-		response.PageSize = filters.PageSize
-	}
-	
-	logger.WithFields(logrus.Fields{
-		"count": len(summaries),
-		"total": totalCount,
-	}).Info("✅ Product catalog retrieved successfully")
-	
-	return response, nil
-}
-
-// CreateProduct adds a new product with validation
-func (s *ProductService) CreateProduct(ctx context.Context, product *models.Product) error {
-	s.requests++
-	
-	logger := s.logger.WithFields(logrus.Fields{
-		"operation": "CreateProduct",
-		"productId": product.ProductID,
-		"requestId": ctx.Value("requestId"),
-	})
-	
-	logger.Info("➕ Creating new product")
-	
-	// Business validation
-	if err := s.validateProduct(product); err != nil {
-		s.errors++
-		logger.WithError(err).Error("💥 Product validation failed")
-		return fmt.Errorf("validation failed: %w", err)
-	}
-	
-	// Create in repository
-	if err := s.repo.Create(ctx, product); err != nil {
-		if err == repository.ErrProductExists {
-			logger.WithField("reason", "already_exists").Warn("⚠️ Product already exists")
-			return fmt.Errorf("product %s already exists", product.ProductID)
-		}
-		s.errors++
-		logger.WithError(err).Error("💥 Failed to create product")
-		return fmt.Errorf("failed to create product: %w", err)
-	}
-	
-	logger.WithFields(logrus.Fields{
-		"name":  product.Name,
-		"price": product.Price,
-	}).Info("✅ Product created successfully")
-	
-	return nil
-}
-
-// GetHealthStatus returns the service health status
-func (s *ProductService) GetHealthStatus(ctx context.Context) (*models.HealthResponse, error) {
-	logger := s.logger.WithFields(logrus.Fields{
-		"operation": "HealthCheck",
-		"requestId": ctx.Value("requestId"),
-	})
-	
-	// Check repository health
-	dependencies := make(map[string]string)
-	if err := s.repo.HealthCheck(ctx); err != nil {
-		dependencies["repository"] = "unhealthy: " + err.Error()
-		logger.WithError(err).Error("💥 Repository health check failed")
-	} else {
-		dependencies["repository"] = "healthy"
-	}
-	
-	// Calculate metrics
-	uptime := time.Since(s.startTime)
-	
-	metrics := map[string]int{
-		"total_requests": int(s.requests),
-		"total_errors":   int(s.errors),
-		"products_count": s.getProductCount(ctx),
-	}
-	
-	if s.requests > 0 {
-		metrics["error_rate_percent"] = int((s.errors * 100) / s.requests)
-	}
-	
-	status := "healthy"
-	if s.errors > 0 && s.requests > 0 && (s.errors*100/s.requests) > 10 {
-		status = "degraded"
-	}
-	
-	response := &models.HealthResponse{
-		Status:       status,
-		Service:      "product-api",
-		Version:      s.config.Server.Version,
-		Timestamp:    time.Now(),
-		Uptime:       uptime.String(),
-		Environment:  s.config.Server.Environment,
-		Metrics:      metrics,
-		Dependencies: dependencies,
-	}
-	
-	logger.WithFields(logrus.Fields{
-		"status":   status,
-		"uptime":   uptime,
-		"requests": s.requests,
-		"errors":   s.errors,
-	}).Info("🏥 Health check completed")
-	
-	return response, nil
-}
-
-// validateProduct performs business validation on product data
-func (s *ProductService) validateProduct(product *models.Product) error {
-	if product.ProductID == "" {
-		return fmt.Errorf("product ID is required")
-	}
-	
-	if product.Name == "" {
-		return fmt.Errorf("product name is required")
-	}
-	
-	if product.Price <= 0 {
-		return fmt.Errorf("product price must be greater than 0")
-	}
-	
-	if len(product.Name) > 255 {
-		return fmt.Errorf("product name cannot exceed 255 characters")
-	}
-	
-	return nil
-}
-
-// getProductCount returns the total number of products for metrics
-func (s *ProductService) getProductCount(ctx context.Context) int {
-	count, err := s.repo.Count(ctx, repository.ProductFilters{})
-	if err != nil {
-		s.logger.WithError(err).Warn("⚠️ Failed to get product count for metrics")
-		return 0
-	}
-	return count
-}
-
-// GetMetrics returns service metrics
-func (s *ProductService) GetMetrics() map[string]interface{} {
-	uptime := time.Since(s.startTime)
-	
-	metrics := map[string]interface{}{
-		"service":         "product-api",
-		"version":         s.config.Server.Version,
-		"environment":     s.config.Server.Environment,
-		"uptime_seconds":  int(uptime.Seconds()),
-		"total_requests":  s.requests,
-		"total_errors":    s.errors,
-		"timestamp":       time.Now(),
-	}
-	
-	if s.requests > 0 {
-		metrics["error_rate"] = float64(s.errors) / float64(s.requests)
-		metrics["success_rate"] = 1.0 - (float64(s.errors) / float64(s.requests))
-	}
-	
-	return metrics
-}
\ No newline at end of file
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"slices"
+	"time"
+
+	"github.com/product-api-v2/configs"
+	"github.com/product-api-v2/internal/metrics"
+	"github.com/product-api-v2/internal/models"
+	"github.com/product-api-v2/internal/repository"
+	"github.com/sirupsen/logrus"
+)
+
+// ProductService handles business logic for products
+type ProductService struct {
+	repo       repository.ProductRepository
+	categories repository.CategoryRepository       // nil leaves categories free text
+	history    repository.ProductHistoryRepository // nil records no history
+	outbox     repository.OutboxRepository         // nil publishes no events
+	webhooks   *WebhookService                     // nil sends no webhooks
+	transactor repository.Transactor               // nil writes without transactions
+	config     *configs.Config
+	logger     *logrus.Logger
+	
+	// Metrics
+	startTime time.Time
+	stats     *operationStats
+	
+	readiness readiness
+}
+
+// NewProductService creates a new product service. With categories set,
+// products can only be assigned to its active categories and category
+// filters include subcategories. With history set, every write made
+// through the service is recorded in it, with outbox set a ProductChanged
+// event is queued for it, and with webhooks set the subscribed webhooks
+// are notified. The transactor commits a write together with its history
+// entry, event and webhook deliveries.
+func NewProductService(repo repository.ProductRepository, categories repository.CategoryRepository, history repository.ProductHistoryRepository, outbox repository.OutboxRepository, webhooks *WebhookService, transactor repository.Transactor, config *configs.Config, logger *logrus.Logger) *ProductService {
+	return &ProductService{
+		repo:       repo,
+		categories: categories,
+		history:    history,
+		outbox:     outbox,
+		webhooks:   webhooks,
+		transactor: transactor,
+		config:     config,
+		logger:     logger,
+		startTime:  time.Now(),
+		stats:      newOperationStats(),
+	}
+}
+
+// GetProduct retrieves a product by ID with business logic and error
+// simulation. A variant comes with the fields it inherits from its parent
+// and a parent with its active variants.
+func (s *ProductService) GetProduct(ctx context.Context, productID string) (*models.Product, error) {
+	product, err := s.getProduct(ctx, productID, func() (*models.Product, error) {
+		return s.repo.GetByID(ctx, productID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	
+	if err := s.attachVariants(ctx, []*models.Product{product}, repository.VisibilityPublic, nil); err != nil {
+		s.stats.failure("GetProduct")
+		return nil, repositoryError(err, "failed to retrieve variants")
+	}
+	return product, nil
+}
+
+// getProduct applies the lookup rules of GetProduct to the product load returns
+func (s *ProductService) getProduct(ctx context.Context, productID string, load func() (*models.Product, error)) (*models.Product, error) {
+	s.stats.request("GetProduct")
+	
+	logger := s.logger.WithFields(logrus.Fields{
+		"operation": "GetProduct",
+		"productId": productID,
+		"requestId": ctx.Value("requestId"),
+	})
+	
+	logger.Info("🔍 Starting product lookup")
+	
+	// Simulate latency for testing if enabled
+	if s.config.Features.SimulateLatency {
+		delay := time.Duration(rand.Intn(s.config.Features.MaxLatencyMs)+50) * time.Millisecond
+		logger.WithField("latency", delay).Info("💤 Simulating latency")
+		time.Sleep(delay)
+	}
+	
+	// Simulate errors for testing if enabled
+	if s.config.Features.SimulateErrors && rand.Float64() < s.config.Features.ErrorRate {
+		s.stats.failure("GetProduct")
+		logger.Error("💥 Simulating error for testing")
+		return nil, newError(ErrInternal, "internal_error", nil, "simulated error for testing")
+	}
+	
+	// Special case to always return error (for testing)
+	if productID == "product-error" {
+		s.stats.failure("GetProduct")
+		logger.WithField("reason", "test_product").Error("💥 Test product error")
+		return nil, newError(ErrInternal, "internal_error", nil, "this product always returns an error")
+	}
+	
+	// Get product from repository, completing variants from their parent
+	product, err := load()
+	if err == nil {
+		err = inheritFromParents(ctx, s.repo, product)
+	}
+	if err != nil {
+		if errors.Is(err, repository.ErrProductNotFound) {
+			logger.WithField("reason", "not_found").Warn("⚠️ Product not found")
+			return nil, newError(ErrNotFound, "product_not_found", nil, "product with ID %s not found", productID)
+		}
+		s.stats.failure("GetProduct")
+		logger.WithError(err).Error("💥 Repository error")
+		return nil, repositoryError(err, "failed to retrieve product")
+	}
+	
+	// Business logic: Check if product is active
+	if !product.Active {
+		logger.WithField("reason", "inactive").Warn("⚠️ Product is inactive")
+		return nil, newError(ErrInactive, "product_unavailable", nil, "product %s is not available", productID)
+	}
+	
+	logger.WithFields(logrus.Fields{
+		"name":     product.Name,
+		"price":    product.Price,
+		"category": product.Category,
+	}).Info("✅ Product retrieved successfully")
+	
+	return product, nil
+}
+
+// GetProductsByIDs looks up several products in a single repository call.
+// Duplicate IDs are collapsed; found, missing and inactive products are
+// reported separately so callers can enrich an order in one round trip.
+func (s *ProductService) GetProductsByIDs(ctx context.Context, productIDs []string) (*models.ProductBatchResponse, error) {
+	s.stats.request("GetProductsByIDs")
+
+	logger := s.logger.WithFields(logrus.Fields{
+		"operation": "GetProductsByIDs",
+		"count":     len(productIDs),
+		"requestId": ctx.Value("requestId"),
+	})
+
+	logger.Info("🔍 Starting batch product lookup")
+
+	// Simulate latency for testing if enabled
+	if s.config.Features.SimulateLatency {
+		delay := time.Duration(rand.Intn(s.config.Features.MaxLatencyMs)+50) * time.Millisecond
+		logger.WithField("latency", delay).Info("💤 Simulating latency")
+		time.Sleep(delay)
+	}
+
+	// Simulate errors for testing if enabled
+	if s.config.Features.SimulateErrors && rand.Float64() < s.config.Features.ErrorRate {
+		s.stats.failure("GetProductsByIDs")
+		logger.Error("💥 Simulating error for testing")
+		return nil, newError(ErrInternal, "internal_error", nil, "simulated error for testing")
+	}
+
+	uniqueIDs := make([]string, 0, len(productIDs))
+	seen := make(map[string]bool, len(productIDs))
+	for _, productID := range productIDs {
+		if productID == "" || seen[productID] {
+			continue
+		}
+		seen[productID] = true
+		uniqueIDs = append(uniqueIDs, productID)
+	}
+
+	products, err := s.repo.GetByIDs(ctx, uniqueIDs)
+	if err != nil {
+		s.stats.failure("GetProductsByIDs")
+		logger.WithError(err).Error("💥 Repository error")
+		return nil, repositoryError(err, "failed to retrieve products")
+	}
+
+	if err := inheritFromParents(ctx, s.repo, products...); err != nil {
+		s.stats.failure("GetProductsByIDs")
+		logger.WithError(err).Error("💥 Repository error")
+		return nil, repositoryError(err, "failed to retrieve parent products")
+	}
+
+	byID := make(map[string]*models.Product, len(products))
+	for _, product := range products {
+		byID[product.ProductID] = product
+	}
+
+	response := &models.ProductBatchResponse{
+		Products: make([]*models.Product, 0, len(products)),
+		Missing:  []string{},
+		Inactive: []string{},
+	}
+
+	for _, productID := range uniqueIDs {
+		product, found := byID[productID]
+		switch {
+		case !found:
+			response.Missing = append(response.Missing, productID)
+		case !product.Active:
+			response.Inactive = append(response.Inactive, productID)
+		default:
+			response.Products = append(response.Products, product)
+		}
+	}
+
+	logger.WithFields(logrus.Fields{
+		"found":    len(response.Products),
+		"missing":  len(response.Missing),
+		"inactive": len(response.Inactive),
+	}).Info("✅ Batch product lookup completed")
+
+	return response, nil
+}
+
+// GetProducts retrieves all products with filtering and pagination
+func (s *ProductService) GetProducts(ctx context.Context, filters repository.ProductFilters) (*models.ProductCatalogResponse, error) {
+	s.stats.request("GetProducts")
+	
+	logger := s.logger.WithFields(logrus.Fields{
+		"operation": "GetProducts",
+		"filters":   fmt.Sprintf("%+v", filters),
+		"requestId": ctx.Value("requestId"),
+	})
+	
+	logger.Info("📋 Getting product catalog")
+	
+	order := filters.Sort
+	if len(order) == 0 {
+		order, _ = repository.ParseProductSort("")
+	}
+	if filters.Cursor != nil && filters.PageSize == 0 {
+		filters.PageSize = defaultPageSize
+	}
+	if filters.Visibility == "" {
+		filters.Visibility = repository.VisibilityPublic
+	}
+	if err := validatePriceFilters(&filters); err != nil {
+		s.stats.failure("GetProducts")
+		return nil, validationError(err)
+	}
+	if err := s.expandCategory(ctx, &filters); err != nil {
+		s.stats.failure("GetProducts")
+		logger.WithError(err).Error("💥 Failed to resolve category filter")
+		return nil, err
+	}
+	paging := pagination{page: filters.Page, pageSize: filters.PageSize, cursor: filters.Cursor}
+	
+	// Listings collapse variants under their parent
+	filters.TopLevel = true
+	fields := filters.Fields
+	if len(fields) == 0 {
+		fields = models.ProductViews[models.ViewSummary]
+	}
+	withVariants := slices.Contains(fields, "variants")
+	
+	// Get products from repository
+	query := filters
+	query.PageSize = paging.fetchSize()
+	if withVariants && len(query.Fields) > 0 {
+		query.Fields = withProductFields(query.Fields, "active")
+	}
+	products, err := s.repo.GetAll(ctx, query)
+	if err != nil {
+		s.stats.failure("GetProducts")
+		logger.WithError(err).Error("💥 Failed to get products")
+		return nil, repositoryError(err, "failed to retrieve products")
+	}
+	
+	// Get total count for pagination; Count ignores paging and sorting
+	totalCount, err := s.repo.Count(ctx, filters)
+	if err != nil {
+		logger.WithError(err).Warn("⚠️ Failed to get total count")
+		totalCount = len(products) // Fallback to current page count
+	}
+	
+	products, hasPrev, hasNext := pageWindow(paging, products, totalCount)
+	
+	if withVariants {
+		if err := s.attachVariants(ctx, products, filters.Visibility, query.Fields); err != nil {
+			s.stats.failure("GetProducts")
+			logger.WithError(err).Error("💥 Failed to get variants")
+			return nil, repositoryError(err, "failed to retrieve variants")
+		}
+	}
+	
+	// Listings show the summary view unless filters select fields
+	summaries := make([]models.ProductView, 0, len(products))
+	for _, product := range products {
+		summaries = append(summaries, models.ProductView{Product: product, Fields: fields})
+	}
+	
+	response := &models.ProductCatalogResponse{
+		Products: summaries,
+		Total:    totalCount,
+	}
+	
+	// Include pagination info if applicable
+	if filters.PageSize > 0 {
+		response.Page = filters.Page
+		response.PageSize = filters.PageSize
+	}
+	
+	if len(products) > 0 {
+		if hasNext {
+			response.NextCursor = repository.ProductCursor(products[len(products)-1], order, false)
+		}
+		if hasPrev {
+			response.PrevCursor = repository.ProductCursor(products[0], order, true)
+		}
+	}
+	
+	logger.WithFields(logrus.Fields{
+		"count": len(summaries),
+		"total": totalCount,
+	}).Info("✅ Product catalog retrieved successfully")
+	
+	return response, nil
+}
+
+// CreateProduct adds a new product with validation
+func (s *ProductService) CreateProduct(ctx context.Context, product *models.Product) error {
+	s.stats.request("CreateProduct")
+	
+	logger := s.logger.WithFields(logrus.Fields{
+		"operation": "CreateProduct",
+		"productId": product.ProductID,
+		"requestId": ctx.Value("requestId"),
+	})
+	
+	logger.Info("➕ Creating new product")
+	
+	if err := s.checkHierarchy(ctx, logger, product); err != nil {
+		s.stats.failure("CreateProduct")
+		logger.WithError(err).Error("💥 Product hierarchy check failed")
+		return err
+	}
+	
+	// Business validation
+	if err := s.validateProduct(ctx, product); err != nil {
+		s.stats.failure("CreateProduct")
+		logger.WithError(err).Error("💥 Product validation failed")
+		return err
+	}
+	prepareProduct(product)
+	
+	// Create in repository
+	err := s.commit(ctx, logger, "CreateProduct", func(ctx context.Context) ([]*models.ProductChange, error) {
+		if err := s.repo.Create(ctx, product); err != nil {
+			if errors.Is(err, repository.ErrProductExists) {
+				logger.WithField("reason", "already_exists").Warn("⚠️ Product already exists")
+				return nil, newError(ErrConflict, "product_exists", nil, "product %s already exists", product.ProductID)
+			}
+			if errors.Is(err, repository.ErrSKUExists) {
+				logger.WithField("reason", "sku_exists").Warn("⚠️ SKU already taken")
+				return nil, skuExistsError(product.SKU)
+			}
+			s.stats.failure("CreateProduct")
+			logger.WithError(err).Error("💥 Failed to create product")
+			return nil, repositoryError(err, "failed to create product")
+		}
+		return []*models.ProductChange{newProductChange(ctx, models.AuditCreate, nil, product)}, nil
+	})
+	if err != nil {
+		return err
+	}
+	
+	logger.WithFields(logrus.Fields{
+		"name":  product.Name,
+		"price": product.Price,
+	}).Info("✅ Product created successfully")
+	
+	return nil
+}
+
+// UpdateProduct replaces an existing product with the given representation.
+// If ifMatch is set the product must still be at that version.
+func (s *ProductService) UpdateProduct(ctx context.Context, productID string, product *models.Product, ifMatch *int64) (*models.Product, error) {
+	s.stats.request("UpdateProduct")
+
+	logger := s.logger.WithFields(logrus.Fields{
+		"operation": "UpdateProduct",
+		"productId": productID,
+		"requestId": ctx.Value("requestId"),
+	})
+
+	logger.Info("✏️ Updating product")
+
+	if product.ProductID == "" {
+		product.ProductID = productID
+	}
+
+	if product.ProductID != productID {
+		s.stats.failure("UpdateProduct")
+		logger.WithField("bodyProductId", product.ProductID).Error("💥 Product ID mismatch")
+		return nil, validationError(fmt.Errorf("product ID in body does not match %s", productID))
+	}
+
+	if err := s.checkHierarchy(ctx, logger, product); err != nil {
+		s.stats.failure("UpdateProduct")
+		logger.WithError(err).Error("💥 Product hierarchy check failed")
+		return nil, err
+	}
+
+	if err := s.validateProduct(ctx, product); err != nil {
+		s.stats.failure("UpdateProduct")
+		logger.WithError(err).Error("💥 Product validation failed")
+		return nil, err
+	}
+	prepareProduct(product)
+
+	existing, err := s.getExistingProduct(ctx, logger, "UpdateProduct", productID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkVersion(ifMatch, existing.Version); err != nil {
+		logger.WithField("version", existing.Version).Warn("⚠️ Stale product version")
+		return nil, err
+	}
+
+	product.CreatedAt = existing.CreatedAt
+	if err := s.commitUpdate(ctx, logger, "UpdateProduct", models.AuditUpdate, existing, product, ifMatch); err != nil {
+		return nil, err
+	}
+
+	logger.WithFields(logrus.Fields{
+		"name":  product.Name,
+		"price": product.Price,
+	}).Info("✅ Product updated successfully")
+
+	return product, nil
+}
+
+// PatchProduct applies a JSON merge patch to an existing product. The
+// product ID, version and timestamps are read-only; the result is validated
+// like a full update before it is stored.
+func (s *ProductService) PatchProduct(ctx context.Context, productID string, patch map[string]interface{}, ifMatch *int64) (*models.Product, error) {
+	s.stats.request("PatchProduct")
+
+	logger := s.logger.WithFields(logrus.Fields{
+		"operation": "PatchProduct",
+		"productId": productID,
+		"requestId": ctx.Value("requestId"),
+	})
+
+	logger.Info("🩹 Patching product")
+
+	if id, ok := patch["productId"]; ok && id != productID {
+		s.stats.failure("PatchProduct")
+		logger.Error("💥 Attempt to change product ID")
+		return nil, validationError(fmt.Errorf("product ID cannot be changed"))
+	}
+	delete(patch, "version")
+	delete(patch, "createdAt")
+	delete(patch, "updatedAt")
+	settlePricePatch(patch)
+
+	existing, err := s.getExistingProduct(ctx, logger, "PatchProduct", productID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkVersion(ifMatch, existing.Version); err != nil {
+		logger.WithField("version", existing.Version).Warn("⚠️ Stale product version")
+		return nil, err
+	}
+
+	var product models.Product
+	if err := mergePatchInto(existing, patch, &product); err != nil {
+		s.stats.failure("PatchProduct")
+		logger.WithError(err).Error("💥 Invalid patch document")
+		return nil, validationError(fmt.Errorf("invalid patch document: %w", err))
+	}
+	product.ProductID = productID
+	product.Version = existing.Version
+	product.CreatedAt = existing.CreatedAt
+
+	if err := s.checkHierarchy(ctx, logger, &product); err != nil {
+		s.stats.failure("PatchProduct")
+		logger.WithError(err).Error("💥 Product hierarchy check failed")
+		return nil, err
+	}
+
+	if err := s.validateProduct(ctx, &product); err != nil {
+		s.stats.failure("PatchProduct")
+		logger.WithError(err).Error("💥 Product validation failed")
+		return nil, err
+	}
+	prepareProduct(&product)
+
+	if err := s.commitUpdate(ctx, logger, "PatchProduct", models.AuditUpdate, existing, &product, ifMatch); err != nil {
+		return nil, err
+	}
+
+	logger.WithField("fields", len(patch)).Info("✅ Product patched successfully")
+
+	return &product, nil
+}
+
+// SetProductActive activates or deactivates a product. Setting the flag to
+// its current value is a no-op.
+func (s *ProductService) SetProductActive(ctx context.Context, productID string, active bool, ifMatch *int64) (*models.Product, error) {
+	s.stats.request("SetProductActive")
+
+	logger := s.logger.WithFields(logrus.Fields{
+		"operation": "SetProductActive",
+		"productId": productID,
+		"active":    active,
+		"requestId": ctx.Value("requestId"),
+	})
+
+	product, err := s.getExistingProduct(ctx, logger, "SetProductActive", productID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkVersion(ifMatch, product.Version); err != nil {
+		logger.WithField("version", product.Version).Warn("⚠️ Stale product version")
+		return nil, err
+	}
+
+	if product.Active == active {
+		logger.Info("ℹ️ Product already in requested state")
+		return product, nil
+	}
+
+	before := *product
+	product.Active = active
+	if err := s.commitUpdate(ctx, logger, "SetProductActive", models.AuditUpdate, &before, product, ifMatch); err != nil {
+		return nil, err
+	}
+
+	logger.Info("✅ Product state changed")
+
+	return product, nil
+}
+
+// DeleteProduct retires a product. By default the product is only
+// deactivated so existing orders can still reference it; hard removes the
+// document from the repository.
+func (s *ProductService) DeleteProduct(ctx context.Context, productID string, hard bool, ifMatch *int64) error {
+	if !hard {
+		_, err := s.SetProductActive(ctx, productID, false, ifMatch)
+		return err
+	}
+
+	s.stats.request("DeleteProduct")
+
+	logger := s.logger.WithFields(logrus.Fields{
+		"operation": "DeleteProduct",
+		"productId": productID,
+		"hard":      hard,
+		"requestId": ctx.Value("requestId"),
+	})
+
+	variants, err := s.repo.Count(ctx, repository.ProductFilters{ParentIDs: []string{productID}})
+	if err != nil {
+		s.stats.failure("DeleteProduct")
+		logger.WithError(err).Error("💥 Failed to count variants")
+		return repositoryError(err, "failed to count variants")
+	}
+	if variants > 0 {
+		logger.WithField("variants", variants).Warn("⚠️ Product has variants")
+		return newError(ErrConflict, "product_has_variants", nil, "product %s has %d variants; delete them first", productID, variants)
+	}
+
+	// The deleted state is kept in the history and the event
+	var existing *models.Product
+	if ifMatch != nil || s.recordsChanges() {
+		existing, err = s.getExistingProduct(ctx, logger, "DeleteProduct", productID)
+		if err != nil {
+			return err
+		}
+		if err := checkVersion(ifMatch, existing.Version); err != nil {
+			logger.WithField("version", existing.Version).Warn("⚠️ Stale product version")
+			return err
+		}
+	}
+
+	err = s.commit(ctx, logger, "DeleteProduct", func(ctx context.Context) ([]*models.ProductChange, error) {
+		if err := s.repo.Delete(ctx, productID); err != nil {
+			if errors.Is(err, repository.ErrProductNotFound) {
+				logger.WithField("reason", "not_found").Warn("⚠️ Product not found")
+				return nil, newError(ErrNotFound, "product_not_found", nil, "product with ID %s not found", productID)
+			}
+			s.stats.failure("DeleteProduct")
+			logger.WithError(err).Error("💥 Failed to delete product")
+			return nil, repositoryError(err, "failed to delete product")
+		}
+		if existing == nil {
+			return nil, nil
+		}
+		return []*models.ProductChange{newProductChange(ctx, models.AuditDelete, existing, nil)}, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	logger.Info("🗑️ Product deleted permanently")
+
+	return nil
+}
+
+// getExistingProduct loads a product regardless of its active flag, for
+// operations that manage the product rather than sell it
+func (s *ProductService) getExistingProduct(ctx context.Context, logger *logrus.Entry, operation, productID string) (*models.Product, error) {
+	product, err := s.repo.GetByID(ctx, productID)
+	if err != nil {
+		if errors.Is(err, repository.ErrProductNotFound) {
+			logger.WithField("reason", "not_found").Warn("⚠️ Product not found")
+			return nil, newError(ErrNotFound, "product_not_found", nil, "product with ID %s not found", productID)
+		}
+		s.stats.failure(operation)
+		logger.WithError(err).Error("💥 Repository error")
+		return nil, repositoryError(err, "failed to retrieve product")
+	}
+	return product, nil
+}
+
+// saveProduct stores an updated product and maps repository errors. The
+// product must carry the version it was read at.
+func (s *ProductService) saveProduct(ctx context.Context, logger *logrus.Entry, operation string, product *models.Product, ifMatch *int64) error {
+	if err := s.repo.Update(ctx, product); err != nil {
+		if errors.Is(err, repository.ErrProductNotFound) {
+			logger.WithField("reason", "not_found").Warn("⚠️ Product not found")
+			return newError(ErrNotFound, "product_not_found", nil, "product with ID %s not found", product.ProductID)
+		}
+		if errors.Is(err, repository.ErrVersionConflict) {
+			logger.WithField("reason", "version_conflict").Warn("⚠️ Product modified concurrently")
+			return versionConflictError(ifMatch)
+		}
+		if errors.Is(err, repository.ErrSKUExists) {
+			logger.WithField("reason", "sku_exists").Warn("⚠️ SKU already taken")
+			return skuExistsError(product.SKU)
+		}
+		s.stats.failure(operation)
+		logger.WithError(err).Error("💥 Failed to update product")
+		return repositoryError(err, "failed to update product")
+	}
+	return nil
+}
+
+// commitUpdate stores product, which was read as before, and records the
+// change as action. A new category is passed on to the product's variants.
+func (s *ProductService) commitUpdate(ctx context.Context, logger *logrus.Entry, operation, action string, before, product *models.Product, ifMatch *int64) error {
+	return s.commit(ctx, logger, operation, func(ctx context.Context) ([]*models.ProductChange, error) {
+		// A retried transaction starts again from the version that was read
+		product.Version = before.Version
+		if err := s.saveProduct(ctx, logger, operation, product, ifMatch); err != nil {
+			return nil, err
+		}
+		changes := []*models.ProductChange{newProductChange(ctx, action, before, product)}
+		if product.Category == before.Category {
+			return changes, nil
+		}
+		cascaded, err := s.cascadeCategory(ctx, logger, operation, product)
+		if err != nil {
+			return nil, err
+		}
+		return append(changes, cascaded...), nil
+	})
+}
+
+// GetHealthStatus returns the service health status
+func (s *ProductService) GetHealthStatus(ctx context.Context) (*models.HealthResponse, error) {
+	logger := s.logger.WithFields(logrus.Fields{
+		"operation": "HealthCheck",
+		"requestId": ctx.Value("requestId"),
+	})
+	
+	// Check repository health
+	repositoryHealth := checkDependency(ctx, s.config.Server.ProbeTimeout, s.repo.HealthCheck)
+	dependencies := map[string]models.DependencyHealth{"repository": repositoryHealth}
+	if repositoryHealth.Status != DependencyHealthy {
+		logger.WithField("error", repositoryHealth.Error).Error("💥 Repository health check failed")
+	}
+	
+	// Counts are bounded like the dependency check so that a slow database
+	// cannot hang the probe
+	countCtx, cancel := probeContext(ctx, s.config.Server.ProbeTimeout)
+	defer cancel()
+	
+	// Calculate metrics
+	uptime := time.Since(s.startTime)
+	
+	totals := s.stats.totals()
+	recent := s.stats.recent()
+	
+	metrics := map[string]int{
+		"total_requests":            int(totals.Requests),
+		"total_errors":              int(totals.Errors),
+		"recent_requests":           int(recent.Requests),
+		"recent_errors":             int(recent.Errors),
+		"recent_error_rate_percent": int(recent.ErrorRate() * 100),
+		"products_count":            s.getProductCount(countCtx),
+	}
+	
+	if totals.Requests > 0 {
+		metrics["error_rate_percent"] = int(totals.ErrorRate() * 100)
+	}
+	
+	if stats, ok := s.cacheStats(); ok {
+		metrics["cache_hits"] = int(stats.Hits)
+		metrics["cache_misses"] = int(stats.Misses)
+		metrics["cache_size"] = stats.Size
+		metrics["cache_hit_rate_percent"] = int(stats.HitRate * 100)
+		dependencies["cache"] = models.DependencyHealth{Status: DependencyEnabled}
+	}
+	
+	// Degradation reflects the recent error rate, not lifetime totals, so a
+	// service recovers once the errors stop
+	status := "healthy"
+	if repositoryHealth.Status != DependencyHealthy {
+		status = "unhealthy"
+	} else if s.stats.degraded() {
+		status = "degraded"
+	}
+	
+	_, ready := s.readiness.checks()
+	
+	response := &models.HealthResponse{
+		Status:       status,
+		Service:      "product-api",
+		Version:      s.config.Server.Version,
+		Timestamp:    time.Now(),
+		Uptime:       uptime.String(),
+		Environment:  s.config.Server.Environment,
+		Ready:        ready && repositoryHealth.Status == DependencyHealthy,
+		Metrics:      metrics,
+		Dependencies: dependencies,
+	}
+	
+	logger.WithFields(logrus.Fields{
+		"status":   status,
+		"uptime":   uptime,
+		"requests": totals.Requests,
+		"errors":   totals.Errors,
+		"recent":   recent,
+	}).Info("🏥 Health check completed")
+	
+	return response, nil
+}
+
+// MarkStarted records that startup has finished so readiness can pass
+func (s *ProductService) MarkStarted() {
+	s.readiness.started.Store(true)
+}
+
+// MarkDraining fails readiness from now on so load balancers stop sending
+// traffic before the server shuts down
+func (s *ProductService) MarkDraining() {
+	s.readiness.draining.Store(true)
+}
+
+// Liveness reports that the process is running. It performs no I/O so a slow
+// database never gets a healthy process restarted.
+func (s *ProductService) Liveness() *models.ProbeResponse {
+	return &models.ProbeResponse{
+		Status:    ProbeStatusOK,
+		Timestamp: time.Now(),
+	}
+}
+
+// Readiness reports whether the service should receive traffic: startup has
+// finished, shutdown has not begun and the repository answers within the
+// probe timeout
+func (s *ProductService) Readiness(ctx context.Context) (*models.ProbeResponse, bool) {
+	checks, ready := s.readiness.checks()
+	
+	repositoryHealth := checkDependency(ctx, s.config.Server.ProbeTimeout, s.repo.HealthCheck)
+	checks["repository"] = repositoryHealth.Status
+	if repositoryHealth.Status != DependencyHealthy {
+		ready = false
+		s.logger.WithFields(logrus.Fields{
+			"operation": "Readiness",
+			"requestId": ctx.Value("requestId"),
+			"error":     repositoryHealth.Error,
+		}).Warn("⚠️ Readiness check failed")
+	}
+	
+	status := ProbeStatusOK
+	if !ready {
+		status = ProbeStatusNotReady
+	}
+	
+	return &models.ProbeResponse{
+		Status:    status,
+		Checks:    checks,
+		Timestamp: time.Now(),
+	}, ready
+}
+
+// validateProduct performs business validation on product data. When the
+// service manages categories, the product's category must be an active one
+// of the tree, if a tree exists.
+func (s *ProductService) validateProduct(ctx context.Context, product *models.Product) error {
+	if err := ValidateProduct(product); err != nil {
+		return validationError(err)
+	}
+	
+	if s.categories == nil || product.Category == "" {
+		return nil
+	}
+	
+	category, err := s.categories.GetBySlug(ctx, product.Category)
+	if errors.Is(err, repository.ErrCategoryNotFound) {
+		return s.checkUnknownCategory(ctx, product.Category)
+	}
+	if err != nil {
+		return repositoryError(err, "failed to retrieve category")
+	}
+	if !category.Active {
+		return validationError(fmt.Errorf("category %s is not active", product.Category))
+	}
+	return nil
+}
+
+// checkUnknownCategory rejects a category outside the tree, unless there is
+// no tree at all. Without one, as in a memory run without category
+// fixtures, categories are free-form labels like before the tree existed.
+func (s *ProductService) checkUnknownCategory(ctx context.Context, slug string) error {
+	categories, err := s.categories.GetAll(ctx)
+	if err != nil {
+		return repositoryError(err, "failed to retrieve categories")
+	}
+	if len(categories) == 0 {
+		return nil
+	}
+	return validationError(fmt.Errorf("category %s does not exist", slug))
+}
+
+// expandCategory widens a category filter to the category's subtree. A
+// category outside the tree keeps matching exactly, so products with a
+// legacy category can still be listed.
+func (s *ProductService) expandCategory(ctx context.Context, filters *repository.ProductFilters) error {
+	if s.categories == nil || filters.Category == "" {
+		return nil
+	}
+	
+	categories, err := s.categories.GetAll(ctx)
+	if err != nil {
+		return repositoryError(err, "failed to retrieve categories")
+	}
+	if findCategory(categories, filters.Category) == nil {
+		return nil
+	}
+	
+	filters.Categories = categorySubtree(categories, filters.Category)
+	filters.Category = ""
+	return nil
+}
+
+// ValidateProduct applies the business rules every stored product must meet.
+// It is exported so fixture loading enforces the same rules as the API.
+// Prices are checked as they would be stored, but the product itself is
+// left unchanged. Variants need a SKU and may leave their name and price to
+// the parent.
+func ValidateProduct(original *models.Product) error {
+	product := *original
+	
+	if product.ProductID == "" {
+		return fmt.Errorf("product ID is required")
+	}
+	
+	if product.Name == "" && !product.IsVariant() {
+		return fmt.Errorf("product name is required")
+	}
+	
+	if err := product.CheckPrice(); err != nil {
+		return err
+	}
+	product.NormalizePrice()
+	
+	if product.PriceMinor < 0 || product.PriceMinor == 0 && !product.IsVariant() {
+		return fmt.Errorf("product price must be greater than 0")
+	}
+	
+	if product.IsVariant() {
+		if product.ParentID == product.ProductID {
+			return fmt.Errorf("product cannot be a variant of itself")
+		}
+		if product.SKU == "" {
+			return fmt.Errorf("variant SKU is required")
+		}
+	}
+	
+	for name := range product.Attributes {
+		if name == "" {
+			return fmt.Errorf("attribute names cannot be empty")
+		}
+	}
+	
+	if len(product.Name) > 255 {
+		return fmt.Errorf("product name cannot exceed 255 characters")
+	}
+	
+	return nil
+}
+
+// prepareProduct brings a validated product into the form it is stored and
+// returned in. A product sent with only the legacy decimal price gets its
+// minor units, and one without a currency the default. Variants are read
+// from their own documents, never written through the parent.
+func prepareProduct(product *models.Product) {
+	product.NormalizePrice()
+	product.Variants = nil
+}
+
+// validatePriceFilters checks the currency of filters. A price range given
+// without one is taken to be in the default currency.
+func validatePriceFilters(filters *repository.ProductFilters) error {
+	if filters.Currency == "" {
+		if filters.MinPrice != nil || filters.MaxPrice != nil {
+			filters.Currency = models.DefaultCurrency
+		}
+		return nil
+	}
+	
+	if _, ok := models.CurrencyExponent(filters.Currency); !ok {
+		return fmt.Errorf("currency %q is not a supported ISO 4217 code", filters.Currency)
+	}
+	return nil
+}
+
+// getProductCount returns the total number of products for metrics
+func (s *ProductService) getProductCount(ctx context.Context) int {
+	count, err := s.repo.Count(ctx, repository.ProductFilters{})
+	if err != nil {
+		s.logger.WithError(err).Warn("⚠️ Failed to get product count for metrics")
+		return 0
+	}
+	return count
+}
+
+// GetMetrics returns service metrics
+func (s *ProductService) GetMetrics() map[string]interface{} {
+	uptime := time.Since(s.startTime)
+	totals := s.stats.totals()
+	recent := s.stats.recent()
+	
+	metrics := map[string]interface{}{
+		"service":               "product-api",
+		"version":               s.config.Server.Version,
+		"environment":           s.config.Server.Environment,
+		"uptime_seconds":        int(uptime.Seconds()),
+		"total_requests":        totals.Requests,
+		"total_errors":          totals.Errors,
+		"operations":            s.stats.byOperation(),
+		"recent":                recent,
+		"recent_window_seconds": int((errorWindowBucket * errorWindowBuckets).Seconds()),
+		"timestamp":             time.Now(),
+	}
+	
+	if totals.Requests > 0 {
+		metrics["error_rate"] = totals.ErrorRate()
+		metrics["success_rate"] = 1.0 - totals.ErrorRate()
+	}
+	
+	if recent.Requests > 0 {
+		metrics["recent_error_rate"] = recent.ErrorRate()
+	}
+	
+	if stats, ok := s.cacheStats(); ok {
+		metrics["cache"] = stats
+	}
+	
+	return metrics
+}
+
+// cacheStats returns the repository cache statistics when caching is enabled
+func (s *ProductService) cacheStats() (repository.CacheStats, bool) {
+	provider, ok := s.repo.(repository.CacheStatsProvider)
+	if !ok {
+		return repository.CacheStats{}, false
+	}
+	return provider.CacheStats(), true
+}
+
+// metricsScrapeTimeout bounds repository queries made while serving /metrics
+const metricsScrapeTimeout = 2 * time.Second
+
+// RegisterMetrics publishes the catalog gauges and cache counters on registry.
+// Gauges are computed at scrape time.
+func (s *ProductService) RegisterMetrics(registry *metrics.Registry) {
+	registry.NewGaugeFunc("products_total", "Number of products in the catalog.", func() float64 {
+		return s.countProducts(repository.ProductFilters{})
+	})
+
+	active := true
+	registry.NewGaugeFunc("products_active", "Number of active products in the catalog.", func() float64 {
+		return s.countProducts(repository.ProductFilters{Active: &active})
+	})
+
+	if _, ok := s.cacheStats(); ok {
+		registry.NewCounterFunc("product_cache_hits_total", "Product cache hits.", func() float64 {
+			stats, _ := s.cacheStats()
+			return float64(stats.Hits)
+		})
+		registry.NewCounterFunc("product_cache_misses_total", "Product cache misses.", func() float64 {
+			stats, _ := s.cacheStats()
+			return float64(stats.Misses)
+		})
+		registry.NewCounterFunc("product_cache_evictions_total", "Product cache evictions.", func() float64 {
+			stats, _ := s.cacheStats()
+			return float64(stats.Evictions)
+		})
+		registry.NewGaugeFunc("product_cache_entries", "Products currently cached.", func() float64 {
+			stats, _ := s.cacheStats()
+			return float64(stats.Size)
+		})
+	}
+}
+
+// countProducts counts products for a metrics scrape with a bounded timeout.
+// Failures yield NaN so a broken count is not mistaken for an empty catalog.
+func (s *ProductService) countProducts(filters repository.ProductFilters) float64 {
+	ctx, cancel := context.WithTimeout(context.Background(), metricsScrapeTimeout)
+	defer cancel()
+
+	count, err := s.repo.Count(ctx, filters)
+	if err != nil {
+		s.logger.WithError(err).Warn("⚠️ Failed to count products for metrics")
+		return math.NaN()
+	}
+	return float64(count)
+}
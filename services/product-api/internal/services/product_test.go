@@ -3,15 +3,22 @@ package services
 import (
 	"context"
 	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/product-api-v2/configs"
+	"github.com/product-api-v2/internal/metrics"
 	"github.com/product-api-v2/internal/models"
 	"github.com/product-api-v2/internal/repository"
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 // MockProductRepository is a mock implementation of ProductRepository
@@ -27,6 +34,22 @@ func (m *MockProductRepository) GetByID(ctx context.Context, productID string) (
 	return args.Get(0).(*models.Product), args.Error(1)
 }
 
+func (m *MockProductRepository) GetByIDFields(ctx context.Context, productID string, fields []string) (*models.Product, error) {
+	args := m.Called(ctx, productID, fields)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Product), args.Error(1)
+}
+
+func (m *MockProductRepository) GetByIDs(ctx context.Context, productIDs []string) ([]*models.Product, error) {
+	args := m.Called(ctx, productIDs)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.Product), args.Error(1)
+}
+
 func (m *MockProductRepository) GetAll(ctx context.Context, filters repository.ProductFilters) ([]*models.Product, error) {
 	args := m.Called(ctx, filters)
 	if args.Get(0) == nil {
@@ -55,6 +78,45 @@ func (m *MockProductRepository) Count(ctx context.Context, filters repository.Pr
 	return args.Int(0), args.Error(1)
 }
 
+func (m *MockProductRepository) CountByCategory(ctx context.Context, filters repository.ProductFilters) (map[string]int, error) {
+	args := m.Called(ctx, filters)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(map[string]int), args.Error(1)
+}
+
+func (m *MockProductRepository) ReserveStock(ctx context.Context, lines []models.StockLine) error {
+	args := m.Called(ctx, lines)
+	return args.Error(0)
+}
+
+func (m *MockProductRepository) ReleaseStock(ctx context.Context, lines []models.StockLine) error {
+	args := m.Called(ctx, lines)
+	return args.Error(0)
+}
+
+func (m *MockProductRepository) BulkWrite(ctx context.Context, products []*models.Product, mode repository.BulkMode) (*repository.BulkWriteResult, error) {
+	args := m.Called(ctx, products, mode)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.BulkWriteResult), args.Error(1)
+}
+
+func (m *MockProductRepository) Iterate(ctx context.Context, filters repository.ProductFilters, yield func(*models.Product) error) error {
+	args := m.Called(ctx, filters, yield)
+	return args.Error(0)
+}
+
+func (m *MockProductRepository) Search(ctx context.Context, search repository.ProductSearch) (*repository.ProductSearchResult, error) {
+	args := m.Called(ctx, search)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.ProductSearchResult), args.Error(1)
+}
+
 func (m *MockProductRepository) HealthCheck(ctx context.Context) error {
 	args := m.Called(ctx)
 	return args.Error(0)
@@ -78,7 +140,7 @@ func createTestProductService(repo repository.ProductRepository) *ProductService
 	logger := logrus.New()
 	logger.SetLevel(logrus.ErrorLevel) // Reduce log noise in tests
 	
-	return NewProductService(repo, config, logger)
+	return NewProductService(repo, nil, nil, nil, nil, nil, config, logger)
 }
 
 // Helper function to create a test product
@@ -114,12 +176,16 @@ func TestProductService_GetProduct_Success(t *testing.T) {
 	
 	expectedProduct := createTestProduct()
 	mockRepo.On("GetByID", ctx, "test-product-1").Return(expectedProduct, nil)
+	mockRepo.On("GetAll", ctx, repository.ProductFilters{
+		ParentIDs:  []string{"test-product-1"},
+		Visibility: repository.VisibilityPublic,
+	}).Return([]*models.Product{}, nil)
 	
 	product, err := service.GetProduct(ctx, "test-product-1")
 	
 	assert.NoError(t, err)
 	assert.Equal(t, expectedProduct, product)
-	assert.Equal(t, int64(1), service.requests)
+	assert.Equal(t, int64(1), service.stats.totals().Requests)
 	mockRepo.AssertExpectations(t)
 }
 
@@ -135,7 +201,7 @@ func TestProductService_GetProduct_NotFound(t *testing.T) {
 	assert.Error(t, err)
 	assert.Nil(t, product)
 	assert.Contains(t, err.Error(), "not found")
-	assert.Equal(t, int64(1), service.requests)
+	assert.Equal(t, int64(1), service.stats.totals().Requests)
 	mockRepo.AssertExpectations(t)
 }
 
@@ -169,7 +235,7 @@ func TestProductService_GetProduct_ErrorCase(t *testing.T) {
 	assert.Error(t, err)
 	assert.Nil(t, product)
 	assert.Contains(t, err.Error(), "this product always returns an error")
-	assert.Equal(t, int64(1), service.errors)
+	assert.Equal(t, int64(1), service.stats.totals().Errors)
 	// No need to assert expectations since no repository call is made
 }
 
@@ -184,7 +250,286 @@ func TestProductService_GetProduct_TestErrorProduct(t *testing.T) {
 	assert.Error(t, err)
 	assert.Nil(t, product)
 	assert.Contains(t, err.Error(), "always returns an error")
-	assert.Equal(t, int64(1), service.errors)
+	assert.Equal(t, int64(1), service.stats.totals().Errors)
+}
+
+func TestProductService_GetProduct_ErrorKinds(t *testing.T) {
+	ctx := context.Background()
+	inactiveProduct := createTestProduct()
+	inactiveProduct.Active = false
+
+	tests := []struct {
+		name    string
+		product *models.Product
+		repoErr error
+		kind    error
+		code    string
+	}{
+		{"not found", nil, repository.ErrProductNotFound, ErrNotFound, "product_not_found"},
+		{"inactive", inactiveProduct, nil, ErrInactive, "product_unavailable"},
+		{"unavailable", nil, repository.ErrRepositoryUnavailable, ErrUnavailable, "service_unavailable"},
+		{"deadline", nil, context.DeadlineExceeded, ErrUnavailable, "service_unavailable"},
+		{"internal", nil, errors.New("boom"), ErrInternal, "internal_error"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := &MockProductRepository{}
+			service := createTestProductService(mockRepo)
+			mockRepo.On("GetByID", ctx, "test-product-1").Return(tt.product, tt.repoErr)
+
+			_, err := service.GetProduct(ctx, "test-product-1")
+
+			var serviceErr *Error
+			assert.ErrorIs(t, err, tt.kind)
+			assert.True(t, errors.As(err, &serviceErr))
+			assert.Equal(t, tt.code, serviceErr.Code)
+		})
+	}
+}
+
+func TestProductService_CreateProduct_ErrorKinds(t *testing.T) {
+	mockRepo := &MockProductRepository{}
+	service := createTestProductService(mockRepo)
+	ctx := context.Background()
+
+	err := service.CreateProduct(ctx, &models.Product{})
+	assert.ErrorIs(t, err, ErrValidation)
+
+	product := createTestProduct()
+	mockRepo.On("Create", ctx, product).Return(repository.ErrProductExists)
+
+	err = service.CreateProduct(ctx, product)
+	assert.ErrorIs(t, err, ErrConflict)
+}
+
+func TestProductService_GetProductsByIDs_Success(t *testing.T) {
+	mockRepo := &MockProductRepository{}
+	service := createTestProductService(mockRepo)
+	ctx := context.Background()
+
+	active := createTestProduct()
+	inactive := createTestProduct()
+	inactive.ProductID = "test-product-2"
+	inactive.Active = false
+
+	mockRepo.On("GetByIDs", ctx, []string{"test-product-2", "test-product-1", "missing"}).
+		Return([]*models.Product{active, inactive}, nil)
+
+	response, err := service.GetProductsByIDs(ctx, []string{"test-product-2", "test-product-1", "missing", "test-product-1"})
+
+	assert.NoError(t, err)
+	assert.Len(t, response.Products, 1)
+	assert.Equal(t, "test-product-1", response.Products[0].ProductID)
+	assert.Equal(t, []string{"missing"}, response.Missing)
+	assert.Equal(t, []string{"test-product-2"}, response.Inactive)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestProductService_GetProductsByIDs_Error(t *testing.T) {
+	mockRepo := &MockProductRepository{}
+	service := createTestProductService(mockRepo)
+	ctx := context.Background()
+
+	mockRepo.On("GetByIDs", ctx, []string{"test-product-1"}).Return(nil, errors.New("database error"))
+
+	response, err := service.GetProductsByIDs(ctx, []string{"test-product-1"})
+
+	assert.Error(t, err)
+	assert.Nil(t, response)
+	assert.Equal(t, int64(1), service.stats.totals().Errors)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestMemoryProductRepository_GetByIDs(t *testing.T) {
+	repo := repository.NewMemoryProductRepository()
+	ctx := context.Background()
+
+	product := createTestProduct()
+	assert.NoError(t, repo.Create(ctx, product))
+
+	products, err := repo.GetByIDs(ctx, []string{"test-product-1", "missing"})
+
+	assert.NoError(t, err)
+	assert.Len(t, products, 1)
+	assert.Equal(t, "test-product-1", products[0].ProductID)
+}
+
+func TestMemoryProductRepository_UpdateChecksVersion(t *testing.T) {
+	repo := repository.NewMemoryProductRepository()
+	ctx := context.Background()
+
+	product := createTestProduct()
+	assert.NoError(t, repo.Create(ctx, product))
+	assert.Equal(t, int64(1), product.Version)
+
+	first := *product
+	second := *product
+
+	assert.NoError(t, repo.Update(ctx, &first))
+	assert.Equal(t, int64(2), first.Version)
+	assert.ErrorIs(t, repo.Update(ctx, &second), repository.ErrVersionConflict)
+
+	assert.NoError(t, repo.ReserveStock(ctx, []models.StockLine{{ProductID: "test-product-1", Quantity: 1}}))
+	stored, err := repo.GetByID(ctx, "test-product-1")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), stored.Version)
+}
+
+func TestCachedProductRepository_HitsAndInvalidation(t *testing.T) {
+	mockRepo := &MockProductRepository{}
+	cached := repository.NewCachedProductRepository(mockRepo, time.Minute, 10)
+	ctx := context.Background()
+
+	mockRepo.On("GetByID", mock.Anything, "test-product-1").Return(createTestProduct(), nil)
+	mockRepo.On("Update", ctx, mock.AnythingOfType("*models.Product")).Return(nil)
+
+	first, err := cached.GetByID(ctx, "test-product-1")
+	assert.NoError(t, err)
+	first.Name = "Mutated by caller"
+
+	second, err := cached.GetByID(ctx, "test-product-1")
+	assert.NoError(t, err)
+	assert.Equal(t, "Test Product", second.Name)
+	mockRepo.AssertNumberOfCalls(t, "GetByID", 1)
+
+	assert.NoError(t, cached.Update(ctx, second))
+	_, err = cached.GetByID(ctx, "test-product-1")
+	assert.NoError(t, err)
+	mockRepo.AssertNumberOfCalls(t, "GetByID", 2)
+
+	stats := cached.CacheStats()
+	assert.Equal(t, int64(1), stats.Hits)
+	assert.Equal(t, int64(2), stats.Misses)
+	assert.Equal(t, 1, stats.Size)
+}
+
+func TestCachedProductRepository_CoalescesConcurrentMisses(t *testing.T) {
+	mockRepo := &MockProductRepository{}
+	cached := repository.NewCachedProductRepository(mockRepo, time.Minute, 10)
+	ctx := context.Background()
+
+	mockRepo.On("GetByID", mock.Anything, "test-product-1").After(50*time.Millisecond).Return(createTestProduct(), nil)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := cached.GetByID(ctx, "test-product-1")
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	mockRepo.AssertNumberOfCalls(t, "GetByID", 1)
+}
+
+func TestCachedProductRepository_CancelledCallerDoesNotFailOthers(t *testing.T) {
+	mockRepo := &MockProductRepository{}
+	cached := repository.NewCachedProductRepository(mockRepo, time.Minute, 10)
+
+	mockRepo.On("GetByID", mock.Anything, "test-product-1").After(50*time.Millisecond).Return(createTestProduct(), nil)
+
+	impatient, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		_, err := cached.GetByID(impatient, "test-product-1")
+		done <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+
+	// Joins the load the impatient caller started
+	waiting := make(chan error, 1)
+	go func() {
+		_, err := cached.GetByID(context.Background(), "test-product-1")
+		waiting <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+
+	assert.ErrorIs(t, <-done, context.Canceled)
+	assert.NoError(t, <-waiting)
+	mockRepo.AssertNumberOfCalls(t, "GetByID", 1)
+}
+
+func TestCachedProductRepository_CopiesAttributes(t *testing.T) {
+	repo := repository.NewMemoryProductRepository()
+	cached := repository.NewCachedProductRepository(repo, time.Minute, 10)
+	ctx := context.Background()
+
+	product := createTestProduct()
+	product.Attributes = map[string]string{"color": "black"}
+	require.NoError(t, repo.Create(ctx, product))
+
+	first, err := cached.GetByID(ctx, product.ProductID)
+	require.NoError(t, err)
+	first.Attributes["color"] = "white"
+
+	second, err := cached.GetByID(ctx, product.ProductID)
+	require.NoError(t, err)
+	assert.Equal(t, "black", second.Attributes["color"])
+}
+
+func TestCachedProductRepository_EvictsLeastRecentlyUsed(t *testing.T) {
+	repo := repository.NewMemoryProductRepository()
+	cached := repository.NewCachedProductRepository(repo, time.Minute, 2)
+	ctx := context.Background()
+
+	for _, productID := range []string{"product-1", "product-2", "product-3"} {
+		product := createTestProduct()
+		product.ProductID = productID
+		assert.NoError(t, repo.Create(ctx, product))
+
+		_, err := cached.GetByID(ctx, productID)
+		assert.NoError(t, err)
+	}
+
+	stats := cached.CacheStats()
+	assert.Equal(t, 2, stats.Size)
+	assert.Equal(t, int64(1), stats.Evictions)
+}
+
+func TestCachedProductRepository_ExpiresEntries(t *testing.T) {
+	mockRepo := &MockProductRepository{}
+	cached := repository.NewCachedProductRepository(mockRepo, 10*time.Millisecond, 10)
+	ctx := context.Background()
+
+	mockRepo.On("GetByID", mock.Anything, "test-product-1").Return(createTestProduct(), nil)
+
+	_, err := cached.GetByID(ctx, "test-product-1")
+	assert.NoError(t, err)
+	time.Sleep(20 * time.Millisecond)
+	_, err = cached.GetByID(ctx, "test-product-1")
+	assert.NoError(t, err)
+
+	mockRepo.AssertNumberOfCalls(t, "GetByID", 2)
+}
+
+func TestProductService_GetMetrics_IncludesCacheStats(t *testing.T) {
+	cached := repository.NewCachedProductRepository(&MockProductRepository{}, time.Minute, 10)
+	service := createTestProductService(cached)
+
+	metrics := service.GetMetrics()
+
+	assert.Contains(t, metrics, "cache")
+}
+
+func TestProductService_RegisterMetrics(t *testing.T) {
+	mockRepo := &MockProductRepository{}
+	service := createTestProductService(mockRepo)
+	registry := metrics.NewRegistry()
+
+	mockRepo.On("Count", mock.Anything, repository.ProductFilters{}).Return(7, nil)
+	mockRepo.On("Count", mock.Anything, mock.MatchedBy(func(f repository.ProductFilters) bool { return f.Active != nil })).Return(5, nil)
+
+	service.RegisterMetrics(registry)
+
+	var out strings.Builder
+	assert.NoError(t, registry.WriteText(&out))
+	assert.Contains(t, out.String(), "# TYPE products_total gauge\nproducts_total 7\n")
+	assert.Contains(t, out.String(), "products_active 5\n")
+	assert.NotContains(t, out.String(), "product_cache_hits_total")
 }
 
 func TestProductService_GetProducts_Success(t *testing.T) {
@@ -195,7 +540,16 @@ func TestProductService_GetProducts_Success(t *testing.T) {
 	products := []*models.Product{createTestProduct()}
 	filters := repository.ProductFilters{Category: "electronics"}
 	
-	mockRepo.On("GetAll", ctx, filters).Return(products, nil)
+	// Listings are public unless the caller asks otherwise, and show
+	// variants under their parent
+	query := filters
+	query.Visibility = repository.VisibilityPublic
+	query.TopLevel = true
+	mockRepo.On("GetAll", ctx, query).Return(products, nil)
+	mockRepo.On("GetAll", ctx, repository.ProductFilters{
+		ParentIDs:  []string{"test-product-1"},
+		Visibility: repository.VisibilityPublic,
+	}).Return([]*models.Product{}, nil)
 	mockRepo.On("Count", ctx, mock.AnythingOfType("repository.ProductFilters")).Return(1, nil)
 	
 	response, err := service.GetProducts(ctx, filters)
@@ -213,18 +567,176 @@ func TestProductService_GetProducts_Error(t *testing.T) {
 	service := createTestProductService(mockRepo)
 	ctx := context.Background()
 	
-	filters := repository.ProductFilters{Category: "electronics"}
-	mockRepo.On("GetAll", ctx, filters).Return(nil, errors.New("database error"))
+	filters := repository.ProductFilters{Category: "electronics", Visibility: repository.VisibilityAdmin}
+	query := filters
+	query.TopLevel = true
+	mockRepo.On("GetAll", ctx, query).Return(nil, errors.New("database error"))
 	
 	response, err := service.GetProducts(ctx, filters)
 	
 	assert.Error(t, err)
 	assert.Nil(t, response)
 	assert.Contains(t, err.Error(), "failed to retrieve products")
-	assert.Equal(t, int64(1), service.errors)
+	assert.Equal(t, int64(1), service.stats.totals().Errors)
 	mockRepo.AssertExpectations(t)
 }
 
+func TestProductService_GetProducts_VisibilityKeepsCountsConsistent(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewMemoryProductRepository()
+	for i := 1; i <= 6; i++ {
+		assert.NoError(t, repo.Create(ctx, &models.Product{
+			ProductID: fmt.Sprintf("product-%d", i),
+			Name:      fmt.Sprintf("Product %d", i),
+			Price:     10,
+			Active:    i%2 == 0,
+		}))
+	}
+	service := createTestProductService(repo)
+	
+	// Inactive products are left out by the query, so pages stay full
+	response, err := service.GetProducts(ctx, repository.ProductFilters{PageSize: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, response.Total)
+	assert.Len(t, response.Products, 2)
+	
+	response, err = service.GetProducts(ctx, repository.ProductFilters{PageSize: 2, Page: 1})
+	assert.NoError(t, err)
+	assert.Len(t, response.Products, 1)
+	
+	response, err = service.GetProducts(ctx, repository.ProductFilters{Visibility: repository.VisibilityAdmin})
+	assert.NoError(t, err)
+	assert.Equal(t, 6, response.Total)
+	assert.Len(t, response.Products, 6)
+	assert.False(t, response.Products[0].Active)
+	
+	inactive := false
+	response, err = service.GetProducts(ctx, repository.ProductFilters{Active: &inactive})
+	assert.NoError(t, err)
+	assert.Zero(t, response.Total)
+	assert.Empty(t, response.Products)
+}
+
+func TestProductService_GetProducts_CursorPagination(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewMemoryProductRepository()
+	prices := []float64{30, 10, 20, 10, 50, 40, 10}
+	for i, price := range prices {
+		assert.NoError(t, repo.Create(ctx, &models.Product{
+			ProductID: fmt.Sprintf("product-%d", i+1),
+			Name:      fmt.Sprintf("Product %d", i+1),
+			Price:     price,
+			Active:    true,
+		}))
+	}
+	service := createTestProductService(repo)
+	
+	sort, err := repository.ParseProductSort("price,-productId")
+	assert.NoError(t, err)
+	
+	ids := func(response *models.ProductCatalogResponse) []string {
+		var result []string
+		for _, product := range response.Products {
+			result = append(result, product.ProductID)
+		}
+		return result
+	}
+	page := func(token string) *models.ProductCatalogResponse {
+		filters := repository.ProductFilters{Sort: sort, PageSize: 3}
+		if token != "" {
+			cursor, err := repository.DecodeProductCursor(token, sort)
+			assert.NoError(t, err)
+			filters.Cursor = cursor
+		}
+		response, err := service.GetProducts(ctx, filters)
+		assert.NoError(t, err)
+		return response
+	}
+	
+	first := page("")
+	assert.Equal(t, []string{"product-7", "product-4", "product-2"}, ids(first))
+	assert.Empty(t, first.PrevCursor)
+	assert.NotEmpty(t, first.NextCursor)
+	
+	second := page(first.NextCursor)
+	assert.Equal(t, []string{"product-3", "product-1", "product-6"}, ids(second))
+	assert.NotEmpty(t, second.PrevCursor)
+	
+	last := page(second.NextCursor)
+	assert.Equal(t, []string{"product-5"}, ids(last))
+	assert.Empty(t, last.NextCursor)
+	
+	// Walking back returns the same pages
+	assert.Equal(t, ids(second), ids(page(last.PrevCursor)))
+	back := page(second.PrevCursor)
+	assert.Equal(t, ids(first), ids(back))
+	assert.Empty(t, back.PrevCursor)
+	
+	// A product inserted behind the cursor does not shift the next page
+	assert.NoError(t, repo.Create(ctx, &models.Product{ProductID: "product-0", Name: "Cheap", Price: 5, Active: true}))
+	assert.Equal(t, ids(second), ids(page(first.NextCursor)))
+}
+
+func TestProductService_GetProducts_RejectsMismatchedCursor(t *testing.T) {
+	byPrice, err := repository.ParseProductSort("price")
+	assert.NoError(t, err)
+	byName, err := repository.ParseProductSort("-name")
+	assert.NoError(t, err)
+	
+	token := repository.ProductCursor(createTestProduct(), byPrice, false)
+	_, err = repository.DecodeProductCursor(token, byName)
+	assert.ErrorIs(t, err, repository.ErrInvalidCursor)
+	
+	_, err = repository.DecodeProductCursor("not-a-cursor", byPrice)
+	assert.ErrorIs(t, err, repository.ErrInvalidCursor)
+	
+	_, err = repository.ParseProductSort("description")
+	assert.ErrorIs(t, err, repository.ErrInvalidSort)
+}
+
+func TestProductService_GetProducts_PriceRangeByCurrency(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewMemoryProductRepository()
+	products := []*models.Product{
+		{ProductID: "usd-cheap", PriceMinor: 999, Currency: "USD"},
+		{ProductID: "usd-mid", PriceMinor: 2500, Currency: "USD"},
+		{ProductID: "eur-mid", PriceMinor: 2500, Currency: "EUR"},
+		{ProductID: "jpy-mid", PriceMinor: 2500, Currency: "JPY"},
+		{ProductID: "legacy", Price: 30},
+	}
+	for _, product := range products {
+		product.Name = product.ProductID
+		product.Active = true
+		assert.NoError(t, repo.Create(ctx, product))
+	}
+	service := createTestProductService(repo)
+	
+	ids := func(filters repository.ProductFilters) []string {
+		response, err := service.GetProducts(ctx, filters)
+		assert.NoError(t, err)
+		var result []string
+		for _, product := range response.Products {
+			result = append(result, product.ProductID)
+		}
+		return result
+	}
+	
+	// Without a currency the range is in dollars; legacy prices are dollars
+	minPrice, maxPrice := 10.0, 30.0
+	assert.Equal(t, []string{"legacy", "usd-mid"}, ids(repository.ProductFilters{MinPrice: &minPrice, MaxPrice: &maxPrice}))
+	assert.Equal(t, []string{"eur-mid"}, ids(repository.ProductFilters{Currency: "EUR", MinPrice: &minPrice}))
+	
+	minPrice = 2000
+	assert.Equal(t, []string{"jpy-mid"}, ids(repository.ProductFilters{Currency: "JPY", MinPrice: &minPrice}))
+	
+	// A bound below the minor unit admits the same prices as the rounded one
+	maxPrice = 9.995
+	assert.Equal(t, []string{"usd-cheap"}, ids(repository.ProductFilters{MaxPrice: &maxPrice}))
+	
+	_, err := service.GetProducts(ctx, repository.ProductFilters{Currency: "XYZ"})
+	assert.ErrorIs(t, err, ErrValidation)
+}
+
 func TestProductService_CreateProduct_Success(t *testing.T) {
 	mockRepo := &MockProductRepository{}
 	service := createTestProductService(mockRepo)
@@ -236,7 +748,7 @@ func TestProductService_CreateProduct_Success(t *testing.T) {
 	err := service.CreateProduct(ctx, product)
 	
 	assert.NoError(t, err)
-	assert.Equal(t, int64(1), service.requests)
+	assert.Equal(t, int64(1), service.stats.totals().Requests)
 	mockRepo.AssertExpectations(t)
 }
 
@@ -252,7 +764,7 @@ func TestProductService_CreateProduct_ValidationError(t *testing.T) {
 	
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "validation failed")
-	assert.Equal(t, int64(1), service.errors)
+	assert.Equal(t, int64(1), service.stats.totals().Errors)
 }
 
 func TestProductService_CreateProduct_AlreadyExists(t *testing.T) {
@@ -270,11 +782,164 @@ func TestProductService_CreateProduct_AlreadyExists(t *testing.T) {
 	mockRepo.AssertExpectations(t)
 }
 
+func TestProductService_UpdateProduct_Success(t *testing.T) {
+	mockRepo := &MockProductRepository{}
+	service := createTestProductService(mockRepo)
+	ctx := context.Background()
+
+	existing := createTestProduct()
+	update := createTestProduct()
+	update.ProductID = ""
+	update.Price = 89.99
+
+	mockRepo.On("GetByID", ctx, "test-product-1").Return(existing, nil)
+	mockRepo.On("Update", ctx, mock.AnythingOfType("*models.Product")).Return(nil)
+
+	updated, err := service.UpdateProduct(ctx, "test-product-1", update, nil)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "test-product-1", updated.ProductID)
+	assert.Equal(t, 89.99, updated.Price)
+	assert.Equal(t, existing.CreatedAt, updated.CreatedAt)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestProductService_UpdateProduct_IDMismatch(t *testing.T) {
+	service := createTestProductService(&MockProductRepository{})
+
+	product := createTestProduct()
+	_, err := service.UpdateProduct(context.Background(), "other-product", product, nil)
+
+	assert.ErrorIs(t, err, ErrValidation)
+}
+
+func TestProductService_UpdateProduct_StaleIfMatch(t *testing.T) {
+	mockRepo := &MockProductRepository{}
+	service := createTestProductService(mockRepo)
+	ctx := context.Background()
+
+	existing := createTestProduct()
+	existing.Version = 3
+	mockRepo.On("GetByID", ctx, "test-product-1").Return(existing, nil)
+
+	staleVersion := int64(2)
+	_, err := service.UpdateProduct(ctx, "test-product-1", createTestProduct(), &staleVersion)
+
+	assert.ErrorIs(t, err, ErrPreconditionFailed)
+	mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+}
+
+func TestProductService_UpdateProduct_ConcurrentWrite(t *testing.T) {
+	mockRepo := &MockProductRepository{}
+	service := createTestProductService(mockRepo)
+	ctx := context.Background()
+
+	mockRepo.On("GetByID", ctx, "test-product-1").Return(createTestProduct(), nil)
+	mockRepo.On("Update", ctx, mock.AnythingOfType("*models.Product")).Return(repository.ErrVersionConflict)
+
+	_, err := service.UpdateProduct(ctx, "test-product-1", createTestProduct(), nil)
+	assert.ErrorIs(t, err, ErrConflict)
+
+	currentVersion := int64(0)
+	_, err = service.UpdateProduct(ctx, "test-product-1", createTestProduct(), &currentVersion)
+	assert.ErrorIs(t, err, ErrPreconditionFailed)
+}
+
+func TestProductService_PatchProduct_MergesFields(t *testing.T) {
+	mockRepo := &MockProductRepository{}
+	service := createTestProductService(mockRepo)
+	ctx := context.Background()
+
+	mockRepo.On("GetByID", ctx, "test-product-1").Return(createTestProduct(), nil)
+	mockRepo.On("Update", ctx, mock.AnythingOfType("*models.Product")).Return(nil)
+
+	patched, err := service.PatchProduct(ctx, "test-product-1", map[string]interface{}{
+		"price":       49.5,
+		"description": nil,
+	}, nil)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 49.5, patched.Price)
+	assert.Equal(t, "", patched.Description)
+	assert.Equal(t, "Test Product", patched.Name)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestProductService_PatchProduct_Prices(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewMemoryProductRepository()
+	service := createTestProductService(repo)
+	assert.NoError(t, service.CreateProduct(ctx, createTestProduct()))
+
+	// Old clients patch the decimal price, newer ones the minor units
+	patched, err := service.PatchProduct(ctx, "test-product-1", map[string]interface{}{"price": 5.5}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(550), patched.PriceMinor)
+
+	patched, err = service.PatchProduct(ctx, "test-product-1", map[string]interface{}{"priceMinor": 700}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 7.0, patched.Price)
+
+	// Changing the currency keeps the amount in minor units
+	patched, err = service.PatchProduct(ctx, "test-product-1", map[string]interface{}{"currency": "JPY"}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(700), patched.PriceMinor)
+	assert.Equal(t, 700.0, patched.Price)
+
+	_, err = service.PatchProduct(ctx, "test-product-1", map[string]interface{}{"price": 5, "priceMinor": 600}, nil)
+	assert.ErrorIs(t, err, ErrValidation)
+}
+
+func TestProductService_PatchProduct_InvalidResult(t *testing.T) {
+	mockRepo := &MockProductRepository{}
+	service := createTestProductService(mockRepo)
+	ctx := context.Background()
+
+	mockRepo.On("GetByID", ctx, "test-product-1").Return(createTestProduct(), nil)
+
+	_, err := service.PatchProduct(ctx, "test-product-1", map[string]interface{}{"price": -1}, nil)
+	assert.ErrorIs(t, err, ErrValidation)
+
+	_, err = service.PatchProduct(ctx, "test-product-1", map[string]interface{}{"productId": "renamed"}, nil)
+	assert.ErrorIs(t, err, ErrValidation)
+
+	mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+}
+
+func TestProductService_DeleteProduct_SoftDeactivates(t *testing.T) {
+	mockRepo := &MockProductRepository{}
+	service := createTestProductService(mockRepo)
+	ctx := context.Background()
+
+	mockRepo.On("GetByID", ctx, "test-product-1").Return(createTestProduct(), nil)
+	mockRepo.On("Update", ctx, mock.MatchedBy(func(p *models.Product) bool { return !p.Active })).Return(nil)
+
+	err := service.DeleteProduct(ctx, "test-product-1", false, nil)
+
+	assert.NoError(t, err)
+	mockRepo.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestProductService_DeleteProduct_Hard(t *testing.T) {
+	mockRepo := &MockProductRepository{}
+	service := createTestProductService(mockRepo)
+	ctx := context.Background()
+
+	mockRepo.On("Count", ctx, mock.AnythingOfType("repository.ProductFilters")).Return(0, nil)
+	mockRepo.On("Delete", ctx, "test-product-1").Return(nil)
+	mockRepo.On("Delete", ctx, "missing").Return(repository.ErrProductNotFound)
+
+	assert.NoError(t, service.DeleteProduct(ctx, "test-product-1", true, nil))
+	assert.ErrorIs(t, service.DeleteProduct(ctx, "missing", true, nil), ErrNotFound)
+	mockRepo.AssertExpectations(t)
+}
+
 func TestProductService_ValidateProduct_Success(t *testing.T) {
 	service := createTestProductService(&MockProductRepository{})
 	
 	validProduct := createTestProduct()
-	err := service.validateProduct(validProduct)
+	err := service.validateProduct(context.Background(), validProduct)
 	
 	assert.NoError(t, err)
 }
@@ -285,7 +950,7 @@ func TestProductService_ValidateProduct_MissingProductID(t *testing.T) {
 	invalidProduct := createTestProduct()
 	invalidProduct.ProductID = ""
 	
-	err := service.validateProduct(invalidProduct)
+	err := service.validateProduct(context.Background(), invalidProduct)
 	
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "product ID is required")
@@ -297,7 +962,7 @@ func TestProductService_ValidateProduct_MissingName(t *testing.T) {
 	invalidProduct := createTestProduct()
 	invalidProduct.Name = ""
 	
-	err := service.validateProduct(invalidProduct)
+	err := service.validateProduct(context.Background(), invalidProduct)
 	
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "product name is required")
@@ -309,19 +974,63 @@ func TestProductService_ValidateProduct_InvalidPrice(t *testing.T) {
 	invalidProduct := createTestProduct()
 	invalidProduct.Price = -10
 	
-	err := service.validateProduct(invalidProduct)
+	err := service.validateProduct(context.Background(), invalidProduct)
 	
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "price must be greater than 0")
 }
 
+func TestProductService_ValidateProduct_Prices(t *testing.T) {
+	tests := []struct {
+		name     string
+		product  models.Product
+		minor    int64
+		currency string
+		price    float64
+		err      string
+	}{
+		{"legacy decimal price", models.Product{Price: 12.5}, 1250, "USD", 12.5, ""},
+		{"minor units", models.Product{PriceMinor: 1999, Currency: "EUR"}, 1999, "EUR", 19.99, ""},
+		{"matching price and minor units", models.Product{Price: 19.99, PriceMinor: 1999}, 1999, "USD", 19.99, ""},
+		{"currency without decimals", models.Product{Price: 1500, Currency: "JPY"}, 1500, "JPY", 1500, ""},
+		{"three decimals", models.Product{Price: 1.255, Currency: "KWD"}, 1255, "KWD", 1.255, ""},
+		{"sub-cent price", models.Product{Price: 1.005}, 0, "", 0, "more than 2 decimals"},
+		{"fractional yen", models.Product{Price: 9.5, Currency: "JPY"}, 0, "", 0, "more than 0 decimals"},
+		{"conflicting price", models.Product{Price: 10, PriceMinor: 999}, 0, "", 0, "does not match"},
+		{"unknown currency", models.Product{PriceMinor: 100, Currency: "XYZ"}, 0, "", 0, "ISO 4217"},
+		{"missing price", models.Product{Currency: "EUR"}, 0, "", 0, "greater than 0"},
+	}
+	
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			product := tt.product
+			product.ProductID = "product-1"
+			product.Name = "Product"
+			
+			validated := product
+			err := ValidateProduct(&validated)
+			assert.Equal(t, product, validated, "validation leaves the product unchanged")
+			if tt.err != "" {
+				assert.ErrorContains(t, err, tt.err)
+				return
+			}
+			assert.NoError(t, err)
+			
+			prepareProduct(&product)
+			assert.Equal(t, tt.minor, product.PriceMinor)
+			assert.Equal(t, tt.currency, product.Currency)
+			assert.Equal(t, tt.price, product.Price)
+		})
+	}
+}
+
 func TestProductService_ValidateProduct_NameTooLong(t *testing.T) {
 	service := createTestProductService(&MockProductRepository{})
 	
 	invalidProduct := createTestProduct()
 	invalidProduct.Name = string(make([]byte, 256)) // 256 characters
 	
-	err := service.validateProduct(invalidProduct)
+	err := service.validateProduct(context.Background(), invalidProduct)
 	
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "cannot exceed 255 characters")
@@ -332,8 +1041,8 @@ func TestProductService_GetHealthStatus_Healthy(t *testing.T) {
 	service := createTestProductService(mockRepo)
 	ctx := context.Background()
 	
-	mockRepo.On("HealthCheck", ctx).Return(nil)
-	mockRepo.On("Count", ctx, repository.ProductFilters{}).Return(5, nil)
+	mockRepo.On("HealthCheck", mock.Anything).Return(nil)
+	mockRepo.On("Count", mock.Anything, repository.ProductFilters{}).Return(5, nil)
 	
 	health, err := service.GetHealthStatus(ctx)
 	
@@ -344,7 +1053,8 @@ func TestProductService_GetHealthStatus_Healthy(t *testing.T) {
 	assert.Equal(t, "test-v1.0.0", health.Version)
 	assert.Equal(t, "test", health.Environment)
 	assert.Contains(t, health.Dependencies, "repository")
-	assert.Equal(t, "healthy", health.Dependencies["repository"])
+	assert.Equal(t, DependencyHealthy, health.Dependencies["repository"].Status)
+	assert.GreaterOrEqual(t, health.Dependencies["repository"].LatencyMs, 0.0)
 	mockRepo.AssertExpectations(t)
 }
 
@@ -353,23 +1063,224 @@ func TestProductService_GetHealthStatus_UnhealthyRepository(t *testing.T) {
 	service := createTestProductService(mockRepo)
 	ctx := context.Background()
 	
-	mockRepo.On("HealthCheck", ctx).Return(errors.New("database connection failed"))
-	mockRepo.On("Count", ctx, repository.ProductFilters{}).Return(0, nil)
+	mockRepo.On("HealthCheck", mock.Anything).Return(errors.New("database connection failed"))
+	mockRepo.On("Count", mock.Anything, repository.ProductFilters{}).Return(0, nil)
 	
 	health, err := service.GetHealthStatus(ctx)
 	
 	assert.NoError(t, err)
 	assert.NotNil(t, health)
-	assert.Contains(t, health.Dependencies["repository"], "unhealthy")
+	assert.Equal(t, "unhealthy", health.Status)
+	assert.False(t, health.Ready)
+	assert.Equal(t, DependencyUnhealthy, health.Dependencies["repository"].Status)
+	assert.Equal(t, "database connection failed", health.Dependencies["repository"].Error)
 	mockRepo.AssertExpectations(t)
 }
 
+func TestProductService_Liveness(t *testing.T) {
+	mockRepo := &MockProductRepository{}
+	service := createTestProductService(mockRepo)
+	
+	probe := service.Liveness()
+	
+	assert.Equal(t, ProbeStatusOK, probe.Status)
+	// Liveness must not touch the repository
+	mockRepo.AssertNotCalled(t, "HealthCheck", mock.Anything)
+}
+
+func TestProductService_Readiness(t *testing.T) {
+	ctx := context.Background()
+	
+	t.Run("not ready until startup finishes", func(t *testing.T) {
+		mockRepo := &MockProductRepository{}
+		service := createTestProductService(mockRepo)
+		mockRepo.On("HealthCheck", mock.Anything).Return(nil)
+		
+		probe, ready := service.Readiness(ctx)
+		
+		assert.False(t, ready)
+		assert.Equal(t, ProbeStatusNotReady, probe.Status)
+		assert.Equal(t, "in_progress", probe.Checks["startup"])
+	})
+	
+	t.Run("ready once started with a healthy repository", func(t *testing.T) {
+		mockRepo := &MockProductRepository{}
+		service := createTestProductService(mockRepo)
+		mockRepo.On("HealthCheck", mock.Anything).Return(nil)
+		service.MarkStarted()
+		
+		probe, ready := service.Readiness(ctx)
+		
+		assert.True(t, ready)
+		assert.Equal(t, ProbeStatusOK, probe.Status)
+		assert.Equal(t, DependencyHealthy, probe.Checks["repository"])
+		mockRepo.AssertNotCalled(t, "Count", mock.Anything, mock.Anything)
+	})
+	
+	t.Run("not ready when the repository is unreachable", func(t *testing.T) {
+		mockRepo := &MockProductRepository{}
+		service := createTestProductService(mockRepo)
+		mockRepo.On("HealthCheck", mock.Anything).Return(errors.New("server selection timeout"))
+		service.MarkStarted()
+		
+		probe, ready := service.Readiness(ctx)
+		
+		assert.False(t, ready)
+		assert.Equal(t, DependencyUnhealthy, probe.Checks["repository"])
+	})
+	
+	t.Run("not ready while draining", func(t *testing.T) {
+		mockRepo := &MockProductRepository{}
+		service := createTestProductService(mockRepo)
+		mockRepo.On("HealthCheck", mock.Anything).Return(nil)
+		service.MarkStarted()
+		service.MarkDraining()
+		
+		probe, ready := service.Readiness(ctx)
+		
+		assert.False(t, ready)
+		assert.Equal(t, "draining", probe.Checks["shutdown"])
+	})
+	
+	t.Run("repository check is bounded by the probe timeout", func(t *testing.T) {
+		mockRepo := &MockProductRepository{}
+		service := createTestProductService(mockRepo)
+		service.config.Server.ProbeTimeout = 20 * time.Millisecond
+		mockRepo.On("HealthCheck", mock.Anything).Return(context.DeadlineExceeded).Run(func(args mock.Arguments) {
+			<-args.Get(0).(context.Context).Done()
+		})
+		service.MarkStarted()
+		
+		start := time.Now()
+		_, ready := service.Readiness(ctx)
+		
+		assert.False(t, ready)
+		assert.Less(t, time.Since(start), time.Second)
+	})
+	
+	t.Run("health product count is bounded by the probe timeout", func(t *testing.T) {
+		mockRepo := &MockProductRepository{}
+		service := createTestProductService(mockRepo)
+		service.config.Server.ProbeTimeout = 20 * time.Millisecond
+		mockRepo.On("HealthCheck", mock.Anything).Return(nil)
+		mockRepo.On("Count", mock.Anything, mock.Anything).Return(0, context.DeadlineExceeded).Run(func(args mock.Arguments) {
+			<-args.Get(0).(context.Context).Done()
+		})
+		
+		start := time.Now()
+		health, err := service.GetHealthStatus(ctx)
+		
+		assert.NoError(t, err)
+		assert.Equal(t, 0, health.Metrics["products_count"])
+		assert.Less(t, time.Since(start), time.Second)
+	})
+}
+
+func TestProductService_DegradedStartupRecoversAfterSwap(t *testing.T) {
+	ctx := context.Background()
+	swappable := repository.NewSwappableProductRepository(repository.NewUnavailableProductRepository(errors.New("connection refused")))
+	service := createTestProductService(swappable)
+	service.MarkStarted()
+	
+	_, err := service.GetProduct(ctx, "test-product-1")
+	assert.ErrorIs(t, err, ErrUnavailable)
+	
+	_, ready := service.Readiness(ctx)
+	assert.False(t, ready)
+	
+	health, err := service.GetHealthStatus(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "unhealthy", health.Status)
+	
+	// The background reconnect installs the real repository
+	memory := repository.NewMemoryProductRepository()
+	assert.NoError(t, memory.Create(ctx, createTestProduct()))
+	swappable.Swap(memory)
+	
+	_, ready = service.Readiness(ctx)
+	assert.True(t, ready)
+	
+	found, err := service.GetProduct(ctx, "test-product-1")
+	assert.NoError(t, err)
+	assert.Equal(t, "test-product-1", found.ProductID)
+}
+
+func TestMemoryProductRepository_SeedFromFixtures(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "products.ndjson")
+	fixtures := `{"productId":"product-1","name":"Laptop Pro","price":1299.99,"category":"electronics","active":true,"stock":50}
+
+{"productId":"product-2","name":"Mouse","price":29.99,"category":"electronics","active":true,"stock":200}
+`
+	assert.NoError(t, os.WriteFile(path, []byte(fixtures), 0o644))
+	
+	products, err := repository.LoadProductFixtures(path, "products")
+	assert.NoError(t, err)
+	assert.Len(t, products, 2)
+	
+	memory := repository.NewMemoryProductRepository()
+	assert.NoError(t, memory.Seed(products, ValidateProduct))
+	
+	service := createTestProductService(memory)
+	found, err := service.GetProduct(ctx, "product-1")
+	assert.NoError(t, err)
+	assert.Equal(t, "Laptop Pro", found.Name)
+	assert.Equal(t, 50, found.Stock)
+	assert.Equal(t, int64(1), found.Version)
+}
+
+func TestMemoryProductRepository_SeedRejectsInvalidFixtures(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "products.ndjson"), []byte("{\"productId\":\"product-1\"}\n{broken\n"), 0o644))
+	_, err := repository.LoadProductFixtures(dir, "products")
+	assert.ErrorContains(t, err, "products.ndjson:2")
+	
+	memory := repository.NewMemoryProductRepository()
+	err = memory.Seed([]*models.Product{
+		{ProductID: "product-1", Name: "Laptop Pro", Price: 1299.99},
+		{ProductID: "product-2", Name: "Mouse", Price: 0},
+	}, ValidateProduct)
+	assert.ErrorContains(t, err, "fixture 2 (product-2)")
+	
+	// Nothing is loaded from a rejected batch
+	count, err := memory.Count(context.Background(), repository.ProductFilters{})
+	assert.NoError(t, err)
+	assert.Equal(t, 0, count)
+}
+
+func TestMemoryProductRepository_PersistsWritesToFixtures(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	path := filepath.Join(dir, "products.json")
+	assert.NoError(t, os.WriteFile(path, []byte(`[{"productId":"product-1","name":"Laptop Pro","price":1299.99,"active":true,"stock":50}]`), 0o644))
+	
+	products, err := repository.LoadProductFixtures(dir, "products")
+	assert.NoError(t, err)
+	memory := repository.NewMemoryProductRepository()
+	assert.NoError(t, memory.Seed(products, ValidateProduct))
+	assert.NoError(t, memory.PersistTo(path, func(err error) { t.Error(err) }))
+	
+	assert.NoError(t, memory.ReserveStock(ctx, []models.StockLine{{ProductID: "product-1", Quantity: 5}}))
+	
+	// A restart sees the stock reserved before it
+	reloaded, err := repository.LoadProductFixtures(path, "products")
+	assert.NoError(t, err)
+	assert.Len(t, reloaded, 1)
+	assert.Equal(t, 45, reloaded[0].Stock)
+	
+	assert.Error(t, memory.PersistTo(dir, nil))
+}
+
 func TestProductService_GetMetrics(t *testing.T) {
 	service := createTestProductService(&MockProductRepository{})
 	
 	// Simulate some requests and errors
-	service.requests = 100
-	service.errors = 5
+	for i := 0; i < 100; i++ {
+		service.stats.request("GetProduct")
+	}
+	for i := 0; i < 5; i++ {
+		service.stats.failure("GetProduct")
+	}
 	
 	metrics := service.GetMetrics()
 	
@@ -381,6 +1292,50 @@ func TestProductService_GetMetrics(t *testing.T) {
 	assert.Equal(t, int64(5), metrics["total_errors"])
 	assert.InDelta(t, 0.05, metrics["error_rate"], 0.001)
 	assert.InDelta(t, 0.95, metrics["success_rate"], 0.001)
+	assert.Equal(t, OperationCounts{Requests: 100, Errors: 5}, metrics["operations"].(map[string]OperationCounts)["GetProduct"])
+}
+
+func TestOperationStats_RecentWindowForgetsOldErrors(t *testing.T) {
+	stats := newOperationStats()
+	now := time.Now()
+	stats.now = func() time.Time { return now }
+
+	for i := 0; i < 20; i++ {
+		stats.request("GetProduct")
+		stats.failure("GetProduct")
+	}
+	assert.True(t, stats.degraded())
+
+	// Once the failures fall out of the window only healthy traffic counts
+	now = now.Add(errorWindowBucket * errorWindowBuckets)
+	for i := 0; i < 20; i++ {
+		stats.request("GetProduct")
+	}
+
+	assert.False(t, stats.degraded())
+	assert.Equal(t, OperationCounts{Requests: 20}, stats.recent())
+	assert.Equal(t, OperationCounts{Requests: 40, Errors: 20}, stats.totals())
+}
+
+func TestOperationStats_ConcurrentUse(t *testing.T) {
+	stats := newOperationStats()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				stats.request("GetProducts")
+				if j%10 == 0 {
+					stats.failure("GetProducts")
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, OperationCounts{Requests: 8000, Errors: 800}, stats.totals())
 }
 
 func TestProductService_GetMetrics_NoRequests(t *testing.T) {
@@ -0,0 +1,70 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/product-api-v2/internal/models"
+	"github.com/product-api-v2/internal/repository"
+)
+
+// ResolveProductFields returns the product fields a response should carry.
+// fields is a comma-separated list that takes precedence over view; with
+// neither, defaultView applies. The product ID is always included.
+func ResolveProductFields(view, fields, defaultView string) ([]string, error) {
+	if fields == "" {
+		if view == "" {
+			view = defaultView
+		}
+		selected, ok := models.ProductViews[view]
+		if !ok {
+			return nil, validationError(fmt.Errorf("view must be %s, %s or %s", models.ViewSummary, models.ViewFull, models.ViewEnrichment))
+		}
+		return selected, nil
+	}
+
+	selected := []string{"productId"}
+	for _, field := range strings.Split(fields, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" || slices.Contains(selected, field) {
+			continue
+		}
+		if !slices.Contains(models.ProductFields, field) {
+			return nil, validationError(fmt.Errorf("unknown field %q", field))
+		}
+		selected = append(selected, field)
+	}
+	return selected, nil
+}
+
+// GetProductFields is GetProduct returning only fields. The repository reads
+// just those, plus what the availability check, the ETag and variant
+// inheritance need.
+func (s *ProductService) GetProductFields(ctx context.Context, productID string, fields []string) (*models.ProductView, error) {
+	product, err := s.getProduct(ctx, productID, func() (*models.Product, error) {
+		return s.repo.GetByIDFields(ctx, productID, withProductFields(fields, "parentId", "active", "version"))
+	})
+	if err != nil {
+		return nil, err
+	}
+	
+	if slices.Contains(fields, "variants") {
+		if err := s.attachVariants(ctx, []*models.Product{product}, repository.VisibilityPublic, fields); err != nil {
+			return nil, repositoryError(err, "failed to retrieve variants")
+		}
+	}
+	return &models.ProductView{Product: product, Fields: fields}, nil
+}
+
+// withProductFields returns fields plus any of extra not already in it
+func withProductFields(fields []string, extra ...string) []string {
+	combined := slices.Clone(fields)
+	for _, field := range extra {
+		if !slices.Contains(combined, field) {
+			combined = append(combined, field)
+		}
+	}
+	return combined
+}
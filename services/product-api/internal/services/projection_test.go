@@ -0,0 +1,91 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/product-api-v2/internal/models"
+	"github.com/product-api-v2/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestResolveProductFields(t *testing.T) {
+	tests := []struct {
+		name     string
+		view     string
+		fields   string
+		expected []string
+	}{
+		{"default view", "", "", models.ProductViews[models.ViewSummary]},
+		{"named view", models.ViewEnrichment, "", []string{"productId", "parentId", "sku", "name", "price", "priceMinor", "currency", "category", "attributes", "stock", "active"}},
+		{"fields win over view", models.ViewFull, "price, stock,price", []string{"productId", "price", "stock"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fields, err := ResolveProductFields(tt.view, tt.fields, models.ViewSummary)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, fields)
+		})
+	}
+
+	_, err := ResolveProductFields("compact", "", models.ViewSummary)
+	assert.ErrorIs(t, err, ErrValidation)
+	_, err = ResolveProductFields("", "name,secret", models.ViewSummary)
+	assert.ErrorIs(t, err, ErrValidation)
+}
+
+func TestProductService_GetProducts_ProjectsFields(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewMemoryProductRepository()
+	product := createTestProduct()
+	product.Description = "Long description"
+	assert.NoError(t, repo.Create(ctx, product))
+	service := createTestProductService(repo)
+
+	fields, err := ResolveProductFields("", "description", models.ViewSummary)
+	assert.NoError(t, err)
+	response, err := service.GetProducts(ctx, repository.ProductFilters{Fields: fields})
+	assert.NoError(t, err)
+
+	encoded, err := json.Marshal(response.Products)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `[{"productId":"test-product-1","description":"Long description"}]`, string(encoded))
+
+	// Fields that were not asked for are not read at all
+	assert.Empty(t, response.Products[0].Name)
+
+	// Without a selection listings keep the summary view
+	response, err = service.GetProducts(ctx, repository.ProductFilters{})
+	assert.NoError(t, err)
+	encoded, err = json.Marshal(response.Products[0])
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"productId":"test-product-1","name":"Test Product","price":99.99,"currency":"USD","active":true}`, string(encoded))
+}
+
+func TestProductService_GetProductFields(t *testing.T) {
+	mockRepo := &MockProductRepository{}
+	service := createTestProductService(mockRepo)
+	ctx := context.Background()
+
+	product := &models.Product{ProductID: "test-product-1", Price: 99.99, Stock: 5, Active: true, Version: 3}
+	mockRepo.On("GetByIDFields", ctx, "test-product-1", []string{"productId", "price", "stock", "parentId", "active", "version"}).
+		Return(product, nil)
+
+	view, err := service.GetProductFields(ctx, "test-product-1", []string{"productId", "price", "stock"})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), view.Version)
+
+	encoded, err := json.Marshal(view)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"productId":"test-product-1","price":99.99,"stock":5}`, string(encoded))
+
+	// Inactive products stay unavailable whatever fields are requested
+	mockRepo.On("GetByIDFields", ctx, "test-product-2", mock.Anything).
+		Return(&models.Product{ProductID: "test-product-2"}, nil)
+	_, err = service.GetProductFields(ctx, "test-product-2", []string{"productId"})
+	assert.ErrorIs(t, err, ErrInactive)
+	mockRepo.AssertExpectations(t)
+}
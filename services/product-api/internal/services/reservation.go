@@ -0,0 +1,476 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/product-api-v2/configs"
+	"github.com/product-api-v2/internal/models"
+	"github.com/product-api-v2/internal/repository"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// maxReservationLines caps the number of lines in a single reservation
+	maxReservationLines = 100
+
+	// expiryBatchSize is the number of expired reservations handled per sweep
+	expiryBatchSize = 100
+)
+
+// ReservationService handles stock reservations for orders. Stock is taken
+// when a reservation is created and either kept on confirm or returned on
+// release and expiry.
+type ReservationService struct {
+	reservations repository.ReservationRepository
+	products     repository.ProductRepository
+	outbox       repository.OutboxRepository // nil records no events
+	webhooks     *WebhookService             // nil sends no webhooks
+	transactor   repository.Transactor       // nil runs writes without a transaction
+	config       *configs.Config
+	logger       *logrus.Logger
+}
+
+// NewReservationService creates a new reservation service. Reservations
+// that sell out a product queue a ProductChanged event in outbox and notify
+// the subscribed webhooks, in the transaction that takes the stock.
+func NewReservationService(reservations repository.ReservationRepository, products repository.ProductRepository, outbox repository.OutboxRepository, webhooks *WebhookService, transactor repository.Transactor, config *configs.Config, logger *logrus.Logger) *ReservationService {
+	return &ReservationService{
+		reservations: reservations,
+		products:     products,
+		outbox:       outbox,
+		webhooks:     webhooks,
+		transactor:   transactor,
+		config:       config,
+		logger:       logger,
+	}
+}
+
+// CreateReservation atomically reserves stock for every line of the
+// request. The stock, the reservation and the events for products it sells
+// out are written in one transaction.
+func (s *ReservationService) CreateReservation(ctx context.Context, request *models.ReservationRequest) (*models.Reservation, error) {
+	logger := s.logger.WithFields(logrus.Fields{
+		"operation": "CreateReservation",
+		"orderId":   request.OrderID,
+		"lines":     len(request.Lines),
+		"requestId": ctx.Value("requestId"),
+	})
+
+	logger.Info("📦 Creating stock reservation")
+
+	ttl, err := s.validateReservationRequest(request)
+	if err != nil {
+		logger.WithError(err).Warn("⚠️ Invalid reservation request")
+		return nil, validationError(err)
+	}
+
+	if err := s.checkProductsAvailable(ctx, request.Lines); err != nil {
+		logger.WithError(err).Warn("⚠️ Reservation references unavailable products")
+		return nil, err
+	}
+
+	var reservation *models.Reservation
+	err = s.withinTransaction(ctx, func(ctx context.Context) error {
+		created, err := s.reserve(ctx, logger, request, ttl)
+		if err != nil {
+			return err
+		}
+		reservation = created
+		return nil
+	})
+	if err != nil {
+		var serviceErr *Error
+		if errors.As(err, &serviceErr) {
+			return nil, err
+		}
+		logger.WithError(err).Error("💥 Failed to create reservation")
+		return nil, repositoryError(err, "failed to create reservation")
+	}
+
+	logger.WithFields(logrus.Fields{
+		"reservationId": reservation.ReservationID,
+		"expiresAt":     reservation.ExpiresAt,
+	}).Info("✅ Stock reserved")
+
+	return reservation, nil
+}
+
+// reserve takes the stock for request, queues the events for products it
+// sells out and stores the reservation. Stock taken is returned if a later
+// step fails, for repositories without transactions.
+func (s *ReservationService) reserve(ctx context.Context, logger *logrus.Entry, request *models.ReservationRequest, ttl time.Duration) (*models.Reservation, error) {
+	if err := s.products.ReserveStock(ctx, request.Lines); err != nil {
+		logger.WithError(err).Warn("⚠️ Stock reservation failed")
+		return nil, stockError(err)
+	}
+
+	reservationID, err := newReservationID()
+	if err != nil {
+		s.compensate(ctx, logger, request.Lines)
+		return nil, newError(ErrInternal, "internal_error", err, "failed to generate reservation ID")
+	}
+
+	reservation := &models.Reservation{
+		ReservationID: reservationID,
+		OrderID:       request.OrderID,
+		Lines:         request.Lines,
+		Status:        models.ReservationPending,
+		TTLSeconds:    int(ttl.Seconds()),
+		ExpiresAt:     time.Now().Add(ttl),
+	}
+
+	// Events are queued before the reservation is stored so a failure
+	// never leaves a reservation behind without a transaction to undo it
+	if err := s.queueSellOuts(ctx, reservation); err != nil {
+		logger.WithError(err).Error("💥 Failed to queue out-of-stock events")
+		s.compensate(ctx, logger, request.Lines)
+		return nil, repositoryError(err, "failed to queue out-of-stock events")
+	}
+
+	if err := s.reservations.Create(ctx, reservation); err != nil {
+		logger.WithError(err).Error("💥 Failed to store reservation")
+		s.compensate(ctx, logger, request.Lines)
+		return nil, repositoryError(err, "failed to create reservation")
+	}
+
+	return reservation, nil
+}
+
+// withinTransaction runs work in a transaction, or directly without a
+// transactor
+func (s *ReservationService) withinTransaction(ctx context.Context, work func(ctx context.Context) error) error {
+	if s.transactor == nil {
+		return work(ctx)
+	}
+	return s.transactor.WithinTransaction(ctx, work)
+}
+
+// GetReservation retrieves a reservation by ID
+func (s *ReservationService) GetReservation(ctx context.Context, reservationID string) (*models.Reservation, error) {
+	reservation, err := s.reservations.GetByID(ctx, reservationID)
+	if err != nil {
+		return nil, reservationLookupError(err, reservationID)
+	}
+	return reservation, nil
+}
+
+// ConfirmReservation turns a pending reservation into a permanent stock
+// deduction. Confirming an already confirmed reservation is a no-op.
+func (s *ReservationService) ConfirmReservation(ctx context.Context, reservationID string) (*models.Reservation, error) {
+	logger := s.logger.WithFields(logrus.Fields{
+		"operation":     "ConfirmReservation",
+		"reservationId": reservationID,
+		"requestId":     ctx.Value("requestId"),
+	})
+
+	reservation, err := s.GetReservation(ctx, reservationID)
+	if err != nil {
+		return nil, err
+	}
+
+	if reservation.Status == models.ReservationConfirmed {
+		return reservation, nil
+	}
+
+	// Expiry is enforced here as well so a confirm cannot win against a
+	// reservation the sweeper has not reached yet
+	if reservation.Status == models.ReservationPending && time.Now().After(reservation.ExpiresAt) {
+		if err := s.expire(ctx, logger, reservation); err != nil {
+			return nil, err
+		}
+		return nil, newError(ErrInactive, "reservation_expired", nil, "reservation %s has expired", reservationID)
+	}
+
+	confirmed, err := s.reservations.UpdateStatus(ctx, reservationID, models.ReservationPending, models.ReservationConfirmed)
+	if err != nil {
+		return nil, s.transitionError(ctx, err, reservationID)
+	}
+
+	logger.Info("✅ Reservation confirmed")
+
+	return confirmed, nil
+}
+
+// ReleaseReservation cancels a pending reservation and returns its stock.
+// Releasing a reservation that was already released or expired is a no-op.
+func (s *ReservationService) ReleaseReservation(ctx context.Context, reservationID string) (*models.Reservation, error) {
+	logger := s.logger.WithFields(logrus.Fields{
+		"operation":     "ReleaseReservation",
+		"reservationId": reservationID,
+		"requestId":     ctx.Value("requestId"),
+	})
+
+	released, err := s.returnStock(ctx, logger, reservationID, models.ReservationReleased)
+	if err != nil {
+		if errors.Is(err, repository.ErrReservationState) {
+			current, getErr := s.GetReservation(ctx, reservationID)
+			if getErr == nil && (current.Status == models.ReservationReleased || current.Status == models.ReservationExpired) {
+				return current, nil
+			}
+		}
+		return nil, s.transitionError(ctx, err, reservationID)
+	}
+
+	logger.Info("↩️ Reservation released")
+
+	return released, nil
+}
+
+// ExpireReservations releases the stock of pending reservations whose TTL
+// has passed and returns how many were expired
+func (s *ReservationService) ExpireReservations(ctx context.Context) (int, error) {
+	logger := s.logger.WithField("operation", "ExpireReservations")
+
+	expired, err := s.reservations.ListExpired(ctx, time.Now(), expiryBatchSize)
+	if err != nil {
+		logger.WithError(err).Error("💥 Failed to list expired reservations")
+		return 0, repositoryError(err, "failed to list expired reservations")
+	}
+
+	count := 0
+	for _, reservation := range expired {
+		if err := s.expire(ctx, logger, reservation); err != nil {
+			return count, err
+		}
+		count++
+	}
+
+	if count > 0 {
+		logger.WithField("count", count).Info("⌛ Expired abandoned reservations")
+	}
+
+	return count, nil
+}
+
+// Run sweeps expired reservations until ctx is cancelled
+func (s *ReservationService) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.config.Reservations.SweepInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := s.ExpireReservations(ctx); err != nil {
+				s.logger.WithError(err).Warn("⚠️ Reservation expiry sweep failed")
+			}
+		}
+	}
+}
+
+// expire marks a pending reservation as expired and returns its stock. A
+// reservation that changed state concurrently is left alone.
+func (s *ReservationService) expire(ctx context.Context, logger *logrus.Entry, reservation *models.Reservation) error {
+	_, err := s.returnStock(ctx, logger, reservation.ReservationID, models.ReservationExpired)
+	if err != nil {
+		if errors.Is(err, repository.ErrReservationState) {
+			return nil
+		}
+		var serviceErr *Error
+		if errors.As(err, &serviceErr) {
+			return err
+		}
+		return repositoryError(err, "failed to expire reservation")
+	}
+
+	return nil
+}
+
+// returnStock moves a pending reservation to status and returns its stock
+// in one transaction. Without a transaction the reservation is set back to
+// pending when the stock cannot be returned, so a retry or the expiry sweep
+// still finds it. Failures to return the stock are service errors; status
+// changes fail with repository errors.
+func (s *ReservationService) returnStock(ctx context.Context, logger *logrus.Entry, reservationID string, status models.ReservationStatus) (*models.Reservation, error) {
+	var updated *models.Reservation
+	err := s.withinTransaction(ctx, func(ctx context.Context) error {
+		reservation, err := s.reservations.UpdateStatus(ctx, reservationID, models.ReservationPending, status)
+		if err != nil {
+			return err
+		}
+
+		if err := s.products.ReleaseStock(ctx, reservation.Lines); err != nil {
+			logger.WithError(err).WithField("reservationId", reservationID).Error("💥 Failed to return reserved stock")
+			if _, revertErr := s.reservations.UpdateStatus(context.WithoutCancel(ctx), reservationID, status, models.ReservationPending); revertErr != nil {
+				logger.WithError(revertErr).WithField("reservationId", reservationID).Error("💥 Failed to set reservation back to pending")
+			}
+			return repositoryError(err, "failed to release stock")
+		}
+
+		updated = reservation
+		return nil
+	})
+	return updated, err
+}
+
+// queueSellOuts queues a ProductChanged event, and the product.out_of_stock
+// webhooks it triggers, for each product the reservation sold out
+func (s *ReservationService) queueSellOuts(ctx context.Context, reservation *models.Reservation) error {
+	if s.outbox == nil && s.webhooks == nil {
+		return nil
+	}
+
+	productIDs := make([]string, 0, len(reservation.Lines))
+	for _, line := range reservation.Lines {
+		productIDs = append(productIDs, line.ProductID)
+	}
+	products, err := s.products.GetByIDs(ctx, productIDs)
+	if err != nil {
+		return err
+	}
+
+	events := []*models.ProductChangedEvent{}
+	for _, product := range products {
+		if product.Stock > 0 {
+			continue
+		}
+		eventID, err := newEventID()
+		if err != nil {
+			return err
+		}
+		event := &models.ProductChangedEvent{
+			EventID:        eventID,
+			Type:           models.EventProductChanged,
+			SchemaVersion:  models.ProductChangedSchemaVersion,
+			Source:         eventSource,
+			IdempotencyKey: reservation.ReservationID + "/" + product.ProductID,
+			OccurredAt:     time.Now(),
+			ProductID:      product.ProductID,
+			Version:        product.Version,
+			Action:         models.AuditReserve,
+			Actor:          actorFrom(ctx),
+			ChangedFields:  []string{"stock"},
+			Product:        product,
+		}
+		if requestID, ok := ctx.Value("requestId").(string); ok {
+			event.RequestID = requestID
+		}
+		events = append(events, event)
+	}
+
+	return queueEvents(ctx, s.outbox, s.webhooks, events...)
+}
+
+// validateReservationRequest checks the request and resolves its TTL
+func (s *ReservationService) validateReservationRequest(request *models.ReservationRequest) (time.Duration, error) {
+	if len(request.Lines) == 0 {
+		return 0, fmt.Errorf("at least one reservation line is required")
+	}
+
+	if len(request.Lines) > maxReservationLines {
+		return 0, fmt.Errorf("a reservation cannot exceed %d lines", maxReservationLines)
+	}
+
+	for _, line := range request.Lines {
+		if line.ProductID == "" {
+			return 0, fmt.Errorf("product ID is required on every line")
+		}
+		if line.Quantity <= 0 {
+			return 0, fmt.Errorf("quantity for product %s must be greater than 0", line.ProductID)
+		}
+	}
+
+	ttl := s.config.Reservations.DefaultTTL
+	if request.TTLSeconds != 0 {
+		ttl = time.Duration(request.TTLSeconds) * time.Second
+	}
+
+	if ttl <= 0 || ttl > s.config.Reservations.MaxTTL {
+		return 0, fmt.Errorf("ttlSeconds must be between 1 and %d", int(s.config.Reservations.MaxTTL.Seconds()))
+	}
+
+	return ttl, nil
+}
+
+// checkProductsAvailable rejects reservations for unknown or inactive
+// products. A variant is inactive while its parent is.
+func (s *ReservationService) checkProductsAvailable(ctx context.Context, lines []models.StockLine) error {
+	productIDs := make([]string, 0, len(lines))
+	for _, line := range lines {
+		productIDs = append(productIDs, line.ProductID)
+	}
+
+	products, err := s.products.GetByIDs(ctx, productIDs)
+	if err == nil {
+		err = inheritFromParents(ctx, s.products, products...)
+	}
+	if err != nil {
+		return repositoryError(err, "failed to retrieve products")
+	}
+
+	byID := make(map[string]*models.Product, len(products))
+	for _, product := range products {
+		byID[product.ProductID] = product
+	}
+
+	for _, productID := range productIDs {
+		product, found := byID[productID]
+		if !found {
+			return newError(ErrNotFound, "product_not_found", nil, "product with ID %s not found", productID)
+		}
+		if !product.Active {
+			return newError(ErrInactive, "product_unavailable", nil, "product %s is not available", productID)
+		}
+	}
+
+	return nil
+}
+
+// compensate returns stock taken for a reservation that could not be stored
+func (s *ReservationService) compensate(ctx context.Context, logger *logrus.Entry, lines []models.StockLine) {
+	if err := s.products.ReleaseStock(context.WithoutCancel(ctx), lines); err != nil {
+		logger.WithError(err).Error("💥 Failed to return stock after reservation failure")
+	}
+}
+
+// transitionError maps a failed status transition to a service error
+func (s *ReservationService) transitionError(ctx context.Context, err error, reservationID string) error {
+	var serviceErr *Error
+	if errors.As(err, &serviceErr) {
+		return err
+	}
+	if errors.Is(err, repository.ErrReservationState) {
+		status := "no longer pending"
+		if current, getErr := s.reservations.GetByID(ctx, reservationID); getErr == nil {
+			status = string(current.Status)
+		}
+		return newError(ErrConflict, "reservation_state_conflict", nil, "reservation %s is %s", reservationID, status)
+	}
+	return reservationLookupError(err, reservationID)
+}
+
+// reservationLookupError maps repository errors for a single reservation
+func reservationLookupError(err error, reservationID string) error {
+	if errors.Is(err, repository.ErrReservationNotFound) {
+		return newError(ErrNotFound, "reservation_not_found", nil, "reservation %s not found", reservationID)
+	}
+	return repositoryError(err, "failed to retrieve reservation")
+}
+
+// stockError maps a failed stock reservation to a service error
+func stockError(err error) error {
+	var stockErr *repository.StockError
+	switch {
+	case errors.Is(err, repository.ErrInsufficientStock):
+		return newError(ErrConflict, "insufficient_stock", nil, "%s", err.Error())
+	case errors.As(err, &stockErr) && errors.Is(err, repository.ErrProductNotFound):
+		return newError(ErrNotFound, "product_not_found", nil, "product with ID %s not found", stockErr.ProductID)
+	default:
+		return repositoryError(err, "failed to reserve stock")
+	}
+}
+
+// newReservationID generates a random reservation identifier
+func newReservationID() (string, error) {
+	buf := make([]byte, 12)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return "res-" + hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,231 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/product-api-v2/configs"
+	"github.com/product-api-v2/internal/models"
+	"github.com/product-api-v2/internal/repository"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Helper function to create a reservation service backed by memory repositories
+func createTestReservationService(t *testing.T) (*ReservationService, *repository.MemoryProductRepository) {
+	config := &configs.Config{
+		Reservations: configs.ReservationConfig{
+			DefaultTTL:    time.Minute,
+			MaxTTL:        time.Hour,
+			SweepInterval: time.Second,
+		},
+	}
+
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+
+	products := repository.NewMemoryProductRepository()
+	for _, product := range []*models.Product{
+		{ProductID: "product-1", Name: "Laptop", Price: 999.99, Stock: 5, Active: true},
+		{ProductID: "product-2", Name: "Mouse", Price: 19.99, Stock: 1, Active: true},
+		{ProductID: "product-3", Name: "Retired", Price: 9.99, Stock: 10, Active: false},
+	} {
+		require.NoError(t, products.Create(context.Background(), product))
+	}
+
+	service := NewReservationService(repository.NewMemoryReservationRepository(), products, nil, nil, nil, config, logger)
+	return service, products
+}
+
+// stockOf returns the current stock of a product
+func stockOf(t *testing.T, products repository.ProductRepository, productID string) int {
+	product, err := products.GetByID(context.Background(), productID)
+	require.NoError(t, err)
+	return product.Stock
+}
+
+func TestReservationService_CreateReservation_ReservesStock(t *testing.T) {
+	service, products := createTestReservationService(t)
+	ctx := context.Background()
+
+	reservation, err := service.CreateReservation(ctx, &models.ReservationRequest{
+		OrderID: "order-1",
+		Lines: []models.StockLine{
+			{ProductID: "product-1", Quantity: 2},
+			{ProductID: "product-2", Quantity: 1},
+		},
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, models.ReservationPending, reservation.Status)
+	assert.Equal(t, 60, reservation.TTLSeconds)
+	assert.Equal(t, 3, stockOf(t, products, "product-1"))
+	assert.Equal(t, 0, stockOf(t, products, "product-2"))
+}
+
+func TestReservationService_CreateReservation_AllOrNothing(t *testing.T) {
+	service, products := createTestReservationService(t)
+	ctx := context.Background()
+
+	_, err := service.CreateReservation(ctx, &models.ReservationRequest{
+		Lines: []models.StockLine{
+			{ProductID: "product-1", Quantity: 2},
+			{ProductID: "product-2", Quantity: 2},
+		},
+	})
+
+	assert.ErrorIs(t, err, ErrConflict)
+	assert.Equal(t, 5, stockOf(t, products, "product-1"))
+	assert.Equal(t, 1, stockOf(t, products, "product-2"))
+}
+
+func TestReservationService_CreateReservation_RejectsInvalidRequests(t *testing.T) {
+	service, _ := createTestReservationService(t)
+	ctx := context.Background()
+
+	_, err := service.CreateReservation(ctx, &models.ReservationRequest{})
+	assert.ErrorIs(t, err, ErrValidation)
+
+	_, err = service.CreateReservation(ctx, &models.ReservationRequest{
+		Lines: []models.StockLine{{ProductID: "product-1", Quantity: 0}},
+	})
+	assert.ErrorIs(t, err, ErrValidation)
+
+	_, err = service.CreateReservation(ctx, &models.ReservationRequest{
+		Lines: []models.StockLine{{ProductID: "product-3", Quantity: 1}},
+	})
+	assert.ErrorIs(t, err, ErrInactive)
+
+	_, err = service.CreateReservation(ctx, &models.ReservationRequest{
+		Lines: []models.StockLine{{ProductID: "missing", Quantity: 1}},
+	})
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestReservationService_CreateReservation_InactiveParent(t *testing.T) {
+	service, products := createTestReservationService(t)
+	ctx := context.Background()
+
+	require.NoError(t, products.Create(ctx, &models.Product{ProductID: "retired-s", ParentID: "product-3", SKU: "RET-S", Stock: 4, Active: true}))
+
+	_, err := service.CreateReservation(ctx, &models.ReservationRequest{
+		Lines: []models.StockLine{{ProductID: "retired-s", Quantity: 1}},
+	})
+	assert.ErrorIs(t, err, ErrInactive, "a variant is unavailable while its parent is")
+	assert.Equal(t, 4, stockOf(t, products, "retired-s"))
+}
+
+func TestReservationService_ReleaseReservation_ReturnsStock(t *testing.T) {
+	service, products := createTestReservationService(t)
+	ctx := context.Background()
+
+	reservation, err := service.CreateReservation(ctx, &models.ReservationRequest{
+		Lines: []models.StockLine{{ProductID: "product-1", Quantity: 3}},
+	})
+	require.NoError(t, err)
+
+	released, err := service.ReleaseReservation(ctx, reservation.ReservationID)
+	require.NoError(t, err)
+	assert.Equal(t, models.ReservationReleased, released.Status)
+	assert.Equal(t, 5, stockOf(t, products, "product-1"))
+
+	// Releasing twice must not return the stock twice
+	_, err = service.ReleaseReservation(ctx, reservation.ReservationID)
+	assert.NoError(t, err)
+	assert.Equal(t, 5, stockOf(t, products, "product-1"))
+}
+
+// flakyStockRepository fails to return stock while failRelease is set
+type flakyStockRepository struct {
+	repository.ProductRepository
+	failRelease bool
+}
+
+func (r *flakyStockRepository) ReleaseStock(ctx context.Context, lines []models.StockLine) error {
+	if r.failRelease {
+		return errors.New("connection reset")
+	}
+	return r.ProductRepository.ReleaseStock(ctx, lines)
+}
+
+func TestReservationService_ReleaseReservation_StockFailureKeepsPending(t *testing.T) {
+	service, products := createTestReservationService(t)
+	flaky := &flakyStockRepository{ProductRepository: products}
+	service.products = flaky
+	ctx := context.Background()
+
+	reservation, err := service.CreateReservation(ctx, &models.ReservationRequest{
+		Lines:      []models.StockLine{{ProductID: "product-1", Quantity: 3}},
+		TTLSeconds: 1,
+	})
+	require.NoError(t, err)
+
+	flaky.failRelease = true
+	_, err = service.ReleaseReservation(ctx, reservation.ReservationID)
+	assert.ErrorIs(t, err, ErrInternal)
+
+	stored, err := service.GetReservation(ctx, reservation.ReservationID)
+	require.NoError(t, err)
+	assert.Equal(t, models.ReservationPending, stored.Status, "a reservation whose stock was not returned stays pending")
+	assert.Equal(t, 2, stockOf(t, products, "product-1"))
+
+	// The expiry sweep hits the same failure and leaves it for the next run
+	time.Sleep(1100 * time.Millisecond)
+	_, err = service.ExpireReservations(ctx)
+	assert.Error(t, err)
+
+	flaky.failRelease = false
+	count, err := service.ExpireReservations(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+	assert.Equal(t, 5, stockOf(t, products, "product-1"))
+}
+
+func TestReservationService_ConfirmReservation(t *testing.T) {
+	service, products := createTestReservationService(t)
+	ctx := context.Background()
+
+	reservation, err := service.CreateReservation(ctx, &models.ReservationRequest{
+		Lines: []models.StockLine{{ProductID: "product-1", Quantity: 1}},
+	})
+	require.NoError(t, err)
+
+	confirmed, err := service.ConfirmReservation(ctx, reservation.ReservationID)
+	require.NoError(t, err)
+	assert.Equal(t, models.ReservationConfirmed, confirmed.Status)
+
+	_, err = service.ReleaseReservation(ctx, reservation.ReservationID)
+	assert.ErrorIs(t, err, ErrConflict)
+	assert.Equal(t, 4, stockOf(t, products, "product-1"))
+
+	_, err = service.ConfirmReservation(ctx, "res-unknown")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestReservationService_ExpireReservations(t *testing.T) {
+	service, products := createTestReservationService(t)
+	ctx := context.Background()
+
+	reservation, err := service.CreateReservation(ctx, &models.ReservationRequest{
+		Lines:      []models.StockLine{{ProductID: "product-1", Quantity: 2}},
+		TTLSeconds: 1,
+	})
+	require.NoError(t, err)
+
+	count, err := service.ExpireReservations(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 0, count)
+
+	time.Sleep(1100 * time.Millisecond)
+
+	_, err = service.ConfirmReservation(ctx, reservation.ReservationID)
+	assert.ErrorIs(t, err, ErrInactive)
+	assert.Equal(t, 5, stockOf(t, products, "product-1"))
+
+	count, err = service.ExpireReservations(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 0, count)
+}
@@ -0,0 +1,124 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/product-api-v2/internal/models"
+	"github.com/product-api-v2/internal/repository"
+	"github.com/sirupsen/logrus"
+)
+
+// SearchProducts runs a full-text search over the active catalog. Matching
+// ignores case and accents; with prefix set the last word of query also
+// matches longer words, for autocomplete. Only Category, the price range and
+// offset pagination of filters apply; a category includes its subcategories
+// and a price range without a currency is in the default one.
+func (s *ProductService) SearchProducts(ctx context.Context, query string, prefix bool, filters repository.ProductFilters) (*models.ProductSearchResponse, error) {
+	s.stats.request("SearchProducts")
+
+	logger := s.logger.WithFields(logrus.Fields{
+		"operation": "SearchProducts",
+		"query":     query,
+		"prefix":    prefix,
+		"requestId": ctx.Value("requestId"),
+	})
+
+	logger.Info("🔍 Searching products")
+
+	if err := s.expandCategory(ctx, &filters); err != nil {
+		s.stats.failure("SearchProducts")
+		logger.WithError(err).Error("💥 Failed to resolve category filter")
+		return nil, err
+	}
+
+	search, err := s.productSearch(query, prefix, filters)
+	if err != nil {
+		s.stats.failure("SearchProducts")
+		return nil, err
+	}
+
+	result, err := s.repo.Search(ctx, search)
+	if err != nil {
+		s.stats.failure("SearchProducts")
+		logger.WithError(err).Error("💥 Failed to search products")
+		return nil, repositoryError(err, "failed to search products")
+	}
+
+	response := &models.ProductSearchResponse{
+		Query:    query,
+		Results:  make([]models.ProductSearchHit, 0, len(result.Hits)),
+		Total:    result.Total,
+		Page:     search.Filters.Page,
+		PageSize: search.Filters.PageSize,
+		Facets:   result.Facets,
+	}
+	for _, hit := range result.Hits {
+		response.Results = append(response.Results, models.ProductSearchHit{
+			ProductID:  hit.Product.ProductID,
+			Name:       hit.Product.Name,
+			Price:      hit.Product.Price,
+			PriceMinor: hit.Product.PriceMinor,
+			Currency:   hit.Product.Currency,
+			Category:   hit.Product.Category,
+			Score:      hit.Score,
+		})
+	}
+
+	logger.WithFields(logrus.Fields{
+		"count": len(response.Results),
+		"total": response.Total,
+	}).Info("✅ Product search completed")
+
+	return response, nil
+}
+
+// productSearch builds the repository query for a search request
+func (s *ProductService) productSearch(query string, prefix bool, filters repository.ProductFilters) (repository.ProductSearch, error) {
+	terms := repository.SearchTokens(query)
+	if len(terms) == 0 {
+		return repository.ProductSearch{}, validationError(fmt.Errorf("q must contain at least one word"))
+	}
+	if maxTerms := s.config.Search.MaxTerms; maxTerms > 0 && len(terms) > maxTerms {
+		return repository.ProductSearch{}, validationError(fmt.Errorf("q must not contain more than %d words", maxTerms))
+	}
+
+	if err := validatePriceFilters(&filters); err != nil {
+		return repository.ProductSearch{}, validationError(err)
+	}
+
+	// Inactive products are never searchable, variants are found through
+	// their parent, and listing order does not apply
+	filters.Visibility = repository.VisibilityPublic
+	filters.TopLevel = true
+	filters.Sort = nil
+	filters.Cursor = nil
+	if filters.PageSize == 0 {
+		filters.PageSize = defaultPageSize
+	}
+
+	words := terms
+	search := repository.ProductSearch{
+		Filters:      filters,
+		PriceBuckets: priceBuckets(s.config.Search.PriceBuckets),
+	}
+	if prefix {
+		search.Prefix = terms[len(terms)-1]
+		words = terms[:len(terms)-1]
+	}
+	search.Terms = slices.Clone(words)
+	slices.Sort(search.Terms)
+	search.Terms = slices.Compact(search.Terms)
+	return search, nil
+}
+
+// priceBuckets returns the configured bucket bounds in ascending order,
+// without duplicates or bounds a price cannot fall below
+func priceBuckets(bounds []float64) []float64 {
+	sorted := slices.DeleteFunc(slices.Clone(bounds), func(bound float64) bool {
+		return bound <= 0
+	})
+	slices.Sort(sorted)
+	return slices.Compact(sorted)
+}
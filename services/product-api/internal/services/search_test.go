@@ -0,0 +1,153 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/product-api-v2/configs"
+	"github.com/product-api-v2/internal/models"
+	"github.com/product-api-v2/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// createTestSearchService returns a service over a memory repository holding
+// a small Spanish catalog
+func createTestSearchService(t *testing.T) (*ProductService, *repository.MemoryProductRepository) {
+	ctx := context.Background()
+	repo := repository.NewMemoryProductRepository()
+	catalog := []*models.Product{
+		{ProductID: "cafe-1", Name: "Café de Colombia", Description: "Café molido, tueste medio", Price: 12.5, Category: "bebidas", Active: true},
+		{ProductID: "cafe-2", Name: "Cafetera italiana", Description: "Para preparar café en casa", Price: 35, Category: "cocina", Active: true},
+		{ProductID: "te-1", Name: "Té verde", Description: "Hojas sueltas, sin cafeína", Price: 8, Category: "bebidas", Active: true},
+		{ProductID: "cana-1", Name: "Caña de pescar", Description: "Fibra de carbono", Price: 120, Category: "deportes", Active: true},
+		{ProductID: "cafe-3", Name: "Café descafeinado", Price: 14, Category: "bebidas", Active: false},
+	}
+	for _, product := range catalog {
+		assert.NoError(t, repo.Create(ctx, product))
+	}
+
+	service := createTestProductService(repo)
+	service.config.Search = configs.SearchConfig{PriceBuckets: []float64{50, 10}, MaxTerms: 3}
+	return service, repo
+}
+
+func TestProductService_SearchProducts_RanksAndFolds(t *testing.T) {
+	service, _ := createTestSearchService(t)
+
+	response, err := service.SearchProducts(context.Background(), "CAFE", false, repository.ProductFilters{})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, response.Total, "the inactive product is not searchable")
+	if assert.Len(t, response.Results, 2) {
+		// A match in the name outranks one in the description
+		assert.Equal(t, "cafe-1", response.Results[0].ProductID)
+		assert.Equal(t, "cafe-2", response.Results[1].ProductID)
+		assert.Greater(t, response.Results[0].Score, response.Results[1].Score)
+	}
+
+	assert.Equal(t, []models.CategoryFacet{{Category: "bebidas", Count: 1}, {Category: "cocina", Count: 1}}, response.Facets.Categories)
+	ten, fifty := 10.0, 50.0
+	assert.Equal(t, []models.PriceFacet{{Min: 10, Max: &fifty, Count: 2}}, response.Facets.Prices)
+
+	cheap, err := service.SearchProducts(context.Background(), "té", false, repository.ProductFilters{})
+	assert.NoError(t, err)
+	if assert.Len(t, cheap.Results, 1) {
+		assert.Equal(t, "te-1", cheap.Results[0].ProductID)
+	}
+	assert.Equal(t, []models.PriceFacet{{Min: 0, Max: &ten, Count: 1}}, cheap.Facets.Prices)
+}
+
+func TestProductService_SearchProducts_PriceFacetsPerCurrency(t *testing.T) {
+	service, repo := createTestSearchService(t)
+	ctx := context.Background()
+	assert.NoError(t, repo.Create(ctx, &models.Product{ProductID: "cafe-4", Name: "Café de Etiopía", PriceMinor: 1800, Currency: "EUR", Category: "bebidas", Active: true}))
+
+	// Without a currency filter only the default currency is bucketed
+	response, err := service.SearchProducts(ctx, "cafe", false, repository.ProductFilters{})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, response.Total)
+	assert.Equal(t, models.DefaultCurrency, response.Facets.PriceCurrency)
+	fifty := 50.0
+	assert.Equal(t, []models.PriceFacet{{Min: 10, Max: &fifty, Count: 2}}, response.Facets.Prices)
+
+	response, err = service.SearchProducts(ctx, "cafe", false, repository.ProductFilters{Currency: "EUR"})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, response.Total)
+	assert.Equal(t, "EUR", response.Facets.PriceCurrency)
+	assert.Equal(t, []models.PriceFacet{{Min: 10, Max: &fifty, Count: 1}}, response.Facets.Prices)
+}
+
+func TestProductService_SearchProducts_PrefixAndFilters(t *testing.T) {
+	service, repo := createTestSearchService(t)
+	ctx := context.Background()
+
+	// "caf" completes to café, cafetera and cafeína but not caña
+	response, err := service.SearchProducts(ctx, "caf", true, repository.ProductFilters{})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, response.Total)
+
+	response, err = service.SearchProducts(ctx, "caña", true, repository.ProductFilters{})
+	assert.NoError(t, err)
+	if assert.Len(t, response.Results, 1) {
+		assert.Equal(t, "cana-1", response.Results[0].ProductID)
+	}
+	assert.Equal(t, []models.PriceFacet{{Min: 50, Count: 1}}, response.Facets.Prices)
+
+	// Earlier words must match in full; only the last one is a prefix
+	response, err = service.SearchProducts(ctx, "verde caf", true, repository.ProductFilters{})
+	assert.NoError(t, err)
+	if assert.Len(t, response.Results, 1) {
+		assert.Equal(t, "te-1", response.Results[0].ProductID)
+	}
+
+	response, err = service.SearchProducts(ctx, "cafe", false, repository.ProductFilters{Category: "cocina"})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, response.Total)
+
+	response, err = service.SearchProducts(ctx, "cafe", false, repository.ProductFilters{PageSize: 1, Page: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, response.Total)
+	if assert.Len(t, response.Results, 1) {
+		assert.Equal(t, "cafe-2", response.Results[0].ProductID)
+	}
+
+	// The index follows updates and deletes
+	product, err := repo.GetByID(ctx, "cana-1")
+	assert.NoError(t, err)
+	product.Name = "Caña de azúcar"
+	assert.NoError(t, repo.Update(ctx, product))
+	response, err = service.SearchProducts(ctx, "pescar", false, repository.ProductFilters{})
+	assert.NoError(t, err)
+	assert.Zero(t, response.Total)
+
+	assert.NoError(t, repo.Delete(ctx, "cafe-2"))
+	response, err = service.SearchProducts(ctx, "cafetera", false, repository.ProductFilters{})
+	assert.NoError(t, err)
+	assert.Zero(t, response.Total)
+}
+
+func TestProductService_SearchProducts_RejectsBadQueries(t *testing.T) {
+	mockRepo := &MockProductRepository{}
+	service := createTestProductService(mockRepo)
+	service.config.Search.MaxTerms = 2
+
+	for _, query := range []string{"", "  -- ", "uno dos tres"} {
+		_, err := service.SearchProducts(context.Background(), query, false, repository.ProductFilters{})
+		assert.ErrorIs(t, err, ErrValidation, query)
+	}
+	mockRepo.AssertNotCalled(t, "Search", mock.Anything, mock.Anything)
+}
+
+func TestProductService_SearchProducts_RepositoryError(t *testing.T) {
+	mockRepo := &MockProductRepository{}
+	service := createTestProductService(mockRepo)
+
+	mockRepo.On("Search", mock.Anything, mock.MatchedBy(func(search repository.ProductSearch) bool {
+		return search.Prefix == "mon" && len(search.Terms) == 1 && search.Terms[0] == "raton" &&
+			search.Filters.Visibility == repository.VisibilityPublic && search.Filters.PageSize == defaultPageSize
+	})).Return(nil, repository.ErrRepositoryUnavailable)
+
+	_, err := service.SearchProducts(context.Background(), "Ratón mon", true, repository.ProductFilters{})
+	assert.ErrorIs(t, err, ErrUnavailable)
+	mockRepo.AssertExpectations(t)
+}
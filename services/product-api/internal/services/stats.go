@@ -0,0 +1,160 @@
+package services
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// errorWindowBucket and errorWindowBuckets define the sliding window used
+	// for the recent error rate: 30 buckets of 10s cover the last five minutes
+	errorWindowBucket  = 10 * time.Second
+	errorWindowBuckets = 30
+
+	// degradedErrorRate is the recent error rate above which a service
+	// reports itself as degraded
+	degradedErrorRate = 0.10
+
+	// degradedMinRequests avoids flapping to degraded on a handful of requests
+	degradedMinRequests = 10
+)
+
+// OperationCounts holds request and error counts for one operation or window
+type OperationCounts struct {
+	Requests int64 `json:"requests"`
+	Errors   int64 `json:"errors"`
+}
+
+// ErrorRate returns errors as a fraction of requests
+func (c OperationCounts) ErrorRate() float64 {
+	if c.Requests == 0 {
+		return 0
+	}
+	return float64(c.Errors) / float64(c.Requests)
+}
+
+// operationStats counts requests and errors per service operation. Lifetime
+// totals are atomic per operation; a sliding window of recent outcomes
+// drives the error rate used for health. It is safe for concurrent use.
+type operationStats struct {
+	counters sync.Map // operation name -> *operationCounter
+	window   outcomeWindow
+	now      func() time.Time
+}
+
+// operationCounter is the lifetime count for one operation
+type operationCounter struct {
+	requests atomic.Int64
+	errors   atomic.Int64
+}
+
+// newOperationStats creates empty statistics
+func newOperationStats() *operationStats {
+	return &operationStats{
+		window: outcomeWindow{buckets: make([]outcomeBucket, errorWindowBuckets)},
+		now:    time.Now,
+	}
+}
+
+// request records that an operation was called
+func (o *operationStats) request(operation string) {
+	o.counter(operation).requests.Add(1)
+	o.window.add(o.now(), 1, 0)
+}
+
+// failure records that an operation failed
+func (o *operationStats) failure(operation string) {
+	o.counter(operation).errors.Add(1)
+	o.window.add(o.now(), 0, 1)
+}
+
+// counter returns the counter for operation, creating it on first use
+func (o *operationStats) counter(operation string) *operationCounter {
+	if counter, ok := o.counters.Load(operation); ok {
+		return counter.(*operationCounter)
+	}
+	counter, _ := o.counters.LoadOrStore(operation, &operationCounter{})
+	return counter.(*operationCounter)
+}
+
+// byOperation returns lifetime counts keyed by operation name
+func (o *operationStats) byOperation() map[string]OperationCounts {
+	snapshot := make(map[string]OperationCounts)
+	o.counters.Range(func(key, value interface{}) bool {
+		counter := value.(*operationCounter)
+		snapshot[key.(string)] = OperationCounts{
+			Requests: counter.requests.Load(),
+			Errors:   counter.errors.Load(),
+		}
+		return true
+	})
+	return snapshot
+}
+
+// totals returns lifetime counts summed over all operations
+func (o *operationStats) totals() OperationCounts {
+	var total OperationCounts
+	for _, counts := range o.byOperation() {
+		total.Requests += counts.Requests
+		total.Errors += counts.Errors
+	}
+	return total
+}
+
+// recent returns counts for the sliding window ending now
+func (o *operationStats) recent() OperationCounts {
+	return o.window.sum(o.now())
+}
+
+// degraded reports whether the recent error rate exceeds the threshold
+func (o *operationStats) degraded() bool {
+	recent := o.recent()
+	return recent.Requests >= degradedMinRequests && recent.ErrorRate() > degradedErrorRate
+}
+
+// outcomeWindow is a ring of time buckets; a bucket is reused once its slot
+// comes around again, which discards counts older than the window
+type outcomeWindow struct {
+	mutex   sync.Mutex
+	buckets []outcomeBucket
+}
+
+// outcomeBucket holds the counts of one errorWindowBucket interval
+type outcomeBucket struct {
+	epoch int64
+	OperationCounts
+}
+
+// add records counts in the bucket covering now
+func (w *outcomeWindow) add(now time.Time, requests, errors int64) {
+	epoch := now.UnixNano() / int64(errorWindowBucket)
+
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	bucket := &w.buckets[epoch%int64(len(w.buckets))]
+	if bucket.epoch != epoch {
+		*bucket = outcomeBucket{epoch: epoch}
+	}
+	bucket.Requests += requests
+	bucket.Errors += errors
+}
+
+// sum returns the counts of all buckets inside the window ending at now
+func (w *outcomeWindow) sum(now time.Time) OperationCounts {
+	epoch := now.UnixNano() / int64(errorWindowBucket)
+	oldest := epoch - int64(len(w.buckets)) + 1
+
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	var total OperationCounts
+	for _, bucket := range w.buckets {
+		if bucket.epoch >= oldest && bucket.epoch <= epoch {
+			total.Requests += bucket.Requests
+			total.Errors += bucket.Errors
+		}
+	}
+	return total
+}
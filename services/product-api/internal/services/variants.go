@@ -0,0 +1,185 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/product-api-v2/internal/models"
+	"github.com/product-api-v2/internal/repository"
+	"github.com/sirupsen/logrus"
+)
+
+// checkHierarchy applies the rules that depend on other products before a
+// product is stored: a variant's parent must exist and not be a variant
+// itself, a product with variants cannot become one, and SKUs are unique.
+// The SKU check gives an early answer; the repository enforces uniqueness
+// against concurrent writes.
+// A variant takes its parent's category, which cascadeCategory keeps in
+// step when the parent moves, and the parent's currency for a price given
+// without one.
+func (s *ProductService) checkHierarchy(ctx context.Context, logger *logrus.Entry, product *models.Product) error {
+	if product.IsVariant() && product.ParentID != product.ProductID {
+		parent, err := s.repo.GetByID(ctx, product.ParentID)
+		if err != nil {
+			if errors.Is(err, repository.ErrProductNotFound) {
+				return validationError(fmt.Errorf("parent product %s not found", product.ParentID))
+			}
+			logger.WithError(err).Error("💥 Failed to retrieve parent product")
+			return repositoryError(err, "failed to retrieve parent product")
+		}
+		if parent.IsVariant() {
+			return validationError(fmt.Errorf("parent product %s is itself a variant of %s", parent.ProductID, parent.ParentID))
+		}
+
+		if product.Currency == "" {
+			product.Currency = parent.Currency
+		}
+		if (product.PriceMinor != 0 || product.Price != 0) && product.Currency != parent.Currency {
+			return validationError(fmt.Errorf("variant currency %s does not match parent currency %s", product.Currency, parent.Currency))
+		}
+		product.Category = parent.Category
+
+		variants, err := s.repo.Count(ctx, repository.ProductFilters{ParentIDs: []string{product.ProductID}})
+		if err != nil {
+			logger.WithError(err).Error("💥 Failed to count variants")
+			return repositoryError(err, "failed to count variants")
+		}
+		if variants > 0 {
+			return newError(ErrConflict, "product_has_variants", nil, "product %s has variants and cannot become a variant", product.ProductID)
+		}
+	}
+
+	if product.SKU != "" {
+		owners, err := s.repo.GetAll(ctx, repository.ProductFilters{SKU: product.SKU, Fields: []string{"productId"}})
+		if err != nil {
+			logger.WithError(err).Error("💥 Failed to look up SKU")
+			return repositoryError(err, "failed to look up SKU")
+		}
+		for _, owner := range owners {
+			if owner.ProductID != product.ProductID {
+				return newError(ErrConflict, "sku_exists", nil, "SKU %s already belongs to product %s", product.SKU, owner.ProductID)
+			}
+		}
+	}
+
+	return nil
+}
+
+// cascadeCategory gives the variants of parents their parent's category,
+// as a variant always has, and returns the changes it made. Variants are
+// stored with the category so that category filters and search find them.
+func (s *ProductService) cascadeCategory(ctx context.Context, logger *logrus.Entry, operation string, parents ...*models.Product) ([]*models.ProductChange, error) {
+	byID := make(map[string]*models.Product, len(parents))
+	parentIDs := make([]string, 0, len(parents))
+	for _, parent := range parents {
+		if !parent.IsVariant() {
+			byID[parent.ProductID] = parent
+			parentIDs = append(parentIDs, parent.ProductID)
+		}
+	}
+	if len(parentIDs) == 0 {
+		return nil, nil
+	}
+
+	variants, err := s.repo.GetAll(ctx, repository.ProductFilters{ParentIDs: parentIDs})
+	if err != nil {
+		logger.WithError(err).Error("💥 Failed to read variants")
+		return nil, repositoryError(err, "failed to retrieve variants")
+	}
+
+	var changes []*models.ProductChange
+	for _, variant := range variants {
+		category := byID[variant.ParentID].Category
+		if variant.Category == category {
+			continue
+		}
+		before := *variant
+		variant.Category = category
+		if err := s.saveProduct(ctx, logger, operation, variant, nil); err != nil {
+			return nil, err
+		}
+		changes = append(changes, newProductChange(ctx, models.AuditUpdate, &before, variant))
+	}
+
+	if len(changes) > 0 {
+		logger.WithField("variants", len(changes)).Info("🔁 Parent category passed on to variants")
+	}
+	return changes, nil
+}
+
+// skuExistsError reports a SKU the repository found on another product
+func skuExistsError(sku string) *Error {
+	return newError(ErrConflict, "sku_exists", nil, "SKU %s already belongs to another product", sku)
+}
+
+// inheritFromParents completes the variants among products with the fields
+// they take from their parent. A variant whose parent is gone is inactive.
+func inheritFromParents(ctx context.Context, repo repository.ProductRepository, products ...*models.Product) error {
+	var parentIDs []string
+	for _, product := range products {
+		if product.IsVariant() {
+			parentIDs = append(parentIDs, product.ParentID)
+		}
+	}
+	if len(parentIDs) == 0 {
+		return nil
+	}
+
+	parents, err := repo.GetByIDs(ctx, parentIDs)
+	if err != nil {
+		return err
+	}
+	byID := make(map[string]*models.Product, len(parents))
+	for _, parent := range parents {
+		byID[parent.ProductID] = parent
+	}
+
+	for _, product := range products {
+		if !product.IsVariant() {
+			continue
+		}
+		if parent, ok := byID[product.ParentID]; ok {
+			product.Inherit(parent)
+		} else {
+			product.Active = false
+		}
+	}
+	return nil
+}
+
+// attachVariants reads the variants of parents visible under visibility and
+// nests them under their parent, in product ID order. A non-empty fields
+// limits the variant fields read.
+func (s *ProductService) attachVariants(ctx context.Context, parents []*models.Product, visibility repository.Visibility, fields []string) error {
+	parentIDs := make([]string, 0, len(parents))
+	byID := make(map[string]*models.Product, len(parents))
+	for _, parent := range parents {
+		if !parent.IsVariant() {
+			parentIDs = append(parentIDs, parent.ProductID)
+			byID[parent.ProductID] = parent
+		}
+	}
+	if len(parentIDs) == 0 {
+		return nil
+	}
+
+	filters := repository.ProductFilters{ParentIDs: parentIDs, Visibility: visibility}
+	if len(fields) > 0 {
+		filters.Fields = withProductFields(fields, "parentId", "sku", "attributes", "priceMinor", "active")
+	}
+	variants, err := s.repo.GetAll(ctx, filters)
+	if err != nil {
+		return err
+	}
+
+	for _, variant := range variants {
+		parent := byID[variant.ParentID]
+		variant.Inherit(parent)
+		if visibility == repository.VisibilityPublic && !variant.Active {
+			continue
+		}
+		parent.Variants = append(parent.Variants, variant)
+	}
+	return nil
+}
@@ -0,0 +1,202 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/product-api-v2/internal/models"
+	"github.com/product-api-v2/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// createTestCatalogWithVariants returns a service over a monitor sold in two
+// sizes, one of them priced above the parent, and a standalone product
+func createTestCatalogWithVariants(t *testing.T) (*ProductService, *repository.MemoryProductRepository) {
+	ctx := context.Background()
+	repo := repository.NewMemoryProductRepository()
+	service := createTestProductService(repo)
+
+	require.NoError(t, service.CreateProduct(ctx, &models.Product{
+		ProductID: "monitor", Name: "Monitor", Description: "IPS panel", PriceMinor: 19900, Currency: "EUR",
+		Category: "displays", Active: true,
+	}))
+	require.NoError(t, service.CreateProduct(ctx, &models.Product{
+		ProductID: "monitor-24", ParentID: "monitor", SKU: "MON-24", Attributes: map[string]string{"size": "24"},
+		Stock: 4, Active: true,
+	}))
+	require.NoError(t, service.CreateProduct(ctx, &models.Product{
+		ProductID: "monitor-27", ParentID: "monitor", SKU: "MON-27", Attributes: map[string]string{"size": "27"},
+		PriceMinor: 24900, Stock: 2, Active: true,
+	}))
+	require.NoError(t, service.CreateProduct(ctx, &models.Product{
+		ProductID: "cable", Name: "Cable", PriceMinor: 990, Currency: "EUR", Active: true,
+	}))
+	return service, repo
+}
+
+func TestProductService_GetProduct_Variant(t *testing.T) {
+	service, _ := createTestCatalogWithVariants(t)
+	ctx := context.Background()
+
+	variant, err := service.GetProduct(ctx, "monitor-24")
+	require.NoError(t, err)
+	assert.Equal(t, "Monitor", variant.Name)
+	assert.Equal(t, "IPS panel", variant.Description)
+	assert.Equal(t, int64(19900), variant.PriceMinor)
+	assert.Equal(t, "EUR", variant.Currency)
+	assert.Equal(t, "displays", variant.Category)
+	assert.Equal(t, 4, variant.Stock)
+
+	override, err := service.GetProduct(ctx, "monitor-27")
+	require.NoError(t, err)
+	assert.Equal(t, int64(24900), override.PriceMinor)
+	assert.Equal(t, 249.0, override.Price)
+
+	parent, err := service.GetProduct(ctx, "monitor")
+	require.NoError(t, err)
+	require.Len(t, parent.Variants, 2)
+	assert.Equal(t, "MON-24", parent.Variants[0].SKU)
+	assert.Equal(t, "27", parent.Variants[1].Attributes["size"])
+}
+
+func TestProductService_GetProduct_VariantOfInactiveParent(t *testing.T) {
+	service, _ := createTestCatalogWithVariants(t)
+	ctx := context.Background()
+
+	_, err := service.SetProductActive(ctx, "monitor", false, nil)
+	require.NoError(t, err)
+
+	_, err = service.GetProduct(ctx, "monitor-24")
+	assert.ErrorIs(t, err, ErrInactive)
+}
+
+func TestProductService_GetProducts_CollapsesVariants(t *testing.T) {
+	service, repo := createTestCatalogWithVariants(t)
+	ctx := context.Background()
+
+	// Inactive variants are left out of public listings
+	variant, err := repo.GetByID(ctx, "monitor-27")
+	require.NoError(t, err)
+	variant.Active = false
+	require.NoError(t, repo.Update(ctx, variant))
+
+	response, err := service.GetProducts(ctx, repository.ProductFilters{})
+	require.NoError(t, err)
+	assert.Equal(t, 2, response.Total)
+	require.Len(t, response.Products, 2)
+	assert.Equal(t, "cable", response.Products[0].ProductID)
+	assert.Empty(t, response.Products[0].Variants)
+	assert.Equal(t, "monitor", response.Products[1].ProductID)
+	require.Len(t, response.Products[1].Variants, 1)
+
+	encoded, err := response.Products[1].MarshalJSON()
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"productId": "monitor", "name": "Monitor", "price": 199, "currency": "EUR", "active": true,
+		"variants": [{
+			"productId": "monitor-24", "sku": "MON-24", "name": "Monitor", "price": 199, "currency": "EUR",
+			"attributes": {"size": "24"}, "active": true
+		}]
+	}`, string(encoded))
+}
+
+func TestProductService_CreateProduct_VariantRules(t *testing.T) {
+	service, _ := createTestCatalogWithVariants(t)
+	ctx := context.Background()
+
+	tests := []struct {
+		name    string
+		product *models.Product
+		kind    error
+	}{
+		{"missing parent", &models.Product{ProductID: "v", ParentID: "missing", SKU: "V-1"}, ErrValidation},
+		{"variant of a variant", &models.Product{ProductID: "v", ParentID: "monitor-24", SKU: "V-1"}, ErrValidation},
+		{"missing SKU", &models.Product{ProductID: "v", ParentID: "monitor"}, ErrValidation},
+		{"duplicate SKU", &models.Product{ProductID: "v", ParentID: "monitor", SKU: "MON-24"}, ErrConflict},
+		{"other currency", &models.Product{ProductID: "v", ParentID: "monitor", SKU: "V-1", PriceMinor: 100, Currency: "USD"}, ErrValidation},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := service.CreateProduct(ctx, tt.product)
+			assert.ErrorIs(t, err, tt.kind)
+		})
+	}
+
+	// A product with variants cannot become one
+	_, err := service.PatchProduct(ctx, "monitor", map[string]interface{}{"parentId": "cable", "sku": "MON"}, nil)
+	assert.ErrorIs(t, err, ErrConflict)
+}
+
+func TestProductService_CreateProduct_ConcurrentSKU(t *testing.T) {
+	service, repo := createTestCatalogWithVariants(t)
+	ctx := context.Background()
+
+	// Both creates may pass the SKU lookup; the repository lets one through
+	errs := make(chan error, 2)
+	var wg sync.WaitGroup
+	for _, productID := range []string{"monitor-32", "monitor-34"} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- service.CreateProduct(ctx, &models.Product{ProductID: productID, ParentID: "monitor", SKU: "MON-32", Active: true})
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	var conflicts int
+	for err := range errs {
+		if err != nil {
+			assert.ErrorIs(t, err, ErrConflict)
+			conflicts++
+		}
+	}
+	assert.Equal(t, 1, conflicts)
+
+	owners, err := repo.GetAll(ctx, repository.ProductFilters{SKU: "MON-32"})
+	require.NoError(t, err)
+	assert.Len(t, owners, 1)
+}
+
+func TestProductService_PatchProduct_CascadesCategory(t *testing.T) {
+	service, repo := createTestCatalogWithVariants(t)
+	ctx := context.Background()
+
+	_, err := service.PatchProduct(ctx, "monitor", map[string]interface{}{"category": "monitors"}, nil)
+	require.NoError(t, err)
+
+	for _, variantID := range []string{"monitor-24", "monitor-27"} {
+		variant, err := repo.GetByID(ctx, variantID)
+		require.NoError(t, err)
+		assert.Equal(t, "monitors", variant.Category, variantID)
+	}
+	stale, err := repo.Count(ctx, repository.ProductFilters{Category: "displays"})
+	require.NoError(t, err)
+	assert.Zero(t, stale, "no product is left in the old category")
+
+	// An import that moves the parent moves its variants too
+	input := `{"productId":"monitor","name":"Monitor","priceMinor":19900,"currency":"EUR","category":"screens","active":true}
+`
+	report, err := service.ImportProducts(ctx, strings.NewReader(input), FormatNDJSON, ImportUpsert)
+	require.NoError(t, err)
+	assert.Equal(t, 1, report.Updated)
+	variant, err := repo.GetByID(ctx, "monitor-27")
+	require.NoError(t, err)
+	assert.Equal(t, "screens", variant.Category)
+}
+
+func TestProductService_DeleteProduct_ParentWithVariants(t *testing.T) {
+	service, _ := createTestCatalogWithVariants(t)
+	ctx := context.Background()
+
+	err := service.DeleteProduct(ctx, "monitor", true, nil)
+	assert.ErrorIs(t, err, ErrConflict)
+
+	require.NoError(t, service.DeleteProduct(ctx, "monitor-24", true, nil))
+	require.NoError(t, service.DeleteProduct(ctx, "monitor-27", true, nil))
+	assert.NoError(t, service.DeleteProduct(ctx, "monitor", true, nil))
+}
@@ -0,0 +1,671 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	mathrand "math/rand"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"slices"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/product-api-v2/configs"
+	"github.com/product-api-v2/internal/models"
+	"github.com/product-api-v2/internal/repository"
+	"github.com/sirupsen/logrus"
+)
+
+// Headers sent with every webhook delivery. The signature is
+// "sha256=" followed by the hex HMAC-SHA256 of the timestamp, a dot and
+// the body, keyed with the subscription secret; see SignWebhook.
+const (
+	HeaderWebhookID        = "X-Webhook-Id"
+	HeaderWebhookEvent     = "X-Webhook-Event"
+	HeaderWebhookDelivery  = "X-Webhook-Delivery"
+	HeaderWebhookTimestamp = "X-Webhook-Timestamp"
+	HeaderWebhookSignature = "X-Webhook-Signature"
+)
+
+const (
+	// minWebhookSecretLength is the shortest secret a subscriber may choose
+	minWebhookSecretLength = 16
+
+	// defaultDeliveryLogLimit and maxDeliveryLogLimit bound delivery log pages
+	defaultDeliveryLogLimit = 50
+	maxDeliveryLogLimit     = 500
+
+	// Defaults for unset webhook configuration
+	defaultWebhookTimeout     = 10 * time.Second
+	defaultWebhookBatchSize   = 50
+	defaultWebhookMaxAttempts = 8
+
+	// subscriptionCacheTTL bounds how long a subscription created or
+	// deleted through another instance goes unnoticed by enqueue
+	subscriptionCacheTTL = 30 * time.Second
+)
+
+// errPrivateWebhookAddress rejects a delivery to an address outside the
+// public internet
+var errPrivateWebhookAddress = errors.New("webhook address is not public")
+
+// WebhookService manages webhook subscriptions and delivers the events they
+// subscribe to. Deliveries are queued with the write that triggers them and
+// sent by Run, at least once: a delivery that fails is retried with
+// exponential backoff and jitter, and dead-lettered once it runs out of
+// attempts.
+type WebhookService struct {
+	webhooks   repository.WebhookRepository
+	deliveries repository.WebhookDeliveryRepository
+	client     *http.Client
+	config     *configs.Config
+	logger     *logrus.Logger
+
+	// subscriptions caches every subscription for enqueue, which runs on
+	// every write; nil until loaded and after a change
+	subscriptions       []*models.WebhookSubscription
+	subscriptionsLoaded time.Time
+	subscriptionsMutex  sync.Mutex
+}
+
+// NewWebhookService creates a new webhook service. Its client checks every
+// address it connects to after name resolution, so a host that resolves to
+// a private address is refused even if it did not when it was subscribed;
+// proxies are not used, as they would connect on the client's behalf.
+func NewWebhookService(webhooks repository.WebhookRepository, deliveries repository.WebhookDeliveryRepository, config *configs.Config, logger *logrus.Logger) *WebhookService {
+	timeout := config.Webhooks.Timeout
+	if timeout <= 0 {
+		timeout = defaultWebhookTimeout
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	if !config.Webhooks.AllowPrivateNetworks {
+		dialer := &net.Dialer{Timeout: timeout, KeepAlive: 30 * time.Second, Control: checkWebhookDial}
+		transport.DialContext = dialer.DialContext
+	}
+
+	return &WebhookService{
+		webhooks:   webhooks,
+		deliveries: deliveries,
+		client:     &http.Client{Timeout: timeout, Transport: transport},
+		config:     config,
+		logger:     logger,
+	}
+}
+
+// CreateWebhook subscribes request.URL to request.EventTypes. The returned
+// subscription is the only one to include the signing secret.
+func (s *WebhookService) CreateWebhook(ctx context.Context, request *models.WebhookRequest) (*models.WebhookSubscription, error) {
+	logger := s.logger.WithFields(logrus.Fields{
+		"operation": "CreateWebhook",
+		"url":       request.URL,
+		"requestId": ctx.Value("requestId"),
+	})
+
+	eventTypes, err := validateWebhookRequest(request, s.config.Webhooks.AllowPrivateNetworks)
+	if err != nil {
+		logger.WithError(err).Warn("⚠️ Invalid webhook request")
+		return nil, validationError(err)
+	}
+
+	webhookID, err := newWebhookID()
+	if err != nil {
+		return nil, newError(ErrInternal, "internal_error", err, "failed to generate webhook ID")
+	}
+	secret := request.Secret
+	if secret == "" {
+		if secret, err = newWebhookSecret(); err != nil {
+			return nil, newError(ErrInternal, "internal_error", err, "failed to generate webhook secret")
+		}
+	}
+
+	webhook := &models.WebhookSubscription{
+		WebhookID:   webhookID,
+		URL:         request.URL,
+		EventTypes:  eventTypes,
+		Description: request.Description,
+		Secret:      secret,
+		CreatedAt:   time.Now(),
+	}
+	if err := s.webhooks.Create(ctx, webhook); err != nil {
+		logger.WithError(err).Error("💥 Failed to store webhook")
+		return nil, repositoryError(err, "failed to create webhook")
+	}
+	s.forgetSubscriptions()
+
+	logger.WithFields(logrus.Fields{
+		"webhookId":  webhook.WebhookID,
+		"eventTypes": webhook.EventTypes,
+	}).Info("🪝 Webhook created")
+
+	return webhook, nil
+}
+
+// GetWebhooks returns every subscription, without secrets
+func (s *WebhookService) GetWebhooks(ctx context.Context) (*models.WebhookListResponse, error) {
+	webhooks, err := s.webhooks.GetAll(ctx)
+	if err != nil {
+		return nil, repositoryError(err, "failed to retrieve webhooks")
+	}
+
+	for _, webhook := range webhooks {
+		webhook.Secret = ""
+	}
+	return &models.WebhookListResponse{Webhooks: webhooks, Total: len(webhooks)}, nil
+}
+
+// GetWebhook returns a subscription, without its secret
+func (s *WebhookService) GetWebhook(ctx context.Context, webhookID string) (*models.WebhookSubscription, error) {
+	webhook, err := s.getWebhook(ctx, webhookID)
+	if err != nil {
+		return nil, err
+	}
+
+	webhook.Secret = ""
+	return webhook, nil
+}
+
+// DeleteWebhook removes a subscription. Its pending deliveries are
+// dead-lettered when they come due.
+func (s *WebhookService) DeleteWebhook(ctx context.Context, webhookID string) error {
+	if err := s.webhooks.Delete(ctx, webhookID); err != nil {
+		if errors.Is(err, repository.ErrWebhookNotFound) {
+			return webhookNotFoundError(webhookID)
+		}
+		return repositoryError(err, "failed to delete webhook")
+	}
+	s.forgetSubscriptions()
+
+	s.logger.WithFields(logrus.Fields{
+		"webhookId": webhookID,
+		"requestId": ctx.Value("requestId"),
+	}).Info("🗑️ Webhook deleted")
+
+	return nil
+}
+
+// GetDeliveries returns the delivery log, newest first. An empty webhookID
+// covers every subscription, so status models.DeliveryDead lists the whole
+// dead-letter queue.
+func (s *WebhookService) GetDeliveries(ctx context.Context, webhookID, status string, limit int) (*models.WebhookDeliveryResponse, error) {
+	if status != "" && !slices.Contains([]string{models.DeliveryPending, models.DeliveryDelivered, models.DeliveryDead}, status) {
+		return nil, validationError(fmt.Errorf("unknown delivery status %q", status))
+	}
+	if webhookID != "" {
+		if _, err := s.getWebhook(ctx, webhookID); err != nil {
+			return nil, err
+		}
+	}
+	if limit <= 0 {
+		limit = defaultDeliveryLogLimit
+	}
+
+	deliveries, err := s.deliveries.List(ctx, repository.WebhookDeliveryFilter{
+		WebhookID: webhookID,
+		Status:    status,
+		Limit:     min(limit, maxDeliveryLogLimit),
+	})
+	if err != nil {
+		return nil, repositoryError(err, "failed to retrieve webhook deliveries")
+	}
+
+	return &models.WebhookDeliveryResponse{Deliveries: deliveries, Total: len(deliveries)}, nil
+}
+
+// RedeliverDelivery queues a delivery of a subscription again with a fresh
+// set of attempts, typically to replay a dead letter once the receiver is
+// fixed. The payload, and so its event ID, stay the same.
+func (s *WebhookService) RedeliverDelivery(ctx context.Context, webhookID, deliveryID string) (*models.WebhookDelivery, error) {
+	if _, err := s.getWebhook(ctx, webhookID); err != nil {
+		return nil, err
+	}
+
+	delivery, err := s.deliveries.GetByID(ctx, deliveryID)
+	if err != nil && !errors.Is(err, repository.ErrDeliveryNotFound) {
+		return nil, repositoryError(err, "failed to retrieve webhook delivery")
+	}
+	if err != nil || delivery.WebhookID != webhookID {
+		return nil, newError(ErrNotFound, "delivery_not_found", nil, "webhook %s has no delivery %s", webhookID, deliveryID)
+	}
+	if delivery.Status == models.DeliveryPending {
+		return nil, newError(ErrConflict, "delivery_pending", nil, "delivery %s is already pending", deliveryID)
+	}
+
+	delivery.Status = models.DeliveryPending
+	delivery.Attempts = 0
+	delivery.NextAttemptAt = time.Now()
+	delivery.DeliveredAt = nil
+	if err := s.deliveries.Update(ctx, delivery); err != nil {
+		return nil, repositoryError(err, "failed to update webhook delivery")
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"webhookId":  webhookID,
+		"deliveryId": deliveryID,
+		"requestId":  ctx.Value("requestId"),
+	}).Info("🔁 Webhook delivery queued again")
+
+	return delivery, nil
+}
+
+// DispatchDue sends the deliveries that are due, concurrently, and returns
+// how many were attempted. A delivery whose subscription cannot be loaded
+// is left claimed, so it is attempted again once its lease runs out; the
+// others are still sent, and the lookup errors are returned joined.
+func (s *WebhookService) DispatchDue(ctx context.Context) (int, error) {
+	batchSize := s.config.Webhooks.BatchSize
+	if batchSize <= 0 {
+		batchSize = defaultWebhookBatchSize
+	}
+
+	// The lease outlasts an attempt, so a delivery is only claimed again
+	// if its dispatcher stopped before recording the outcome
+	deliveries, err := s.deliveries.ClaimDue(ctx, time.Now(), 2*s.client.Timeout, batchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	webhooks := make(map[string]*models.WebhookSubscription)
+	var errs []error
+	var wg sync.WaitGroup
+	attempted := 0
+	for _, delivery := range deliveries {
+		webhook, found := webhooks[delivery.WebhookID]
+		if !found {
+			webhook, err = s.webhooks.GetByID(ctx, delivery.WebhookID)
+			if err != nil && !errors.Is(err, repository.ErrWebhookNotFound) {
+				errs = append(errs, fmt.Errorf("delivery %s: %w", delivery.DeliveryID, err))
+				continue
+			}
+			webhooks[delivery.WebhookID] = webhook
+		}
+
+		attempted++
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.attempt(ctx, webhook, delivery)
+		}()
+	}
+	wg.Wait()
+
+	return attempted, errors.Join(errs...)
+}
+
+// Run dispatches due deliveries until ctx is cancelled
+func (s *WebhookService) Run(ctx context.Context) {
+	interval := s.config.Webhooks.PollInterval
+	if interval <= 0 {
+		interval = time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := s.DispatchDue(ctx); err != nil && ctx.Err() == nil {
+				s.logger.WithError(err).Warn("⚠️ Webhook dispatch failed")
+			}
+		}
+	}
+}
+
+// SignWebhook returns the signature header value of a delivery body sent
+// at timestamp, in Unix seconds. Receivers recompute it to authenticate a
+// delivery and should reject stale timestamps.
+func SignWebhook(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// enqueue queues a delivery to every subscription of each webhook event
+// that events trigger
+func (s *WebhookService) enqueue(ctx context.Context, events ...*models.ProductChangedEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	webhooks, err := s.subscribers(ctx)
+	if err != nil || len(webhooks) == 0 {
+		return err
+	}
+
+	now := time.Now()
+	deliveries := []*models.WebhookDelivery{}
+	for _, event := range events {
+		for _, eventType := range webhookEventTypes(event) {
+			subscribers := []*models.WebhookSubscription{}
+			for _, webhook := range webhooks {
+				if slices.Contains(webhook.EventTypes, eventType) {
+					subscribers = append(subscribers, webhook)
+				}
+			}
+			if len(subscribers) == 0 {
+				continue
+			}
+
+			eventID, err := newEventID()
+			if err != nil {
+				return err
+			}
+			payload, err := json.Marshal(models.WebhookEvent{
+				EventID:    eventID,
+				Type:       eventType,
+				OccurredAt: event.OccurredAt,
+				Data:       event,
+			})
+			if err != nil {
+				return err
+			}
+
+			for _, webhook := range subscribers {
+				deliveryID, err := newDeliveryID()
+				if err != nil {
+					return err
+				}
+				deliveries = append(deliveries, &models.WebhookDelivery{
+					DeliveryID:    deliveryID,
+					WebhookID:     webhook.WebhookID,
+					EventID:       eventID,
+					EventType:     eventType,
+					Payload:       string(payload),
+					Status:        models.DeliveryPending,
+					NextAttemptAt: now,
+					CreatedAt:     now,
+				})
+			}
+		}
+	}
+
+	return s.deliveries.Append(ctx, deliveries...)
+}
+
+// subscribers returns every subscription, loading them at most once per
+// subscriptionCacheTTL. Changes made through this instance are seen at
+// once. The subscriptions are shared and must not be modified.
+func (s *WebhookService) subscribers(ctx context.Context) ([]*models.WebhookSubscription, error) {
+	s.subscriptionsMutex.Lock()
+	defer s.subscriptionsMutex.Unlock()
+
+	if s.subscriptions != nil && time.Since(s.subscriptionsLoaded) < subscriptionCacheTTL {
+		return s.subscriptions, nil
+	}
+
+	webhooks, err := s.webhooks.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	s.subscriptions = webhooks
+	s.subscriptionsLoaded = time.Now()
+	return webhooks, nil
+}
+
+// forgetSubscriptions drops the cached subscriptions after a change
+func (s *WebhookService) forgetSubscriptions() {
+	s.subscriptionsMutex.Lock()
+	defer s.subscriptionsMutex.Unlock()
+	s.subscriptions = nil
+}
+
+// attempt sends a delivery once and records the outcome. Deliveries of a
+// deleted subscription are dead-lettered without being sent.
+func (s *WebhookService) attempt(ctx context.Context, webhook *models.WebhookSubscription, delivery *models.WebhookDelivery) {
+	logger := s.logger.WithFields(logrus.Fields{
+		"webhookId":  delivery.WebhookID,
+		"deliveryId": delivery.DeliveryID,
+		"eventType":  delivery.EventType,
+	})
+
+	if webhook == nil {
+		delivery.Status = models.DeliveryDead
+		delivery.LastError = "webhook was deleted"
+		if err := s.deliveries.Update(ctx, delivery); err != nil {
+			logger.WithError(err).Error("💥 Failed to record webhook delivery")
+			return
+		}
+		logger.Warn("☠️ Webhook delivery dead-lettered; webhook was deleted")
+		return
+	}
+
+	var err error
+	delivery.LastStatusCode, err = s.post(ctx, webhook, delivery)
+	if ctx.Err() != nil {
+		// Shutting down; the lease expires and the attempt is made again
+		return
+	}
+	delivery.Attempts++
+
+	now := time.Now()
+	switch {
+	case err == nil:
+		delivery.Status = models.DeliveryDelivered
+		delivery.DeliveredAt = &now
+		delivery.LastError = ""
+	case delivery.Attempts >= s.maxAttempts():
+		delivery.Status = models.DeliveryDead
+		delivery.LastError = err.Error()
+	default:
+		delivery.LastError = err.Error()
+		delivery.NextAttemptAt = now.Add(s.backoff(delivery.Attempts))
+	}
+
+	if err := s.deliveries.Update(ctx, delivery); err != nil {
+		logger.WithError(err).Error("💥 Failed to record webhook delivery")
+		return
+	}
+
+	switch delivery.Status {
+	case models.DeliveryDelivered:
+		logger.WithField("attempts", delivery.Attempts).Debug("🪝 Webhook delivered")
+	case models.DeliveryDead:
+		logger.WithError(err).WithField("attempts", delivery.Attempts).Warn("☠️ Webhook delivery dead-lettered")
+	default:
+		logger.WithError(err).WithFields(logrus.Fields{
+			"attempts":      delivery.Attempts,
+			"nextAttemptAt": delivery.NextAttemptAt,
+		}).Info("⏳ Webhook delivery failed; will retry")
+	}
+}
+
+// post sends a signed delivery and returns the response status. Any status
+// outside 2xx is an error.
+func (s *WebhookService) post(ctx context.Context, webhook *models.WebhookSubscription, delivery *models.WebhookDelivery) (int, error) {
+	body := []byte(delivery.Payload)
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("User-Agent", eventSource+"-webhooks")
+	request.Header.Set(HeaderWebhookID, webhook.WebhookID)
+	request.Header.Set(HeaderWebhookEvent, delivery.EventType)
+	request.Header.Set(HeaderWebhookDelivery, delivery.DeliveryID)
+	request.Header.Set(HeaderWebhookTimestamp, timestamp)
+	request.Header.Set(HeaderWebhookSignature, SignWebhook(webhook.Secret, timestamp, body))
+
+	response, err := s.client.Do(request)
+	if err != nil {
+		return 0, err
+	}
+	defer response.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(response.Body, 64<<10))
+
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return response.StatusCode, fmt.Errorf("webhook responded %s", response.Status)
+	}
+	return response.StatusCode, nil
+}
+
+// backoff returns the wait before the attempt after attempts failed ones:
+// the initial backoff doubled per failure up to the maximum, of which the
+// second half is random so that receivers recovering from an outage are
+// not hit by every retry at once
+func (s *WebhookService) backoff(attempts int) time.Duration {
+	initial := s.config.Webhooks.InitialBackoff
+	if initial <= 0 {
+		initial = time.Second
+	}
+	maxBackoff := max(s.config.Webhooks.MaxBackoff, initial)
+
+	wait := initial
+	for i := 1; i < attempts && wait < maxBackoff; i++ {
+		wait *= 2
+	}
+	wait = min(wait, maxBackoff)
+
+	half := wait / 2
+	return half + time.Duration(mathrand.Int63n(int64(wait-half)+1))
+}
+
+// maxAttempts returns how many attempts a delivery gets
+func (s *WebhookService) maxAttempts() int {
+	if s.config.Webhooks.MaxAttempts <= 0 {
+		return defaultWebhookMaxAttempts
+	}
+	return s.config.Webhooks.MaxAttempts
+}
+
+// getWebhook loads a subscription and maps repository errors
+func (s *WebhookService) getWebhook(ctx context.Context, webhookID string) (*models.WebhookSubscription, error) {
+	webhook, err := s.webhooks.GetByID(ctx, webhookID)
+	if err != nil {
+		if errors.Is(err, repository.ErrWebhookNotFound) {
+			return nil, webhookNotFoundError(webhookID)
+		}
+		return nil, repositoryError(err, "failed to retrieve webhook")
+	}
+	return webhook, nil
+}
+
+// webhookEventTypes returns the webhook events a product event triggers
+func webhookEventTypes(event *models.ProductChangedEvent) []string {
+	eventTypes := []string{}
+	switch event.Action {
+	case models.AuditCreate:
+		eventTypes = append(eventTypes, models.WebhookProductCreated)
+	case models.AuditUpdate, models.AuditRestore:
+		eventTypes = append(eventTypes, models.WebhookProductUpdated)
+	case models.AuditDelete:
+		eventTypes = append(eventTypes, models.WebhookProductDeleted)
+	}
+
+	// Stock never goes below zero, so a change to zero means it ran out
+	if event.Product != nil && event.Product.Stock == 0 && slices.Contains(event.ChangedFields, "stock") {
+		eventTypes = append(eventTypes, models.WebhookProductOutOfStock)
+	}
+	return eventTypes
+}
+
+// validateWebhookRequest checks a subscription request and returns its event
+// types without duplicates. A URL naming a private address is rejected
+// unless allowPrivate is set; host names are checked when delivering.
+func validateWebhookRequest(request *models.WebhookRequest, allowPrivate bool) ([]string, error) {
+	target, err := url.Parse(request.URL)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		return nil, fmt.Errorf("url must be an absolute http or https URL")
+	}
+	if address, err := netip.ParseAddr(target.Hostname()); err == nil && !allowPrivate && !isPublicAddress(address) {
+		return nil, fmt.Errorf("url must not point to a loopback, private or link-local address")
+	}
+
+	if len(request.EventTypes) == 0 {
+		return nil, fmt.Errorf("at least one event type is required; known types are %v", models.WebhookEventTypes)
+	}
+	eventTypes := make([]string, 0, len(request.EventTypes))
+	for _, eventType := range request.EventTypes {
+		if !slices.Contains(models.WebhookEventTypes, eventType) {
+			return nil, fmt.Errorf("unknown event type %q; known types are %v", eventType, models.WebhookEventTypes)
+		}
+		if !slices.Contains(eventTypes, eventType) {
+			eventTypes = append(eventTypes, eventType)
+		}
+	}
+
+	if request.Secret != "" && len(request.Secret) < minWebhookSecretLength {
+		return nil, fmt.Errorf("secret must be at least %d characters", minWebhookSecretLength)
+	}
+	return eventTypes, nil
+}
+
+// checkWebhookDial is the dialer control of the webhook client. It runs
+// after name resolution and refuses any address that is not public.
+func checkWebhookDial(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip, err := netip.ParseAddr(host)
+	if err != nil {
+		return err
+	}
+	if !isPublicAddress(ip) {
+		return fmt.Errorf("%w: %s", errPrivateWebhookAddress, ip)
+	}
+	return nil
+}
+
+// isPublicAddress reports whether address may be reached on the public
+// internet, as opposed to loopback, private, link-local (including cloud
+// metadata endpoints such as 169.254.169.254) or unspecified addresses
+func isPublicAddress(address netip.Addr) bool {
+	address = address.Unmap()
+	return !address.IsLoopback() &&
+		!address.IsPrivate() &&
+		!address.IsLinkLocalUnicast() &&
+		!address.IsLinkLocalMulticast() &&
+		!address.IsInterfaceLocalMulticast() &&
+		!address.IsUnspecified()
+}
+
+// webhookNotFoundError reports an unknown subscription
+func webhookNotFoundError(webhookID string) *Error {
+	return newError(ErrNotFound, "webhook_not_found", nil, "webhook %s not found", webhookID)
+}
+
+// newWebhookID generates a random subscription identifier
+func newWebhookID() (string, error) {
+	buf := make([]byte, 12)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return "wh-" + hex.EncodeToString(buf), nil
+}
+
+// newWebhookSecret generates a random signing secret
+func newWebhookSecret() (string, error) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(buf), nil
+}
+
+// newDeliveryID generates a random delivery identifier
+func newDeliveryID() (string, error) {
+	buf := make([]byte, 12)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return "dlv-" + hex.EncodeToString(buf), nil
+}